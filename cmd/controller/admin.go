@@ -0,0 +1,393 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/yaml"
+)
+
+// keyAdmin operates on the cluster-wide keys, in-process on the key
+// store or through the /admin endpoints of a running controller.
+type keyAdmin interface {
+	List() ([]keyInfo, error)
+	// Blacklist blacklists the key called name. The report is nil if
+	// the SealedSecrets weren't resealed.
+	Blacklist(name, reason string) (*resealReport, error)
+	Rotate() error
+	// Export writes the key Secrets, as a YAML List, to w.
+	Export(w io.Writer) error
+}
+
+// localKeyAdmin operates on the key store, eg. from `kubectl exec` in
+// the controller pod. The running controller only picks the changes up
+// when it reloads its keys, eg. on restart.
+type localKeyAdmin struct {
+	registry  *KeyRegistry
+	namespace string
+}
+
+// newLocalKeyAdmin returns the keyAdmin of the key store selected by
+// the flags.
+func newLocalKeyAdmin() (*localKeyAdmin, error) {
+	var clientset kubernetes.Interface
+	config, err := rest.InClusterConfig()
+	if err == nil {
+		clientset, err = kubernetes.NewForConfig(config)
+	}
+	if err != nil && strings.HasPrefix(*keyStoreFlag, "secrets") {
+		// Only the Secrets need the cluster
+		return nil, err
+	}
+
+	prefix, err := initKeyPrefix(*keyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	namespace := myNamespace()
+	store, err := newKeyStore(*keyStoreFlag, clientset, namespace)
+	if err != nil {
+		return nil, err
+	}
+	registry, err := initKeyRegistry(store, prefix, *keySize)
+	if err != nil {
+		return nil, err
+	}
+	return &localKeyAdmin{registry: registry, namespace: namespace}, nil
+}
+
+func (a *localKeyAdmin) List() ([]keyInfo, error) {
+	return a.registry.dump()
+}
+
+func (a *localKeyAdmin) Blacklist(name, reason string) (*resealReport, error) {
+	_, err := a.registry.blacklist(name, reason)
+	return nil, err
+}
+
+func (a *localKeyAdmin) Rotate() error {
+	_, err := a.registry.generateKey()
+	return err
+}
+
+func (a *localKeyAdmin) Export(w io.Writer) error {
+	stored, err := a.registry.store.List(a.registry.keyNamespace)
+	if err != nil {
+		return err
+	}
+	var secrets []interface{}
+	for _, k := range stored {
+		if k.Err != nil {
+			return fmt.Errorf("cannot export key %s: %v", k.Name, k.Err)
+		}
+		secret := keyAsSecret(k.PrivateKey, k.Certs)
+		secret.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"}
+		secret.Name = k.Name
+		secret.Namespace = a.namespace
+		secret.Labels = map[string]string{SealedSecretsKeyLabel: "active"}
+		if k.Blacklisted {
+			secret.Labels[SealedSecretsKeyLabel] = compromised
+			secret.Annotations = map[string]string{
+				SealedSecretsBlacklistedAtAnnotation:   k.BlacklistedAt,
+				SealedSecretsBlacklistReasonAnnotation: k.BlacklistReason,
+			}
+		}
+		secrets = append(secrets, secret)
+	}
+	return writeList(w, secrets)
+}
+
+// writeList writes objects to w as a YAML List.
+func writeList(w io.Writer, objects []interface{}) error {
+	list := v1.List{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "List"}, Items: []runtime.RawExtension{}}
+	for _, obj := range objects {
+		data, err := json.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		list.Items = append(list.Items, runtime.RawExtension{Raw: data})
+	}
+	data, err := json.Marshal(list)
+	if err == nil {
+		data, err = yaml.JSONToYAML(data)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// remoteKeyAdmin calls the /admin endpoints of the controller at url.
+type remoteKeyAdmin struct {
+	url   string
+	token string
+}
+
+// call sends body, if any, as JSON to path and decodes the response
+// into out, if any.
+func (a *remoteKeyAdmin) call(method, path string, body, out interface{}) error {
+	var content io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		content = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(a.url, "/")+path, content)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+a.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound && path == "/admin/keys/blacklist":
+		return errKeyNotFound
+	case resp.StatusCode >= 300:
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%s %s: %s %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	case out != nil:
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+func (a *remoteKeyAdmin) List() ([]keyInfo, error) {
+	var keys []keyInfo
+	err := a.call(http.MethodGet, "/admin/keys", nil, &keys)
+	return keys, err
+}
+
+func (a *remoteKeyAdmin) Blacklist(name, reason string) (*resealReport, error) {
+	var report resealReport
+	if err := a.call(http.MethodPost, "/admin/keys/blacklist", blacklistRequest{Name: name, Reason: reason}, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (a *remoteKeyAdmin) Rotate() error {
+	return a.call(http.MethodPost, "/admin/keys/rotate", nil, nil)
+}
+
+func (a *remoteKeyAdmin) Export(w io.Writer) error {
+	// The private keys are never served
+	return fmt.Errorf("keys export reads the key store, run it without --controller-url")
+}
+
+// newKeyAdmin returns the keyAdmin of the controller at controllerURL,
+// authenticated with the token of tokenFile, or of the key store if
+// controllerURL is empty.
+func newKeyAdmin(controllerURL, tokenFile string) (keyAdmin, error) {
+	if controllerURL == "" {
+		return newLocalKeyAdmin()
+	}
+	token, err := ioutil.ReadFile(tokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read --admin-token-file: %v", err)
+	}
+	return &remoteKeyAdmin{url: controllerURL, token: strings.TrimSpace(string(token))}, nil
+}
+
+// keyStoreFlags are the flags of the controller selecting the key store
+// the keys subcommands operate on.
+var keyStoreFlags = []string{"key-store", "key-prefix", "key-size", "vault-addr", "vault-auth-mount", "vault-role", "vault-ca-cert", "vault-kv-mount"}
+
+// newKeysCommand returns the keys command, whose subcommands operate
+// with the keyAdmin newAdmin returns for their --controller-url and
+// --admin-token-file.
+func newKeysCommand(newAdmin func(controllerURL, tokenFile string) (keyAdmin, error)) *cobra.Command {
+	var controllerURL, tokenFile string
+	cmd := &cobra.Command{
+		Use:   "keys",
+		Short: "Manage the cluster-wide keys",
+		Long: `Manage the cluster-wide keys, in-process on the key store selected by
+--key-store, or through the /admin endpoints of the controller at
+--controller-url. The running controller only picks the changes made
+in-process up when it reloads its keys, eg. on restart.`,
+	}
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&controllerURL, "controller-url", "", "URL of a running controller to call, eg. http://sealed-secrets-controller.kube-system:8080, which requires --enable-admin-endpoints. The subcommands operate in-process on the key store otherwise.")
+	flags.StringVar(&tokenFile, "admin-token-file", "/var/run/secrets/kubernetes.io/serviceaccount/token", "File of the bearer token to authenticate to --controller-url with.")
+	for _, name := range keyStoreFlags {
+		flags.AddFlag(controllerFlags.Lookup(name))
+	}
+
+	admin := func() (keyAdmin, error) {
+		return newAdmin(controllerURL, tokenFile)
+	}
+	cmd.AddCommand(
+		newKeysListCommand(admin),
+		newKeysBlacklistCommand(admin),
+		newKeysRotateCommand(admin),
+		newKeysExportCommand(admin),
+	)
+	return cmd
+}
+
+func newKeysListCommand(newAdmin func() (keyAdmin, error)) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the keys with their fingerprint and status",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			admin, err := newAdmin()
+			if err != nil {
+				return err
+			}
+			keys, err := admin.List()
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 8, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tNAMESPACE\tFINGERPRINT\tCREATED\tSTATUS")
+			for _, k := range keys {
+				status := "loaded"
+				switch {
+				case k.Blacklisted:
+					status = "blacklisted"
+				case k.Active:
+					status = "active"
+				case !k.Loaded:
+					status = "not loaded"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", k.Name, orDash(k.Namespace), orDash(k.Fingerprint), k.Created.UTC().Format(time.RFC3339), status)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func newKeysBlacklistCommand(newAdmin func() (keyAdmin, error)) *cobra.Command {
+	var reason, resealedOutput string
+	cmd := &cobra.Command{
+		Use:   "blacklist <name>",
+		Short: "Blacklist a compromised key",
+		Long: `Blacklist the key called name: it is no longer used to seal. With
+--controller-url, the SealedSecrets recorded as sealed with it are
+resealed with the latest key, and written to --resealed-output to be
+committed in place of their manifests: they aren't updated in the
+cluster.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if reason == "" {
+				return fmt.Errorf("keys blacklist requires --reason")
+			}
+			admin, err := newAdmin()
+			if err != nil {
+				return err
+			}
+			report, err := admin.Blacklist(args[0], reason)
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "Key %s blacklisted\n", args[0])
+			if report == nil {
+				fmt.Fprintf(out, "The running controller uses it until it is restarted, and doesn't reseal its SealedSecrets: blacklist keys with --controller-url to reseal them.\n")
+				return nil
+			}
+			var manifests []interface{}
+			for _, ssecret := range report.Resealed {
+				fmt.Fprintf(out, "%s/%s: resealed\n", ssecret.Namespace, ssecret.Name)
+				manifests = append(manifests, ssecret)
+			}
+			for _, id := range report.Failed {
+				fmt.Fprintf(out, "%s: failed\n", id)
+			}
+			for _, id := range report.Unknown {
+				fmt.Fprintf(out, "%s: unknown, never unsealed\n", id)
+			}
+			if len(manifests) == 0 {
+				return nil
+			}
+			if resealedOutput == "" {
+				fmt.Fprintf(out, "Run with --resealed-output to write the resealed SealedSecrets, and commit them.\n")
+				return nil
+			}
+			f, err := os.Create(resealedOutput)
+			if err != nil {
+				return err
+			}
+			if err := writeList(f, manifests); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Resealed SealedSecrets written to %s, commit them in place of their manifests.\n", resealedOutput)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&reason, "reason", "", "Why the key is blacklisted, recorded with it. Required.")
+	cmd.Flags().StringVar(&resealedOutput, "resealed-output", "", "File to write the resealed SealedSecrets to, as a YAML List, with --controller-url.")
+	return cmd
+}
+
+func newKeysRotateCommand(newAdmin func() (keyAdmin, error)) *cobra.Command {
+	return &cobra.Command{
+		Use:   "rotate",
+		Short: "Generate a new key to seal with",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			admin, err := newAdmin()
+			if err != nil {
+				return err
+			}
+			if err := admin.Rotate(); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "New key requested\n")
+			return nil
+		},
+	}
+}
+
+func newKeysExportCommand(newAdmin func() (keyAdmin, error)) *cobra.Command {
+	return &cobra.Command{
+		Use:   "export",
+		Short: "Print the key Secrets as a YAML List",
+		Long: `Print the key Secrets, private keys included, as a YAML List, to back
+them up or restore them with kubectl apply. It always reads the key
+store: the private keys are never served by the controller.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			admin, err := newAdmin()
+			if err != nil {
+				return err
+			}
+			return admin.Export(cmd.OutOrStdout())
+		},
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+// runKeys runs the keys command with args, operating with admin and
+// writing its output to out.
+func runKeys(admin keyAdmin, args []string, out io.Writer) error {
+	cmd := newKeysCommand(func(controllerURL, tokenFile string) (keyAdmin, error) {
+		return admin, nil
+	})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	cmd.SetOutput(out)
+	cmd.SetArgs(args)
+	return cmd.Execute()
+}
+
+func TestLocalKeyAdmin(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store, err := newDirKeyStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry, err := initKeyRegistry(store, "prefix-", 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin := &localKeyAdmin{registry: registry, namespace: "kube-system"}
+
+	var out bytes.Buffer
+	for i := 0; i < 2; i++ {
+		if err := runKeys(admin, []string{"rotate"}, &out); err != nil {
+			t.Fatalf("keys rotate failed: %v", err)
+		}
+	}
+	names := registry.keyNames()
+	if len(names) != 2 {
+		t.Fatalf("Expected 2 keys, got %v", names)
+	}
+
+	if err := runKeys(admin, []string{"blacklist", names[0]}, &out); err == nil {
+		t.Errorf("Expected keys blacklist to require --reason")
+	}
+	if err := runKeys(admin, []string{"blacklist", names[0], "--reason", "leaked"}, &out); err != nil {
+		t.Fatalf("keys blacklist failed: %v", err)
+	}
+
+	out.Reset()
+	if err := runKeys(admin, []string{"list"}, &out); err != nil {
+		t.Fatalf("keys list failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.HasSuffix(lines[1], "blacklisted") || !strings.HasSuffix(lines[2], "active") {
+		t.Errorf("Unexpected keys list %q", out.String())
+	}
+
+	out.Reset()
+	if err := runKeys(admin, []string{"export"}, &out); err != nil {
+		t.Fatalf("keys export failed: %v", err)
+	}
+	for _, s := range []string{"kind: List", "name: " + names[0], "name: " + names[1], "namespace: kube-system", compromised, "tls.key"} {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("Expected %q in the export, got %q", s, out.String())
+		}
+	}
+
+	if err := runKeys(admin, []string{"unknown"}, &out); err == nil {
+		t.Errorf("Expected an unknown subcommand to fail")
+	}
+}
+
+func TestRemoteKeyAdmin(t *testing.T) {
+	aa := func(token string) (bool, error) {
+		return token == "admin", nil
+	}
+	kd := func() ([]keyInfo, error) {
+		return []keyInfo{{Name: "key1", Fingerprint: "ab:cd", Created: time.Now(), Active: true, Loaded: true}}, nil
+	}
+	kb := func(name, reason string) (*resealReport, error) {
+		if name != "key1" {
+			return nil, errKeyNotFound
+		}
+		resealed := &ssv1alpha1.SealedSecret{ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "myns"}}
+		return &resealReport{Key: name, Resealed: []*ssv1alpha1.SealedSecret{resealed}, Failed: []string{}, Unknown: []string{"myns/new"}}, nil
+	}
+	rotated := 0
+	mux := http.NewServeMux()
+	mux.Handle("/admin/keys", adminHandler(aa, keysHandler(kd)))
+	mux.Handle("/admin/keys/blacklist", adminHandler(aa, blacklistHandler(kb)))
+	mux.Handle("/admin/keys/rotate", adminHandler(aa, keyRotateHandler(func() { rotated++ })))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	admin := &remoteKeyAdmin{url: server.URL, token: "admin"}
+	var out bytes.Buffer
+	if err := runKeys(admin, []string{"list"}, &out); err != nil || !strings.Contains(out.String(), "key1") {
+		t.Errorf("keys list failed: %v %q", err, out.String())
+	}
+
+	dir, err := ioutil.TempDir("", "resealed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	output := filepath.Join(dir, "resealed.yaml")
+	out.Reset()
+	if err := runKeys(admin, []string{"blacklist", "key1", "--reason=leaked", "--resealed-output", output}, &out); err != nil || !strings.Contains(out.String(), "myns/mysecret: resealed\n") || !strings.Contains(out.String(), "myns/new: unknown") {
+		t.Errorf("keys blacklist failed: %v %q", err, out.String())
+	}
+	manifests, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(manifests), "kind: List") || !strings.Contains(string(manifests), "name: mysecret") {
+		t.Errorf("Unexpected resealed manifests %q", manifests)
+	}
+	if err := runKeys(admin, []string{"blacklist", "key2", "--reason=leaked"}, &out); err != errKeyNotFound {
+		t.Errorf("Expected errKeyNotFound, got %v", err)
+	}
+
+	if err := runKeys(admin, []string{"rotate"}, &out); err != nil || rotated != 1 {
+		t.Errorf("keys rotate failed: %v, %d rotations", err, rotated)
+	}
+	if err := runKeys(admin, []string{"export"}, &out); err == nil {
+		t.Errorf("Expected keys export to require the key store")
+	}
+
+	denied := &remoteKeyAdmin{url: server.URL, token: "user"}
+	if err := runKeys(denied, []string{"list"}, &out); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Expected a 403 error, got %v", err)
+	}
+}
+
+func TestRootCommand(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected []string
+		absent   []string
+	}{
+		{[]string{"--help"}, []string{"serve", "keys", "--rotate-period"}, nil},
+		{[]string{"serve", "--help"}, []string{"--rotate-period", "--key-store"}, []string{"--controller-url"}},
+		{[]string{"keys", "--help"}, []string{"list", "blacklist", "rotate", "export", "--controller-url", "--key-store"}, []string{"--rotate-period"}},
+		{[]string{"keys", "blacklist", "--help"}, []string{"--reason", "--controller-url"}, []string{"--rotate-period"}},
+	}
+	for _, tc := range testCases {
+		cmd := newRootCommand()
+		var out bytes.Buffer
+		cmd.SetOutput(&out)
+		cmd.SetArgs(tc.args)
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("%v: %v", tc.args, err)
+		}
+		for _, s := range tc.expected {
+			if !strings.Contains(out.String(), s) {
+				t.Errorf("%v: expected %q in the help, got %q", tc.args, s, out.String())
+			}
+		}
+		for _, s := range tc.absent {
+			if strings.Contains(out.String(), s) {
+				t.Errorf("%v: unexpected %q in the help", tc.args, s)
+			}
+		}
+	}
+
+	cmd := newRootCommand()
+	cmd.SetOutput(ioutil.Discard)
+	cmd.SetArgs([]string{"unknown"})
+	if err := cmd.Execute(); err == nil {
+		t.Errorf("Expected an unknown subcommand to fail")
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// apiError is the body of the error responses of the /v2 endpoints.
+type apiError struct {
+	// Code is the HTTP status code.
+	Code int `json:"code"`
+	// Reason is a machine readable CamelCase reason, eg. Forbidden.
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+	// Retryable is set when the same request may succeed later.
+	Retryable bool `json:"retryable"`
+}
+
+// newAPIError returns the error of the status code, with the reasons
+// specific to an endpoint overriding the default ones.
+func newAPIError(code int, reasons map[int]string) apiError {
+	reason, ok := reasons[code]
+	if !ok {
+		reason = strings.Replace(http.StatusText(code), " ", "", -1)
+	}
+	return apiError{
+		Code:    code,
+		Reason:  reason,
+		Message: http.StatusText(code),
+		Retryable: code == http.StatusTooManyRequests || code == http.StatusInternalServerError ||
+			code == http.StatusServiceUnavailable || code == http.StatusGatewayTimeout,
+	}
+}
+
+// apiErrorWriter replaces the body of error responses with an apiError.
+type apiErrorWriter struct {
+	http.ResponseWriter
+	reasons map[int]string
+	failed  bool
+}
+
+func (w *apiErrorWriter) WriteHeader(code int) {
+	if code < http.StatusBadRequest {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.failed = true
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(code)
+	json.NewEncoder(w.ResponseWriter).Encode(newAPIError(code, w.reasons))
+}
+
+func (w *apiErrorWriter) Write(b []byte) (int, error) {
+	if w.failed {
+		// The plain text error of the /v1 handler
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// v2Handler serves the /v1 handler h under /v2, with structured JSON
+// error responses. reasons overrides the default reasons of the status
+// codes meaning something specific to the endpoint.
+func v2Handler(h http.Handler, reasons map[int]string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(&apiErrorWriter{ResponseWriter: w, reasons: reasons}, r)
+	})
+}
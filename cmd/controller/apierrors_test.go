@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestV2Handler(t *testing.T) {
+	sc := func(content []byte) (bool, error) {
+		return string(content) == "valid", nil
+	}
+	handler := v2Handler(verifyHandler(sc), map[int]string{http.StatusConflict: "InvalidSealedSecret"})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v2/verify", strings.NewReader("valid")))
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 {
+		t.Errorf("Expected an empty 200 response, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v2/verify", strings.NewReader("invalid")))
+	var apiErr apiError
+	if err := json.Unmarshal(rec.Body.Bytes(), &apiErr); err != nil {
+		t.Fatalf("Expected a JSON error, got %q: %v", rec.Body.String(), err)
+	}
+	if expected := (apiError{Code: http.StatusConflict, Reason: "InvalidSealedSecret", Message: "Conflict"}); apiErr != expected {
+		t.Errorf("Expected %+v, got %+v", expected, apiErr)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected JSON content type, got %q", ct)
+	}
+
+	// Plain text errors of the /v1 handlers are replaced
+	rec = httptest.NewRecorder()
+	v2Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "limit exceeded", http.StatusTooManyRequests)
+	}), nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v2/cert.pem", nil))
+	if err := json.Unmarshal(rec.Body.Bytes(), &apiErr); err != nil {
+		t.Fatalf("Expected a JSON error, got %q: %v", rec.Body.String(), err)
+	}
+	if apiErr.Reason != "TooManyRequests" || !apiErr.Retryable {
+		t.Errorf("Expected a retryable TooManyRequests error, got %+v", apiErr)
+	}
+}
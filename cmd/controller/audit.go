@@ -0,0 +1,162 @@
+package main
+
+import (
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	flag "github.com/spf13/pflag"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	"github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+var (
+	auditLogPath = flag.String("audit-log", "", "File to append the audit log of unseal, verify, rotate, seal and delete operations to, as JSON lines. Use - for stdout. Disabled if empty.")
+)
+
+// auditLog records the audited operations, nil if disabled.
+var auditLog *auditLogger
+
+// auditEvent is a line of the audit log.
+type auditEvent struct {
+	Time      time.Time `json:"time"`
+	Operation string    `json:"operation"`
+	// Object is the namespace/name of the SealedSecret, if known.
+	Object string `json:"object,omitempty"`
+	// Key is the fingerprint of the key that decrypted the object.
+	Key string `json:"key,omitempty"`
+	// Caller identifies the client of the verify, rotate and seal calls.
+	Caller string `json:"caller,omitempty"`
+	// Identity is the identity of the client certificate of the caller,
+	// see --allowed-client-identities.
+	Identity string `json:"identity,omitempty"`
+	Result   string `json:"result"`
+	Error    string `json:"error,omitempty"`
+}
+
+// auditLogger appends events to w, one JSON object per line.
+type auditLogger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// openAuditLog opens the audit log at path, see --audit-log.
+func openAuditLog(path string) (*auditLogger, error) {
+	if path == "-" {
+		return &auditLogger{w: os.Stdout}, nil
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &auditLogger{w: f}, nil
+}
+
+// record appends ev to the log. It does nothing if a is nil.
+func (a *auditLogger) record(ev auditEvent) {
+	if a == nil {
+		return
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now().UTC()
+	}
+	line, err := json.Marshal(ev)
+	if err != nil {
+		log.Printf("Error encoding audit event: %v", err)
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := a.w.Write(append(line, '\n')); err != nil {
+		log.Printf("Error writing audit event: %v", err)
+	}
+}
+
+// auditResult fills in the result of ev from err.
+func auditResult(ev auditEvent, result string, err error) auditEvent {
+	ev.Result = result
+	if err != nil {
+		ev.Result = "error"
+		ev.Error = err.Error()
+	}
+	return ev
+}
+
+// keyFingerprint is the fingerprint of key for the audit log, empty if
+// it can't be computed.
+func keyFingerprint(key *rsa.PrivateKey) string {
+	if key == nil {
+		return ""
+	}
+	fp, err := seal.Fingerprint(&key.PublicKey)
+	if err != nil {
+		return ""
+	}
+	return fp
+}
+
+// sealedSecretID returns the namespace/name of the SealedSecret in
+// content, empty if it can't be decoded.
+func sealedSecretID(content []byte) string {
+	object, err := runtime.Decode(scheme.Codecs.UniversalDecoder(ssv1alpha1.SchemeGroupVersion), content)
+	if err != nil {
+		return ""
+	}
+	ss, ok := object.(*ssv1alpha1.SealedSecret)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s", ss.GetNamespace(), ss.GetName())
+}
+
+// secretID returns the namespace/name of the Secret in content, empty
+// if it can't be decoded.
+func secretID(content []byte) string {
+	object, err := runtime.Decode(scheme.Codecs.UniversalDecoder(v1.SchemeGroupVersion), content)
+	if err != nil {
+		return ""
+	}
+	secret, ok := object.(*v1.Secret)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s", secret.GetNamespace(), secret.GetName())
+}
+
+// httpCaller identifies the client of r by its address, and the
+// addresses it was forwarded for by proxies.
+func httpCaller(r *http.Request) string {
+	if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+		return fmt.Sprintf("%s (forwarded for %s)", r.RemoteAddr, strings.TrimSpace(fwd))
+	}
+	return r.RemoteAddr
+}
+
+// grpcCaller identifies the client of a gRPC call by its address, and
+// the subject of its certificate when mutual TLS is used.
+func grpcCaller(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	caller := p.Addr.String()
+	if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.PeerCertificates) > 0 {
+		caller = fmt.Sprintf("%s (%s)", caller, info.State.PeerCertificates[0].Subject.CommonName)
+	}
+	return caller
+}
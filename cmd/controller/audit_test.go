@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	"github.com/bitnami-labs/sealed-secrets/pkg/grpcapi"
+	"github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+// captureAuditLog makes the audit log write to the returned buffer,
+// until the returned function is called.
+func captureAuditLog() (*bytes.Buffer, func()) {
+	var buf bytes.Buffer
+	auditLog = &auditLogger{w: &buf}
+	return &buf, func() { auditLog = nil }
+}
+
+func auditEvents(t *testing.T, buf *bytes.Buffer) []auditEvent {
+	var events []auditEvent
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var ev auditEvent
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("Invalid audit log line %q: %v", line, err)
+		}
+		events = append(events, ev)
+	}
+	return events
+}
+
+func TestAuditUnseal(t *testing.T) {
+	buf, done := captureAuditLog()
+	defer done()
+
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+	c := newTestController(t, ssecret)
+	c.keyRegistry = registry
+
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+
+	fp, err := seal.Fingerprint(&registry.latestPrivateKey().PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := auditEvents(t, buf)
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 audit event, got %v", events)
+	}
+	ev := events[0]
+	if ev.Operation != "unseal" || ev.Object != "myns/mysecret" || ev.Key != fp || ev.Result != "success" || ev.Time.IsZero() {
+		t.Errorf("Unexpected audit event %+v", ev)
+	}
+}
+
+func TestAuditVerify(t *testing.T) {
+	buf, done := captureAuditLog()
+	defer done()
+
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+	c := newTestController(t, ssecret)
+	c.keyRegistry = registry
+
+	ssecret.TypeMeta = metav1.TypeMeta{APIVersion: ssv1alpha1.SchemeGroupVersion.String(), Kind: "SealedSecret"}
+	content, err := json.Marshal(ssecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := &grpcService{sc: c.AttemptUnseal}
+	resp, err := svc.Verify(context.Background(), &grpcapi.VerifyRequest{SealedSecret: content})
+	if err != nil || !resp.Valid {
+		t.Fatalf("Verify() returned %v, %v", resp, err)
+	}
+
+	events := auditEvents(t, buf)
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 audit event, got %v", events)
+	}
+	ev := events[0]
+	if ev.Operation != "verify" || ev.Object != "myns/mysecret" || ev.Result != "valid" {
+		t.Errorf("Unexpected audit event %+v", ev)
+	}
+}
+
+func TestHTTPCaller(t *testing.T) {
+	req := httptest.NewRequest("POST", "/v1/verify", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	if got := httpCaller(req); got != "10.0.0.1:1234" {
+		t.Errorf("Unexpected caller %q", got)
+	}
+	req.Header.Set("X-Forwarded-For", "192.168.0.1")
+	if got := httpCaller(req); got != "10.0.0.1:1234 (forwarded for 192.168.0.1)" {
+		t.Errorf("Unexpected caller %q", got)
+	}
+}
+
+func TestAuditDisabled(t *testing.T) {
+	var a *auditLogger
+	// Must not panic
+	a.record(auditEvent{Operation: "unseal"})
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	authnv1 "k8s.io/api/authentication/v1"
+	authzv1 "k8s.io/api/authorization/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+// Called on every request to /v1/seal. Returns whether the bearer
+// token is allowed to create SealedSecrets in namespace.
+type sealAuthorizer func(token, namespace string) (bool, error)
+
+// bearerToken returns the token of an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if !strings.HasPrefix(auth, prefix) {
+		return ""
+	}
+	return strings.TrimSpace(auth[len(prefix):])
+}
+
+// secretNamespace returns the namespace of a Secret manifest.
+func secretNamespace(content []byte) (string, error) {
+	object, err := runtime.Decode(scheme.Codecs.UniversalDecoder(apiv1.SchemeGroupVersion), content)
+	if err != nil {
+		return "", err
+	}
+	secret, ok := object.(*apiv1.Secret)
+	if !ok {
+		return "", fmt.Errorf("Unexpected resource type: %s", object.GetObjectKind().GroupVersionKind().String())
+	}
+	if secret.GetNamespace() == "" {
+		return "", fmt.Errorf("Secret must declare a namespace")
+	}
+	return secret.GetNamespace(), nil
+}
+
+// kubeSealAuthorizer authenticates tokens with the TokenReview API and
+// checks that the user may create SealedSecrets in the namespace with
+// the SubjectAccessReview API, so that sealing obeys the cluster RBAC.
+func kubeSealAuthorizer(client kubernetes.Interface) sealAuthorizer {
+	return func(token, namespace string) (bool, error) {
+		return kubeAccessReview(client, token, &authzv1.ResourceAttributes{
+			Namespace: namespace,
+			Verb:      "create",
+			Group:     ssv1alpha1.GroupName,
+			Resource:  "sealedsecrets",
+		})
+	}
+}
+
+// Called on every request to the /admin endpoints. Returns whether the
+// bearer token is allowed to administer the controller.
+type adminAuthorizer func(token string) (bool, error)
+
+// kubeAdminAuthorizer allows the users who may update Secrets in the
+// namespace of the controller, ie. who could relabel its keys anyway.
+func kubeAdminAuthorizer(client kubernetes.Interface, namespace string) adminAuthorizer {
+	return func(token string) (bool, error) {
+		return kubeAccessReview(client, token, &authzv1.ResourceAttributes{
+			Namespace: namespace,
+			Verb:      "update",
+			Resource:  "secrets",
+		})
+	}
+}
+
+// kubeAccessReview checks whether the user authenticated by token is
+// allowed access to attrs.
+func kubeAccessReview(client kubernetes.Interface, token string, attrs *authzv1.ResourceAttributes) (bool, error) {
+	review, err := client.AuthenticationV1().TokenReviews().Create(&authnv1.TokenReview{
+		Spec: authnv1.TokenReviewSpec{Token: token},
+	})
+	if err != nil {
+		return false, err
+	}
+	if !review.Status.Authenticated {
+		return false, nil
+	}
+
+	user := review.Status.User
+	extra := map[string]authzv1.ExtraValue{}
+	for k, v := range user.Extra {
+		extra[k] = authzv1.ExtraValue(v)
+	}
+	access, err := client.AuthorizationV1().SubjectAccessReviews().Create(&authzv1.SubjectAccessReview{
+		Spec: authzv1.SubjectAccessReviewSpec{
+			User:               user.Username,
+			UID:                user.UID,
+			Groups:             user.Groups,
+			Extra:              extra,
+			ResourceAttributes: attrs,
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	return access.Status.Allowed, nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+
+	authnv1 "k8s.io/api/authentication/v1"
+	authzv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+)
+
+func TestKubeSealAuthorizer(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "tokenreviews", func(action ktesting.Action) (bool, runtime.Object, error) {
+		review := action.(ktesting.CreateAction).GetObject().(*authnv1.TokenReview)
+		if review.Spec.Token == "valid" {
+			review.Status.Authenticated = true
+			review.Status.User.Username = "alice"
+		}
+		return true, review, nil
+	})
+	client.PrependReactor("create", "subjectaccessreviews", func(action ktesting.Action) (bool, runtime.Object, error) {
+		review := action.(ktesting.CreateAction).GetObject().(*authzv1.SubjectAccessReview)
+		attrs := review.Spec.ResourceAttributes
+		review.Status.Allowed = review.Spec.User == "alice" && attrs.Namespace == "myns" &&
+			attrs.Verb == "create" && attrs.Resource == "sealedsecrets"
+		return true, review, nil
+	})
+
+	authorize := kubeSealAuthorizer(client)
+	testCases := []struct {
+		token, namespace string
+		allowed          bool
+	}{
+		{"valid", "myns", true},
+		{"valid", "otherns", false},
+		{"invalid", "myns", false},
+	}
+	for _, tc := range testCases {
+		allowed, err := authorize(tc.token, tc.namespace)
+		if err != nil {
+			t.Errorf("authorize(%q, %q) returned error: %v", tc.token, tc.namespace, err)
+		}
+		if allowed != tc.allowed {
+			t.Errorf("authorize(%q, %q) = %v, expected %v", tc.token, tc.namespace, allowed, tc.allowed)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	flag "github.com/spf13/pflag"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	"github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+const (
+	// SealedSecretsBlacklistedAtAnnotation is the time at which a key
+	// was blacklisted.
+	SealedSecretsBlacklistedAtAnnotation = "sealedsecrets.bitnami.com/blacklisted-at"
+	// SealedSecretsBlacklistReasonAnnotation is why a key was
+	// blacklisted.
+	SealedSecretsBlacklistReasonAnnotation = "sealedsecrets.bitnami.com/blacklist-reason"
+)
+
+var (
+	adminEndpoints = flag.Bool("enable-admin-endpoints", false, "Serve the /admin endpoints, eg. to blacklist keys, for the users who may update Secrets in the controller namespace.")
+)
+
+var errKeyNotFound = errors.New("no such key")
+
+// blacklist marks the key called name as compromised, so that it isn't
+// loaded anymore, and removes it from the registry. A new key is
+// generated if it was the latest one. The removed key is returned.
+func (kr *KeyRegistry) blacklist(name, reason string) (*rsa.PrivateKey, error) {
+	names := kr.keyNames()
+	i := -1
+	for j, n := range names {
+		if n == name {
+			i = j
+		}
+	}
+	if i < 0 {
+		return nil, errKeyNotFound
+	}
+
+	if err := kr.store.Blacklist(name, reason); err != nil {
+		return nil, fmt.Errorf("failed to mark key as compromised: %v", err)
+	}
+
+	key, err := kr.keys.Blacklist(name)
+	if err != nil {
+		return nil, err
+	}
+	kr.addCompromised(name, reason, key.PrivateKey)
+	latest := i == len(names)-1
+	log.Printf("Key %s blacklisted: %s", name, reason)
+
+	if latest && kr.external == nil {
+		// Don't keep sealing with it
+		if _, err := kr.generateKey(); err != nil {
+			return key.PrivateKey, fmt.Errorf("failed to generate new key: %v", err)
+		}
+	}
+	return key.PrivateKey, nil
+}
+
+// compromisedKey is a blacklisted key of a KeyRegistry.
+type compromisedKey struct {
+	name    string
+	reason  string
+	privKey *rsa.PrivateKey
+}
+
+// addCompromised keeps the blacklisted key called name.
+func (kr *KeyRegistry) addCompromised(name, reason string, privKey *rsa.PrivateKey) {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	for _, k := range kr.compromised {
+		if k.name == name {
+			return
+		}
+	}
+	kr.compromised = append(kr.compromised, compromisedKey{name: name, reason: reason, privKey: privKey})
+}
+
+// compromisedKeyError is returned when a SealedSecret only decrypts
+// with a blacklisted key.
+type compromisedKeyError struct {
+	key    string
+	reason string
+}
+
+func (e *compromisedKeyError) Error() string {
+	reason := ""
+	if e.reason != "" {
+		reason = " (" + e.reason + ")"
+	}
+	return fmt.Sprintf("sealed with key %s, blacklisted as compromised%s: seal it again with the current certificate of the controller", e.key, reason)
+}
+
+// sealedWithCompromisedKey returns the error telling which blacklisted
+// key of kr decrypts ssecret, nil if none does.
+func (kr *KeyRegistry) sealedWithCompromisedKey(ssecret *ssv1alpha1.SealedSecret) *compromisedKeyError {
+	kr.mu.Lock()
+	compromised := kr.compromised
+	kr.mu.Unlock()
+	for _, k := range compromised {
+		if _, err := seal.Unseal(ssecret, seal.PrivateKeys{k.privKey}); err == nil {
+			return &compromisedKeyError{key: k.name, reason: k.reason}
+		}
+	}
+	return nil
+}
+
+// resealReport lists the SealedSecrets sealed with a blacklisted key.
+type resealReport struct {
+	Key string `json:"key"`
+	// Resealed are the manifests of the SealedSecrets sealed with the
+	// key, sealed again with the latest key. The controller doesn't
+	// update them, they are to be committed wherever they are stored.
+	Resealed []*ssv1alpha1.SealedSecret `json:"resealed"`
+	// Failed couldn't be resealed and must be re-created manually.
+	Failed []string `json:"failed"`
+	// Unknown have no recorded key fingerprint, never having been
+	// unsealed, see /admin/keys/impact.
+	Unknown []string `json:"unknown"`
+}
+
+// BlacklistKey blacklists the key called name, whether it is a
+// cluster-wide or a per-namespace key, and reseals the SealedSecrets
+// it sealed with the latest key, see resealSealedWith.
+func (c *Controller) BlacklistKey(name, reason string) (*resealReport, error) {
+	key, err := c.keyRegistry.blacklist(name, reason)
+	if err == errKeyNotFound && c.nsKeys != nil {
+		key, err = c.nsKeys.blacklist(name, reason)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.resealSealedWith(name, key), nil
+}
+
+// resealSealedWith reseals the SealedSecrets whose status records they
+// were decrypted by key, named name, and returns their manifests. They
+// aren't updated in the cluster, where they would be reverted the next
+// time their manifests are applied, eg. by a GitOps tool.
+func (c *Controller) resealSealedWith(name string, key *rsa.PrivateKey) *resealReport {
+	fingerprint := keyFingerprint(key)
+	report := &resealReport{Key: name, Resealed: []*ssv1alpha1.SealedSecret{}, Failed: []string{}, Unknown: []string{}}
+	for _, obj := range c.informer.GetIndexer().List() {
+		ssecret, ok := obj.(*ssv1alpha1.SealedSecret)
+		if !ok {
+			continue
+		}
+		id := fmt.Sprintf("%s/%s", ssecret.GetNamespace(), ssecret.GetName())
+		recorded := ""
+		if ssecret.Status != nil {
+			recorded = ssecret.Status.KeyFingerprint
+		}
+		if recorded == "" {
+			report.Unknown = append(report.Unknown, id)
+			continue
+		}
+		if recorded != fingerprint {
+			continue
+		}
+
+		manifest, err := c.resealedManifest(ssecret, key)
+		if err != nil {
+			log.Printf("Failed to reseal SealedSecret %s sealed with blacklisted key %s: %v", id, name, err)
+			report.Failed = append(report.Failed, id)
+			continue
+		}
+		log.Printf("Resealed SealedSecret %s sealed with blacklisted key %s, to be committed", id, name)
+		report.Resealed = append(report.Resealed, manifest)
+	}
+	sort.Slice(report.Resealed, func(i, j int) bool {
+		a, b := report.Resealed[i], report.Resealed[j]
+		return a.Namespace < b.Namespace || (a.Namespace == b.Namespace && a.Name < b.Name)
+	})
+	sort.Strings(report.Failed)
+	sort.Strings(report.Unknown)
+	return report
+}
+
+// resealedManifest returns the manifest of ssecret, decrypted with key,
+// sealed again with the latest key. It has the metadata and the spec of
+// ssecret, to replace it wherever it is stored.
+func (c *Controller) resealedManifest(ssecret *ssv1alpha1.SealedSecret, key *rsa.PrivateKey) (*ssv1alpha1.SealedSecret, error) {
+	secret, err := seal.Unseal(ssecret, seal.PrivateKeys{key})
+	if err != nil {
+		return nil, err
+	}
+	resealed, err := c.reseal(ssecret, secret)
+	if err != nil {
+		return nil, err
+	}
+	annotations := copyStrings(ssecret.GetAnnotations())
+	delete(annotations, apiv1.LastAppliedConfigAnnotation)
+	manifest := &ssv1alpha1.SealedSecret{
+		TypeMeta: metav1.TypeMeta{APIVersion: ssv1alpha1.SchemeGroupVersion.String(), Kind: "SealedSecret"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        ssecret.GetName(),
+			Namespace:   ssecret.GetNamespace(),
+			Labels:      ssecret.GetLabels(),
+			Annotations: annotations,
+		},
+		Spec: *ssecret.Spec.DeepCopy(),
+		Type: ssecret.Type,
+	}
+	if len(manifest.Annotations) == 0 {
+		manifest.Annotations = nil
+	}
+	manifest.Spec.Data = resealed.Spec.Data
+	manifest.Spec.EncryptedData = resealed.Spec.EncryptedData
+	return manifest, nil
+}
+
+func (n *namespaceKeys) blacklist(name, reason string) (*rsa.PrivateKey, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, kr := range n.registries {
+		if key, err := kr.blacklist(name, reason); err != errKeyNotFound {
+			return key, err
+		}
+	}
+	return nil, errKeyNotFound
+}
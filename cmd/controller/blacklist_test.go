@@ -0,0 +1,130 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+)
+
+// testKeys returns a registry with a key for each name.
+func testKeys(t *testing.T, names ...string) *KeyRegistry {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "secrets", func(action ktesting.Action) (bool, runtime.Object, error) {
+		// The fake clientset ignores GenerateName, new keys aren't stored
+		secret := action.(ktesting.CreateAction).GetObject().(*v1.Secret)
+		if secret.Name == "" {
+			secret.Name = secret.GenerateName + "generated"
+			return true, secret, nil
+		}
+		return false, nil, nil
+	})
+	kr := NewKeyRegistry(newSecretKeyStore(client, "kube-system", SealedSecretsKeyLabel), "prefix", 1024)
+	for _, name := range names {
+		key, cert, err := generatePrivateKeyAndCert(1024)
+		if err != nil {
+			t.Fatal(err)
+		}
+		secret := &v1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "kube-system",
+			Labels:    map[string]string{SealedSecretsKeyLabel: "active"},
+		}}
+		if _, err := client.Core().Secrets("kube-system").Create(secret); err != nil {
+			t.Fatal(err)
+		}
+		if err := kr.registerNewKey(name, key, cert); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return kr
+}
+
+func TestBlacklist(t *testing.T) {
+	kr := testKeys(t, "key1", "key2")
+	client := kr.store.(*secretKeyStore).client
+	latest := kr.latestPrivateKey()
+
+	if _, err := kr.blacklist("missing", "leaked"); err != errKeyNotFound {
+		t.Errorf("Expected errKeyNotFound, got %v", err)
+	}
+
+	if _, err := kr.blacklist("key1", "leaked in CI logs"); err != nil {
+		t.Fatalf("blacklist() returned err: %v", err)
+	}
+	if len(kr.PrivateKeys()) != 1 || kr.latestPrivateKey() != latest {
+		t.Errorf("Expected only key2 to remain")
+	}
+	secret, err := client.Core().Secrets("kube-system").Get("key1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Labels[SealedSecretsKeyLabel] != compromised {
+		t.Errorf("Expected key to be labelled as compromised, got %v", secret.Labels)
+	}
+	if secret.Annotations[SealedSecretsBlacklistReasonAnnotation] != "leaked in CI logs" || secret.Annotations[SealedSecretsBlacklistedAtAnnotation] == "" {
+		t.Errorf("Unexpected annotations %v", secret.Annotations)
+	}
+
+	// The latest key is replaced
+	if _, err := kr.blacklist("key2", "leaked"); err != nil {
+		t.Fatalf("blacklist() returned err: %v", err)
+	}
+	if len(kr.PrivateKeys()) != 1 || kr.latestPrivateKey() == latest {
+		t.Errorf("Expected a new key")
+	}
+}
+
+func TestBlacklistKeyReseals(t *testing.T) {
+	kr := testKeys(t, "key1")
+	ssecret := testSealedSecret(t, kr)
+	ssecret.Annotations = map[string]string{"app": "kept", v1.LastAppliedConfigAnnotation: "{}"}
+	ssecret.SetKeyFingerprint(keyFingerprint(kr.latestPrivateKey()))
+	otherKeys := testKeys(t, "other")
+	other := testSealedSecret(t, otherKeys)
+	other.Name = "other"
+	other.SetKeyFingerprint(keyFingerprint(otherKeys.latestPrivateKey()))
+	// Sealed with key1, but never unsealed
+	unknown := testSealedSecret(t, kr)
+	unknown.Name = "unknown"
+
+	c := newTestController(t, ssecret)
+	c.keyRegistry = kr
+	c.informer.GetIndexer().Add(other)
+	c.informer.GetIndexer().Add(unknown)
+
+	report, err := c.BlacklistKey("key1", "leaked")
+	if err != nil {
+		t.Fatalf("BlacklistKey() returned err: %v", err)
+	}
+	if len(report.Resealed) != 1 || len(report.Failed) != 0 || !reflect.DeepEqual(report.Unknown, []string{"myns/unknown"}) {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+
+	// The manifest is reported, the SealedSecret isn't updated
+	stored, err := c.ssclient.BitnamiV1alpha1().SealedSecrets("myns").Get("mysecret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(stored.Spec, ssecret.Spec) {
+		t.Errorf("Expected the SealedSecret not to be updated")
+	}
+	resealed := report.Resealed[0]
+	if resealed.Name != "mysecret" || resealed.Kind != "SealedSecret" || resealed.Status != nil || resealed.ResourceVersion != "" {
+		t.Errorf("Unexpected manifest %+v", resealed)
+	}
+	if !reflect.DeepEqual(resealed.Annotations, map[string]string{"app": "kept"}) {
+		t.Errorf("Unexpected annotations %v", resealed.Annotations)
+	}
+	secret, err := c.attemptUnseal(resealed)
+	if err != nil {
+		t.Fatalf("Expected resealed secret to be unsealed with the new key: %v", err)
+	}
+	if string(secret.Data["foo"]) != "bar" {
+		t.Errorf("Unexpected data %v", secret.Data)
+	}
+}
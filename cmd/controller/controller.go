@@ -1,9 +1,11 @@
 package main
 
 import (
+	"crypto/rsa"
 	"encoding/json"
 	"fmt"
 	"log"
+	"reflect"
 	"time"
 
 	"k8s.io/apimachinery/pkg/runtime"
@@ -11,27 +13,87 @@ import (
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	runtimeserializer "k8s.io/apimachinery/pkg/runtime/serializer"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 
 	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	ssclientset "github.com/bitnami-labs/sealed-secrets/pkg/client/clientset/versioned"
 	ssinformer "github.com/bitnami-labs/sealed-secrets/pkg/client/informers/externalversions"
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+	"github.com/bitnami-labs/sealed-secrets/pkg/seal"
 )
 
 const maxRetries = 5
 
+// Kinds of the objects handled by the controller.
+const (
+	sealedSecretKind    = "SealedSecret"
+	sealedConfigMapKind = "SealedConfigMap"
+	sealedObjectKind    = "SealedObject"
+)
+
+// queueKey identifies an object in the work queue. Objects of different
+// kinds may share the same namespace/name key.
+type queueKey struct {
+	kind string
+	key  string
+}
+
+func (k queueKey) String() string {
+	return fmt.Sprintf("%s %s", k.kind, k.key)
+}
+
 // Controller implements the main sealed-secrets-controller loop.
 type Controller struct {
 	queue       workqueue.RateLimitingInterface
 	informer    cache.SharedIndexInformer
+	cmInformer  cache.SharedIndexInformer
+	objInformer cache.SharedIndexInformer
 	sclient     v1.SecretsGetter
+	nsclient    v1.NamespacesGetter
+	cmclient    v1.ConfigMapsGetter
+	recorder    record.EventRecorder
+	ssclient    ssclientset.Interface
+	applier     objectApplier
+	objectKinds objectKinds
 	keyRegistry *KeyRegistry
+	// nsKeys is only set with --per-namespace-keys, see keysFor.
+	nsKeys *namespaceKeys
+	// historyLimit is the number of previous versions kept for each
+	// Secret, see saveRevision.
+	historyLimit int
+
+	// secretInformer is only set when converting Secrets, see
+	// watchConvertibleSecrets.
+	secretInformer cache.SharedIndexInformer
+	// nsInformer and nsSelector are only set when unsealing is limited
+	// to some namespaces, see watchNamespaces.
+	nsInformer cache.SharedIndexInformer
+	nsSelector labels.Selector
+	// quota limits the SealedSecrets unsealed in each namespace, see
+	// checkQuota.
+	quota quota
+	// sizeLimits bounds the size of each SealedSecret and Secret.
+	sizeLimits sizeLimits
+	// policyInformer is only set with --enable-policies, see
+	// watchPolicies.
+	policyInformer cache.SharedIndexInformer
+	// dryRun disables the writes of unsealed objects, see dryRunSecret.
+	dryRun bool
+	// standby is only set with --standby, which disables writes until
+	// promoted, see Promote.
+	standby *standbyState
+	// parked are the objects waiting for a new key, see keyRegistered.
+	parked parkedObjects
 }
 
 func unseal(sclient v1.SecretsGetter, codecs runtimeserializer.CodecFactory, keyRegistry *KeyRegistry, ssecret *ssv1alpha1.SealedSecret) error {
@@ -62,45 +124,110 @@ func unseal(sclient v1.SecretsGetter, codecs runtimeserializer.CodecFactory, key
 }
 
 // NewController returns the main sealed-secrets controller loop.
-func NewController(clientset kubernetes.Interface, ssinformer ssinformer.SharedInformerFactory, keyRegistry *KeyRegistry) *Controller {
-	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+// SealedObjects are only watched if objectKinds isn't empty, and
+// SealedConfigMaps if their CRD is installed.
+func NewController(clientset kubernetes.Interface, ssclient ssclientset.Interface, ssinformer ssinformer.SharedInformerFactory, keyRegistry *KeyRegistry, objectKinds objectKinds) *Controller {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), queueName)
 
 	informer := ssinformer.Bitnami().V1alpha1().
 		SealedSecrets().
 		Informer()
+	informer.AddEventHandler(queueEventHandler(queue, sealedSecretKind))
+
+	var cmInformer cache.SharedIndexInformer
+	if servesResource(ssclient.Discovery(), "sealedconfigmaps") {
+		cmInformer = ssinformer.Bitnami().V1alpha1().
+			SealedConfigMaps().
+			Informer()
+		cmInformer.AddEventHandler(queueEventHandler(queue, sealedConfigMapKind))
+	} else {
+		log.Printf("SealedConfigMap CRD is not installed, not watching SealedConfigMaps")
+	}
 
-	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+	var objInformer cache.SharedIndexInformer
+	if len(objectKinds) > 0 {
+		objInformer = ssinformer.Bitnami().V1alpha1().
+			SealedObjects().
+			Informer()
+		objInformer.AddEventHandler(queueEventHandler(queue, sealedObjectKind))
+	}
+
+	return &Controller{
+		informer:    informer,
+		cmInformer:  cmInformer,
+		objInformer: objInformer,
+		queue:       queue,
+		sclient:     clientset.Core(),
+		nsclient:    clientset.Core(),
+		cmclient:    clientset.Core(),
+		recorder:    newEventRecorder(clientset.Core()),
+		ssclient:    ssclient,
+		applier:     restObjectApplier{clientset.Discovery()},
+		objectKinds: objectKinds,
+		keyRegistry: keyRegistry,
+	}
+}
+
+// servesResource reports whether the API server serves resource in the
+// sealed-secrets API group, that is whether its CRD is installed.
+func servesResource(client discovery.DiscoveryInterface, resource string) bool {
+	resources, err := client.ServerResourcesForGroupVersion(ssv1alpha1.SchemeGroupVersion.String())
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			log.Printf("Error discovering the resources of %s: %v", ssv1alpha1.SchemeGroupVersion, err)
+		}
+		return false
+	}
+	for _, r := range resources.APIResources {
+		if r.Name == resource {
+			return true
+		}
+	}
+	return false
+}
+
+// queueEventHandler adds the objects of an informer to queue.
+func queueEventHandler(queue workqueue.Interface, kind string) cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			key, err := cache.MetaNamespaceKeyFunc(obj)
 			if err == nil {
-				queue.Add(key)
+				queue.Add(queueKey{kind, key})
 			}
 		},
 		UpdateFunc: func(oldObj, newObj interface{}) {
 			key, err := cache.MetaNamespaceKeyFunc(newObj)
 			if err == nil {
-				queue.Add(key)
+				queue.Add(queueKey{kind, key})
 			}
 		},
 		DeleteFunc: func(obj interface{}) {
 			key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
 			if err == nil {
-				queue.Add(key)
+				queue.Add(queueKey{kind, key})
 			}
 		},
-	})
-
-	return &Controller{
-		informer:    informer,
-		queue:       queue,
-		sclient:     clientset.Core(),
-		keyRegistry: keyRegistry,
 	}
 }
 
 // HasSynced returns true once this controller has completed an
 // initial resource listing
 func (c *Controller) HasSynced() bool {
+	if c.cmInformer != nil && !c.cmInformer.HasSynced() {
+		return false
+	}
+	if c.objInformer != nil && !c.objInformer.HasSynced() {
+		return false
+	}
+	if c.secretInformer != nil && !c.secretInformer.HasSynced() {
+		return false
+	}
+	if c.nsInformer != nil && !c.nsInformer.HasSynced() {
+		return false
+	}
+	if c.policyInformer != nil && !c.policyInformer.HasSynced() {
+		return false
+	}
 	return c.informer.HasSynced()
 }
 
@@ -121,6 +248,21 @@ func (c *Controller) Run(stopCh <-chan struct{}) {
 	defer c.queue.ShutDown()
 
 	go c.informer.Run(stopCh)
+	if c.cmInformer != nil {
+		go c.cmInformer.Run(stopCh)
+	}
+	if c.objInformer != nil {
+		go c.objInformer.Run(stopCh)
+	}
+	if c.secretInformer != nil {
+		go c.secretInformer.Run(stopCh)
+	}
+	if c.nsInformer != nil {
+		go c.nsInformer.Run(stopCh)
+	}
+	if c.policyInformer != nil {
+		go c.policyInformer.Run(stopCh)
+	}
 
 	if !cache.WaitForCacheSync(stopCh, c.HasSynced) {
 		utilruntime.HandleError(fmt.Errorf("Timed out waiting for caches to sync"))
@@ -145,10 +287,30 @@ func (c *Controller) processNextItem() bool {
 	}
 
 	defer c.queue.Done(key)
-	err := c.unseal(key.(string))
+	generation := c.parked.current()
+	err := c.sync(key.(queueKey))
+	if err != nil {
+		unsealErrors.inc(unsealFailureReason(err))
+	}
 	if err == nil {
 		// No error, reset the ratelimit counters
 		c.queue.Forget(key)
+		c.parked.remove(key.(queueKey))
+	} else if err == seal.ErrNoKey {
+		// Only a new key can help, see keyRegistered
+		c.queue.Forget(key)
+		if c.parked.park(key.(queueKey), generation) {
+			log.Printf("No key could decrypt %s, parked until a new key is registered", key)
+			notifications.notify(notifyUnsealFailed, key.(queueKey).String(), err)
+		} else {
+			c.queue.Add(key)
+		}
+	} else if isNamespaceTerminating(err) {
+		// Retrying would fail the same way until the namespace is
+		// gone. The object is synced again when it changes, or when
+		// the namespace reappears with --namespace-selector.
+		log.Printf("Namespace of %s is terminating, not retrying: %v", key, err)
+		c.queue.Forget(key)
 	} else if c.queue.NumRequeues(key) < maxRetries {
 		log.Printf("Error updating %s, will retry: %v", key, err)
 		c.queue.AddRateLimited(key)
@@ -156,12 +318,29 @@ func (c *Controller) processNextItem() bool {
 		// err != nil and too many retries
 		log.Printf("Error updating %s, giving up: %v", key, err)
 		c.queue.Forget(key)
+		notifications.notify(notifyUnsealFailed, key.(queueKey).String(), err)
 		utilruntime.HandleError(err)
 	}
 
 	return true
 }
 
+func (c *Controller) sync(key queueKey) error {
+	switch key.kind {
+	case sealedConfigMapKind:
+		if c.cmInformer == nil {
+			return nil
+		}
+		return c.unsealConfigMap(key.key)
+	case sealedObjectKind:
+		return c.unsealObject(key.key)
+	case secretKind:
+		return c.convert(key.key)
+	default:
+		return c.unseal(key.key)
+	}
+}
+
 func (c *Controller) unseal(key string) error {
 	obj, exists, err := c.informer.GetIndexer().GetByKey(key)
 	if err != nil {
@@ -170,53 +349,384 @@ func (c *Controller) unseal(key string) error {
 	}
 
 	if !exists {
-		log.Printf("SealedSecret %s has gone, deleting Secret", key)
 		ns, name, err := cache.SplitMetaNamespaceKey(key)
 		if err != nil {
 			return err
 		}
-		err = c.sclient.Secrets(ns).Delete(name, &metav1.DeleteOptions{})
-		if err != nil && !errors.IsNotFound(err) {
-			return err
+		secret, err := c.sclient.Secrets(ns).Get(name, metav1.GetOptions{})
+		if err == nil {
+			if released(secret) {
+				// Retained by finalize
+				return nil
+			}
+			moved, err := c.ownerExists(secret)
+			if err != nil {
+				return err
+			}
+			if moved {
+				log.Printf("SealedSecret %s no longer matches --label-selector, leaving Secret to the controller handling it", key)
+				return nil
+			}
 		}
-		return nil
+		log.Printf("SealedSecret %s has gone, deleting Secret", key)
+		return c.deleteSecret(key)
 	}
 
 	ssecret := obj.(*ssv1alpha1.SealedSecret)
+	if ssecret.GetDeletionTimestamp() != nil {
+		return c.finalize(ssecret)
+	}
+	if ssecret, err = c.ensureFinalizer(ssecret); err != nil {
+		return err
+	}
+	skip, err := c.skipDisabledNamespace(sealedSecretKind, key, ssecret.GetNamespace())
+	if err != nil {
+		return err
+	}
+	if skip {
+		return c.updateResult(ssecret, ssv1alpha1.SealedSecretSkipped, apiv1.ConditionTrue, "NamespaceNotEnabled", fmt.Sprintf("Namespace %s doesn't match %q", ssecret.GetNamespace(), c.nsSelector.String()))
+	}
+	if cond := ssecret.GetCondition(ssv1alpha1.SealedSecretSkipped); cond != nil && cond.Status == apiv1.ConditionTrue {
+		if err := c.updateCondition(ssecret, ssv1alpha1.SealedSecretSkipped, apiv1.ConditionFalse, "NamespaceEnabled", ""); err != nil {
+			return err
+		}
+	}
+	if ok, msg := c.checkQuota(ssecret); !ok {
+		log.Printf("SealedSecret %s is over quota: %s", key, msg)
+		return c.updateResult(ssecret, ssv1alpha1.SealedSecretQuotaExceeded, apiv1.ConditionTrue, ssv1alpha1.ReasonQuota, msg)
+	}
+	if cond := ssecret.GetCondition(ssv1alpha1.SealedSecretQuotaExceeded); cond != nil && cond.Status == apiv1.ConditionTrue {
+		if err := c.updateCondition(ssecret, ssv1alpha1.SealedSecretQuotaExceeded, apiv1.ConditionFalse, "WithinQuota", ""); err != nil {
+			return err
+		}
+	}
+	if msg := c.sizeLimits.checkSealed(ssecret); msg != "" {
+		log.Printf("SealedSecret %s is too large: %s", key, msg)
+		return c.updateResult(ssecret, ssv1alpha1.SealedSecretTooLarge, apiv1.ConditionTrue, "SealedSecretTooLarge", msg)
+	}
 	log.Printf("Updating %s", key)
 
-	secret, err := c.attemptUnseal(ssecret)
+	if expiry, ok := ssecret.Expiry(); ok {
+		remaining := time.Until(expiry)
+		if remaining <= 0 {
+			return c.expire(ssecret)
+		}
+		// Come back when it expires
+		c.queue.AddAfter(queueKey{sealedSecretKind, key}, remaining)
+	}
+	if cond := ssecret.GetCondition(ssv1alpha1.SealedSecretExpired); cond != nil && cond.Status == apiv1.ConditionTrue {
+		// The expiry has been postponed
+		if err := c.updateCondition(ssecret, ssv1alpha1.SealedSecretExpired, apiv1.ConditionFalse, "", ""); err != nil {
+			return err
+		}
+	}
+
+	if activateAt := ssecret.Spec.ActivateAt; activateAt != nil {
+		if remaining := time.Until(activateAt.Time); remaining > 0 {
+			log.Printf("SealedSecret %s is not active yet", key)
+			c.queue.AddAfter(queueKey{sealedSecretKind, key}, remaining)
+			return c.updateResult(ssecret, ssv1alpha1.SealedSecretPending, apiv1.ConditionTrue, "NotYetActive", fmt.Sprintf("Activates at %s", activateAt.UTC().Format(time.RFC3339)))
+		}
+	}
+	if cond := ssecret.GetCondition(ssv1alpha1.SealedSecretPending); cond != nil && cond.Status == apiv1.ConditionTrue {
+		if err := c.updateCondition(ssecret, ssv1alpha1.SealedSecretPending, apiv1.ConditionFalse, "Activated", ""); err != nil {
+			return err
+		}
+	}
+
+	secret, privKey, err := c.attemptUnsealWithKey(ssecret)
+	if err == seal.ErrNoKey {
+		if cerr := c.sealedWithCompromisedKey(ssecret); cerr != nil {
+			log.Printf("SealedSecret %s was sealed with blacklisted key %s", key, cerr.key)
+			if uerr := c.updateCondition(ssecret, ssv1alpha1.SealedSecretSealedWithCompromisedKey, apiv1.ConditionTrue, "KeyBlacklisted", cerr.Error()); uerr != nil {
+				log.Printf("Error updating status of SealedSecret %s: %v", key, uerr)
+			}
+			c.recordEvent(ssecret, apiv1.EventTypeWarning, "KeyBlacklisted", cerr.Error())
+			err = cerr
+		}
+	}
+	auditLog.record(auditResult(auditEvent{
+		Operation: "unseal",
+		Object:    key,
+		Key:       keyFingerprint(privKey),
+	}, "success", err))
 	if err != nil {
+		return c.syncFailed(ssecret, err)
+	}
+	if cond := ssecret.GetCondition(ssv1alpha1.SealedSecretSealedWithCompromisedKey); cond != nil && cond.Status == apiv1.ConditionTrue {
+		if err := c.updateCondition(ssecret, ssv1alpha1.SealedSecretSealedWithCompromisedKey, apiv1.ConditionFalse, "Resealed", ""); err != nil {
+			return err
+		}
+	}
+	countKeyDecryption(privKey)
+	if ssecret, err = c.updateKeyFingerprint(ssecret, keyFingerprint(privKey)); err != nil {
 		return err
 	}
+	if err := checkMergeStrategy(ssecret.Spec.MergeStrategy); err != nil {
+		return c.syncFailed(ssecret, err)
+	}
+	if err := checkMetadataStrategy(ssecret.Spec.MetadataStrategy); err != nil {
+		return c.syncFailed(ssecret, err)
+	}
+	if err := checkTargets(ssecret); err != nil {
+		return c.syncFailed(ssecret, err)
+	}
+	filterAnnotations(secret, annotationAllowlist(ssecret))
+	copyLabels(ssecret, secret, labelAllowlist(ssecret))
+	rotateAt, err := c.generateItems(ssecret, secret)
+	if err != nil {
+		return c.syncFailed(ssecret, err)
+	}
+	if !rotateAt.IsZero() {
+		c.queue.AddAfter(queueKey{sealedSecretKind, key}, time.Until(rotateAt))
+	}
+	if err := renderTemplate(ssecret, secret); err != nil {
+		return c.syncFailed(ssecret, err)
+	}
+	if ssecret.Spec.MergeStrategy == ssv1alpha1.MergeStrategyMerge {
+		setManagedKeys(secret, dataKeys(secret.Data))
+	}
+	if standalone(ssecret) {
+		detach(secret, string(ssecret.GetUID()))
+	}
+	if ssecret.Spec.MetadataStrategy == ssv1alpha1.MetadataStrategyPrune {
+		setManagedMetadata(secret)
+	}
+	setDataHash(secret)
+
+	if msg := c.sizeLimits.checkSecret(secret); msg != "" {
+		log.Printf("SealedSecret %s is too large: %s", key, msg)
+		return c.updateResult(ssecret, ssv1alpha1.SealedSecretTooLarge, apiv1.ConditionTrue, "SecretTooLarge", msg)
+	}
+	if cond := ssecret.GetCondition(ssv1alpha1.SealedSecretTooLarge); cond != nil && cond.Status == apiv1.ConditionTrue {
+		if err := c.updateCondition(ssecret, ssv1alpha1.SealedSecretTooLarge, apiv1.ConditionFalse, "WithinLimits", ""); err != nil {
+			return err
+		}
+	}
+
+	if msg := c.checkPolicies(secret); msg != "" {
+		log.Printf("SealedSecret %s violates a policy, not unsealing it", key)
+		return c.updateResult(ssecret, ssv1alpha1.SealedSecretPolicyDenied, apiv1.ConditionTrue, "PolicyViolation", msg)
+	}
+	if cond := ssecret.GetCondition(ssv1alpha1.SealedSecretPolicyDenied); cond != nil && cond.Status == apiv1.ConditionTrue {
+		if err := c.updateCondition(ssecret, ssv1alpha1.SealedSecretPolicyDenied, apiv1.ConditionFalse, "Allowed", ""); err != nil {
+			return err
+		}
+	}
+
+	if c.readOnly() {
+		return c.dryRunSecret(ssecret, secret)
+	}
+	if cond := ssecret.GetCondition(ssv1alpha1.SealedSecretDryRun); cond != nil && cond.Status == apiv1.ConditionTrue {
+		if err := c.updateCondition(ssecret, ssv1alpha1.SealedSecretDryRun, apiv1.ConditionFalse, "Applied", ""); err != nil {
+			return err
+		}
+	}
+
+	if err := c.replicate(ssecret, secret); err != nil {
+		return c.syncFailed(ssecret, err)
+	}
 
 	_, err = c.sclient.Secrets(ssecret.GetObjectMeta().GetNamespace()).Create(secret)
 	if err == nil {
 		// Secret successfully created
-		return nil
+		return c.updateResult(ssecret, ssv1alpha1.SealedSecretSynced, apiv1.ConditionTrue, ssv1alpha1.ReasonUnsealed, "")
 	}
 	if !errors.IsAlreadyExists(err) {
 		// Error wasn't already exists so is real error
-		return err
+		return c.syncFailed(ssecret, err)
 	}
 
 
 	// Secret already exists so update it in place with new data/owner reference
-	updatedSecret, err := c.updateSecret(secret)
+	existingSecret, err := c.sclient.Secrets(secret.GetNamespace()).Get(secret.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to read existing secret: %s", err)
+	}
+	drifted := hasDrifted(existingSecret, secret)
+	forced := forceSyncRequested(existingSecret, secret)
+	if forced {
+		log.Printf("Force-sync of Secret %s requested", key)
+	}
+	if drifted && !*restoreDrift && !forced {
+		log.Printf("Secret %s has been edited, leaving it alone", key)
+		return c.updateResult(ssecret, ssv1alpha1.SealedSecretDrifted, apiv1.ConditionTrue, "ManuallyEdited", "Secret data no longer matches the SealedSecret")
+	}
+
+	updatedSecret, err := c.updateSecret(existingSecret, secret, ssecret.Spec.MergeStrategy, ssecret.Spec.MetadataStrategy, annotationAllowlist(ssecret), labelAllowlist(ssecret))
 	if err != nil {
 		return fmt.Errorf("failed to update existing secret: %s", err)
 	}
 	_, err = c.sclient.Secrets(ssecret.GetObjectMeta().GetNamespace()).Update(updatedSecret)
+	if err != nil {
+		return c.syncFailed(ssecret, err)
+	}
+	// Only once the data has actually been overwritten, so that a
+	// failed update doesn't leave a revision behind on every retry.
+	if c.historyLimit > 0 && !reflect.DeepEqual(existingSecret.Data, updatedSecret.Data) {
+		if err := c.saveRevision(existingSecret); err != nil {
+			return err
+		}
+	}
+	if err := c.updateResult(ssecret, ssv1alpha1.SealedSecretSynced, apiv1.ConditionTrue, ssv1alpha1.ReasonUnsealed, ""); err != nil {
+		return err
+	}
+
+	if cond := ssecret.GetCondition(ssv1alpha1.SealedSecretDrifted); drifted || (cond != nil && cond.Status == apiv1.ConditionTrue) {
+		return c.updateCondition(ssecret, ssv1alpha1.SealedSecretDrifted, apiv1.ConditionFalse, "Restored", "")
+	}
+	return nil
+}
+
+// expire deletes the Secret of an expired SealedSecret.
+func (c *Controller) expire(ssecret *ssv1alpha1.SealedSecret) error {
+	expiry, _ := ssecret.Expiry()
+	log.Printf("SealedSecret %s/%s has expired, deleting Secret", ssecret.GetNamespace(), ssecret.GetName())
+	if c.readOnly() {
+		msg := fmt.Sprintf("Secret %s/%s would be deleted, expired at %s", ssecret.GetNamespace(), ssecret.GetName(), expiry.UTC().Format(time.RFC3339))
+		log.Printf("Dry run: %s", msg)
+		return c.updateResult(ssecret, ssv1alpha1.SealedSecretDryRun, apiv1.ConditionTrue, "WouldDelete", msg)
+	}
+	err := c.sclient.Secrets(ssecret.GetNamespace()).Delete(ssecret.GetName(), &metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err := c.deleteReplicas(fmt.Sprintf("%s/%s", ssecret.GetNamespace(), ssecret.GetName()), nil); err != nil {
+		return err
+	}
+	return c.updateResult(ssecret, ssv1alpha1.SealedSecretExpired, apiv1.ConditionTrue, "Expired", fmt.Sprintf("Expired at %s", expiry.UTC().Format(time.RFC3339)))
+}
+
+// updateCondition sets a condition in the status of ssecret, if it
+// isn't set already.
+func (c *Controller) updateCondition(ssecret *ssv1alpha1.SealedSecret, t ssv1alpha1.SealedSecretConditionType, status apiv1.ConditionStatus, reason, message string) error {
+	_, err := c.updateStatus(ssecret, func(s *ssv1alpha1.SealedSecret) bool {
+		return s.SetCondition(t, status, reason, message)
+	})
 	return err
 }
 
-func (c *Controller) updateSecret(newSecret *apiv1.Secret) (*apiv1.Secret, error) {
-	existingSecret, err := c.sclient.Secrets(newSecret.GetObjectMeta().GetNamespace()).Get(newSecret.GetObjectMeta().GetName(), metav1.GetOptions{})
+// updateResult is like updateCondition, for the condition telling the
+// outcome of a sync, and also records the generation of ssecret that
+// has been processed.
+func (c *Controller) updateResult(ssecret *ssv1alpha1.SealedSecret, t ssv1alpha1.SealedSecretConditionType, status apiv1.ConditionStatus, reason, message string) error {
+	_, err := c.updateStatus(ssecret, func(s *ssv1alpha1.SealedSecret) bool {
+		changed := s.SetCondition(t, status, reason, message)
+		return s.SetObservedGeneration(ssecret.GetGeneration()) || changed
+	})
+	return err
+}
+
+// updateKeyFingerprint records the fingerprint of the key that
+// decrypted ssecret in its status, and returns the updated ssecret.
+func (c *Controller) updateKeyFingerprint(ssecret *ssv1alpha1.SealedSecret, fingerprint string) (*ssv1alpha1.SealedSecret, error) {
+	if fingerprint == "" {
+		return ssecret, nil
+	}
+	return c.updateStatus(ssecret, func(s *ssv1alpha1.SealedSecret) bool {
+		return s.SetKeyFingerprint(fingerprint)
+	})
+}
+
+// updateStatus applies update to the status of ssecret and stores it
+// if update reports a change. The status is read again before the
+// update, as other parts of it may have been updated since ssecret was
+// fetched from the informer. Returns the updated ssecret.
+func (c *Controller) updateStatus(ssecret *ssv1alpha1.SealedSecret, update func(*ssv1alpha1.SealedSecret) bool) (*ssv1alpha1.SealedSecret, error) {
+	if !update(ssecret.DeepCopy()) {
+		return ssecret, nil
+	}
+	client := c.ssclient.BitnamiV1alpha1().SealedSecrets(ssecret.GetNamespace())
+	current, err := client.Get(ssecret.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if !update(current) {
+		return current, nil
+	}
+	return client.UpdateStatus(current)
+}
+
+func (c *Controller) unsealConfigMap(key string) error {
+	obj, exists, err := c.cmInformer.GetIndexer().GetByKey(key)
+	if err != nil {
+		log.Printf("Error fetching object with key %s from store: %v", key, err)
+		return err
+	}
+
+	ns, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		log.Printf("SealedConfigMap %s has gone, deleting ConfigMap", key)
+		if c.readOnly() {
+			log.Printf("Dry run: ConfigMap %s would be deleted", key)
+			return nil
+		}
+		err = c.cmclient.ConfigMaps(ns).Delete(name, &metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+
+	scm := obj.(*ssv1alpha1.SealedConfigMap)
+	if skip, err := c.skipDisabledNamespace(sealedConfigMapKind, key, ns); err != nil || skip {
+		return err
+	}
+	log.Printf("Updating SealedConfigMap %s", key)
+
+	keys, err := c.keysFor(ns)
+	if err != nil {
+		return err
+	}
+	cm, err := seal.UnsealConfigMap(scm, keys)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read existing secret: %s", err)
+		return err
 	}
+	if c.readOnly() {
+		log.Printf("Dry run: ConfigMap %s would be created or updated", key)
+		return nil
+	}
+
+	_, err = c.cmclient.ConfigMaps(ns).Create(cm)
+	if err == nil || !errors.IsAlreadyExists(err) {
+		return err
+	}
+
+	// ConfigMap already exists so update it in place with new data/owner reference
+	existing, err := c.cmclient.ConfigMaps(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to read existing configmap: %s", err)
+	}
+	existing = existing.DeepCopy()
+	existing.Data = cm.Data
+	existing.BinaryData = cm.BinaryData
+	existing.SetOwnerReferences(mergeOwnerReferences(existing.GetOwnerReferences(), cm.GetOwnerReferences()))
+
+	_, err = c.cmclient.ConfigMaps(ns).Update(existing)
+	return err
+}
+
+func (c *Controller) updateSecret(existingSecret, newSecret *apiv1.Secret, strategy, metadataStrategy string, annotations, labels []string) (*apiv1.Secret, error) {
+	data := mergeData(existingSecret, newSecret, strategy)
 	existingSecret = existingSecret.DeepCopy()
-	existingSecret.Data = newSecret.Data
+	mergeMetadata(existingSecret, newSecret, metadataStrategy)
+	syncMetadata(existingSecret, newSecret, annotations, labels)
+	existingSecret.Data = data
+	if managedKeys(newSecret) != nil {
+		setManagedKeys(existingSecret, managedKeys(newSecret))
+	} else {
+		delete(existingSecret.Annotations, SealedSecretsManagedKeysAnnotation)
+	}
+	setDataHash(existingSecret)
+	for _, k := range []string{SealedSecretsForceSyncAnnotation, SealedSecretsGeneratedAtAnnotation} {
+		if value, ok := newSecret.GetAnnotations()[k]; ok {
+			existingSecret.Annotations[k] = value
+		}
+	}
 
 	c.updateOwnerReferences(existingSecret, newSecret)
 
@@ -224,9 +734,18 @@ func (c *Controller) updateSecret(newSecret *apiv1.Secret) (*apiv1.Secret, error
 }
 
 func (c *Controller) updateOwnerReferences(existing, new *apiv1.Secret) {
-	ownerRefs := existing.GetOwnerReferences()
+	if uid, ok := new.GetLabels()[SealedSecretsUIDLabel]; ok {
+		detach(existing, uid)
+		return
+	}
+	delete(existing.Labels, SealedSecretsUIDLabel)
+	existing.SetOwnerReferences(mergeOwnerReferences(existing.GetOwnerReferences(), new.GetOwnerReferences()))
+}
 
-	for _, newRef := range new.GetOwnerReferences() {
+// mergeOwnerReferences adds the references of newRefs missing from
+// ownerRefs.
+func mergeOwnerReferences(ownerRefs, newRefs []metav1.OwnerReference) []metav1.OwnerReference {
+	for _, newRef := range newRefs {
 		found := false
 		for _, ref := range ownerRefs {
 			if newRef.UID == ref.UID {
@@ -238,7 +757,7 @@ func (c *Controller) updateOwnerReferences(existing, new *apiv1.Secret) {
 			ownerRefs = append(ownerRefs, newRef)
 		}
 	}
-	existing.SetOwnerReferences(ownerRefs)
+	return ownerRefs
 }
 
 func (c *Controller) AttemptUnseal(content []byte) (bool, error) {
@@ -274,10 +793,9 @@ func (c *Controller) Rotate(content []byte) ([]byte, error) {
 		if err != nil {
 			return nil, fmt.Errorf("Error decrypting secret. %v", err)
 		}
-		latestPrivKey := c.keyRegistry.latestPrivateKey()
-		resealedSecret, err := ssv1alpha1.NewSealedSecret(scheme.Codecs, &latestPrivKey.PublicKey, secret)
+		resealedSecret, err := c.reseal(s, secret)
 		if err != nil {
-			return nil, fmt.Errorf("Error creating new sealed secret. %v", err)
+			return nil, err
 		}
 		data, err := json.Marshal(resealedSecret)
 		if err != nil {
@@ -289,15 +807,112 @@ func (c *Controller) Rotate(content []byte) ([]byte, error) {
 	}
 }
 
-func (c *Controller) attemptUnseal(ss *ssv1alpha1.SealedSecret) (*apiv1.Secret, error) {
-	return attemptUnseal(ss, c.keyRegistry)
+// reseal seals secret, unsealed from s, again with the latest key.
+func (c *Controller) reseal(s *ssv1alpha1.SealedSecret, secret *apiv1.Secret) (*ssv1alpha1.SealedSecret, error) {
+	keys, err := c.keysFor(s.GetNamespace())
+	if err != nil {
+		return nil, err
+	}
+	latestPrivKey := keys.latestPrivateKey()
+	var resealedSecret *ssv1alpha1.SealedSecret
+	switch {
+	case keys.external != nil:
+		pubKey := keys.external.certificate().PublicKey.(*rsa.PublicKey)
+		resealedSecret, err = ssv1alpha1.NewSealedSecretExternal(scheme.Codecs, pubKey, secret)
+	case s.Spec.Algorithm == ssv1alpha1.AlgorithmX25519:
+		_, x25519PubKey, xerr := crypto.X25519KeyFromRSA(latestPrivKey)
+		if xerr != nil {
+			return nil, xerr
+		}
+		resealedSecret, err = ssv1alpha1.NewSealedSecretX25519(scheme.Codecs, x25519PubKey, secret)
+	case s.Spec.Algorithm == ssv1alpha1.AlgorithmRSAMLKEM:
+		kemKey, kerr := crypto.MLKEMKeyFromRSA(latestPrivKey)
+		if kerr != nil {
+			return nil, kerr
+		}
+		resealedSecret, err = ssv1alpha1.NewSealedSecretRSAMLKEM(scheme.Codecs, &latestPrivKey.PublicKey, kemKey.EncapsulationKey(), secret)
+	default:
+		resealedSecret, err = ssv1alpha1.NewSealedSecretParams(scheme.Codecs, &latestPrivKey.PublicKey, s.Spec.SealingParams(), secret)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error creating new sealed secret. %v", err)
+	}
+	resealedSecret.Spec.ExpireAfter = s.Spec.ExpireAfter
+	resealedSecret.Spec.NotAfter = s.Spec.NotAfter
+	resealedSecret.Spec.ActivateAt = s.Spec.ActivateAt
+	resealedSecret.Spec.MergeStrategy = s.Spec.MergeStrategy
+	resealedSecret.Spec.MetadataStrategy = s.Spec.MetadataStrategy
+	resealedSecret.Spec.Targets = s.Spec.Targets
+	resealedSecret.Spec.Template = s.Spec.Template
+	resealedSecret.Spec.Generate = s.Spec.Generate
+	return resealedSecret, nil
 }
 
-func attemptUnseal(ss *ssv1alpha1.SealedSecret, keyRegistry *KeyRegistry) (*apiv1.Secret, error) {
-	for _, privKey := range keyRegistry.privateKeys {
-		if secret, err := ss.Unseal(scheme.Codecs, privKey); err == nil {
-			return secret, nil
+// Seal takes a plain secret and returns it sealed with the latest key,
+// in the scope requested by its annotations.
+func (c *Controller) Seal(content []byte) ([]byte, error) {
+	object, err := runtime.Decode(scheme.Codecs.UniversalDecoder(apiv1.SchemeGroupVersion), content)
+	if err != nil {
+		return nil, err
+	}
+
+	switch s := object.(type) {
+	case *apiv1.Secret:
+		keys, err := c.keysFor(s.GetNamespace())
+		if err != nil {
+			return nil, err
+		}
+		ssecret, err := keys.seal(s, seal.ScopeOf(s))
+		if err != nil {
+			return nil, fmt.Errorf("Error creating new sealed secret. %v", err)
+		}
+		ssecret.TypeMeta = metav1.TypeMeta{
+			APIVersion: ssv1alpha1.SchemeGroupVersion.String(),
+			Kind:       "SealedSecret",
 		}
+		data, err := json.Marshal(ssecret)
+		if err != nil {
+			return nil, fmt.Errorf("Error marshalling new secret to json. %v", err)
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("Unexpected resource type: %s", s.GetObjectKind().GroupVersionKind().String())
+	}
+}
+
+// sealedWithCompromisedKey returns the error telling which blacklisted
+// key decrypts ssecret, nil if none does.
+func (c *Controller) sealedWithCompromisedKey(ssecret *ssv1alpha1.SealedSecret) *compromisedKeyError {
+	keys, err := c.keysFor(ssecret.GetNamespace())
+	if err != nil {
+		return nil
+	}
+	return keys.sealedWithCompromisedKey(ssecret)
+}
+
+// keysFor returns the keys used for objects in namespace ns.
+func (c *Controller) keysFor(ns string) (*KeyRegistry, error) {
+	if c.nsKeys != nil {
+		return c.nsKeys.registry(ns)
+	}
+	return c.keyRegistry, nil
+}
+
+func (c *Controller) attemptUnseal(ss *ssv1alpha1.SealedSecret) (*apiv1.Secret, error) {
+	secret, _, err := c.attemptUnsealWithKey(ss)
+	return secret, err
+}
+
+// attemptUnsealWithKey is like attemptUnseal, and also returns the key
+// that decrypted ss.
+func (c *Controller) attemptUnsealWithKey(ss *ssv1alpha1.SealedSecret) (*apiv1.Secret, *rsa.PrivateKey, error) {
+	keys, err := c.keysFor(ss.GetNamespace())
+	if err != nil {
+		return nil, nil, err
 	}
-	return nil, fmt.Errorf("No key could decrypt secret")
+	return seal.UnsealWithKey(ss, keys)
+}
+
+func attemptUnseal(ss *ssv1alpha1.SealedSecret, keyRegistry *KeyRegistry) (*apiv1.Secret, error) {
+	return seal.Unseal(ss, keyRegistry)
 }
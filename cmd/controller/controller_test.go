@@ -0,0 +1,739 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	ssfake "github.com/bitnami-labs/sealed-secrets/pkg/client/clientset/versioned/fake"
+	ssinformer "github.com/bitnami-labs/sealed-secrets/pkg/client/informers/externalversions"
+	"github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+// newTestController returns a controller, without running informers,
+// whose store holds ssecret.
+func newTestController(t *testing.T, ssecret *ssv1alpha1.SealedSecret, objs ...runtime.Object) *Controller {
+	client := fake.NewSimpleClientset(objs...)
+	ssclient := ssfake.NewSimpleClientset(ssecret)
+
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &ssv1alpha1.SealedSecret{}, 0, cache.Indexers{})
+	if err := informer.GetIndexer().Add(ssecret); err != nil {
+		t.Fatal(err)
+	}
+
+	return &Controller{
+		queue:    workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		informer: informer,
+		sclient:  client.Core(),
+		nsclient: client.Core(),
+		ssclient: ssclient,
+	}
+}
+
+func testRegistry(t *testing.T) *KeyRegistry {
+	registry, err := initKeyRegistry(newSecretKeyStore(fake.NewSimpleClientset(), "namespace", "label"), "prefix", 1024)
+	if err != nil {
+		t.Fatalf("initKeyRegistry() returned err: %v", err)
+	}
+	if _, err := registry.generateKey(); err != nil {
+		t.Fatalf("generateKey() returned err: %v", err)
+	}
+	return registry
+}
+
+func testSealedSecret(t *testing.T, registry *KeyRegistry) *ssv1alpha1.SealedSecret {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "myns"},
+		Data:       map[string][]byte{"foo": []byte("bar")},
+	}
+	ssecret, err := seal.Seal(secret, &registry.latestPrivateKey().PublicKey, seal.StrictScope)
+	if err != nil {
+		t.Fatalf("Seal() returned err: %v", err)
+	}
+	ssecret.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+	return ssecret
+}
+
+func TestNewControllerSealedConfigMaps(t *testing.T) {
+	ssclient := ssfake.NewSimpleClientset()
+	factory := ssinformer.NewSharedInformerFactory(ssclient, 0)
+
+	// CRD not installed
+	c := NewController(fake.NewSimpleClientset(), ssclient, factory, nil, nil)
+	if c.cmInformer != nil {
+		t.Errorf("Expected SealedConfigMaps not to be watched without their CRD")
+	}
+	if err := c.sync(queueKey{sealedConfigMapKind, "myns/myconfig"}); err != nil {
+		t.Errorf("sync() returned err: %v", err)
+	}
+
+	ssclient.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{{
+		GroupVersion: ssv1alpha1.SchemeGroupVersion.String(),
+		APIResources: []metav1.APIResource{{Name: "sealedsecrets"}, {Name: "sealedconfigmaps"}},
+	}}
+	c = NewController(fake.NewSimpleClientset(), ssclient, factory, nil, nil)
+	if c.cmInformer == nil {
+		t.Errorf("Expected SealedConfigMaps to be watched with their CRD")
+	}
+}
+
+func TestUnsealExpired(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+	ssecret.Spec.ExpireAfter = &metav1.Duration{Duration: time.Minute}
+
+	existing := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "myns"}}
+	c := newTestController(t, ssecret, existing)
+	c.keyRegistry = registry
+
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	if _, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected Secret to be deleted, got %v", err)
+	}
+
+	updated, err := c.ssclient.BitnamiV1alpha1().SealedSecrets("myns").Get("mysecret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cond := updated.GetCondition(ssv1alpha1.SealedSecretExpired); cond == nil || cond.Status != v1.ConditionTrue {
+		t.Errorf("Expected Expired condition, got %v", updated.Status)
+	}
+}
+
+func TestUnsealNotExpired(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+	ssecret.Spec.ExpireAfter = &metav1.Duration{Duration: 2 * time.Hour}
+	ssecret.SetCondition(ssv1alpha1.SealedSecretExpired, v1.ConditionTrue, "Expired", "")
+
+	c := newTestController(t, ssecret)
+	c.keyRegistry = registry
+
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	secret, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected Secret to be created, got %v", err)
+	}
+	if string(secret.Data["foo"]) != "bar" {
+		t.Errorf("Unexpected data %v", secret.Data)
+	}
+
+	updated, err := c.ssclient.BitnamiV1alpha1().SealedSecrets("myns").Get("mysecret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cond := updated.GetCondition(ssv1alpha1.SealedSecretExpired); cond == nil || cond.Status != v1.ConditionFalse {
+		t.Errorf("Expected Expired condition to be cleared, got %v", updated.Status)
+	}
+}
+
+func TestUnsealRecordsKeyFingerprint(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+
+	c := newTestController(t, ssecret)
+	c.keyRegistry = registry
+
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	updated, err := c.ssclient.BitnamiV1alpha1().SealedSecrets("myns").Get("mysecret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := seal.Fingerprint(&registry.latestPrivateKey().PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Status == nil || updated.Status.KeyFingerprint != expected {
+		t.Errorf("Expected key fingerprint %s, got status %v", expected, updated.Status)
+	}
+}
+
+func TestUnsealPending(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+	activateAt := metav1.NewTime(time.Now().Add(time.Hour))
+	ssecret.Spec.ActivateAt = &activateAt
+
+	c := newTestController(t, ssecret)
+	c.keyRegistry = registry
+
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	if _, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected no Secret before activation, got %v", err)
+	}
+	updated, err := c.ssclient.BitnamiV1alpha1().SealedSecrets("myns").Get("mysecret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cond := updated.GetCondition(ssv1alpha1.SealedSecretPending); cond == nil || cond.Status != v1.ConditionTrue {
+		t.Fatalf("Expected Pending condition, got %v", updated.Status)
+	}
+
+	// Activation time reached
+	activateAt = metav1.NewTime(time.Now().Add(-time.Minute))
+	updated.Spec.ActivateAt = &activateAt
+	if err := c.informer.GetIndexer().Update(updated); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	if _, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected Secret to be created, got %v", err)
+	}
+	updated, err = c.ssclient.BitnamiV1alpha1().SealedSecrets("myns").Get("mysecret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cond := updated.GetCondition(ssv1alpha1.SealedSecretPending); cond == nil || cond.Status != v1.ConditionFalse {
+		t.Errorf("Expected Pending condition to be cleared, got %v", updated.Status)
+	}
+}
+
+func TestUnsealHistory(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+
+	existing := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "myns"},
+		Data:       map[string][]byte{"foo": []byte("old")},
+	}
+	c := newTestController(t, ssecret, existing)
+	c.keyRegistry = registry
+	c.historyLimit = 2
+
+	for i := 0; i < 3; i++ {
+		if err := c.unseal("myns/mysecret"); err != nil {
+			t.Fatalf("unseal() returned err: %v", err)
+		}
+		// Simulate a previous SealedSecret having been unsealed
+		secret, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		secret.Data["foo"] = []byte(fmt.Sprintf("old%d", i))
+		setDataHash(secret)
+		if _, err := c.sclient.Secrets("myns").Update(secret); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for name, value := range map[string]string{"mysecret-rev-2": "old0", "mysecret-rev-3": "old1"} {
+		rev, err := c.sclient.Secrets("myns").Get(name, metav1.GetOptions{})
+		if err != nil {
+			t.Errorf("Expected revision %s, got %v", name, err)
+			continue
+		}
+		if string(rev.Data["foo"]) != value {
+			t.Errorf("Unexpected data %q in %s", rev.Data["foo"], name)
+		}
+	}
+	if _, err := c.sclient.Secrets("myns").Get("mysecret-rev-1", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected oldest revision to be pruned, got %v", err)
+	}
+}
+
+func TestUnsealHistoryFailedUpdate(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+
+	existing := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "myns"},
+		Data:       map[string][]byte{"foo": []byte("old")},
+	}
+	c := newTestController(t, ssecret)
+	c.keyRegistry = registry
+	c.historyLimit = 2
+
+	client := fake.NewSimpleClientset(existing)
+	client.PrependReactor("update", "secrets", func(action ktesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.NewConflict(schema.GroupResource{Resource: "secrets"}, "mysecret", fmt.Errorf("the object has been modified"))
+	})
+	c.sclient = client.Core()
+
+	for i := 0; i < 3; i++ {
+		if err := c.unseal("myns/mysecret"); err == nil {
+			t.Fatalf("Expected unseal() to fail")
+		}
+	}
+
+	list, err := c.sclient.Secrets("myns").List(metav1.ListOptions{LabelSelector: SealedSecretsHistoryLabel + "=mysecret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 0 {
+		t.Errorf("Expected no revision after failed updates, got %d", len(list.Items))
+	}
+}
+
+func TestUnsealDrifted(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+
+	c := newTestController(t, ssecret)
+	c.keyRegistry = registry
+
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	secret, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Annotations[SealedSecretsDataHashAnnotation] != dataHash(secret.Data) {
+		t.Errorf("Unexpected data hash annotation %v", secret.Annotations)
+	}
+
+	// Manual edit
+	secret.Data["foo"] = []byte("edited")
+	if _, err := c.sclient.Secrets("myns").Update(secret); err != nil {
+		t.Fatal(err)
+	}
+
+	getCondition := func() *ssv1alpha1.SealedSecretCondition {
+		updated, err := c.ssclient.BitnamiV1alpha1().SealedSecrets("myns").Get("mysecret", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Keep the store up to date, as the informer would
+		c.informer.GetIndexer().Update(updated)
+		return updated.GetCondition(ssv1alpha1.SealedSecretDrifted)
+	}
+
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	if cond := getCondition(); cond == nil || cond.Status != v1.ConditionTrue {
+		t.Errorf("Expected Drifted condition, got %v", cond)
+	}
+	secret, _ = c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{})
+	if string(secret.Data["foo"]) != "edited" {
+		t.Errorf("Expected Secret to be left alone, got %q", secret.Data["foo"])
+	}
+
+	*restoreDrift = true
+	defer func() { *restoreDrift = false }()
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	if cond := getCondition(); cond == nil || cond.Status != v1.ConditionFalse {
+		t.Errorf("Expected Drifted condition to be cleared, got %v", cond)
+	}
+	secret, _ = c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{})
+	if string(secret.Data["foo"]) != "bar" {
+		t.Errorf("Expected Secret to be restored, got %q", secret.Data["foo"])
+	}
+}
+
+func TestUnsealForceSync(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+
+	c := newTestController(t, ssecret)
+	c.keyRegistry = registry
+
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	edit := func() {
+		secret, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		secret.Data["foo"] = []byte("edited")
+		if _, err := c.sclient.Secrets("myns").Update(secret); err != nil {
+			t.Fatal(err)
+		}
+	}
+	unsealed := func() string {
+		if err := c.unseal("myns/mysecret"); err != nil {
+			t.Fatalf("unseal() returned err: %v", err)
+		}
+		secret, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(secret.Data["foo"])
+	}
+
+	edit()
+	updated, err := c.ssclient.BitnamiV1alpha1().SealedSecrets("myns").Get("mysecret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated.Annotations = map[string]string{SealedSecretsForceSyncAnnotation: "2019-06-01T10:00:00Z"}
+	c.informer.GetIndexer().Update(updated)
+	if got := unsealed(); got != "bar" {
+		t.Errorf("Expected force-sync to restore the Secret, got %q", got)
+	}
+
+	// Already handled
+	edit()
+	if got := unsealed(); got != "edited" {
+		t.Errorf("Expected Secret to be left alone, got %q", got)
+	}
+}
+
+func TestUnsealObservedGeneration(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+	ssecret.Generation = 2
+	activateAt := metav1.NewTime(time.Now().Add(time.Hour))
+	ssecret.Spec.ActivateAt = &activateAt
+
+	c := newTestController(t, ssecret)
+	c.keyRegistry = registry
+
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	updated, err := c.ssclient.BitnamiV1alpha1().SealedSecrets("myns").Get("mysecret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Status == nil || updated.Status.ObservedGeneration != 2 {
+		t.Errorf("Expected observed generation 2, got status %v", updated.Status)
+	}
+}
+
+func TestUnsealMergeStrategy(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+	ssecret.Spec.MergeStrategy = ssv1alpha1.MergeStrategyMerge
+
+	existing := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "mysecret",
+			Namespace:   "myns",
+			Annotations: map[string]string{SealedSecretsManagedKeysAnnotation: "removed"},
+		},
+		Data: map[string][]byte{"removed": []byte("1"), "other": []byte("2")},
+	}
+	c := newTestController(t, ssecret, existing)
+	c.keyRegistry = registry
+
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	secret, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]byte{"foo": []byte("bar"), "other": []byte("2")}
+	if !reflect.DeepEqual(secret.Data, expected) {
+		t.Errorf("Expected data %v, got %v", expected, secret.Data)
+	}
+	if got := secret.Annotations[SealedSecretsManagedKeysAnnotation]; got != "foo" {
+		t.Errorf("Expected managed keys %q, got %q", "foo", got)
+	}
+
+	// Keys of other tools aren't drift
+	secret.Data["other"] = []byte("3")
+	if _, err := c.sclient.Secrets("myns").Update(secret); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	updated, err := c.ssclient.BitnamiV1alpha1().SealedSecrets("myns").Get("mysecret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cond := updated.GetCondition(ssv1alpha1.SealedSecretDrifted); cond != nil && cond.Status == v1.ConditionTrue {
+		t.Errorf("Expected no drift, got %v", cond)
+	}
+}
+
+func TestUnsealUnknownMergeStrategy(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+	ssecret.Spec.MergeStrategy = "Union"
+
+	c := newTestController(t, ssecret)
+	c.keyRegistry = registry
+
+	if err := c.unseal("myns/mysecret"); err == nil {
+		t.Fatal("Expected unseal() to fail")
+	}
+	if _, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected no Secret, got err %v", err)
+	}
+}
+
+func TestUnsealEmptyData(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+	ssecret.Spec.EncryptedData = map[string][]byte{}
+
+	c := newTestController(t, ssecret)
+	c.keyRegistry = registry
+
+	if err := c.unseal("myns/mysecret"); err != ssv1alpha1.ErrEmptyData {
+		t.Fatalf("Expected %v, got %v", ssv1alpha1.ErrEmptyData, err)
+	}
+
+	ssecret.Annotations = map[string]string{ssv1alpha1.SealedSecretAllowEmptyDataAnnotation: "true"}
+	c.informer.GetIndexer().Update(ssecret)
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	secret, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secret.Data) != 0 {
+		t.Errorf("Expected no data, got %v", secret.Data)
+	}
+}
+
+func TestUnsealMetadataStrategy(t *testing.T) {
+	testCases := []struct {
+		strategy    string
+		labels      map[string]string
+		annotations []string
+	}{
+		{"", map[string]string{"old": "1", "other": "2"}, []string{"tool"}},
+		{ssv1alpha1.MetadataStrategyReplace, map[string]string{}, []string{"mine"}},
+		{ssv1alpha1.MetadataStrategyPrune, map[string]string{"other": "2"}, []string{"mine", "tool"}},
+	}
+	for _, tc := range testCases {
+		registry := testRegistry(t)
+		ssecret := testSealedSecret(t, registry)
+		ssecret.Annotations = map[string]string{"mine": "z"}
+		ssecret.Spec.MetadataStrategy = tc.strategy
+
+		existing := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "mysecret",
+				Namespace:   "myns",
+				Labels:      map[string]string{"old": "1", "other": "2"},
+				Annotations: map[string]string{"tool": "y", SealedSecretsManagedLabelsAnnotation: "old"},
+			},
+		}
+		c := newTestController(t, ssecret, existing)
+		c.keyRegistry = registry
+
+		if err := c.unseal("myns/mysecret"); err != nil {
+			t.Fatalf("unseal() returned err: %v", err)
+		}
+		secret, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		labels := secret.Labels
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		if !reflect.DeepEqual(labels, tc.labels) {
+			t.Errorf("%q: expected labels %v, got %v", tc.strategy, tc.labels, labels)
+		}
+		if got := stringKeys(secret.Annotations, bookkeepingAnnotations); !reflect.DeepEqual(got, tc.annotations) {
+			t.Errorf("%q: expected annotations %v, got %v", tc.strategy, tc.annotations, got)
+		}
+	}
+}
+
+func TestUnsealPropagateAnnotations(t *testing.T) {
+	defer func(patterns []string) { *propagateAnnotations = patterns }(*propagateAnnotations)
+	*propagateAnnotations = []string{"reloader.stakater.com/*"}
+
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+	ssecret.Annotations = map[string]string{
+		"reloader.stakater.com/match": "true",
+		"team":                        "search",
+		"kubectl.kubernetes.io/last-applied-configuration": "{}",
+	}
+	ssecret.Spec.Template = &ssv1alpha1.SealedSecretTemplate{PropagateAnnotations: []string{"team"}}
+	expected := []string{"reloader.stakater.com/match", "team"}
+
+	c := newTestController(t, ssecret)
+	c.keyRegistry = registry
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	secret, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := stringKeys(secret.Annotations, bookkeepingAnnotations); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected annotations %v on creation, got %v", expected, got)
+	}
+
+	existing := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "mysecret",
+			Namespace:   "myns",
+			Annotations: map[string]string{"tool": "y", "reloader.stakater.com/stale": "true", "team": "platform"},
+		},
+	}
+	c = newTestController(t, ssecret, existing)
+	c.keyRegistry = registry
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	if secret, err = c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expected = []string{"reloader.stakater.com/match", "team", "tool"}
+	if got := stringKeys(secret.Annotations, bookkeepingAnnotations); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected annotations %v on update, got %v", expected, got)
+	}
+	if secret.Annotations["team"] != "search" {
+		t.Errorf("Expected the team annotation to be updated, got %q", secret.Annotations["team"])
+	}
+}
+
+func TestUnsealPropagateLabels(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+	ssecret.Labels = map[string]string{
+		"app.kubernetes.io/name":    "search",
+		"team":                      "search",
+		"other":                     "x",
+		SealedSecretsReplicaOfLabel: "source",
+	}
+	ssecret.Spec.Template = &ssv1alpha1.SealedSecretTemplate{PropagateLabels: []string{"app.kubernetes.io/*", "team", "sealedsecrets.bitnami.com/*"}}
+
+	c := newTestController(t, ssecret)
+	c.keyRegistry = registry
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	secret, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"app.kubernetes.io/name": "search", "team": "search"}
+	if !reflect.DeepEqual(secret.Labels, expected) {
+		t.Errorf("Expected labels %v on creation, got %v", expected, secret.Labels)
+	}
+
+	existing := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mysecret",
+			Namespace: "myns",
+			Labels:    map[string]string{"tool": "y", "app.kubernetes.io/version": "1", "team": "platform"},
+		},
+	}
+	c = newTestController(t, ssecret, existing)
+	c.keyRegistry = registry
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	if secret, err = c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expected = map[string]string{"app.kubernetes.io/name": "search", "team": "search", "tool": "y"}
+	if !reflect.DeepEqual(secret.Labels, expected) {
+		t.Errorf("Expected labels %v on update, got %v", expected, secret.Labels)
+	}
+}
+
+func TestUnsealNoOwnerReferences(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+	ssecret.UID = "uid"
+	ssecret.Annotations = map[string]string{SealedSecretsNoOwnerReferencesAnnotation: "true"}
+
+	existing := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mysecret",
+			Namespace: "myns",
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "bitnami.com/v1alpha1", Kind: "SealedSecret", Name: "mysecret", UID: "uid"},
+			},
+		},
+	}
+	c := newTestController(t, ssecret, existing)
+	c.keyRegistry = registry
+
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	secret, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refs := secret.GetOwnerReferences(); len(refs) != 0 {
+		t.Errorf("Expected no owner references, got %v", refs)
+	}
+	if got := secret.Labels[SealedSecretsUIDLabel]; got != "uid" {
+		t.Errorf("Expected label %q, got %q", "uid", got)
+	}
+	if !ownedBySealedSecret(secret) {
+		t.Errorf("Expected Secret to be owned by its SealedSecret")
+	}
+}
+
+func TestUnsealOutOfShard(t *testing.T) {
+	testCases := []struct {
+		name string
+		// uid is that of the SealedSecret in the API server, empty if
+		// it was deleted.
+		uid      types.UID
+		labelled bool
+		kept     bool
+	}{
+		{"relabelled", "uid", false, true},
+		{"relabelled without owner references", "uid", true, true},
+		{"recreated", "other", false, false},
+		{"deleted", "", false, false},
+	}
+	for _, tc := range testCases {
+		registry := testRegistry(t)
+		ssecret := testSealedSecret(t, registry)
+		ssecret.UID = tc.uid
+		existing := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "myns"}}
+		if tc.labelled {
+			existing.Labels = map[string]string{SealedSecretsUIDLabel: "uid"}
+		} else {
+			existing.OwnerReferences = []metav1.OwnerReference{{APIVersion: "bitnami.com/v1alpha1", Kind: "SealedSecret", Name: "mysecret", UID: "uid"}}
+		}
+		c := newTestController(t, ssecret, existing)
+		c.keyRegistry = registry
+
+		// Gone from the informer of this controller
+		if err := c.informer.GetIndexer().Delete(ssecret); err != nil {
+			t.Fatal(err)
+		}
+		if tc.uid == "" {
+			if err := c.ssclient.BitnamiV1alpha1().SealedSecrets("myns").Delete("mysecret", &metav1.DeleteOptions{}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := c.unseal("myns/mysecret"); err != nil {
+			t.Fatalf("%s: unseal() returned err: %v", tc.name, err)
+		}
+		_, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{})
+		if tc.kept && err != nil {
+			t.Errorf("%s: expected Secret to be kept, got %v", tc.name, err)
+		}
+		if !tc.kept && !errors.IsNotFound(err) {
+			t.Errorf("%s: expected Secret to be deleted, got %v", tc.name, err)
+		}
+	}
+}
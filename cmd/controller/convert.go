@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	flag "github.com/spf13/pflag"
+	apiv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	"github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+const (
+	secretKind = "Secret"
+
+	// SealedSecretsConvertAnnotation requests the conversion of a
+	// Secret to a SealedSecret. With the "scrub" value, the data of
+	// the Secret is removed once converted.
+	SealedSecretsConvertAnnotation = "sealedsecrets.bitnami.com/convert"
+	// SealedSecretsConvertibleLabel must be set to "true" along with
+	// SealedSecretsConvertAnnotation, as only the Secrets having it are
+	// watched.
+	SealedSecretsConvertibleLabel = "sealedsecrets.bitnami.com/convertible"
+	// SealedSecretsManifestAnnotation holds the SealedSecret manifest,
+	// as JSON, of a converted Secret.
+	SealedSecretsManifestAnnotation = "sealedsecrets.bitnami.com/sealed-secret"
+)
+
+var (
+	convertSecrets = flag.Bool("enable-convert", false, "Watch the Secrets with the "+SealedSecretsConvertibleLabel+"=true label and convert those with the "+SealedSecretsConvertAnnotation+" annotation to SealedSecrets.")
+)
+
+// convertSelector selects the Secrets to watch for conversion.
+var convertSelector = SealedSecretsConvertibleLabel + "=true"
+
+// convertMode returns the value of the conversion annotation of secret,
+// empty if it has none.
+func convertMode(secret *apiv1.Secret) string {
+	return secret.GetAnnotations()[SealedSecretsConvertAnnotation]
+}
+
+// watchConvertibleSecrets makes c convert Secrets, see convert. It must
+// be called before Run.
+func (c *Controller) watchConvertibleSecrets(clientset kubernetes.Interface) {
+	lw := dataless(cache.NewFilteredListWatchFromClient(clientset.Core().RESTClient(), "secrets", metav1.NamespaceAll, func(options *metav1.ListOptions) {
+		options.LabelSelector = convertSelector
+	}))
+	c.secretInformer = cache.NewSharedIndexInformer(lw, &apiv1.Secret{}, 0, cache.Indexers{})
+	c.secretInformer.AddEventHandler(queueEventHandler(c.queue, secretKind))
+	go func() {
+		if err := c.reportUnwatchedConversions(clientset.Core()); err != nil {
+			log.Printf("Error looking for Secrets to convert without the %s label: %v", SealedSecretsConvertibleLabel, err)
+		}
+	}()
+}
+
+// reportUnwatchedConversions warns about the Secrets that have the
+// conversion annotation but not the label needed for them to be
+// watched, which would otherwise never be converted. The Secrets are
+// listed a page at a time, only once.
+func (c *Controller) reportUnwatchedConversions(sclient corev1.SecretsGetter) error {
+	options := metav1.ListOptions{Limit: 500}
+	for {
+		list, err := sclient.Secrets(metav1.NamespaceAll).List(options)
+		if err != nil {
+			return err
+		}
+		for i := range list.Items {
+			secret := &list.Items[i]
+			if convertMode(secret) == "" || secret.GetLabels()[SealedSecretsConvertibleLabel] == "true" {
+				continue
+			}
+			msg := fmt.Sprintf("Secret has the %s annotation but not the %s=true label, it won't be converted", SealedSecretsConvertAnnotation, SealedSecretsConvertibleLabel)
+			log.Printf("Secret %s/%s: %s", secret.GetNamespace(), secret.GetName(), msg)
+			dropData(secret)
+			c.recordEvent(secret, apiv1.EventTypeWarning, "ConvertibleLabelMissing", msg)
+		}
+		if options.Continue = list.GetContinue(); options.Continue == "" {
+			return nil
+		}
+	}
+}
+
+// dropData removes the data of obj if it is a Secret.
+func dropData(obj runtime.Object) {
+	if secret, ok := obj.(*apiv1.Secret); ok {
+		secret.Data = nil
+		secret.StringData = nil
+	}
+}
+
+// dataless wraps lw so that the Secrets it lists and watches have no
+// data, to avoid keeping every Secret of the cluster in memory. Their
+// data must be read from the API server when needed.
+func dataless(lw *cache.ListWatch) *cache.ListWatch {
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			obj, err := lw.List(options)
+			if err != nil {
+				return nil, err
+			}
+			if list, ok := obj.(*apiv1.SecretList); ok {
+				for i := range list.Items {
+					dropData(&list.Items[i])
+				}
+			}
+			return obj, nil
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			w, err := lw.Watch(options)
+			if err != nil {
+				return nil, err
+			}
+			return watch.Filter(w, func(e watch.Event) (watch.Event, bool) {
+				dropData(e.Object)
+				return e, true
+			}), nil
+		},
+	}
+}
+
+// convert seals a Secret annotated for conversion with the latest key
+// and stores the resulting SealedSecret in an annotation, ready to be
+// committed. The conversion annotation and label are removed, set them
+// again to convert the Secret once more.
+func (c *Controller) convert(key string) error {
+	obj, exists, err := c.secretInformer.GetIndexer().GetByKey(key)
+	if err != nil {
+		log.Printf("Error fetching object with key %s from store: %v", key, err)
+		return err
+	}
+	if !exists {
+		return nil
+	}
+
+	secret := obj.(*apiv1.Secret)
+	mode := convertMode(secret)
+	if mode == "" {
+		return nil
+	}
+	if mode != "true" && mode != "scrub" {
+		msg := fmt.Sprintf("Invalid %s annotation %q, must be true or scrub", SealedSecretsConvertAnnotation, mode)
+		log.Printf("Secret %s: %s", key, msg)
+		c.recordEvent(secret, apiv1.EventTypeWarning, "InvalidConvertAnnotation", msg)
+		return nil
+	}
+	if ownedBySealedSecret(secret) {
+		log.Printf("Secret %s is already managed by a SealedSecret, not converting it", key)
+		return nil
+	}
+	log.Printf("Converting Secret %s", key)
+
+	// The cached Secrets have no data
+	secret, err = c.sclient.Secrets(secret.GetNamespace()).Get(secret.GetName(), metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	plain := secret.DeepCopy()
+	annotations := map[string]string{}
+	for k, v := range plain.GetAnnotations() {
+		annotations[k] = v
+	}
+	delete(annotations, SealedSecretsConvertAnnotation)
+	delete(annotations, SealedSecretsManifestAnnotation)
+	plain.SetAnnotations(annotations)
+	plain.SetLabels(nil)
+	plain.SetOwnerReferences(nil)
+
+	keys, err := c.keysFor(secret.GetNamespace())
+	if err != nil {
+		return err
+	}
+	ssecret, err := keys.seal(plain, seal.ScopeOf(plain))
+	if err != nil {
+		return fmt.Errorf("Error creating new sealed secret. %v", err)
+	}
+	ssecret.TypeMeta = metav1.TypeMeta{
+		APIVersion: ssv1alpha1.SchemeGroupVersion.String(),
+		Kind:       "SealedSecret",
+	}
+	manifest, err := json.Marshal(ssecret)
+	if err != nil {
+		return fmt.Errorf("Error marshalling new secret to json. %v", err)
+	}
+
+	updated := secret.DeepCopy()
+	labels := copyStrings(updated.GetLabels())
+	delete(labels, SealedSecretsConvertibleLabel)
+	updated.SetLabels(labels)
+	updated.SetAnnotations(annotations)
+	updated.Annotations[SealedSecretsManifestAnnotation] = string(manifest)
+	if mode == "scrub" {
+		updated.Data = nil
+		updated.StringData = nil
+	}
+	if c.readOnly() {
+		log.Printf("Dry run: Secret %s would be annotated with its SealedSecret", key)
+		return nil
+	}
+	_, err = c.sclient.Secrets(secret.GetNamespace()).Update(updated)
+	return err
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	"github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+func TestConvert(t *testing.T) {
+	registry := testRegistry(t)
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "mysecret",
+			Namespace:   "myns",
+			Labels:      map[string]string{SealedSecretsConvertibleLabel: "true", "app": "kept"},
+			Annotations: map[string]string{SealedSecretsConvertAnnotation: "scrub", "other": "kept"},
+		},
+		Data: map[string][]byte{"foo": []byte("bar")},
+	}
+	client := fake.NewSimpleClientset(secret)
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &v1.Secret{}, 0, cache.Indexers{})
+	cached := secret.DeepCopy()
+	dropData(cached)
+	informer.GetIndexer().Add(cached)
+
+	c := &Controller{
+		secretInformer: informer,
+		sclient:        client.Core(),
+		keyRegistry:    registry,
+	}
+	if err := c.convert("myns/mysecret"); err != nil {
+		t.Fatalf("convert() returned err: %v", err)
+	}
+
+	updated, err := client.Core().Secrets("myns").Get("mysecret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := updated.Labels[SealedSecretsConvertibleLabel]; ok || updated.Labels["app"] != "kept" {
+		t.Errorf("Expected only the convertible label to be removed, got %v", updated.Labels)
+	}
+	if _, ok := updated.Annotations[SealedSecretsConvertAnnotation]; ok {
+		t.Errorf("Expected the convert annotation to be removed, got %v", updated.Annotations)
+	}
+	if len(updated.Data) != 0 {
+		t.Errorf("Expected data to be scrubbed, got %v", updated.Data)
+	}
+
+	var ssecret ssv1alpha1.SealedSecret
+	manifest := []byte(updated.Annotations[SealedSecretsManifestAnnotation])
+	if err := runtime.DecodeInto(scheme.Codecs.UniversalDecoder(), manifest, &ssecret); err != nil {
+		t.Fatalf("Failed to decode manifest: %v", err)
+	}
+	if _, ok := ssecret.Annotations[SealedSecretsConvertAnnotation]; ok || ssecret.Annotations["other"] != "kept" {
+		t.Errorf("Unexpected annotations %v", ssecret.Annotations)
+	}
+	result, err := seal.Unseal(&ssecret, registry)
+	if err != nil {
+		t.Fatalf("Unseal() returned err: %v", err)
+	}
+	if string(result.Data["foo"]) != "bar" {
+		t.Errorf("Unexpected data %v", result.Data)
+	}
+}
+
+func TestConvertInvalidMode(t *testing.T) {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "mysecret",
+			Namespace:   "myns",
+			Labels:      map[string]string{SealedSecretsConvertibleLabel: "true"},
+			Annotations: map[string]string{SealedSecretsConvertAnnotation: "yes"},
+		},
+	}
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &v1.Secret{}, 0, cache.Indexers{})
+	informer.GetIndexer().Add(secret)
+	recorder := record.NewFakeRecorder(1)
+	c := &Controller{
+		secretInformer: informer,
+		sclient:        fake.NewSimpleClientset(secret).Core(),
+		recorder:       recorder,
+	}
+	if err := c.convert("myns/mysecret"); err != nil {
+		t.Fatalf("convert() returned err: %v", err)
+	}
+	select {
+	case event := <-recorder.Events:
+		if !strings.Contains(event, "InvalidConvertAnnotation") {
+			t.Errorf("Unexpected event %q", event)
+		}
+	default:
+		t.Errorf("Expected an event for the invalid annotation")
+	}
+}
+
+func TestReportUnwatchedConversions(t *testing.T) {
+	secret := func(name string, labels, annotations map[string]string) *v1.Secret {
+		return &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "myns", Labels: labels, Annotations: annotations}}
+	}
+	client := fake.NewSimpleClientset(
+		secret("unlabelled", nil, map[string]string{SealedSecretsConvertAnnotation: "true"}),
+		secret("labelled", map[string]string{SealedSecretsConvertibleLabel: "true"}, map[string]string{SealedSecretsConvertAnnotation: "true"}),
+		secret("other", nil, nil),
+	)
+	recorder := record.NewFakeRecorder(10)
+	c := &Controller{recorder: recorder}
+	if err := c.reportUnwatchedConversions(client.Core()); err != nil {
+		t.Fatalf("reportUnwatchedConversions() returned err: %v", err)
+	}
+	if len(recorder.Events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(recorder.Events))
+	}
+	if event := <-recorder.Events; !strings.Contains(event, "ConvertibleLabelMissing") {
+		t.Errorf("Unexpected event %q", event)
+	}
+}
+
+func TestDataless(t *testing.T) {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "myns"},
+		Data:       map[string][]byte{"foo": []byte("bar")},
+	}
+	client := fake.NewSimpleClientset(secret)
+	lw := dataless(&cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return client.Core().Secrets(metav1.NamespaceAll).List(options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return client.Core().Secrets(metav1.NamespaceAll).Watch(options)
+		},
+	})
+
+	list, err := lw.List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items := list.(*v1.SecretList).Items; len(items) != 1 || items[0].Data != nil {
+		t.Errorf("Expected listed Secrets without data, got %v", items)
+	}
+
+	w, err := lw.Watch(metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+	other := secret.DeepCopy()
+	other.Name = "other"
+	if _, err := client.Core().Secrets("myns").Create(other); err != nil {
+		t.Fatal(err)
+	}
+	e := <-w.ResultChan()
+	if s, ok := e.Object.(*v1.Secret); !ok || s.GetName() != "other" || s.Data != nil {
+		t.Errorf("Expected watched Secret without data, got %v", e.Object)
+	}
+	if secret.Data == nil {
+		t.Errorf("Expected the Secrets of the client to keep their data")
+	}
+}
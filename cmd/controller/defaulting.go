@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+// patchOperation is an operation of the JSON patch returned by the
+// defaulting webhook.
+type patchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// defaultSealedSecret returns the JSON patch filling the defaults of
+// ssecret, so that it behaves the same whichever tool produced it:
+//   - the type of its Secret is Opaque, unless it is sealed in the old
+//     format where the type is sealed too,
+//   - the defaultLabels of the policies are set if missing,
+//   - the scope annotations are only kept with the "true" value, the
+//     cluster-wide one winning over the namespace-wide one, as they are
+//     read when unsealing.
+func defaultSealedSecret(ssecret *ssv1alpha1.SealedSecret, policies []*ssv1alpha1.SealedSecretPolicy) []patchOperation {
+	var patch []patchOperation
+	if ssecret.Type == "" && len(ssecret.Spec.Data) == 0 {
+		patch = append(patch, patchOperation{Op: "add", Path: "/type", Value: apiv1.SecretTypeOpaque})
+	}
+
+	labels := copyStrings(ssecret.GetLabels())
+	labelsChanged := false
+	for _, p := range policies {
+		for k, v := range p.Spec.DefaultLabels {
+			if _, ok := labels[k]; !ok {
+				labels[k] = v
+				labelsChanged = true
+			}
+		}
+	}
+	if labelsChanged {
+		patch = append(patch, patchOperation{Op: "add", Path: "/metadata/labels", Value: labels})
+	}
+
+	annotations := copyStrings(ssecret.GetAnnotations())
+	annotationsChanged := false
+	for _, a := range []string{ssv1alpha1.SealedSecretClusterWideAnnotation, ssv1alpha1.SealedSecretNamespaceWideAnnotation} {
+		v, ok := annotations[a]
+		if !ok {
+			continue
+		}
+		if v != "true" || (a == ssv1alpha1.SealedSecretNamespaceWideAnnotation && annotations[ssv1alpha1.SealedSecretClusterWideAnnotation] == "true") {
+			delete(annotations, a)
+			annotationsChanged = true
+		}
+	}
+	if annotationsChanged {
+		patch = append(patch, patchOperation{Op: "add", Path: "/metadata/annotations", Value: annotations})
+	}
+	return patch
+}
+
+// defaultingHandler serves the mutating webhook filling the defaults of
+// the created and updated SealedSecrets, see defaultSealedSecret.
+func defaultingHandler(policies func() []*ssv1alpha1.SealedSecretPolicy) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		var review admissionReview
+		content, err := ioutil.ReadAll(r.Body)
+		if err == nil {
+			err = json.Unmarshal(content, &review)
+		}
+		if err != nil || review.Request == nil {
+			log.Printf("Error handling admission review: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		resp := &admissionResponse{UID: review.Request.UID}
+		var ssecret ssv1alpha1.SealedSecret
+		var patch []byte
+		err = json.Unmarshal(review.Request.Object.Raw, &ssecret)
+		if err == nil {
+			if ops := defaultSealedSecret(&ssecret, policies()); len(ops) > 0 {
+				patch, err = json.Marshal(ops)
+			}
+		}
+		switch {
+		case err != nil:
+			log.Printf("Error handling admission review: %v", err)
+			resp.Result = &metav1.Status{Message: err.Error()}
+		case patch != nil:
+			patchType := "JSONPatch"
+			resp.Allowed = true
+			resp.Patch = patch
+			resp.PatchType = &patchType
+		default:
+			resp.Allowed = true
+		}
+
+		review.Request = nil
+		review.Response = resp
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(review)
+	})
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+func TestDefaultingHandler(t *testing.T) {
+	policies := []*ssv1alpha1.SealedSecretPolicy{{
+		ObjectMeta: metav1.ObjectMeta{Name: "production"},
+		Spec: ssv1alpha1.SealedSecretPolicySpec{
+			RequiredLabels: []string{"team"},
+			DefaultLabels:  map[string]string{"team": "platform", "tier": "backend"},
+		},
+	}}
+	handler := defaultingHandler(func() []*ssv1alpha1.SealedSecretPolicy { return policies })
+
+	meta := func(labels, annotations map[string]string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: "mysecret", Namespace: "myns", Labels: labels, Annotations: annotations}
+	}
+	encrypted := ssv1alpha1.SealedSecretSpec{EncryptedData: map[string][]byte{"foo": []byte("bar")}}
+	testCases := []struct {
+		name     string
+		in       ssv1alpha1.SealedSecret
+		expected ssv1alpha1.SealedSecret
+	}{
+		{
+			"defaults",
+			ssv1alpha1.SealedSecret{ObjectMeta: meta(nil, nil), Spec: encrypted},
+			ssv1alpha1.SealedSecret{ObjectMeta: meta(map[string]string{"team": "platform", "tier": "backend"}, nil), Spec: encrypted, Type: apiv1.SecretTypeOpaque},
+		},
+		{
+			"set values kept",
+			ssv1alpha1.SealedSecret{ObjectMeta: meta(map[string]string{"team": "search", "tier": "frontend"}, nil), Spec: encrypted, Type: apiv1.SecretTypeTLS},
+			ssv1alpha1.SealedSecret{ObjectMeta: meta(map[string]string{"team": "search", "tier": "frontend"}, nil), Spec: encrypted, Type: apiv1.SecretTypeTLS},
+		},
+		{
+			"old format keeps its sealed type",
+			ssv1alpha1.SealedSecret{ObjectMeta: meta(map[string]string{"team": "search", "tier": "frontend"}, nil), Spec: ssv1alpha1.SealedSecretSpec{Data: []byte("sealed")}},
+			ssv1alpha1.SealedSecret{ObjectMeta: meta(map[string]string{"team": "search", "tier": "frontend"}, nil), Spec: ssv1alpha1.SealedSecretSpec{Data: []byte("sealed")}},
+		},
+		{
+			"scope annotations normalized",
+			ssv1alpha1.SealedSecret{ObjectMeta: meta(map[string]string{"team": "search", "tier": "frontend"}, map[string]string{
+				ssv1alpha1.SealedSecretClusterWideAnnotation:   "true",
+				ssv1alpha1.SealedSecretNamespaceWideAnnotation: "true",
+				"other": "kept",
+			}), Spec: encrypted, Type: apiv1.SecretTypeOpaque},
+			ssv1alpha1.SealedSecret{ObjectMeta: meta(map[string]string{"team": "search", "tier": "frontend"}, map[string]string{
+				ssv1alpha1.SealedSecretClusterWideAnnotation: "true",
+				"other": "kept",
+			}), Spec: encrypted, Type: apiv1.SecretTypeOpaque},
+		},
+		{
+			"scope annotations not true removed",
+			ssv1alpha1.SealedSecret{ObjectMeta: meta(map[string]string{"team": "search", "tier": "frontend"}, map[string]string{
+				ssv1alpha1.SealedSecretNamespaceWideAnnotation: "True",
+			}), Spec: encrypted, Type: apiv1.SecretTypeOpaque},
+			ssv1alpha1.SealedSecret{ObjectMeta: meta(map[string]string{"team": "search", "tier": "frontend"}, map[string]string{}), Spec: encrypted, Type: apiv1.SecretTypeOpaque},
+		},
+	}
+
+	for _, tc := range testCases {
+		object, err := json.Marshal(tc.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := json.Marshal(admissionReview{Request: &admissionRequest{UID: "42", Operation: "CREATE", Object: rawExt(object)}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/default-sealedsecret", bytes.NewReader(body)))
+		var review admissionReview
+		if err := json.Unmarshal(w.Body.Bytes(), &review); err != nil {
+			t.Fatal(err)
+		}
+		if review.Response == nil || !review.Response.Allowed || review.Response.UID != "42" {
+			t.Fatalf("%s: unexpected response %+v", tc.name, review.Response)
+		}
+
+		patched := object
+		if len(review.Response.Patch) > 0 {
+			if review.Response.PatchType == nil || *review.Response.PatchType != "JSONPatch" {
+				t.Errorf("%s: unexpected patch type %v", tc.name, review.Response.PatchType)
+			}
+			patch, err := jsonpatch.DecodePatch(review.Response.Patch)
+			if err != nil {
+				t.Fatalf("%s: invalid patch %s: %v", tc.name, review.Response.Patch, err)
+			}
+			if patched, err = patch.Apply(object); err != nil {
+				t.Fatalf("%s: failed to apply patch %s: %v", tc.name, review.Response.Patch, err)
+			}
+		}
+		var got ssv1alpha1.SealedSecret
+		if err := json.Unmarshal(patched, &got); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("%s: got %+v, expected %+v", tc.name, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"sort"
+
+	flag "github.com/spf13/pflag"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+const (
+	// SealedSecretsDataHashAnnotation holds the hash of the data last
+	// written to a Secret by the controller.
+	SealedSecretsDataHashAnnotation = "sealedsecrets.bitnami.com/data-hash"
+	// SealedSecretsForceSyncAnnotation, when set to a new value (eg. a
+	// timestamp) on a SealedSecret, makes the controller overwrite its
+	// Secret even if it has drifted. The value is copied to the Secret
+	// once handled.
+	SealedSecretsForceSyncAnnotation = "sealedsecrets.bitnami.com/force-sync"
+)
+
+var (
+	restoreDrift = flag.Bool("restore-drift", false, "Overwrite Secrets whose data has been edited directly, instead of only setting the Drifted condition.")
+)
+
+// dataHash returns a hash of data, independent of the order of its keys.
+func dataHash(data map[string][]byte) string {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, k := range keys {
+		for _, b := range [][]byte{[]byte(k), data[k]} {
+			binary.Write(h, binary.BigEndian, uint64(len(b)))
+			h.Write(b)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// setDataHash records the hash of the data of secret written by the
+// controller in its annotations.
+func setDataHash(secret *apiv1.Secret) {
+	annotations := map[string]string{}
+	for k, v := range secret.GetAnnotations() {
+		annotations[k] = v
+	}
+	annotations[SealedSecretsDataHashAnnotation] = dataHash(managedData(secret))
+	secret.SetAnnotations(annotations)
+}
+
+// hasDrifted reports whether existing has been edited since the
+// controller last wrote it, while the SealedSecret didn't change.
+func hasDrifted(existing, expected *apiv1.Secret) bool {
+	written := existing.GetAnnotations()[SealedSecretsDataHashAnnotation]
+	if written == "" {
+		return false
+	}
+	return dataHash(managedData(existing)) != written && dataHash(managedData(expected)) == written
+}
+
+// forceSyncRequested reports whether the force-sync annotation of
+// expected hasn't been handled yet on existing.
+func forceSyncRequested(existing, expected *apiv1.Secret) bool {
+	value := expected.GetAnnotations()[SealedSecretsForceSyncAnnotation]
+	return value != "" && value != existing.GetAnnotations()[SealedSecretsForceSyncAnnotation]
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+
+	flag "github.com/spf13/pflag"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+var (
+	dryRun = flag.Bool("dry-run", false, "Decrypt the sealed objects and report what would be written, in the logs and the DryRun condition of SealedSecrets, without writing any Secret, ConfigMap or object. Keys are neither generated nor rotated.")
+)
+
+// dryRunSecret reports what unsealing ssecret to secret would do, instead
+// of doing it.
+func (c *Controller) dryRunSecret(ssecret *ssv1alpha1.SealedSecret, secret *apiv1.Secret) error {
+	ns, name := secret.GetNamespace(), secret.GetName()
+	reason, msg := "WouldCreate", fmt.Sprintf("Secret %s/%s would be created", ns, name)
+	existing, err := c.sclient.Secrets(ns).Get(name, metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+	case err != nil:
+		return err
+	case reflect.DeepEqual(existing.Data, mergeData(existing, secret, ssecret.Spec.MergeStrategy)):
+		reason, msg = "UpToDate", fmt.Sprintf("Secret %s/%s is up to date", ns, name)
+	case hasDrifted(existing, secret) && !*restoreDrift && !forceSyncRequested(existing, secret):
+		reason, msg = "WouldLeaveDrifted", fmt.Sprintf("Secret %s/%s has been edited and would be left alone", ns, name)
+	default:
+		reason, msg = "WouldUpdate", fmt.Sprintf("Secret %s/%s would be updated", ns, name)
+	}
+	log.Printf("Dry run: %s", msg)
+	return c.updateResult(ssecret, ssv1alpha1.SealedSecretDryRun, apiv1.ConditionTrue, reason, msg)
+}
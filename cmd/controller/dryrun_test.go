@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+func dryRunCondition(t *testing.T, c *Controller) *ssv1alpha1.SealedSecretCondition {
+	updated, err := c.ssclient.BitnamiV1alpha1().SealedSecrets("myns").Get("mysecret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return updated.GetCondition(ssv1alpha1.SealedSecretDryRun)
+}
+
+func TestDryRunCreate(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+	c := newTestController(t, ssecret)
+	c.keyRegistry = registry
+	c.dryRun = true
+
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	if _, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected no Secret to be created, got %v", err)
+	}
+	if cond := dryRunCondition(t, c); cond == nil || cond.Status != v1.ConditionTrue || cond.Reason != "WouldCreate" {
+		t.Errorf("Expected WouldCreate condition, got %v", cond)
+	}
+}
+
+func TestDryRunUpdate(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+	existing := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "myns"},
+		Data:       map[string][]byte{"foo": []byte("old")},
+	}
+	c := newTestController(t, ssecret, existing)
+	c.keyRegistry = registry
+	c.dryRun = true
+
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	secret, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(secret.Data["foo"]) != "old" {
+		t.Errorf("Expected Secret to be left alone, got %v", secret.Data)
+	}
+	if cond := dryRunCondition(t, c); cond == nil || cond.Reason != "WouldUpdate" {
+		t.Errorf("Expected WouldUpdate condition, got %v", cond)
+	}
+}
+
+func TestDryRunExpired(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+	ssecret.Spec.ExpireAfter = &metav1.Duration{Duration: time.Minute}
+	existing := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "myns"}}
+	c := newTestController(t, ssecret, existing)
+	c.keyRegistry = registry
+	c.dryRun = true
+
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	if _, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected Secret to be kept, got %v", err)
+	}
+	if cond := dryRunCondition(t, c); cond == nil || cond.Reason != "WouldDelete" {
+		t.Errorf("Expected WouldDelete condition, got %v", cond)
+	}
+}
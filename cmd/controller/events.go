@@ -0,0 +1,31 @@
+package main
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// eventComponent is the source of the events of the controller.
+const eventComponent = "sealed-secrets-controller"
+
+// newEventRecorder returns the recorder of the events of the
+// controller. Its broadcaster sends them in the background, its
+// correlator aggregating the similar events and counting the repeated
+// ones rather than creating a new event each time.
+func newEventRecorder(evclient v1.EventsGetter) record.EventRecorder {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&v1.EventSinkImpl{Interface: evclient.Events("")})
+	return broadcaster.NewRecorder(scheme.Scheme, apiv1.EventSource{Component: eventComponent})
+}
+
+// recordEvent records an event of obj, usually a sealed object. It does
+// nothing without recorder.
+func (c *Controller) recordEvent(obj runtime.Object, eventType, reason, message string) {
+	if c.recorder == nil {
+		return
+	}
+	c.recorder.Event(obj, eventType, reason, message)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	flag "github.com/spf13/pflag"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+// SealedSecretsFinalizer holds the deletion of a SealedSecret until the
+// controller has cleaned up its Secret, see finalize.
+const SealedSecretsFinalizer = "sealedsecrets.bitnami.com/cleanup"
+
+var (
+	enableFinalizer = flag.Bool("enable-finalizer", false, "Add a finalizer to the SealedSecrets, so that their Secret is deleted, or released with spec.deletionPolicy Retain, by the controller before they go, after recording it in their status, an event and the audit log. Those that already have it are finalized even without this flag.")
+)
+
+func checkDeletionPolicy(policy string) error {
+	switch policy {
+	case "", ssv1alpha1.DeletionPolicyDelete, ssv1alpha1.DeletionPolicyRetain:
+		return nil
+	default:
+		return fmt.Errorf("Unsupported deletion policy %q", policy)
+	}
+}
+
+func hasFinalizer(ssecret *ssv1alpha1.SealedSecret) bool {
+	for _, f := range ssecret.GetFinalizers() {
+		if f == SealedSecretsFinalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// updateFinalizers applies update to the finalizers of ssecret and
+// stores it, as updateStatus does for its status.
+func (c *Controller) updateFinalizers(ssecret *ssv1alpha1.SealedSecret, update func([]string) []string) (*ssv1alpha1.SealedSecret, error) {
+	client := c.ssclient.BitnamiV1alpha1().SealedSecrets(ssecret.GetNamespace())
+	current, err := client.Get(ssecret.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	current.SetFinalizers(update(current.GetFinalizers()))
+	return client.Update(current)
+}
+
+// ensureFinalizer adds the finalizer to ssecret with --enable-finalizer,
+// and returns the updated ssecret.
+func (c *Controller) ensureFinalizer(ssecret *ssv1alpha1.SealedSecret) (*ssv1alpha1.SealedSecret, error) {
+	if !*enableFinalizer || c.readOnly() || hasFinalizer(ssecret) {
+		return ssecret, nil
+	}
+	return c.updateFinalizers(ssecret, func(finalizers []string) []string {
+		for _, f := range finalizers {
+			if f == SealedSecretsFinalizer {
+				return finalizers
+			}
+		}
+		return append(finalizers, SealedSecretsFinalizer)
+	})
+}
+
+// finalize cleans up after the deleted ssecret, in order: it records
+// the deletion in its status, an event and the audit log, deletes or
+// releases its Secret as told by its deletion policy, and finally
+// removes its finalizer so that it goes. It does nothing without the
+// finalizer, the Secret being deleted once ssecret has gone.
+func (c *Controller) finalize(ssecret *ssv1alpha1.SealedSecret) error {
+	if !hasFinalizer(ssecret) {
+		return nil
+	}
+	key := fmt.Sprintf("%s/%s", ssecret.GetNamespace(), ssecret.GetName())
+	policy := ssecret.Spec.DeletionPolicy
+	if policy == "" {
+		policy = ssv1alpha1.DeletionPolicyDelete
+	}
+	if err := checkDeletionPolicy(policy); err != nil {
+		return c.syncFailed(ssecret, err)
+	}
+	if c.readOnly() {
+		log.Printf("Dry run: Secret %s would be finalized with deletion policy %s", key, policy)
+		return nil
+	}
+
+	msg := fmt.Sprintf("Deleting, deletion policy %s", policy)
+	log.Printf("SealedSecret %s is being deleted, deletion policy %s", key, policy)
+	if err := c.updateCondition(ssecret, ssv1alpha1.SealedSecretSynced, apiv1.ConditionFalse, "Deleting", msg); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	c.recordEvent(ssecret, apiv1.EventTypeNormal, "Deleting", msg)
+	auditLog.record(auditEvent{
+		Operation: "delete",
+		Object:    key,
+		Result:    "success",
+	})
+
+	var err error
+	if policy == ssv1alpha1.DeletionPolicyRetain {
+		err = c.releaseSecret(ssecret)
+	} else {
+		err = c.deleteSecret(key)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = c.updateFinalizers(ssecret, func(finalizers []string) []string {
+		var kept []string
+		for _, f := range finalizers {
+			if f != SealedSecretsFinalizer {
+				kept = append(kept, f)
+			}
+		}
+		return kept
+	})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// deleteSecret deletes the Secret of the SealedSecret key, and its
+// replicas.
+func (c *Controller) deleteSecret(key string) error {
+	ns, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+	if c.readOnly() {
+		log.Printf("Dry run: Secret %s would be deleted", key)
+		return nil
+	}
+	err = c.sclient.Secrets(ns).Delete(name, &metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err := c.deleteReplicas(key, nil); err != nil {
+		return err
+	}
+	if c.quota.enabled() {
+		// Make room for those over quota
+		c.requeueNamespace(ns)
+	}
+	return nil
+}
+
+// releaseSecret removes the references of the Secret of ssecret to it,
+// so that it isn't deleted with it, and deletes its replicas.
+func (c *Controller) releaseSecret(ssecret *ssv1alpha1.SealedSecret) error {
+	key := fmt.Sprintf("%s/%s", ssecret.GetNamespace(), ssecret.GetName())
+	secret, err := c.sclient.Secrets(ssecret.GetNamespace()).Get(ssecret.GetName(), metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err == nil && !released(secret) {
+		secret = secret.DeepCopy()
+		var refs []metav1.OwnerReference
+		for _, ref := range secret.GetOwnerReferences() {
+			if !isSealedSecretReference(ref) {
+				refs = append(refs, ref)
+			}
+		}
+		secret.SetOwnerReferences(refs)
+		delete(secret.Labels, SealedSecretsUIDLabel)
+		if _, err := c.sclient.Secrets(secret.GetNamespace()).Update(secret); err != nil {
+			return err
+		}
+		log.Printf("Secret %s released", key)
+	}
+	return c.deleteReplicas(key, nil)
+}
+
+// released reports whether secret no longer belongs to a SealedSecret,
+// once retained by releaseSecret.
+func released(secret *apiv1.Secret) bool {
+	if _, ok := secret.GetLabels()[SealedSecretsUIDLabel]; ok {
+		return false
+	}
+	for _, ref := range secret.GetOwnerReferences() {
+		if isSealedSecretReference(ref) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+func TestEnsureFinalizer(t *testing.T) {
+	defer func(enabled bool) { *enableFinalizer = enabled }(*enableFinalizer)
+	*enableFinalizer = true
+
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+	c := newTestController(t, ssecret)
+	c.keyRegistry = registry
+
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	updated, err := c.ssclient.BitnamiV1alpha1().SealedSecrets("myns").Get("mysecret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasFinalizer(updated) {
+		t.Errorf("Expected the finalizer to be added, got %v", updated.GetFinalizers())
+	}
+	if _, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected Secret to be created, got %v", err)
+	}
+}
+
+func TestFinalize(t *testing.T) {
+	isController := true
+	testCases := []struct {
+		policy   string
+		retained bool
+	}{
+		{"", false},
+		{ssv1alpha1.DeletionPolicyDelete, false},
+		{ssv1alpha1.DeletionPolicyRetain, true},
+	}
+	for _, tc := range testCases {
+		registry := testRegistry(t)
+		ssecret := testSealedSecret(t, registry)
+		ssecret.UID = "uid"
+		ssecret.Spec.DeletionPolicy = tc.policy
+		now := metav1.Now()
+		ssecret.SetDeletionTimestamp(&now)
+		ssecret.SetFinalizers([]string{"other", SealedSecretsFinalizer})
+
+		existing := &v1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:            "mysecret",
+			Namespace:       "myns",
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: "bitnami.com/v1alpha1", Kind: "SealedSecret", Name: "mysecret", UID: "uid", Controller: &isController}},
+		}}
+		c := newTestController(t, ssecret, existing)
+		c.keyRegistry = registry
+		events := record.NewFakeRecorder(10)
+		c.recorder = events
+
+		if err := c.unseal("myns/mysecret"); err != nil {
+			t.Fatalf("%q: unseal() returned err: %v", tc.policy, err)
+		}
+
+		updated, err := c.ssclient.BitnamiV1alpha1().SealedSecrets("myns").Get("mysecret", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hasFinalizer(updated) || len(updated.GetFinalizers()) != 1 {
+			t.Errorf("%q: expected only the finalizer to be removed, got %v", tc.policy, updated.GetFinalizers())
+		}
+		if cond := updated.GetCondition(ssv1alpha1.SealedSecretSynced); cond == nil || cond.Reason != "Deleting" {
+			t.Errorf("%q: expected Deleting condition, got %v", tc.policy, updated.Status)
+		}
+		if len(events.Events) != 1 {
+			t.Errorf("%q: expected a Deleting event, got %d", tc.policy, len(events.Events))
+		} else if event := <-events.Events; !strings.HasPrefix(event, "Normal Deleting ") {
+			t.Errorf("%q: expected a Deleting event, got %q", tc.policy, event)
+		}
+
+		// The SealedSecret goes once finalized
+		if err := c.informer.GetIndexer().Delete(ssecret); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.unseal("myns/mysecret"); err != nil {
+			t.Fatalf("%q: unseal() returned err: %v", tc.policy, err)
+		}
+		secret, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{})
+		if !tc.retained {
+			if !errors.IsNotFound(err) {
+				t.Errorf("%q: expected Secret to be deleted, got %v", tc.policy, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%q: expected Secret to be retained, got %v", tc.policy, err)
+		}
+		if len(secret.GetOwnerReferences()) != 0 {
+			t.Errorf("%q: expected Secret to be released, got %v", tc.policy, secret.GetOwnerReferences())
+		}
+	}
+}
+
+func TestFinalizeUnknownDeletionPolicy(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+	ssecret.Spec.DeletionPolicy = "Orphan"
+	now := metav1.Now()
+	ssecret.SetDeletionTimestamp(&now)
+	ssecret.SetFinalizers([]string{SealedSecretsFinalizer})
+	existing := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "myns"}}
+	c := newTestController(t, ssecret, existing)
+
+	if err := c.unseal("myns/mysecret"); err == nil {
+		t.Errorf("Expected an error for an unknown deletion policy")
+	}
+	if _, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected Secret to be kept, got %v", err)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+)
+
+var (
+	fips = flag.Bool("fips", false, "Restrict to FIPS 140-2 approved algorithms, key sizes and random sources, and refuse to load non-compliant keys. Build with boringcrypto for a validated module.")
+)
+
+// initFIPS enables the FIPS mode with --fips, checking keySize.
+func initFIPS(keySize int) error {
+	if !*fips {
+		return nil
+	}
+	if keySize < crypto.FIPSMinKeySize {
+		return fmt.Errorf("--fips requires a --key-size of at least %d", crypto.FIPSMinKeySize)
+	}
+	crypto.SetFIPS(true)
+	if crypto.FIPSModule() {
+		log.Printf("FIPS mode, using the BoringCrypto module")
+	} else {
+		log.Printf("FIPS mode, WARNING: not built with a validated module, rebuild with boringcrypto to be compliant")
+	}
+	return nil
+}
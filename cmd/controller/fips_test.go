@@ -0,0 +1,82 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	certUtil "k8s.io/client-go/util/cert"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+	"github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+func TestFIPS(t *testing.T) {
+	defer func() {
+		*fips = false
+		crypto.SetFIPS(false)
+	}()
+
+	*fips = true
+	if err := initFIPS(1024); err == nil {
+		t.Errorf("initFIPS() accepted a 1024 bits --key-size")
+	}
+	if crypto.FIPSEnabled() {
+		t.Fatalf("FIPS mode enabled despite the error")
+	}
+	if err := initFIPS(2048); err != nil {
+		t.Fatalf("initFIPS() returned error: %v", err)
+	}
+
+	keySecret := func(bits int) v1.Secret {
+		key, err := rsa.GenerateKey(testRand(), bits)
+		if err != nil {
+			t.Fatalf("Failed to generate test key: %v", err)
+		}
+		cert, err := signKey(rand.Reader, key)
+		if err != nil {
+			t.Fatalf("signKey() returned error: %v", err)
+		}
+		for _, ext := range cert.Extensions {
+			if ext.Id.Equal(seal.X25519KeyExtension) || ext.Id.Equal(seal.MLKEMKeyExtension) {
+				t.Errorf("signKey() published a non-approved key in FIPS mode")
+			}
+		}
+		return v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "mykey", Namespace: "myns"},
+			Data: map[string][]byte{
+				v1.TLSPrivateKeyKey: certUtil.EncodePrivateKeyPEM(key),
+				v1.TLSCertKey:       certUtil.EncodeCertPEM(cert),
+			},
+		}
+	}
+
+	if _, _, err := readKey(keySecret(1024)); err == nil {
+		t.Errorf("readKey() loaded a 1024 bits key in FIPS mode")
+	}
+	key, _, err := readKey(keySecret(2048))
+	if err != nil {
+		t.Fatalf("readKey() returned error: %v", err)
+	}
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "myns"},
+		Data:       map[string][]byte{"foo": []byte("bar")},
+	}
+	ss, err := seal.Seal(secret, &key.PublicKey, seal.StrictScope)
+	if err != nil {
+		t.Fatalf("Seal() returned error: %v", err)
+	}
+	if _, err := seal.Unseal(ss, seal.PrivateKeys{key}); err != nil {
+		t.Errorf("Unseal() returned error: %v", err)
+	}
+	if _, err := seal.SealParams(secret, &key.PublicKey, ssv1alpha1.SealingParams{Cipher: ssv1alpha1.CipherChaCha20Poly1305}, seal.StrictScope); err == nil {
+		t.Errorf("Sealed with ChaCha20-Poly1305 in FIPS mode")
+	}
+	if _, err := crypto.HybridEncrypt(testRand(), &key.PublicKey, []byte("foo"), nil); err != crypto.ErrNotFIPSApproved {
+		t.Errorf("Expected ErrNotFIPSApproved with a non-approved random source, got %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"sort"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+// SealedSecretsGeneratedAtAnnotation records when the generated items
+// of a Secret were generated, as a JSON object of times by key.
+const SealedSecretsGeneratedAtAnnotation = "sealedsecrets.bitnami.com/generated-at"
+
+const (
+	defaultGeneratedLength = 32
+	maxGeneratedLength     = 4096
+)
+
+var charsets = map[string]string{
+	ssv1alpha1.CharsetAlphanumeric: "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789",
+	ssv1alpha1.CharsetNumeric:      "0123456789",
+	ssv1alpha1.CharsetHex:          "0123456789abcdef",
+	ssv1alpha1.CharsetSymbols:      "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789!#$%&()*+,-./:;<=>?@[]^_{|}~",
+}
+
+// generateValue returns a random value following g, read from rnd.
+func generateValue(rnd io.Reader, g ssv1alpha1.SealedSecretGenerator) ([]byte, error) {
+	charset := g.Charset
+	if charset == "" {
+		charset = ssv1alpha1.CharsetAlphanumeric
+	}
+	chars, ok := charsets[charset]
+	if !ok {
+		return nil, fmt.Errorf("Unsupported charset %q", charset)
+	}
+	length := g.Length
+	if length == 0 {
+		length = defaultGeneratedLength
+	}
+	if length < 0 || length > maxGeneratedLength {
+		return nil, fmt.Errorf("Invalid length %d, must be at most %d", length, maxGeneratedLength)
+	}
+
+	max := big.NewInt(int64(len(chars)))
+	value := make([]byte, length)
+	for i := range value {
+		n, err := rand.Int(rnd, max)
+		if err != nil {
+			return nil, err
+		}
+		value[i] = chars[n.Int64()]
+	}
+	return value, nil
+}
+
+// generatedAt returns the times the generated items of secret were
+// generated at, recorded in its annotations.
+func generatedAt(secret *apiv1.Secret) map[string]time.Time {
+	times := map[string]time.Time{}
+	if value := secret.GetAnnotations()[SealedSecretsGeneratedAtAnnotation]; value != "" {
+		if err := json.Unmarshal([]byte(value), &times); err != nil {
+			log.Printf("Ignoring invalid %s annotation of Secret %s/%s: %v", SealedSecretsGeneratedAtAnnotation, secret.GetNamespace(), secret.GetName(), err)
+		}
+	}
+	return times
+}
+
+// generateItems adds the generated items of ssecret to secret. Those
+// already in the existing Secret are kept, so that they are only
+// generated once, unless they are due for rotation. It returns when
+// the next item is due, zero if none rotates.
+func (c *Controller) generateItems(ssecret *ssv1alpha1.SealedSecret, secret *apiv1.Secret) (time.Time, error) {
+	var next time.Time
+	if len(ssecret.Spec.Generate) == 0 {
+		return next, nil
+	}
+
+	var current map[string][]byte
+	times := map[string]time.Time{}
+	existing, err := c.sclient.Secrets(ssecret.GetNamespace()).Get(ssecret.GetName(), metav1.GetOptions{})
+	switch {
+	case err == nil:
+		current = existing.Data
+		times = generatedAt(existing)
+	case !errors.IsNotFound(err):
+		return next, err
+	}
+
+	keys := make([]string, 0, len(ssecret.Spec.Generate))
+	for k := range ssecret.Spec.Generate {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	recorded := map[string]time.Time{}
+	for _, k := range keys {
+		g := ssecret.Spec.Generate[k]
+		if _, ok := secret.Data[k]; ok {
+			return next, fmt.Errorf("Item %q is both sealed and generated", k)
+		}
+
+		v, kept := current[k]
+		at, ok := times[k]
+		if !ok {
+			at = now
+		}
+		if kept && g.RotateEvery != nil && !now.Before(at.Add(g.RotateEvery.Duration)) {
+			log.Printf("Rotating generated item %q of SealedSecret %s/%s", k, ssecret.GetNamespace(), ssecret.GetName())
+			auditLog.record(auditEvent{
+				Operation: "regenerate",
+				Object:    fmt.Sprintf("%s/%s", ssecret.GetNamespace(), ssecret.GetName()),
+				Result:    "success",
+			})
+			c.recordEvent(ssecret, apiv1.EventTypeNormal, "Regenerated", fmt.Sprintf("Generated item %q rotated", k))
+			kept = false
+		}
+		if !kept {
+			if v, err = generateValue(rand.Reader, g); err != nil {
+				return next, fmt.Errorf("Error generating item %q: %v", k, err)
+			}
+			at = now
+		}
+		secret.Data[k] = v
+		recorded[k] = at
+
+		if g.RotateEvery != nil {
+			due := at.Add(g.RotateEvery.Duration)
+			if next.IsZero() || due.Before(next) {
+				next = due
+			}
+		}
+	}
+
+	value, err := json.Marshal(recorded)
+	if err != nil {
+		return next, err
+	}
+	annotations := copyStrings(secret.GetAnnotations())
+	annotations[SealedSecretsGeneratedAtAnnotation] = string(value)
+	secret.SetAnnotations(annotations)
+	return next, nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+func TestGenerateValue(t *testing.T) {
+	testCases := []struct {
+		generator ssv1alpha1.SealedSecretGenerator
+		length    int
+		chars     string
+		fails     bool
+	}{
+		{ssv1alpha1.SealedSecretGenerator{}, 32, charsets[ssv1alpha1.CharsetAlphanumeric], false},
+		{ssv1alpha1.SealedSecretGenerator{Length: 6, Charset: ssv1alpha1.CharsetNumeric}, 6, "0123456789", false},
+		{ssv1alpha1.SealedSecretGenerator{Length: 64, Charset: ssv1alpha1.CharsetHex}, 64, "0123456789abcdef", false},
+		{ssv1alpha1.SealedSecretGenerator{Charset: "emoji"}, 0, "", true},
+		{ssv1alpha1.SealedSecretGenerator{Length: -1}, 0, "", true},
+		{ssv1alpha1.SealedSecretGenerator{Length: maxGeneratedLength + 1}, 0, "", true},
+	}
+	for _, tc := range testCases {
+		value, err := generateValue(testRand(), tc.generator)
+		if tc.fails {
+			if err == nil {
+				t.Errorf("%v: expected an error", tc.generator)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: generateValue() returned err: %v", tc.generator, err)
+			continue
+		}
+		if len(value) != tc.length {
+			t.Errorf("%v: expected length %d, got %d", tc.generator, tc.length, len(value))
+		}
+		for _, b := range value {
+			if !strings.ContainsRune(tc.chars, rune(b)) {
+				t.Errorf("%v: unexpected character %q", tc.generator, b)
+			}
+		}
+	}
+}
+
+func TestUnsealGenerate(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+	ssecret.Spec.EncryptedData = nil
+	ssecret.Spec.Generate = map[string]ssv1alpha1.SealedSecretGenerator{
+		"password": {Length: 16},
+	}
+
+	c := newTestController(t, ssecret)
+	c.keyRegistry = registry
+
+	generated := func() string {
+		if err := c.unseal("myns/mysecret"); err != nil {
+			t.Fatalf("unseal() returned err: %v", err)
+		}
+		secret, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(secret.Data["password"])
+	}
+
+	first := generated()
+	if len(first) != 16 {
+		t.Errorf("Expected a 16 characters password, got %q", first)
+	}
+	if second := generated(); second != first {
+		t.Errorf("Expected the password to be kept, got %q then %q", first, second)
+	}
+}
+
+func TestUnsealRegenerate(t *testing.T) {
+	testCases := []struct {
+		age     time.Duration
+		rotated bool
+	}{
+		{2 * time.Hour, true},
+		{time.Minute, false},
+	}
+	for _, tc := range testCases {
+		registry := testRegistry(t)
+		ssecret := testSealedSecret(t, registry)
+		ssecret.Spec.Generate = map[string]ssv1alpha1.SealedSecretGenerator{
+			"password": {RotateEvery: &metav1.Duration{Duration: time.Hour}},
+		}
+
+		at := time.Now().Add(-tc.age).UTC().Format(time.RFC3339)
+		existing := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "mysecret",
+				Namespace:   "myns",
+				Annotations: map[string]string{SealedSecretsGeneratedAtAnnotation: `{"password":"` + at + `"}`},
+			},
+			Data: map[string][]byte{"foo": []byte("bar"), "password": []byte("old")},
+		}
+		c := newTestController(t, ssecret, existing)
+		c.keyRegistry = registry
+		events := record.NewFakeRecorder(10)
+		c.recorder = events
+
+		if err := c.unseal("myns/mysecret"); err != nil {
+			t.Fatalf("unseal() returned err: %v", err)
+		}
+		secret, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rotated := len(events.Events) == 1 && <-events.Events == `Normal Regenerated Generated item "password" rotated`; rotated != tc.rotated {
+			t.Errorf("%s old: expected a Regenerated event %v", tc.age, tc.rotated)
+		}
+		if rotated := string(secret.Data["password"]) != "old"; rotated != tc.rotated {
+			t.Errorf("%s old: expected rotated %v, got %q", tc.age, tc.rotated, secret.Data["password"])
+		}
+		times := generatedAt(secret)
+		if rotated := times["password"].Format(time.RFC3339) != at; rotated != tc.rotated {
+			t.Errorf("%s old: expected generation time to be updated %v, got %v", tc.age, tc.rotated, times)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"io"
+	"log"
+	"net"
+
+	flag "github.com/spf13/pflag"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	certUtil "k8s.io/client-go/util/cert"
+
+	"github.com/bitnami-labs/sealed-secrets/pkg/grpcapi"
+)
+
+var (
+	grpcListenAddr = flag.String("grpc-listen-addr", "", "gRPC serving address. The gRPC API is disabled if empty.")
+	grpcTLSCert    = flag.String("grpc-tls-cert", "", "Certificate file for serving gRPC over TLS.")
+	grpcTLSKey     = flag.String("grpc-tls-key", "", "Private key file for serving gRPC over TLS.")
+	grpcClientCA   = flag.String("grpc-client-ca", "", "CA bundle used to verify gRPC client certificates. Enables mutual TLS.")
+	grpcMaxMsgSize = flag.Int("grpc-max-message-size", 16<<20, "Maximum size of gRPC messages, in bytes.")
+	grpcMaxSealLen = flag.Int("grpc-max-seal-stream-size", 64<<20, "Maximum size of the Secret manifests streamed to SealStream, in bytes.")
+)
+
+// grpcSealChunkSize is the size of the chunks SealStream sends the sealed
+// secrets in, well below the default message size limit of the clients.
+const grpcSealChunkSize = 1 << 20
+
+// grpcService implements grpcapi.SealedSecretsServer on top of the same
+// functions used by the HTTP server.
+type grpcService struct {
+	cp certProvider
+	sc secretChecker
+	sr secretRotator
+	ss secretSealer
+}
+
+func (s *grpcService) Seal(ctx context.Context, req *grpcapi.SealRequest) (*grpcapi.SealResponse, error) {
+	if err := checkGRPCIdentity(ctx, "Seal"); err != nil {
+		return nil, err
+	}
+	sealed, err := s.ss(req.GetSecret())
+	auditLog.record(auditResult(auditEvent{
+		Operation: "seal",
+		Object:    secretID(req.GetSecret()),
+		Caller:    grpcCaller(ctx),
+		Identity:  grpcIdentity(ctx),
+	}, "success", err))
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Error sealing secret: %v", err)
+	}
+	return &grpcapi.SealResponse{SealedSecret: sealed}, nil
+}
+
+func (s *grpcService) SealStream(stream grpcapi.SealedSecrets_SealStreamServer) error {
+	ctx := stream.Context()
+	if err := checkGRPCIdentity(ctx, "SealStream"); err != nil {
+		return err
+	}
+	var secret []byte
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if len(secret)+len(chunk.GetData()) > *grpcMaxSealLen {
+			return status.Errorf(codes.ResourceExhausted, "Secret larger than %d bytes", *grpcMaxSealLen)
+		}
+		secret = append(secret, chunk.GetData()...)
+	}
+
+	sealed, err := s.ss(secret)
+	auditLog.record(auditResult(auditEvent{
+		Operation: "seal",
+		Object:    secretID(secret),
+		Caller:    grpcCaller(ctx),
+		Identity:  grpcIdentity(ctx),
+	}, "success", err))
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "Error sealing secret: %v", err)
+	}
+	for len(sealed) > 0 {
+		n := grpcSealChunkSize
+		if n > len(sealed) {
+			n = len(sealed)
+		}
+		if err := stream.Send(&grpcapi.SealChunk{Data: sealed[:n]}); err != nil {
+			return err
+		}
+		sealed = sealed[n:]
+	}
+	return nil
+}
+
+func (s *grpcService) Verify(ctx context.Context, req *grpcapi.VerifyRequest) (*grpcapi.VerifyResponse, error) {
+	valid, err := s.sc(req.GetSealedSecret())
+	result := "valid"
+	if !valid {
+		result = "invalid"
+	}
+	auditLog.record(auditResult(auditEvent{
+		Operation: "verify",
+		Object:    sealedSecretID(req.GetSealedSecret()),
+		Caller:    grpcCaller(ctx),
+		Identity:  grpcIdentity(ctx),
+	}, result, err))
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Error validating secret: %v", err)
+	}
+	return &grpcapi.VerifyResponse{Valid: valid}, nil
+}
+
+func (s *grpcService) Rotate(ctx context.Context, req *grpcapi.RotateRequest) (*grpcapi.RotateResponse, error) {
+	if err := checkGRPCIdentity(ctx, "Rotate"); err != nil {
+		return nil, err
+	}
+	rotated, err := s.sr(req.GetSealedSecret())
+	auditLog.record(auditResult(auditEvent{
+		Operation: "rotate",
+		Object:    sealedSecretID(req.GetSealedSecret()),
+		Caller:    grpcCaller(ctx),
+		Identity:  grpcIdentity(ctx),
+	}, "success", err))
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "Error rotating secret: %v", err)
+	}
+	return &grpcapi.RotateResponse{SealedSecret: rotated}, nil
+}
+
+func (s *grpcService) GetCerts(ctx context.Context, req *grpcapi.GetCertsRequest) (*grpcapi.GetCertsResponse, error) {
+	var res grpcapi.GetCertsResponse
+	for _, cert := range s.cp() {
+		res.Certs = append(res.Certs, certUtil.EncodeCertPEM(cert))
+	}
+	return &res, nil
+}
+
+// grpcServerOptions returns the transport options selected by the
+// --grpc-* flags.
+func grpcServerOptions() ([]grpc.ServerOption, error) {
+	opts := []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(*grpcMaxMsgSize),
+		grpc.MaxSendMsgSize(*grpcMaxMsgSize),
+	}
+
+	if *grpcTLSCert == "" && *grpcTLSKey == "" {
+		if *grpcClientCA != "" {
+			return nil, errors.New("--grpc-client-ca requires --grpc-tls-cert and --grpc-tls-key")
+		}
+		return opts, nil
+	}
+
+	config, err := serverTLSConfig(*grpcTLSCert, *grpcTLSKey, *grpcClientCA, tls.RequireAndVerifyClientCert)
+	if err != nil {
+		return nil, err
+	}
+	return append(opts, grpc.Creds(credentials.NewTLS(config))), nil
+}
+
+func grpcserver(h serverHandlers) {
+	opts, err := grpcServerOptions()
+	if err != nil {
+		log.Printf("gRPC server not started: %v", err)
+		return
+	}
+
+	lis, err := net.Listen("tcp", *grpcListenAddr)
+	if err != nil {
+		log.Printf("gRPC server not started: %v", err)
+		return
+	}
+
+	server := grpc.NewServer(opts...)
+	grpcapi.RegisterSealedSecretsServer(server, &grpcService{cp: h.certs, sc: h.check, sr: h.rotate, ss: h.seal})
+
+	log.Printf("gRPC server serving on %s", lis.Addr())
+	err = server.Serve(lis)
+	log.Printf("gRPC server exiting: %v", err)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/client-go/kubernetes/fake"
+	certUtil "k8s.io/client-go/util/cert"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	"github.com/bitnami-labs/sealed-secrets/pkg/grpcapi"
+)
+
+// startGRPCService serves the gRPC API of a controller with a fresh key,
+// and returns a client and a function stopping it.
+func startGRPCService(t *testing.T, opts ...grpc.ServerOption) (grpcapi.SealedSecretsClient, func()) {
+	client := fake.NewSimpleClientset()
+	registry, err := initKeyRegistry(newSecretKeyStore(client, "namespace", "label"), "prefix", 1024)
+	if err != nil {
+		t.Fatalf("initKeyRegistry() returned err: %v", err)
+	}
+	if _, err := registry.generateKey(); err != nil {
+		t.Fatalf("generateKey() returned err: %v", err)
+	}
+	controller := &Controller{keyRegistry: registry}
+	cp := func() []*x509.Certificate {
+		return []*x509.Certificate{registry.latestCert()}
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer(opts...)
+	grpcapi.RegisterSealedSecretsServer(server, &grpcService{cp: cp, sc: controller.AttemptUnseal, sr: controller.Rotate, ss: controller.Seal})
+	go server.Serve(lis)
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		server.Stop()
+		t.Fatalf("Dial() returned err: %v", err)
+	}
+	return grpcapi.NewSealedSecretsClient(conn), func() {
+		conn.Close()
+		server.Stop()
+	}
+}
+
+func TestGRPCService(t *testing.T) {
+	c, stop := startGRPCService(t)
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	certs, err := c.GetCerts(ctx, &grpcapi.GetCertsRequest{})
+	if err != nil {
+		t.Fatalf("GetCerts() returned err: %v", err)
+	}
+	if len(certs.Certs) != 1 {
+		t.Fatalf("Expected 1 cert, got %d", len(certs.Certs))
+	}
+	if _, err := certUtil.ParseCertsPEM(certs.Certs[0]); err != nil {
+		t.Errorf("Returned cert is invalid: %v", err)
+	}
+
+	secret := []byte(`{"apiVersion":"v1","kind":"Secret","metadata":{"name":"mysecret","namespace":"myns"},"data":{"foo":"YmFy"}}`)
+	sealed, err := c.Seal(ctx, &grpcapi.SealRequest{Secret: secret})
+	if err != nil {
+		t.Fatalf("Seal() returned err: %v", err)
+	}
+
+	verified, err := c.Verify(ctx, &grpcapi.VerifyRequest{SealedSecret: sealed.SealedSecret})
+	if err != nil {
+		t.Fatalf("Verify() returned err: %v", err)
+	}
+	if !verified.Valid {
+		t.Errorf("Sealed secret did not verify")
+	}
+
+	if _, err := c.Seal(ctx, &grpcapi.SealRequest{Secret: []byte("not a secret")}); err == nil {
+		t.Errorf("Expected error sealing garbage")
+	}
+}
+
+func TestGRPCSealStream(t *testing.T) {
+	defer func(size int) { *grpcMaxSealLen = size }(*grpcMaxSealLen)
+
+	c, stop := startGRPCService(t, grpc.MaxRecvMsgSize(64<<10))
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	value := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte("0123456789"), 20000))
+	secret := []byte(`{"apiVersion":"v1","kind":"Secret","metadata":{"name":"mysecret","namespace":"myns"},"data":{"foo":"` + value + `"}}`)
+	if _, err := c.Seal(ctx, &grpcapi.SealRequest{Secret: secret}); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("Expected Seal() to exceed the message size, got %v", err)
+	}
+
+	sealStream := func(secret []byte) ([]byte, error) {
+		stream, err := c.SealStream(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for len(secret) > 0 {
+			n := 32 << 10
+			if n > len(secret) {
+				n = len(secret)
+			}
+			if err := stream.Send(&grpcapi.SealChunk{Data: secret[:n]}); err != nil {
+				return nil, err
+			}
+			secret = secret[n:]
+		}
+		if err := stream.CloseSend(); err != nil {
+			return nil, err
+		}
+		var sealed []byte
+		for {
+			chunk, err := stream.Recv()
+			if err == io.EOF {
+				return sealed, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			sealed = append(sealed, chunk.GetData()...)
+		}
+	}
+
+	sealed, err := sealStream(secret)
+	if err != nil {
+		t.Fatalf("SealStream() returned err: %v", err)
+	}
+	var ssecret ssv1alpha1.SealedSecret
+	if err := json.Unmarshal(sealed, &ssecret); err != nil {
+		t.Fatalf("SealStream() returned an invalid sealed secret: %v", err)
+	}
+	if ssecret.Name != "mysecret" || len(ssecret.Spec.EncryptedData["foo"]) < 200000 {
+		t.Errorf("Unexpected sealed secret %s/%s", ssecret.Namespace, ssecret.Name)
+	}
+
+	*grpcMaxSealLen = 100 << 10
+	if _, err := sealStream(secret); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expected SealStream() to exceed --grpc-max-seal-stream-size, got %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+
+	flag "github.com/spf13/pflag"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// SealedSecretsHistoryLabel marks the previous versions of a Secret,
+	// its value is the name of the Secret.
+	SealedSecretsHistoryLabel = "sealedsecrets.bitnami.com/history-of"
+	// SealedSecretsRevisionAnnotation is the revision number of a
+	// previous version of a Secret.
+	SealedSecretsRevisionAnnotation = "sealedsecrets.bitnami.com/revision"
+)
+
+var (
+	secretHistory = flag.Int("secret-history", 0, "Number of previous versions of each unsealed Secret to keep, as <name>-rev-<n> Secrets. Disabled if 0.")
+)
+
+func revisionOf(s *apiv1.Secret) int {
+	rev, _ := strconv.Atoi(s.GetAnnotations()[SealedSecretsRevisionAnnotation])
+	return rev
+}
+
+// saveRevision stores the data of secret, which is about to be
+// overwritten, in a new Secret and prunes the revisions beyond
+// c.historyLimit.
+func (c *Controller) saveRevision(secret *apiv1.Secret) error {
+	ns, name := secret.GetNamespace(), secret.GetName()
+	if len(validation.IsValidLabelValue(name)) > 0 {
+		log.Printf("Secret %s/%s: name can't be used as a label value, not keeping its history", ns, name)
+		return nil
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{SealedSecretsHistoryLabel: name})
+	list, err := c.sclient.Secrets(ns).List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return err
+	}
+	revisions := list.Items
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisionOf(&revisions[i]) < revisionOf(&revisions[j])
+	})
+
+	next := 1
+	if len(revisions) > 0 {
+		next = revisionOf(&revisions[len(revisions)-1]) + 1
+	}
+
+	rev := &apiv1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-rev-%d", name, next),
+			Namespace: ns,
+			Labels: map[string]string{
+				SealedSecretsHistoryLabel: name,
+			},
+			Annotations: map[string]string{
+				SealedSecretsRevisionAnnotation: strconv.Itoa(next),
+			},
+			// Garbage collected with the SealedSecret
+			OwnerReferences: secret.GetOwnerReferences(),
+		},
+		Type: secret.Type,
+		Data: secret.Data,
+	}
+	if _, err := c.sclient.Secrets(ns).Create(rev); err != nil {
+		return fmt.Errorf("failed to save previous version of secret: %s", err)
+	}
+	revisions = append(revisions, *rev)
+
+	for i := 0; i < len(revisions)-c.historyLimit; i++ {
+		err := c.sclient.Secrets(ns).Delete(revisions[i].GetName(), &metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	allowedClientIdentities = flag.StringSlice("allowed-client-identities", nil, "Client identities allowed to call rotate and seal on the HTTP and gRPC APIs, eg. spiffe://example.org/ci/*, a trailing * matching any suffix. Requires client certificates, see --tls-client-ca and --grpc-client-ca. Any client may call them if empty.")
+	clientCertIdentities    = flag.StringSlice("client-cert-identities", nil, "Identities of the client certificates without SPIFFE ID, as <common name>=<identity>, the identity not being a SPIFFE ID. Those without SPIFFE ID nor mapped identity have no identity.")
+)
+
+// identities is the policy of the client identities, nil if no
+// identity is required.
+var identities *identityPolicy
+
+// identityPolicy identifies the clients by their verified certificate,
+// and restricts the identities allowed to call rotate and seal.
+type identityPolicy struct {
+	allowed []string
+	// mapped are the identities of the common names of the client
+	// certificates without SPIFFE ID. Common names are only trusted
+	// through them, so that a common name such as spiffe://... can't
+	// pass for a SPIFFE ID.
+	mapped map[string]string
+}
+
+// newIdentityPolicy returns the policy allowing the allowed identities,
+// given the <common name>=<identity> mappings.
+func newIdentityPolicy(allowed, mappings []string) (*identityPolicy, error) {
+	p := &identityPolicy{allowed: allowed, mapped: map[string]string{}}
+	for _, m := range mappings {
+		i := strings.Index(m, "=")
+		if i <= 0 || i == len(m)-1 {
+			return nil, fmt.Errorf("invalid client certificate identity %q, expected <common name>=<identity>", m)
+		}
+		if strings.HasPrefix(strings.ToLower(m[i+1:]), "spiffe:") {
+			return nil, fmt.Errorf("invalid client certificate identity %q, common names can't be mapped to SPIFFE IDs", m)
+		}
+		p.mapped[m[:i]] = m[i+1:]
+	}
+	return p, nil
+}
+
+// spiffeID returns the SPIFFE ID of an X.509 SVID, the URI SAN with
+// the spiffe scheme, empty if cert isn't an SVID.
+func spiffeID(cert *x509.Certificate) string {
+	for _, uri := range cert.URIs {
+		if uri.Scheme == "spiffe" {
+			return uri.String()
+		}
+	}
+	return ""
+}
+
+// identity returns the identity of the client of the verified chains:
+// the SPIFFE ID of its certificate, or the identity its common name is
+// mapped to. It returns an empty identity without verified certificate,
+// or if its common name isn't mapped.
+func (p *identityPolicy) identity(chains [][]*x509.Certificate) string {
+	if len(chains) == 0 || len(chains[0]) == 0 {
+		return ""
+	}
+	cert := chains[0][0]
+	if id := spiffeID(cert); id != "" {
+		return id
+	}
+	if p != nil {
+		return p.mapped[cert.Subject.CommonName]
+	}
+	return ""
+}
+
+// enforced returns whether an allowed identity is required.
+func (p *identityPolicy) enforced() bool {
+	return p != nil && len(p.allowed) > 0
+}
+
+// allows returns whether identity may call rotate and seal.
+func (p *identityPolicy) allows(identity string) bool {
+	if !p.enforced() {
+		return true
+	}
+	if identity == "" {
+		return false
+	}
+	return matchesAny(p.allowed, identity)
+}
+
+// httpIdentity returns the identity of the client of r, empty without
+// verified client certificate.
+func httpIdentity(r *http.Request) string {
+	if r.TLS == nil {
+		return ""
+	}
+	return identities.identity(r.TLS.VerifiedChains)
+}
+
+// grpcIdentity returns the identity of the client of a gRPC call, empty
+// without verified client certificate.
+func grpcIdentity(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return ""
+	}
+	return identities.identity(info.State.VerifiedChains)
+}
+
+// identityHandler only lets the clients with an allowed identity
+// through to h.
+func identityHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !identities.enforced() {
+			h.ServeHTTP(w, r)
+			return
+		}
+		identity := httpIdentity(r)
+		if identity == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if !identities.allows(identity) {
+			log.Printf("Client identity %q denied %s", identity, r.URL.Path)
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// checkGRPCIdentity returns the error of the gRPC calls to rotate and
+// seal from the clients without an allowed identity.
+func checkGRPCIdentity(ctx context.Context, method string) error {
+	if !identities.enforced() {
+		return nil
+	}
+	identity := grpcIdentity(ctx)
+	if identity == "" {
+		return status.Error(codes.Unauthenticated, "a client certificate is required")
+	}
+	if !identities.allows(identity) {
+		log.Printf("Client identity %q denied %s", identity, method)
+		return status.Errorf(codes.PermissionDenied, "client identity %q may not call %s", identity, method)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func clientChains(cn string, uris ...string) [][]*x509.Certificate {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: cn}}
+	for _, u := range uris {
+		parsed, err := url.Parse(u)
+		if err != nil {
+			panic(err)
+		}
+		cert.URIs = append(cert.URIs, parsed)
+	}
+	return [][]*x509.Certificate{{cert}}
+}
+
+func TestIdentityPolicy(t *testing.T) {
+	p, err := newIdentityPolicy([]string{"spiffe://example.org/ci/*", "kubeseal"}, []string{"laptop=kubeseal"})
+	if err != nil {
+		t.Fatalf("newIdentityPolicy() returned err: %v", err)
+	}
+
+	testCases := []struct {
+		chains   [][]*x509.Certificate
+		identity string
+		allowed  bool
+	}{
+		{clientChains("build", "https://example.org", "spiffe://example.org/ci/build"), "spiffe://example.org/ci/build", true},
+		{clientChains("ci", "spiffe://example.org/web"), "spiffe://example.org/web", false},
+		{clientChains("laptop"), "kubeseal", true},
+		{clientChains("other"), "", false},
+		// A common name shaped like a SPIFFE ID isn't one
+		{clientChains("spiffe://example.org/ci/build"), "", false},
+		{clientChains("spiffe://example.org/ci/build", "https://example.org"), "", false},
+		{nil, "", false},
+	}
+	for _, tc := range testCases {
+		identity := p.identity(tc.chains)
+		if identity != tc.identity {
+			t.Errorf("Expected identity %q, got %q", tc.identity, identity)
+		}
+		if allowed := p.allows(identity); allowed != tc.allowed {
+			t.Errorf("allows(%q) = %v", identity, allowed)
+		}
+	}
+
+	for _, m := range []string{"laptop", "=kubeseal", "laptop=", "laptop=spiffe://example.org/ci/laptop"} {
+		if _, err := newIdentityPolicy(nil, []string{m}); err == nil {
+			t.Errorf("newIdentityPolicy(%q) didn't return an error", m)
+		}
+	}
+
+	var none *identityPolicy
+	if none.enforced() || !none.allows("") {
+		t.Errorf("A nil policy must allow any client")
+	}
+}
+
+func TestIdentityHandler(t *testing.T) {
+	defer func(old *identityPolicy) { identities = old }(identities)
+	identities = &identityPolicy{allowed: []string{"spiffe://example.org/ci"}}
+
+	h := identityHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(chains [][]*x509.Certificate) int {
+		req := httptest.NewRequest("POST", "/v1/rotate", nil)
+		if chains != nil {
+			req.TLS = &tls.ConnectionState{VerifiedChains: chains}
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := serve(nil); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without certificate, got %d", code)
+	}
+	if code := serve(clientChains("", "spiffe://example.org/web")); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for another identity, got %d", code)
+	}
+	if code := serve(clientChains("", "spiffe://example.org/ci")); code != http.StatusOK {
+		t.Errorf("Expected 200 for an allowed identity, got %d", code)
+	}
+}
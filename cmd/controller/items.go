@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	flag "github.com/spf13/pflag"
+	authzv1 "k8s.io/api/authorization/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+var (
+	itemsEndpoint = flag.Bool("enable-items-endpoint", false, "Serve /v1/items, adding or removing single items of SealedSecrets on behalf of clients authorized to update them.")
+)
+
+var (
+	errLegacyData       = errors.New("SealedSecret uses the legacy data field")
+	errNoItemsLeft      = errors.New("SealedSecret would have no items left")
+	errUndecryptable    = errors.New("items can't be decrypted for this SealedSecret, they must be sealed with its algorithm and scope")
+	errEmptyItemsUpdate = errors.New("nothing to set or remove")
+)
+
+// itemsRequest is the body of /v1/items requests. The sealed items of
+// Set are added to the SealedSecret Namespace/Name, replacing existing
+// items with the same keys, and the items of Remove are removed.
+type itemsRequest struct {
+	Namespace string            `json:"namespace"`
+	Name      string            `json:"name"`
+	Set       map[string][]byte `json:"set,omitempty"`
+	Remove    []string          `json:"remove,omitempty"`
+}
+
+// Called on every request to /v1/items, see Controller.UpdateItems.
+type itemsUpdater func(namespace, name string, set map[string][]byte, remove []string) error
+
+// kubeUpdateAuthorizer checks that the user may update SealedSecrets in
+// the namespace, so that partial updates obey the cluster RBAC.
+func kubeUpdateAuthorizer(client kubernetes.Interface) sealAuthorizer {
+	return func(token, namespace string) (bool, error) {
+		return kubeAccessReview(client, token, &authzv1.ResourceAttributes{
+			Namespace: namespace,
+			Verb:      "update",
+			Group:     ssv1alpha1.GroupName,
+			Resource:  "sealedsecrets",
+		})
+	}
+}
+
+func itemsHandler(iu itemsUpdater, sa sealAuthorizer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		token := bearerToken(r)
+		if token == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var req itemsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Namespace == "" || req.Name == "" {
+			log.Printf("Error handling /v1/items request: namespace and name are required (%v)", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		allowed, err := sa(token, req.Namespace)
+		if err != nil {
+			log.Printf("Error authorizing /v1/items request: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		err = iu(req.Namespace, req.Name, req.Set, req.Remove)
+		auditLog.record(auditResult(auditEvent{
+			Operation: "update-items",
+			Object:    req.Namespace + "/" + req.Name,
+			Caller:    httpCaller(r),
+		}, "success", err))
+
+		switch {
+		case err == nil:
+			w.WriteHeader(http.StatusOK)
+		case k8serrors.IsNotFound(err):
+			w.WriteHeader(http.StatusNotFound)
+		case k8serrors.IsConflict(err):
+			w.WriteHeader(http.StatusConflict)
+		case err == errLegacyData, err == errNoItemsLeft, err == errUndecryptable, err == errEmptyItemsUpdate:
+			log.Printf("Rejected /v1/items request for %s/%s: %v", req.Namespace, req.Name, err)
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+		default:
+			log.Printf("Error updating items of %s/%s: %v", req.Namespace, req.Name, err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+}
+
+// UpdateItems adds the sealed items of set to the SealedSecret
+// namespace/name and removes the items of remove, so that a single
+// value can be rotated without knowing the others. The new items must
+// decrypt with the keys of the controller, as they would when the
+// SealedSecret is unsealed.
+func (c *Controller) UpdateItems(namespace, name string, set map[string][]byte, remove []string) error {
+	if len(set) == 0 && len(remove) == 0 {
+		return errEmptyItemsUpdate
+	}
+	ssecret, err := c.ssclient.BitnamiV1alpha1().SealedSecrets(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if len(ssecret.Spec.Data) > 0 {
+		return errLegacyData
+	}
+
+	if len(set) > 0 {
+		trial := ssecret.DeepCopy()
+		trial.Spec.EncryptedData = set
+		if _, err := c.attemptUnseal(trial); err != nil {
+			return errUndecryptable
+		}
+	}
+
+	updated := ssecret.DeepCopy()
+	if updated.Spec.EncryptedData == nil {
+		updated.Spec.EncryptedData = map[string][]byte{}
+	}
+	for k, v := range set {
+		updated.Spec.EncryptedData[k] = v
+	}
+	for _, k := range remove {
+		delete(updated.Spec.EncryptedData, k)
+	}
+	if len(updated.Spec.EncryptedData) == 0 {
+		return errNoItemsLeft
+	}
+
+	if c.readOnly() {
+		log.Printf("Dry run: SealedSecret %s/%s would be updated with %d new and %d removed items", namespace, name, len(set), len(remove))
+		return nil
+	}
+	_, err = c.ssclient.BitnamiV1alpha1().SealedSecrets(namespace).Update(updated)
+	return err
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+func TestItemsHandler(t *testing.T) {
+	iu := func(namespace, name string, set map[string][]byte, remove []string) error {
+		switch name {
+		case "missing":
+			return k8serrors.NewNotFound(schema.GroupResource{Resource: "sealedsecrets"}, name)
+		case "last":
+			return errNoItemsLeft
+		case "broken":
+			return errors.New("update failed")
+		}
+		return nil
+	}
+	sa := func(token, namespace string) (bool, error) {
+		return token == "good" && namespace == "myns", nil
+	}
+	handler := itemsHandler(iu, sa)
+
+	testCases := []struct {
+		method string
+		token  string
+		body   string
+		status int
+	}{
+		{"GET", "good", "", http.StatusMethodNotAllowed},
+		{"POST", "", `{"namespace":"myns","name":"mysecret","remove":["foo"]}`, http.StatusUnauthorized},
+		{"POST", "good", `{"namespace":"myns","remove":["foo"]}`, http.StatusBadRequest},
+		{"POST", "good", `{"namespace":"otherns","name":"mysecret","remove":["foo"]}`, http.StatusForbidden},
+		{"POST", "good", `{"namespace":"myns","name":"missing","remove":["foo"]}`, http.StatusNotFound},
+		{"POST", "good", `{"namespace":"myns","name":"last","remove":["foo"]}`, http.StatusBadRequest},
+		{"POST", "good", `{"namespace":"myns","name":"broken","remove":["foo"]}`, http.StatusInternalServerError},
+		{"POST", "good", `{"namespace":"myns","name":"mysecret","set":{"foo":"AgBy"}}`, http.StatusOK},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest(tc.method, "/v1/items", strings.NewReader(tc.body))
+		if tc.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tc.status {
+			t.Errorf("%s %s with token %q: got status %d, expected %d", tc.method, tc.body, tc.token, rec.Code, tc.status)
+		}
+	}
+}
+
+func TestUpdateItems(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+	c := newTestController(t, ssecret)
+	c.keyRegistry = registry
+
+	sealItem := func(name, key, value string) map[string][]byte {
+		secret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "myns"},
+			Data:       map[string][]byte{key: []byte(value)},
+		}
+		s, err := seal.Seal(secret, &registry.latestPrivateKey().PublicKey, seal.StrictScope)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s.Spec.EncryptedData
+	}
+
+	if err := c.UpdateItems("myns", "mysecret", sealItem("mysecret", "baz", "qux"), nil); err != nil {
+		t.Fatalf("UpdateItems() returned err: %v", err)
+	}
+	if err := c.UpdateItems("myns", "mysecret", sealItem("other", "baz", "qux"), nil); err != errUndecryptable {
+		t.Errorf("Expected errUndecryptable for an item sealed for another name, got %v", err)
+	}
+	if err := c.UpdateItems("myns", "mysecret", nil, []string{"foo"}); err != nil {
+		t.Fatalf("UpdateItems() returned err: %v", err)
+	}
+	if err := c.UpdateItems("myns", "mysecret", nil, []string{"baz"}); err != errNoItemsLeft {
+		t.Errorf("Expected errNoItemsLeft, got %v", err)
+	}
+	if err := c.UpdateItems("myns", "missing", nil, []string{"baz"}); !k8serrors.IsNotFound(err) {
+		t.Errorf("Expected a NotFound error, got %v", err)
+	}
+
+	updated, err := c.ssclient.BitnamiV1alpha1().SealedSecrets("myns").Get("mysecret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, err := c.attemptUnseal(updated)
+	if err != nil {
+		t.Fatalf("Updated SealedSecret can't be unsealed: %v", err)
+	}
+	if len(secret.Data) != 1 || string(secret.Data["baz"]) != "qux" {
+		t.Errorf("Unexpected data after update: %v", secret.Data)
+	}
+}
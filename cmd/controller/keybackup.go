@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+var (
+	keyBackupRSARecipients = flag.StringSlice("key-backup-rsa-recipient", nil, "PEM certificate of an operator to encrypt a backup of every new key for. Can be repeated.")
+	keyBackupAgeRecipients = flag.StringSlice("key-backup-age-recipient", nil, "age recipient to encrypt a backup of every new key for, using the age binary. Can be repeated.")
+	keyBackupSecret        = flag.String("key-backup-secret", "", "Secret, in the controller namespace, to store the encrypted key backups in.")
+	keyBackupDir           = flag.String("key-backup-dir", "", "Directory to write the encrypted key backups to, eg. a mounted object store bucket.")
+)
+
+// keyBackups backs up the new keys, nil if disabled.
+var keyBackups *keyBackuper
+
+// ageEncrypt encrypts plaintext for the age recipients, returning ASCII
+// armored output.
+var ageEncrypt = func(plaintext []byte, recipients []string) ([]byte, error) {
+	args := []string{"--armor"}
+	for _, r := range recipients {
+		args = append(args, "--recipient", r)
+	}
+	cmd := exec.Command("age", args...)
+
+	var stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(plaintext)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("age failed to encrypt key backup: %s", strings.TrimSpace(stderr.String()))
+		}
+		return nil, fmt.Errorf("Error running age: %v", err)
+	}
+	return out, nil
+}
+
+// backupRecipient is an operator RSA public key.
+type backupRecipient struct {
+	fingerprint string
+	pubKey      *rsa.PublicKey
+}
+
+// keyBackuper stores copies of the new keys, encrypted for the
+// operators, in a Secret and/or a directory, so that an always current
+// backup of the keys exists outside of the cluster.
+type keyBackuper struct {
+	client        kubernetes.Interface
+	namespace     string
+	secret        string
+	dir           string
+	rsaRecipients []backupRecipient
+	ageRecipients []string
+}
+
+func newKeyBackuper(client kubernetes.Interface, namespace, secret, dir string, certFiles, ageRecipients []string) (*keyBackuper, error) {
+	if secret == "" && dir == "" {
+		return nil, fmt.Errorf("--key-backup-secret or --key-backup-dir is required with key backup recipients")
+	}
+	b := &keyBackuper{
+		client:        client,
+		namespace:     namespace,
+		secret:        secret,
+		dir:           dir,
+		ageRecipients: ageRecipients,
+	}
+	for _, file := range certFiles {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		pubKey, err := seal.ParsePublicKey(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid key backup recipient %s: %v", file, err)
+		}
+		fp, err := seal.Fingerprint(pubKey)
+		if err != nil {
+			return nil, err
+		}
+		b.rsaRecipients = append(b.rsaRecipients, backupRecipient{fingerprint: fp, pubKey: pubKey})
+	}
+	return b, nil
+}
+
+// backup encrypts the key Secret key for every recipient and stores
+// the copies. Each RSA recipient gets a <key>.<fingerprint>.rsa copy,
+// to be decrypted with kubeseal decrypt-key-backup, and the age
+// recipients share a <key>.age copy. It does nothing if b is nil.
+func (b *keyBackuper) backup(key *apiv1.Secret) error {
+	if b == nil {
+		return nil
+	}
+	pem := append(append([]byte{}, key.Data[apiv1.TLSPrivateKeyKey]...), key.Data[apiv1.TLSCertKey]...)
+
+	copies := map[string][]byte{}
+	for _, r := range b.rsaRecipients {
+		ciphertext, err := seal.WrapKeyBackup(rand.Reader, r.pubKey, pem)
+		if err != nil {
+			return err
+		}
+		copies[fmt.Sprintf("%s.%s.rsa", key.Name, r.fingerprint[:16])] = ciphertext
+	}
+	if len(b.ageRecipients) > 0 {
+		ciphertext, err := ageEncrypt(pem, b.ageRecipients)
+		if err != nil {
+			return err
+		}
+		copies[key.Name+".age"] = ciphertext
+	}
+
+	if b.dir != "" {
+		for name, ciphertext := range copies {
+			if err := ioutil.WriteFile(filepath.Join(b.dir, name), ciphertext, 0600); err != nil {
+				return err
+			}
+		}
+	}
+	if b.secret != "" {
+		return b.storeInSecret(copies)
+	}
+	return nil
+}
+
+// storeInSecret adds copies to the backup Secret, creating it if
+// needed.
+func (b *keyBackuper) storeInSecret(copies map[string][]byte) error {
+	secrets := b.client.Core().Secrets(b.namespace)
+	existing, err := secrets.Get(b.secret, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = secrets.Create(&apiv1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: b.secret, Namespace: b.namespace},
+			Data:       copies,
+		})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	updated := existing.DeepCopy()
+	if updated.Data == nil {
+		updated.Data = map[string][]byte{}
+	}
+	for name, ciphertext := range copies {
+		updated.Data[name] = ciphertext
+	}
+	_, err = secrets.Update(updated)
+	return err
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	certUtil "k8s.io/client-go/util/cert"
+
+	"github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+func TestKeyBackup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keybackup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	operator, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	operatorCert, err := signKey(testRand(), operator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile := filepath.Join(dir, "operator.crt")
+	if err := ioutil.WriteFile(certFile, certUtil.EncodeCertPEM(operatorCert), 0600); err != nil {
+		t.Fatal(err)
+	}
+	fp, err := seal.Fingerprint(&operator.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(f func([]byte, []string) ([]byte, error)) { ageEncrypt = f }(ageEncrypt)
+	ageEncrypt = func(plaintext []byte, recipients []string) ([]byte, error) {
+		return append([]byte(recipients[0]+":"), plaintext...), nil
+	}
+
+	client := fake.NewSimpleClientset(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "key-backups", Namespace: "myns"},
+		Data:       map[string][]byte{"oldkey.age": []byte("old")},
+	})
+	b, err := newKeyBackuper(client, "myns", "key-backups", dir, []string{certFile}, []string{"age1xyz"})
+	if err != nil {
+		t.Fatalf("newKeyBackuper() returned err: %v", err)
+	}
+	key := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "mykey", Namespace: "myns"},
+		Data: map[string][]byte{
+			v1.TLSPrivateKeyKey: []byte("KEY\n"),
+			v1.TLSCertKey:       []byte("CERT\n"),
+		},
+	}
+	if err := b.backup(key); err != nil {
+		t.Fatalf("backup() returned err: %v", err)
+	}
+
+	backups, err := client.Core().Secrets("myns").Get("key-backups", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups.Data) != 3 || string(backups.Data["oldkey.age"]) != "old" {
+		t.Errorf("Unexpected backups: %v", backups.Data)
+	}
+	if got := string(backups.Data["mykey.age"]); got != "age1xyz:KEY\nCERT\n" {
+		t.Errorf("Unexpected age backup: %q", got)
+	}
+
+	rsaName := "mykey." + fp[:16] + ".rsa"
+	onDisk, err := ioutil.ReadFile(filepath.Join(dir, rsaName))
+	if err != nil {
+		t.Fatalf("Backup not written to the directory: %v", err)
+	}
+	if !bytes.Equal(onDisk, backups.Data[rsaName]) {
+		t.Errorf("The directory and the Secret have different backups")
+	}
+	pem, err := seal.UnwrapKeyBackup(rand.Reader, operator, onDisk)
+	if err != nil {
+		t.Fatalf("UnwrapKeyBackup() returned err: %v", err)
+	}
+	if string(pem) != "KEY\nCERT\n" {
+		t.Errorf("Unexpected backup content: %q", pem)
+	}
+}
+
+func TestKeyBackupRequiresDestination(t *testing.T) {
+	if _, err := newKeyBackuper(fake.NewSimpleClientset(), "myns", "", "", nil, []string{"age1xyz"}); err == nil {
+		t.Errorf("Expected an error without --key-backup-secret nor --key-backup-dir")
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+// keyInfo describes a key of a registry, for diagnostics.
+type keyInfo struct {
+	Name string `json:"name"`
+	// Namespace is the namespace served by a per-namespace key.
+	Namespace   string    `json:"namespace,omitempty"`
+	Fingerprint string    `json:"fingerprint,omitempty"`
+	Created     time.Time `json:"created"`
+	// Active is set on the key used for sealing.
+	Active bool `json:"active"`
+	// Loaded keys are used for unsealing.
+	Loaded          bool   `json:"loaded"`
+	Blacklisted     bool   `json:"blacklisted"`
+	BlacklistedAt   string `json:"blacklistedAt,omitempty"`
+	BlacklistReason string `json:"blacklistReason,omitempty"`
+}
+
+// dump describes the keys of kr, including the blacklisted ones which
+// aren't loaded anymore, oldest first.
+func (kr *KeyRegistry) dump() ([]keyInfo, error) {
+	stored, err := kr.store.List(kr.keyNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	registered := kr.keys.Keys()
+	loaded := map[string]int{}
+	for i, k := range registered {
+		loaded[k.Name] = i
+	}
+
+	var keys []keyInfo
+	for _, k := range stored {
+		info := keyInfo{
+			Name:            k.Name,
+			Namespace:       kr.keyNamespace,
+			Created:         k.Created,
+			Blacklisted:     k.Blacklisted,
+			BlacklistedAt:   k.BlacklistedAt,
+			BlacklistReason: k.BlacklistReason,
+		}
+		if i, ok := loaded[k.Name]; ok {
+			info.Loaded = true
+			info.Active = i == len(registered)-1
+			info.Fingerprint = registered[i].Fingerprint
+		} else if k.Err == nil {
+			info.Fingerprint, _ = seal.Fingerprint(&k.PrivateKey.PublicKey)
+		}
+		keys = append(keys, info)
+	}
+	return keys, nil
+}
+
+// DumpKeys describes the cluster-wide keys, and the keys of the
+// namespaces in use with --per-namespace-keys.
+func (c *Controller) DumpKeys() ([]keyInfo, error) {
+	keys, err := c.keyRegistry.dump()
+	if err != nil {
+		return nil, err
+	}
+	if c.nsKeys == nil {
+		return keys, nil
+	}
+
+	c.nsKeys.mu.Lock()
+	defer c.nsKeys.mu.Unlock()
+	namespaces := make([]string, 0, len(c.nsKeys.registries))
+	for ns := range c.nsKeys.registries {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	for _, ns := range namespaces {
+		nsKeys, err := c.nsKeys.registries[ns].dump()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, nsKeys...)
+	}
+	return keys, nil
+}
+
+// keysHandler serves the description of the keys, see DumpKeys.
+func keysHandler(kd keyDumper) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		keys, err := kd()
+		if err != nil {
+			log.Printf("Error listing keys: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(keys)
+	})
+}
+
+// initKeyDumpSignalListener logs the description of the keys on
+// SIGUSR2.
+func initKeyDumpSignalListener(kd keyDumper) {
+	sigChannel := make(chan os.Signal, 1)
+	signal.Notify(sigChannel, syscall.SIGUSR2)
+	go func() {
+		for range sigChannel {
+			keys, err := kd()
+			if err != nil {
+				log.Printf("Error listing keys: %v", err)
+				continue
+			}
+			for _, k := range keys {
+				line, _ := json.Marshal(k)
+				log.Printf("Key: %s", line)
+			}
+		}
+	}()
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+func TestDumpKeys(t *testing.T) {
+	kr := testKeys(t, "key1", "key2", "key3")
+	latestFp, err := seal.Fingerprint(&kr.latestPrivateKey().PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kr.blacklist("key1", "leaked"); err != nil {
+		t.Fatalf("blacklist() returned err: %v", err)
+	}
+
+	keys, err := kr.dump()
+	if err != nil {
+		t.Fatalf("dump() returned err: %v", err)
+	}
+	byName := map[string]keyInfo{}
+	for _, k := range keys {
+		byName[k.Name] = k
+	}
+	if len(byName) != 3 {
+		t.Fatalf("Expected 3 keys, got %v", keys)
+	}
+
+	if k := byName["key1"]; !k.Blacklisted || k.Loaded || k.Active || k.BlacklistReason != "leaked" || k.BlacklistedAt == "" {
+		t.Errorf("Unexpected blacklisted key %+v", k)
+	}
+	if k := byName["key2"]; k.Blacklisted || !k.Loaded || k.Active || k.Fingerprint == "" {
+		t.Errorf("Unexpected old key %+v", k)
+	}
+	if k := byName["key3"]; k.Blacklisted || !k.Loaded || !k.Active || k.Fingerprint != latestFp {
+		t.Errorf("Unexpected active key %+v", k)
+	}
+}
+
+func TestKeysHandler(t *testing.T) {
+	kd := func() ([]keyInfo, error) {
+		return []keyInfo{{Name: "key1", Active: true, Loaded: true}}, nil
+	}
+	handler := keysHandler(kd)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", "/admin/keys", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: got status %d, expected %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/admin/keys", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET: got status %d, expected %d", rec.Code, http.StatusOK)
+	}
+	var keys []keyInfo
+	if err := json.NewDecoder(rec.Body).Decode(&keys); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0].Name != "key1" || !keys[0].Active {
+		t.Errorf("Unexpected keys %v", keys)
+	}
+}
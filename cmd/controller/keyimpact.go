@@ -0,0 +1,125 @@
+package main
+
+import (
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	"github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+// Called on every request to /admin/keys/impact.
+type keyImpactReporter func(fingerprint string) (*impactReport, error)
+
+// impactReport lists the SealedSecrets sealed with a key, by namespaced
+// name only, to scope the exposure of a compromised key.
+type impactReport struct {
+	Fingerprint string `json:"fingerprint"`
+	// Key is the name of the key, empty if the controller doesn't have
+	// it.
+	Key string `json:"key,omitempty"`
+	// SealedSecrets were last decrypted with the key, or decrypt with
+	// it.
+	SealedSecrets []string `json:"sealedSecrets"`
+	// Unknown have never been decrypted, and couldn't be checked
+	// since the controller doesn't have the key.
+	Unknown []string `json:"unknown"`
+}
+
+// keyWithFingerprint returns the name and the private key of the key
+// with fingerprint, registered or blacklisted, nil if unknown.
+func (kr *KeyRegistry) keyWithFingerprint(fingerprint string) (string, *rsa.PrivateKey) {
+	if key, err := kr.keys.KeyForFingerprint(fingerprint); err == nil {
+		return key.Name, key.PrivateKey
+	}
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	for _, k := range kr.compromised {
+		if keyFingerprint(k.privKey) == fingerprint {
+			return k.name, k.privKey
+		}
+	}
+	return "", nil
+}
+
+// keyWithFingerprint is like KeyRegistry.keyWithFingerprint, for the
+// cluster-wide and the per-namespace keys.
+func (c *Controller) keyWithFingerprint(fingerprint string) (string, *rsa.PrivateKey) {
+	if name, key := c.keyRegistry.keyWithFingerprint(fingerprint); key != nil {
+		return name, key
+	}
+	if c.nsKeys == nil {
+		return "", nil
+	}
+	c.nsKeys.mu.Lock()
+	defer c.nsKeys.mu.Unlock()
+	for _, kr := range c.nsKeys.registries {
+		if name, key := kr.keyWithFingerprint(fingerprint); key != nil {
+			return name, key
+		}
+	}
+	return "", nil
+}
+
+// KeyImpact lists the SealedSecrets sealed with the key of fingerprint.
+// The fingerprint recorded in their status is trusted, the others are
+// decrypted with the key if the controller has it.
+func (c *Controller) KeyImpact(fingerprint string) (*impactReport, error) {
+	name, key := c.keyWithFingerprint(fingerprint)
+	report := &impactReport{Fingerprint: fingerprint, Key: name, SealedSecrets: []string{}, Unknown: []string{}}
+	for _, obj := range c.informer.GetIndexer().List() {
+		ssecret, ok := obj.(*ssv1alpha1.SealedSecret)
+		if !ok {
+			continue
+		}
+		id := fmt.Sprintf("%s/%s", ssecret.GetNamespace(), ssecret.GetName())
+		recorded := ""
+		if ssecret.Status != nil {
+			recorded = ssecret.Status.KeyFingerprint
+		}
+		switch {
+		case recorded == fingerprint:
+			report.SealedSecrets = append(report.SealedSecrets, id)
+		case recorded != "":
+			// Decrypted with another key
+		case key == nil:
+			report.Unknown = append(report.Unknown, id)
+		default:
+			if _, err := seal.Unseal(ssecret, seal.PrivateKeys{key}); err == nil {
+				report.SealedSecrets = append(report.SealedSecrets, id)
+			}
+		}
+	}
+	sort.Strings(report.SealedSecrets)
+	sort.Strings(report.Unknown)
+	return report, nil
+}
+
+// keyImpactHandler serves the impact report of the key of the
+// fingerprint query parameter, see KeyImpact.
+func keyImpactHandler(ki keyImpactReporter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		fingerprint := r.URL.Query().Get("fingerprint")
+		if fingerprint == "" {
+			http.Error(w, "fingerprint is required", http.StatusBadRequest)
+			return
+		}
+		report, err := ki(fingerprint)
+		if err != nil {
+			log.Printf("Error reporting the impact of key %s: %v", fingerprint, err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Reported %d SealedSecrets sealed with key %s", len(report.SealedSecrets), fingerprint)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+	})
+}
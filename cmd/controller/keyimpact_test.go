@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+func TestKeyImpact(t *testing.T) {
+	registry := testKeys(t, "key1")
+	fingerprint := keyFingerprint(registry.latestPrivateKey())
+
+	// Never decrypted
+	sealed := testSealedSecret(t, registry)
+	c := newTestController(t, sealed)
+	c.keyRegistry = registry
+
+	recorded := sealed.DeepCopy()
+	recorded.Name = "recorded"
+	recorded.Status = &ssv1alpha1.SealedSecretStatus{KeyFingerprint: fingerprint}
+	other := sealed.DeepCopy()
+	other.Name = "other"
+	other.Status = &ssv1alpha1.SealedSecretStatus{KeyFingerprint: "other"}
+	for _, ssecret := range []*ssv1alpha1.SealedSecret{recorded, other} {
+		c.informer.GetIndexer().Add(ssecret)
+	}
+
+	report, err := c.KeyImpact(fingerprint)
+	if err != nil {
+		t.Fatalf("KeyImpact() returned err: %v", err)
+	}
+	if report.Key != "key1" || !reflect.DeepEqual(report.SealedSecrets, []string{"myns/mysecret", "myns/recorded"}) || len(report.Unknown) != 0 {
+		t.Errorf("Unexpected report %+v", report)
+	}
+
+	// Still found once blacklisted
+	if _, err := registry.blacklist("key1", "leaked"); err != nil {
+		t.Fatalf("blacklist() returned err: %v", err)
+	}
+	if report, err = c.KeyImpact(fingerprint); err != nil || report.Key != "key1" || len(report.SealedSecrets) != 2 {
+		t.Errorf("Unexpected report of a blacklisted key %+v, %v", report, err)
+	}
+
+	// A key the controller doesn't have
+	if report, err = c.KeyImpact("unknown"); err != nil {
+		t.Fatalf("KeyImpact() returned err: %v", err)
+	}
+	if report.Key != "" || len(report.SealedSecrets) != 0 || !reflect.DeepEqual(report.Unknown, []string{"myns/mysecret"}) {
+		t.Errorf("Unexpected report of an unknown key %+v", report)
+	}
+}
+
+func TestKeyImpactHandler(t *testing.T) {
+	handler := keyImpactHandler(func(fingerprint string) (*impactReport, error) {
+		return &impactReport{Fingerprint: fingerprint, SealedSecrets: []string{"myns/mysecret"}, Unknown: []string{}}, nil
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/keys/impact", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without fingerprint, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/keys/impact?fingerprint=3f1c", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	var report impactReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Fingerprint != "3f1c" || len(report.SealedSecrets) != 1 {
+		t.Errorf("Unexpected report %+v", report)
+	}
+}
@@ -4,29 +4,55 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"log"
+	"sync"
 
-	"k8s.io/client-go/kubernetes"
+	"k8s.io/api/core/v1"
 	certUtil "k8s.io/client-go/util/cert"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+	"github.com/bitnami-labs/sealed-secrets/pkg/keyregistry"
+	"github.com/bitnami-labs/sealed-secrets/pkg/seal"
 )
 
+// KeyRegistry stores the keys of a keyregistry.Registry in a KeyStore.
 type KeyRegistry struct {
-	client      kubernetes.Interface
-	namespace   string
-	keyPrefix   string
-	keyLabel    string
-	keysize     int
-	privateKeys []*rsa.PrivateKey
-	cert        *x509.Certificate
+	store     KeyStore
+	keyPrefix string
+	keysize   int
+	keys      *keyregistry.Registry
+	// keyNamespace is the namespace served by the keys of this
+	// registry, empty for the cluster-wide keys.
+	keyNamespace string
+	// external seals instead of the keys, which are only used to
+	// unseal, see --vault-transit-key.
+	external externalKey
+
+	mu sync.Mutex
+	// compromised are the blacklisted keys, only used to explain why
+	// the SealedSecrets sealed with them aren't unsealed.
+	compromised []compromisedKey
+
+	// onKeyRegistered, if set, is called with keyNamespace after a key
+	// is registered.
+	onKeyRegistered func(namespace string)
+}
+
+// externalKey is a sealing key held outside of the cluster.
+type externalKey interface {
+	// certificate returns the certificate of the latest version of
+	// the key.
+	certificate() *x509.Certificate
+	// decrypters returns the versions of the key, latest first.
+	decrypters() []crypto.SessionKeyDecrypter
 }
 
-func NewKeyRegistry(client kubernetes.Interface, namespace, keyPrefix, keyLabel string, keysize int) *KeyRegistry {
+func NewKeyRegistry(store KeyStore, keyPrefix string, keysize int) *KeyRegistry {
 	return &KeyRegistry{
-		client:      client,
-		namespace:   namespace,
-		keyPrefix:   keyPrefix,
-		keysize:     keysize,
-		keyLabel:    keyLabel,
-		privateKeys: []*rsa.PrivateKey{},
+		store:     store,
+		keyPrefix: keyPrefix,
+		keysize:   keysize,
+		keys:      keyregistry.New(),
 	}
 }
 
@@ -36,26 +62,126 @@ func (kr *KeyRegistry) generateKey() (string, error) {
 		return "", err
 	}
 	certs := []*x509.Certificate{cert}
-	generatedName, err := writeKey(kr.client, key, certs, kr.namespace, kr.keyLabel, kr.keyPrefix)
+	generatedName, err := kr.store.Create(kr.keyNamespace, kr.keyPrefix, key, certs)
 	if err != nil {
 		return "", err
 	}
-	// Only store key to local store if write to k8s worked
-	kr.registerNewKey(generatedName, key, cert)
-	log.Printf("New key written to %s/%s\n", kr.namespace, generatedName)
+	// The key is usable even if it couldn't be backed up
+	secret := keyAsSecret(key, certs)
+	secret.Name = generatedName
+	if err := keyBackups.backup(secret); err != nil {
+		log.Printf("Error backing up key %s: %v", generatedName, err)
+		notifications.notify(notifyKeyBackupFailed, generatedName, err)
+	}
+	// Only store key to local store if write to the KeyStore worked
+	if err := kr.registerNewKey(generatedName, key, cert); err != nil {
+		return "", err
+	}
+	log.Printf("New key %s written to %s\n", generatedName, kr.store)
 	log.Printf("Certificate is \n%s\n", certUtil.EncodeCertPEM(cert))
 	return generatedName, nil
 }
 
-func (kr *KeyRegistry) registerNewKey(keyName string, privKey *rsa.PrivateKey, cert *x509.Certificate) {
-	kr.privateKeys = append(kr.privateKeys, privKey)
-	kr.cert = cert
+// load registers the keys of the store which aren't registered yet,
+// skipping the blacklisted and unreadable ones, oldest first. It
+// returns the number of keys registered.
+func (kr *KeyRegistry) load() (int, error) {
+	stored, err := kr.store.List(kr.keyNamespace)
+	if err != nil {
+		return 0, err
+	}
+	known := map[string]bool{}
+	for _, name := range kr.keyNames() {
+		known[name] = true
+	}
+
+	loaded := 0
+	for _, k := range stored {
+		if k.Blacklisted && k.Err == nil {
+			kr.addCompromised(k.Name, k.BlacklistReason, k.PrivateKey)
+		}
+		if known[k.Name] || k.Blacklisted {
+			continue
+		}
+		if k.Err != nil {
+			log.Printf("Error reading key %s: %v", k.Name, k.Err)
+			continue
+		}
+		if err := kr.registerNewKey(k.Name, k.PrivateKey, k.Certs[0]); err != nil {
+			return loaded, err
+		}
+		log.Printf("Loaded key %s from %s", k.Name, kr.store)
+		loaded++
+	}
+	return loaded, nil
+}
+
+func (kr *KeyRegistry) registerNewKey(keyName string, privKey *rsa.PrivateKey, cert *x509.Certificate) error {
+	if err := kr.keys.RegisterKey(keyName, privKey, cert); err != nil {
+		return err
+	}
+	if fp := keyFingerprint(privKey); fp != "" {
+		keyDecryptions.add(0, fp)
+	}
+	if kr.onKeyRegistered != nil {
+		kr.onKeyRegistered(kr.keyNamespace)
+	}
+	return nil
+}
+
+// PrivateKeys returns all the registered keys, so that the registry
+// can be used as a seal.KeySource.
+func (kr *KeyRegistry) PrivateKeys() []*rsa.PrivateKey {
+	return kr.keys.PrivateKeys()
+}
+
+// ExternalKeys returns the versions of the external key, so that the
+// registry can be used as a seal.ExternalKeySource.
+func (kr *KeyRegistry) ExternalKeys() []crypto.SessionKeyDecrypter {
+	if kr.external == nil {
+		return nil
+	}
+	return kr.external.decrypters()
+}
+
+// seal seals secret for scope with the latest key.
+func (kr *KeyRegistry) seal(secret *v1.Secret, scope seal.Scope) (*ssv1alpha1.SealedSecret, error) {
+	if kr.external != nil {
+		return seal.SealExternal(secret, kr.external.certificate().PublicKey.(*rsa.PublicKey), scope)
+	}
+	return seal.Seal(secret, &kr.latestPrivateKey().PublicKey, scope)
 }
 
 func (kr *KeyRegistry) latestPrivateKey() *rsa.PrivateKey {
-	return kr.privateKeys[len(kr.privateKeys)-1]
+	key, err := kr.keys.LatestKey()
+	if err != nil {
+		return nil
+	}
+	return key.PrivateKey
+}
+
+// latestCert returns the certificate published for sealing, nil before
+// the first key is registered.
+func (kr *KeyRegistry) latestCert() *x509.Certificate {
+	if kr.external != nil {
+		return kr.external.certificate()
+	}
+	key, err := kr.keys.LatestKey()
+	if err != nil {
+		return nil
+	}
+	return key.Cert
+}
+
+// keyNames returns the names of the registered keys, oldest first.
+func (kr *KeyRegistry) keyNames() []string {
+	var names []string
+	for _, k := range kr.keys.Keys() {
+		names = append(names, k.Name)
+	}
+	return names
 }
 
 func (kr *KeyRegistry) getCert(keyname string) (*x509.Certificate, error) {
-	return kr.cert, nil
+	return kr.latestCert(), nil
 }
@@ -11,9 +11,10 @@ import (
 	"time"
 
 	"k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
 	certUtil "k8s.io/client-go/util/cert"
+
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+	"github.com/bitnami-labs/sealed-secrets/pkg/seal"
 )
 
 const SealedSecretsKeyLabel = "sealedsecrets.bitnami.com/sealed-secrets-key"
@@ -42,6 +43,9 @@ func readKey(secret v1.Secret) (*rsa.PrivateKey, []*x509.Certificate, error) {
 	}
 	switch rsaKey := key.(type) {
 	case *rsa.PrivateKey:
+		if err := crypto.CheckFIPSKey(&rsaKey.PublicKey); err != nil {
+			return nil, nil, err
+		}
 		certs, err := certUtil.ParseCertsPEM(secret.Data[v1.TLSCertKey])
 		if err != nil {
 			return nil, nil, err
@@ -52,45 +56,70 @@ func readKey(secret v1.Secret) (*rsa.PrivateKey, []*x509.Certificate, error) {
 	}
 }
 
-func writeKey(client kubernetes.Interface, key *rsa.PrivateKey, certs []*x509.Certificate, namespace, label, prefix string) (string, error) {
+// keyAsSecret returns the TLS Secret holding key and its certificates.
+func keyAsSecret(key *rsa.PrivateKey, certs []*x509.Certificate) *v1.Secret {
 	certbytes := []byte{}
 	for _, cert := range certs {
 		certbytes = append(certbytes, certUtil.EncodeCertPEM(cert)...)
 	}
-	secret := v1.Secret{
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace:    namespace,
-			GenerateName: prefix,
-			Labels: map[string]string{
-				label: "active",
-			},
-		},
+	return &v1.Secret{
 		Data: map[string][]byte{
 			v1.TLSPrivateKeyKey: certUtil.EncodePrivateKeyPEM(key),
 			v1.TLSCertKey:       certbytes,
 		},
 		Type: v1.SecretTypeTLS,
 	}
-
-	createdSecret, err := client.Core().Secrets(namespace).Create(&secret)
-	if err != nil {
-		return "", err
-	}
-	return createdSecret.Name, nil
 }
 
 func signKey(r io.Reader, key *rsa.PrivateKey) (*x509.Certificate, error) {
 	// TODO: use certificates API to get this signed by the cluster root CA
 	// See https://kubernetes.io/docs/tasks/tls/managing-tls-in-a-cluster/
 
-	notBefore := time.Now()
+	// Publish the X25519 and ML-KEM keys paired with key, to seal with
+	// any algorithm, unless they can't be used in FIPS mode
+	var exts []pkix.Extension
+	if !crypto.FIPSEnabled() {
+		_, x25519PubKey, err := crypto.X25519KeyFromRSA(key)
+		if err != nil {
+			return nil, err
+		}
+		x25519Ext, err := seal.NewX25519Extension(x25519PubKey)
+		if err != nil {
+			return nil, err
+		}
+		kemKey, err := crypto.MLKEMKeyFromRSA(key)
+		if err != nil {
+			return nil, err
+		}
+		kemExt, err := seal.NewMLKEMExtension(kemKey.EncapsulationKey())
+		if err != nil {
+			return nil, err
+		}
+		exts = append(exts, x25519Ext, kemExt)
+	}
 
+	cert, err := certTemplate(r, exts)
+	if err != nil {
+		return nil, err
+	}
+	data, err := x509.CreateCertificate(r, cert, cert, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return x509.ParseCertificate(data)
+}
+
+// certTemplate returns the template of the self-signed certificates of
+// the keys, with the extensions exts.
+func certTemplate(r io.Reader, exts []pkix.Extension) (*x509.Certificate, error) {
+	notBefore := time.Now()
 	serialNo, err := rand.Int(r, new(big.Int).Lsh(big.NewInt(1), 128))
 	if err != nil {
 		return nil, err
 	}
 
-	cert := x509.Certificate{
+	return &x509.Certificate{
 		SerialNumber: serialNo,
 		KeyUsage:     x509.KeyUsageEncipherOnly,
 		NotBefore:    notBefore.UTC(),
@@ -100,12 +129,6 @@ func signKey(r io.Reader, key *rsa.PrivateKey) (*x509.Certificate, error) {
 		},
 		BasicConstraintsValid: true,
 		IsCA:                  true,
-	}
-
-	data, err := x509.CreateCertificate(r, &cert, &cert, &key.PublicKey, key)
-	if err != nil {
-		return nil, err
-	}
-
-	return x509.ParseCertificate(data)
+		ExtraExtensions:       exts,
+	}, nil
 }
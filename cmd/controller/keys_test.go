@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"crypto/rsa"
 	"crypto/x509"
 	"io"
@@ -12,6 +13,9 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
 	certUtil "k8s.io/client-go/util/cert"
+
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+	"github.com/bitnami-labs/sealed-secrets/pkg/seal"
 )
 
 // This is omg-not safe for real crypto use!
@@ -58,7 +62,7 @@ func TestReadKey(t *testing.T) {
 	}
 }
 
-func TestWriteKey(t *testing.T) {
+func TestSecretKeyStoreCreate(t *testing.T) {
 	rand := testRand()
 	key, err := rsa.GenerateKey(rand, 512)
 	if err != nil {
@@ -72,17 +76,17 @@ func TestWriteKey(t *testing.T) {
 
 	client := fake.NewSimpleClientset()
 
-	_, err = writeKey(client, key, []*x509.Certificate{cert}, "myns", "label", "mykey")
+	_, err = newSecretKeyStore(client, "myns", "label").Create("", "mykey", key, []*x509.Certificate{cert})
 	if err != nil {
-		t.Errorf("writeKey() failed with: %v", err)
+		t.Errorf("Create() failed with: %v", err)
 	}
 
 	t.Logf("actions: %v", client.Actions())
 
 	if a := findAction(client, "create", "secrets"); a == nil {
-		t.Errorf("writeKey didn't create a secret")
+		t.Errorf("Create() didn't create a secret")
 	} else if a.GetNamespace() != "myns" {
-		t.Errorf("writeKey() created key in wrong namespace!")
+		t.Errorf("Create() created key in wrong namespace!")
 	}
 }
 
@@ -102,4 +106,24 @@ func TestSignKey(t *testing.T) {
 	if !reflect.DeepEqual(cert.PublicKey, &key.PublicKey) {
 		t.Errorf("cert pubkey != original pubkey")
 	}
+
+	x25519PubKey, err := seal.ParseX25519PublicKey(bytes.NewReader(certUtil.EncodeCertPEM(cert)))
+	if err != nil {
+		t.Fatalf("The certificate has no X25519 key: %v", err)
+	}
+	if _, want, _ := crypto.X25519KeyFromRSA(key); *x25519PubKey != *want {
+		t.Errorf("The certificate has the wrong X25519 key")
+	}
+
+	kemKey, err := seal.ParseMLKEMPublicKey(bytes.NewReader(certUtil.EncodeCertPEM(cert)))
+	if err != nil {
+		t.Fatalf("The certificate has no ML-KEM key: %v", err)
+	}
+	want, err := crypto.MLKEMKeyFromRSA(key)
+	if err != nil {
+		t.Fatalf("MLKEMKeyFromRSA() returned error: %v", err)
+	}
+	if !bytes.Equal(kemKey.Bytes(), want.EncapsulationKey().Bytes()) {
+		t.Errorf("The certificate has the wrong ML-KEM key")
+	}
 }
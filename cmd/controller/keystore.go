@@ -0,0 +1,332 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	flag "github.com/spf13/pflag"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	keyStoreFlag = flag.String("key-store", "secrets", "Where the keys are stored: secrets, in Secrets of the controller namespace, dir:<path>, in files of a local directory, or vault-kv:<path>, in secrets of the Vault KV engine under path.")
+)
+
+// storedKey is a key of a KeyStore.
+type storedKey struct {
+	Name string
+	// Namespace is the namespace served by a per-namespace key, empty
+	// for the cluster-wide keys.
+	Namespace       string
+	Created         time.Time
+	Blacklisted     bool
+	BlacklistedAt   string
+	BlacklistReason string
+	PrivateKey      *rsa.PrivateKey
+	Certs           []*x509.Certificate
+	// Err is set when the key couldn't be read.
+	Err error
+}
+
+// KeyStore persists the keys of the KeyRegistries.
+type KeyStore interface {
+	// List returns the keys of keyNamespace, the cluster-wide keys if
+	// empty, including the blacklisted ones, oldest first.
+	List(keyNamespace string) ([]storedKey, error)
+	// Create stores a new key of keyNamespace, named from prefix, and
+	// returns its name.
+	Create(keyNamespace, prefix string, key *rsa.PrivateKey, certs []*x509.Certificate) (string, error)
+	// Blacklist marks the key called name as compromised.
+	Blacklist(name, reason string) error
+	// String describes where the keys are stored, for the logs.
+	String() string
+}
+
+// keyStoreBackends returns the KeyStore of a --key-store kind, given
+// the rest of the flag value.
+var keyStoreBackends = map[string]func(client kubernetes.Interface, namespace, arg string) (KeyStore, error){
+	"secrets": func(client kubernetes.Interface, namespace, arg string) (KeyStore, error) {
+		return newSecretKeyStore(client, namespace, SealedSecretsKeyLabel), nil
+	},
+	"dir": func(client kubernetes.Interface, namespace, arg string) (KeyStore, error) {
+		if arg == "" {
+			return nil, fmt.Errorf("--key-store=dir requires a path, eg. dir:/var/lib/sealed-secrets")
+		}
+		return newDirKeyStore(arg)
+	},
+	"vault-kv": func(client kubernetes.Interface, namespace, arg string) (KeyStore, error) {
+		if arg == "" {
+			return nil, fmt.Errorf("--key-store=vault-kv requires a path, eg. vault-kv:sealed-secrets")
+		}
+		c, err := vaultClientFromFlags()
+		if err != nil {
+			return nil, fmt.Errorf("cannot use --key-store=vault-kv: %v", err)
+		}
+		return newVaultKVKeyStore(c, *vaultKVMount, arg), nil
+	},
+}
+
+// newKeyStore returns the KeyStore selected by spec, a backend kind
+// optionally followed by a colon and its argument.
+func newKeyStore(spec string, client kubernetes.Interface, namespace string) (KeyStore, error) {
+	kind, arg := spec, ""
+	if i := strings.Index(spec, ":"); i >= 0 {
+		kind, arg = spec[:i], spec[i+1:]
+	}
+	backend, ok := keyStoreBackends[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown --key-store %q", kind)
+	}
+	return backend(client, namespace, arg)
+}
+
+// secretKeyStore stores the keys in TLS Secrets of the controller
+// namespace.
+type secretKeyStore struct {
+	client    kubernetes.Interface
+	namespace string
+	label     string
+}
+
+func newSecretKeyStore(client kubernetes.Interface, namespace, label string) *secretKeyStore {
+	return &secretKeyStore{client: client, namespace: namespace, label: label}
+}
+
+func (s *secretKeyStore) String() string {
+	return "Secrets of namespace " + s.namespace
+}
+
+func (s *secretKeyStore) List(keyNamespace string) ([]storedKey, error) {
+	selector := s.label + ",!" + SealedSecretsKeyNamespaceLabel
+	if keyNamespace != "" {
+		selector = s.label + "," + SealedSecretsKeyNamespaceLabel + "=" + keyNamespace
+	}
+	secretList, err := s.client.Core().Secrets(s.namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []storedKey
+	for _, secret := range secretList.Items {
+		key := storedKey{
+			Name:            secret.Name,
+			Namespace:       keyNamespace,
+			Created:         secret.CreationTimestamp.Time,
+			Blacklisted:     secret.Labels[s.label] == compromised,
+			BlacklistedAt:   secret.Annotations[SealedSecretsBlacklistedAtAnnotation],
+			BlacklistReason: secret.Annotations[SealedSecretsBlacklistReasonAnnotation],
+		}
+		key.PrivateKey, key.Certs, key.Err = readKey(secret)
+		keys = append(keys, key)
+	}
+	sortStoredKeys(keys)
+	return keys, nil
+}
+
+func (s *secretKeyStore) Create(keyNamespace, prefix string, key *rsa.PrivateKey, certs []*x509.Certificate) (string, error) {
+	secret := keyAsSecret(key, certs)
+	secret.Namespace = s.namespace
+	secret.GenerateName = prefix
+	secret.Labels = map[string]string{s.label: "active"}
+	if keyNamespace != "" {
+		secret.Labels[SealedSecretsKeyNamespaceLabel] = keyNamespace
+	}
+	created, err := s.client.Core().Secrets(s.namespace).Create(secret)
+	if err != nil {
+		return "", err
+	}
+	return created.Name, nil
+}
+
+func (s *secretKeyStore) Blacklist(name, reason string) error {
+	secret, err := s.client.Core().Secrets(s.namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	secret = secret.DeepCopy()
+	secret.Labels[s.label] = compromised
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[SealedSecretsBlacklistedAtAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	secret.Annotations[SealedSecretsBlacklistReasonAnnotation] = reason
+	_, err = s.client.Core().Secrets(s.namespace).Update(secret)
+	return err
+}
+
+// keyRecord is a key serialized by the stores outside of the cluster.
+type keyRecord struct {
+	Namespace       string    `json:"namespace,omitempty"`
+	Created         time.Time `json:"created"`
+	Blacklisted     bool      `json:"blacklisted,omitempty"`
+	BlacklistedAt   string    `json:"blacklistedAt,omitempty"`
+	BlacklistReason string    `json:"blacklistReason,omitempty"`
+	Key             []byte    `json:"tls.key"`
+	Cert            []byte    `json:"tls.crt"`
+}
+
+func newKeyRecord(keyNamespace string, key *rsa.PrivateKey, certs []*x509.Certificate) *keyRecord {
+	secret := keyAsSecret(key, certs)
+	return &keyRecord{
+		Namespace: keyNamespace,
+		Created:   time.Now().UTC(),
+		Key:       secret.Data[v1.TLSPrivateKeyKey],
+		Cert:      secret.Data[v1.TLSCertKey],
+	}
+}
+
+// blacklist marks k as compromised.
+func (k *keyRecord) blacklist(reason string) {
+	k.Blacklisted = true
+	k.BlacklistedAt = time.Now().UTC().Format(time.RFC3339)
+	k.BlacklistReason = reason
+}
+
+// storedKey returns k as the key called name.
+func (k *keyRecord) storedKey(name string) storedKey {
+	key := storedKey{
+		Name:            name,
+		Namespace:       k.Namespace,
+		Created:         k.Created,
+		Blacklisted:     k.Blacklisted,
+		BlacklistedAt:   k.BlacklistedAt,
+		BlacklistReason: k.BlacklistReason,
+	}
+	key.PrivateKey, key.Certs, key.Err = readKey(v1.Secret{Data: map[string][]byte{
+		v1.TLSPrivateKeyKey: k.Key,
+		v1.TLSCertKey:       k.Cert,
+	}})
+	return key
+}
+
+// dirKeyStore stores each key in a <name>.json file of a directory,
+// eg. a volume outside of the cluster.
+type dirKeyStore struct {
+	dir string
+}
+
+func newDirKeyStore(dir string) (*dirKeyStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &dirKeyStore{dir: dir}, nil
+}
+
+func (s *dirKeyStore) String() string {
+	return "directory " + s.dir
+}
+
+func (s *dirKeyStore) read(name string) (*keyRecord, error) {
+	data, err := ioutil.ReadFile(filepath.Join(s.dir, name+".json"))
+	if err != nil {
+		return nil, err
+	}
+	var k keyRecord
+	if err := json.Unmarshal(data, &k); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return &k, nil
+}
+
+func (s *dirKeyStore) write(name string, k *keyRecord, create bool) error {
+	data, err := json.Marshal(k)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(s.dir, name+".json")
+	if create {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(data); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+	// Replace atomically, a torn key file would lose the key
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (s *dirKeyStore) List(keyNamespace string) ([]storedKey, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var keys []storedKey
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".json")
+		k, err := s.read(name)
+		if err != nil {
+			keys = append(keys, storedKey{Name: name, Err: err})
+			continue
+		}
+		if k.Namespace != keyNamespace {
+			continue
+		}
+		keys = append(keys, k.storedKey(name))
+	}
+	sortStoredKeys(keys)
+	return keys, nil
+}
+
+func (s *dirKeyStore) Create(keyNamespace, prefix string, key *rsa.PrivateKey, certs []*x509.Certificate) (string, error) {
+	k := newKeyRecord(keyNamespace, key, certs)
+	for attempt := 0; ; attempt++ {
+		name := prefix + randomSuffix()
+		err := s.write(name, k, true)
+		if err == nil {
+			return name, nil
+		}
+		if !os.IsExist(err) || attempt >= 10 {
+			return "", err
+		}
+	}
+}
+
+func (s *dirKeyStore) Blacklist(name, reason string) error {
+	k, err := s.read(name)
+	if err != nil {
+		return err
+	}
+	k.blacklist(reason)
+	return s.write(name, k, false)
+}
+
+// randomSuffix returns 5 random lowercase alphanumeric characters, like
+// the names generated by the API server.
+func randomSuffix() string {
+	const alphabet = "bcdfghjklmnpqrstvwxz2456789"
+	suffix := make([]byte, 5)
+	for i := range suffix {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+		if err != nil {
+			panic(err)
+		}
+		suffix[i] = alphabet[n.Int64()]
+	}
+	return string(suffix)
+}
+
+func sortStoredKeys(keys []storedKey) {
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].Created.Before(keys[j].Created)
+	})
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDirKeyStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := newKeyStore("dir:"+dir, nil, "")
+	if err != nil {
+		t.Fatalf("newKeyStore() returned err: %v", err)
+	}
+	key, cert, err := generatePrivateKeyAndCert(1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, err := store.Create("", "prefix-", key, []*x509.Certificate{cert})
+	if err != nil {
+		t.Fatalf("Create() returned err: %v", err)
+	}
+	if _, err := store.Create("tenant", "prefix-tenant-", key, []*x509.Certificate{cert}); err != nil {
+		t.Fatalf("Create() returned err: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(dir, name+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected key file mode 0600, got %v", info.Mode().Perm())
+	}
+
+	keys, err := store.List("")
+	if err != nil {
+		t.Fatalf("List() returned err: %v", err)
+	}
+	if len(keys) != 1 || keys[0].Name != name || keys[0].Err != nil || keys[0].PrivateKey.N.Cmp(key.N) != 0 || keys[0].Blacklisted {
+		t.Fatalf("Unexpected keys: %+v", keys)
+	}
+
+	if err := store.Blacklist(name, "leaked"); err != nil {
+		t.Fatalf("Blacklist() returned err: %v", err)
+	}
+	keys, err = store.List("")
+	if err != nil {
+		t.Fatalf("List() returned err: %v", err)
+	}
+	if !keys[0].Blacklisted || keys[0].BlacklistReason != "leaked" || keys[0].BlacklistedAt == "" {
+		t.Errorf("Expected %s to be blacklisted, got %+v", name, keys[0])
+	}
+}
+
+func TestDirKeyStoreRegistry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := newDirKeyStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry, err := initKeyRegistry(store, "prefix-", 1024)
+	if err != nil {
+		t.Fatalf("initKeyRegistry() returned err: %v", err)
+	}
+	first, err := registry.generateKey()
+	if err != nil {
+		t.Fatalf("generateKey() returned err: %v", err)
+	}
+	if _, err := registry.generateKey(); err != nil {
+		t.Fatalf("generateKey() returned err: %v", err)
+	}
+	if _, err := registry.blacklist(first, "leaked"); err != nil {
+		t.Fatalf("blacklist() returned err: %v", err)
+	}
+
+	reloaded, err := initKeyRegistry(store, "prefix-", 1024)
+	if err != nil {
+		t.Fatalf("initKeyRegistry() returned err: %v", err)
+	}
+	if names := reloaded.keyNames(); len(names) != 1 || names[0] == first {
+		t.Errorf("Expected the blacklisted key to be skipped, got %v", names)
+	}
+	// It is still known to explain why its SealedSecrets aren't unsealed
+	if len(reloaded.compromised) != 1 || reloaded.compromised[0].name != first || reloaded.compromised[0].reason != "leaked" {
+		t.Errorf("Expected %s to be kept as compromised, got %+v", first, reloaded.compromised)
+	}
+}
+
+func TestNewKeyStore(t *testing.T) {
+	for _, spec := range []string{"vault", "dir", "dir:", "vault-kv:"} {
+		if _, err := newKeyStore(spec, nil, "kube-system"); err == nil {
+			t.Errorf("newKeyStore(%q) didn't return an error", spec)
+		}
+	}
+	if _, err := newKeyStore("secrets", nil, "kube-system"); err != nil {
+		t.Errorf("newKeyStore(secrets) returned err: %v", err)
+	}
+}
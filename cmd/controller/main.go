@@ -1,27 +1,24 @@
 package main
 
 import (
-	"crypto/rand"
 	"crypto/x509"
 	goflag "flag"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"log"
 	"os"
 	"os/signal"
-	"sort"
 	"strings"
 	"syscall"
 	"time"
 
+	"github.com/spf13/cobra"
 	flag "github.com/spf13/pflag"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
-	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
 	sealedsecrets "github.com/bitnami-labs/sealed-secrets/pkg/client/clientset/versioned"
 	ssinformers "github.com/bitnami-labs/sealed-secrets/pkg/client/informers/externalversions"
 )
@@ -33,15 +30,25 @@ var (
 	myCN            = flag.String("my-cn", "", "CN to use in generated certificate.")
 	printVersion    = flag.Bool("version", false, "Print version information and exit")
 	keyRotatePeriod = flag.Duration("rotate-period", 30*24*time.Hour, "New key generation period")
+	labelSelector   = flag.String("label-selector", "", "Only handle the SealedSecrets matching this label selector, to share them among several controllers.")
+	kubeAPIQPS      = flag.Float32("kube-api-qps", rest.DefaultQPS, "Maximum sustained queries per second to the API server, for each client. Raise it to reseal or unseal thousands of objects without being throttled.")
+	kubeAPIBurst    = flag.Int("kube-api-burst", rest.DefaultBurst, "Maximum burst of queries to the API server, for each client.")
+	resyncPeriod    = flag.Duration("resync-period", 0, "Period of the full reconciliation of the sealed objects, as a safety net against missed watch events. Zero disables it, which is best for large clusters.")
 
 	// VERSION set from Makefile
 	VERSION = "UNKNOWN"
 
-	// Selector used to find existing public/private key pairs on startup
-	keySelector = fields.OneTermEqualSelector(SealedSecretsKeyLabel, "active")
+	// controllerFlags are the flags declared by the files of the
+	// controller, those of the serve command.
+	controllerFlags *flag.FlagSet
 )
 
 func init() {
+	// cobra adds flag.CommandLine to the flags of every command, so
+	// only the standard ones are left there
+	controllerFlags = flag.CommandLine
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
 	// Standard goflags (glog in particular)
 	flag.CommandLine.AddGoFlagSet(goflag.CommandLine)
 	if f := flag.CommandLine.Lookup("logtostderr"); f != nil {
@@ -62,24 +69,13 @@ func initKeyPrefix(keyPrefix string) (string, error) {
 	return prefix, err
 }
 
-func initKeyRegistry(client kubernetes.Interface, r io.Reader, namespace, prefix, label string, keysize int) (*KeyRegistry, error) {
-	log.Printf("Searching for existing private keys")
-	secretList, err := client.Core().Secrets(namespace).List(metav1.ListOptions{
-		LabelSelector: keySelector.String(),
-	})
-	if err != nil {
+func initKeyRegistry(store KeyStore, prefix string, keysize int) (*KeyRegistry, error) {
+	log.Printf("Searching for existing private keys in %s", store)
+	// Per-namespace keys are loaded by namespaceKeys
+	keyRegistry := NewKeyRegistry(store, prefix, keysize)
+	if _, err := keyRegistry.load(); err != nil {
 		return nil, err
 	}
-	keyRegistry := NewKeyRegistry(client, namespace, prefix, label, keysize)
-	sort.Sort(ssv1alpha1.ByCreationTimestamp(secretList.Items))
-	for _, secret := range secretList.Items {
-		key, certs, err := readKey(secret)
-		if err != nil {
-			log.Printf("Error reading key %s: %v", secret.Name, err)
-		}
-		keyRegistry.registerNewKey(secret.Name, key, certs[0])
-		log.Printf("----- %s", secret.Name)
-	}
 	return keyRegistry, nil
 }
 
@@ -103,13 +99,27 @@ func initKeyRotation(registry *KeyRegistry, period time.Duration) (func(), error
 	if _, err := registry.generateKey(); err != nil { // create the first key
 		return nil, err
 	}
+	return ScheduleJobWithTrigger(period, keyGenFunc(registry, nil)), nil
+}
+
+// keyGenFunc returns the key rotation job of registry, which does
+// nothing while sb is a standby.
+func keyGenFunc(registry *KeyRegistry, sb *standbyState) func() {
 	// wrapper function to log error thrown by generateKey function
-	keyGenFunc := func() {
+	return func() {
+		if sb.isStandby() {
+			log.Printf("Standby: not generating a new key")
+			return
+		}
+		// Pick up the keys stored meanwhile, eg. by another controller
+		if _, err := registry.load(); err != nil {
+			log.Printf("Error loading keys: %v", err)
+		}
 		if _, err := registry.generateKey(); err != nil {
 			log.Printf("Failed to generate new key : %v\n", err)
+			notifications.notify(notifyKeyRotationFailed, "cluster-wide keys", err)
 		}
 	}
-	return ScheduleJobWithTrigger(period, keyGenFunc), nil
 }
 
 func initKeyGenSignalListener(trigger func()) {
@@ -128,6 +138,12 @@ func main2() error {
 	if err != nil {
 		return err
 	}
+	if *kubeAPIQPS <= 0 || *kubeAPIBurst <= 0 {
+		return fmt.Errorf("--kube-api-qps and --kube-api-burst must be positive")
+	}
+	config.QPS = *kubeAPIQPS
+	config.Burst = *kubeAPIBurst
+	log.Printf("Limiting API requests to %g per second, with bursts of %d", config.QPS, config.Burst)
 
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
@@ -146,31 +162,214 @@ func main2() error {
 		return err
 	}
 
-	keyRegistry, err := initKeyRegistry(clientset, rand.Reader, myNs, prefix, SealedSecretsKeyLabel, *keySize)
-	if err != nil {
+	if err := initFIPS(*keySize); err != nil {
 		return err
 	}
 
-	trigger, err := initKeyRotation(keyRegistry, *keyRotatePeriod)
+	if *notifyURL != "" {
+		if notifications, err = newNotifier(*notifyURL, *notifyTemplate); err != nil {
+			return fmt.Errorf("invalid --notify-template: %v", err)
+		}
+	}
+
+	if len(*keyBackupRSARecipients) > 0 || len(*keyBackupAgeRecipients) > 0 {
+		if keyBackups, err = newKeyBackuper(clientset, myNs, *keyBackupSecret, *keyBackupDir, *keyBackupRSARecipients, *keyBackupAgeRecipients); err != nil {
+			return err
+		}
+	}
+
+	keyStore, err := newKeyStore(*keyStoreFlag, clientset, myNs)
+	if err != nil {
+		return err
+	}
+	keyRegistry, err := initKeyRegistry(keyStore, prefix, *keySize)
 	if err != nil {
 		return err
 	}
 
-	initKeyGenSignalListener(trigger)
+	var vault *vaultTransit
+	if *vaultTransitKey != "" {
+		if *perNamespaceKeys {
+			return fmt.Errorf("--vault-transit-key can't be used with --per-namespace-keys")
+		}
+		c, err := vaultClientFromFlags()
+		if err == nil {
+			vault, err = newVaultTransit(c, *vaultTransitMount, *vaultTransitKey)
+		}
+		if err != nil {
+			return fmt.Errorf("cannot use --vault-transit-key: %v", err)
+		}
+		keyRegistry.external = vault
+	}
+
+	var sb *standbyState
+	if *standby {
+		sb = newStandbyState()
+	}
+	var trigger func()
+	switch {
+	case vault != nil:
+		// The existing keys are only kept to unseal
+		trigger = func() {
+			log.Printf("Vault transit key: rotate it in Vault, not generating a new key")
+		}
+	case *dryRun:
+		if len(keyRegistry.PrivateKeys()) == 0 {
+			return fmt.Errorf("--dry-run requires existing keys")
+		}
+		trigger = func() {
+			log.Printf("Dry run: not generating a new key")
+		}
+	case sb != nil:
+		if len(keyRegistry.PrivateKeys()) == 0 {
+			return fmt.Errorf("--standby requires keys replicated from the primary cluster")
+		}
+		trigger = ScheduleJobWithTrigger(*keyRotatePeriod, keyGenFunc(keyRegistry, sb))
+	default:
+		if trigger, err = initKeyRotation(keyRegistry, *keyRotatePeriod); err != nil {
+			return err
+		}
+	}
+	registerKeyMetrics(keyRegistry)
+
+	if len(*replicateTo) > 0 {
+		// The replicas are copies of the key Secrets
+		if _, ok := keyStore.(*secretKeyStore); !ok {
+			return fmt.Errorf("--replicate-to requires --key-store=secrets")
+		}
+		followers, err := loadFollowers(clientset, myNs, *replicateTo)
+		if err != nil {
+			return err
+		}
+		replicator := &keyReplicator{client: clientset, namespace: myNs, followers: followers}
+		go replicator.run(*replicatePeriod)
+	}
+
+	if *auditLogPath != "" {
+		if auditLog, err = openAuditLog(*auditLogPath); err != nil {
+			return fmt.Errorf("cannot open --audit-log: %v", err)
+		}
+	}
 
-	ssinformer := ssinformers.NewSharedInformerFactory(ssclient, 0)
-	controller := NewController(clientset, ssinformer, keyRegistry)
+	if _, err := httpTLSConfig(); err != nil {
+		return err
+	}
+	if identities, err = newIdentityPolicy(*allowedClientIdentities, *clientCertIdentities); err != nil {
+		return err
+	}
+	if identities.enforced() && *tlsClientCA == "" && *grpcClientCA == "" {
+		return fmt.Errorf("--allowed-client-identities requires --tls-client-ca or --grpc-client-ca")
+	}
+
+	if _, err := labels.Parse(*labelSelector); err != nil {
+		return fmt.Errorf("invalid --label-selector: %v", err)
+	}
+	if *resyncPeriod < 0 {
+		return fmt.Errorf("--resync-period must not be negative")
+	}
+	ssinformer := ssinformers.NewFilteredSharedInformerFactory(ssclient, *resyncPeriod, metav1.NamespaceAll, func(opts *metav1.ListOptions) {
+		opts.LabelSelector = *labelSelector
+	})
+	controller := NewController(clientset, ssclient, ssinformer, keyRegistry, parseObjectKinds(*sealedObjectKinds))
+	keyRegistry.onKeyRegistered = controller.keyRegistered
+	registerObjectMetrics(controller)
+	controller.historyLimit = *secretHistory
+	controller.dryRun = *dryRun
+	controller.standby = sb
+	controller.quota = quota{count: *quotaCount, bytes: *quotaBytes}
+	controller.sizeLimits = sizeLimits{sealed: *maxSealedSecretBytes, secret: *maxSecretBytes}
+	if *perNamespaceKeys {
+		controller.nsKeys = newNamespaceKeys(clientset, keyStore, prefix, *keySize)
+		controller.nsKeys.readOnly = *dryRun || *standby
+		controller.nsKeys.onKeyRegistered = controller.keyRegistered
+		nsTrigger := ScheduleJobWithTrigger(*keyRotatePeriod, controller.nsKeys.rotate)
+		clusterTrigger := trigger
+		trigger = func() {
+			clusterTrigger()
+			nsTrigger()
+		}
+	}
+	initKeyGenSignalListener(trigger)
+	initKeyDumpSignalListener(controller.DumpKeys)
+	if *convertSecrets {
+		controller.watchConvertibleSecrets(clientset)
+	}
+	if *enablePolicies {
+		controller.watchPolicies()
+	}
+	if *namespaceSelector != "" {
+		selector, err := labels.Parse(*namespaceSelector)
+		if err != nil {
+			return fmt.Errorf("invalid --namespace-selector: %v", err)
+		}
+		controller.watchNamespaces(clientset, selector)
+	}
 
 	stop := make(chan struct{})
 	defer close(stop)
 
 	go controller.Run(stop)
+	if sb != nil {
+		go controller.reloadKeysWhileStandby(*standbyKeyReload)
+	}
+	if *validateOnStart {
+		go controller.validateOnStart(stop, myNs, *validateReport)
+	}
 
 	cp := func() []*x509.Certificate {
-		return []*x509.Certificate{keyRegistry.cert}
+		return []*x509.Certificate{keyRegistry.latestCert()}
+	}
+
+	var sa sealAuthorizer
+	if *sealEndpoint {
+		sa = kubeSealAuthorizer(clientset)
+	}
+
+	var ia sealAuthorizer
+	if *itemsEndpoint {
+		ia = kubeUpdateAuthorizer(clientset)
+	}
+
+	var ncp namespaceCertProvider
+	if controller.nsKeys != nil {
+		ncp = func(namespace string) ([]*x509.Certificate, error) {
+			kr, err := controller.nsKeys.registry(namespace)
+			if err != nil {
+				return nil, err
+			}
+			return []*x509.Certificate{kr.latestCert()}, nil
+		}
 	}
 
-	go httpserver(cp, controller.AttemptUnseal, controller.Rotate)
+	var aa adminAuthorizer
+	if *adminEndpoints {
+		aa = kubeAdminAuthorizer(clientset, myNs)
+	}
+
+	handlers := serverHandlers{
+		certs:          cp,
+		namespaceCerts: ncp,
+		check:          controller.AttemptUnseal,
+		rotate:         controller.Rotate,
+		seal:           controller.Seal,
+		updateItems:    controller.UpdateItems,
+		blacklistKey:   controller.BlacklistKey,
+		dumpKeys:       controller.DumpKeys,
+		keyImpact:      controller.KeyImpact,
+		rotateKey:      trigger,
+		promote:        controller.Promote,
+		reencryptAll:   controller.ReencryptAll,
+		sealAuth:       sa,
+		itemsAuth:      ia,
+		adminAuth:      aa,
+	}
+	go httpserver(handlers)
+	if *grpcListenAddr != "" {
+		go grpcserver(handlers)
+	}
+	if *webhookListenAddr != "" {
+		go webhookserver(clientset.Core(), controller.policies)
+	}
 
 	sigterm := make(chan os.Signal, 1)
 	signal.Notify(sigterm, syscall.SIGTERM)
@@ -179,18 +378,46 @@ func main2() error {
 	return nil
 }
 
-func main() {
-	flag.Parse()
-	goflag.CommandLine.Parse([]string{})
+// newRootCommand returns the controller command, which runs the
+// controller like its serve subcommand, for the existing deployments,
+// and manages the keys with its keys subcommands.
+func newRootCommand() *cobra.Command {
+	serve := func(cmd *cobra.Command, args []string) error {
+		if *printVersion {
+			fmt.Fprintf(cmd.OutOrStdout(), "controller version: %s\n", VERSION)
+			return nil
+		}
+		log.Printf("Starting sealed-secrets controller version: %s\n", VERSION)
+		return main2()
+	}
+
+	root := &cobra.Command{
+		Use:   "controller",
+		Short: "Unseal the SealedSecrets and manage their keys",
+		Long: `Unseal the SealedSecrets into Secrets, and manage the keys they are
+sealed with. Without subcommand, the controller runs as with serve.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE:         serve,
+	}
+	root.Flags().AddFlagSet(controllerFlags)
 
-	if *printVersion {
-		fmt.Printf("controller version: %s\n", VERSION)
-		return
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run the controller",
+		Args:  cobra.NoArgs,
+		RunE:  serve,
 	}
+	serveCmd.Flags().AddFlagSet(controllerFlags)
+
+	root.AddCommand(serveCmd, newKeysCommand(newKeyAdmin))
+	return root
+}
 
-	log.Printf("Starting sealed-secrets controller version: %s\n", VERSION)
+func main() {
+	goflag.CommandLine.Parse([]string{})
 
-	if err := main2(); err != nil {
-		panic(err.Error())
+	if err := newRootCommand().Execute(); err != nil {
+		os.Exit(1)
 	}
 }
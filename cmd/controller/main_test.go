@@ -22,10 +22,9 @@ func hasAction(fake *fake.Clientset, verb, resource string) bool {
 }
 
 func TestInitKeyRegistry(t *testing.T) {
-	rand := testRand()
 	client := fake.NewSimpleClientset()
 
-	registry, err := initKeyRegistry(client, rand, "namespace", "prefix", "label", 1024)
+	registry, err := initKeyRegistry(newSecretKeyStore(client, "namespace", "label"), "prefix", 1024)
 	if err != nil {
 		t.Fatalf("initKeyRegistry() returned err: %v", err)
 	}
@@ -39,7 +38,7 @@ func TestInitKeyRegistry(t *testing.T) {
 
 	// Due to limitations of the fake client, we cannot test whether initKeyRegistry is able
 	// to pick up existing keys
-	_, err = initKeyRegistry(client, rand, "namespace", "prefix", "label", 1024)
+	_, err = initKeyRegistry(newSecretKeyStore(client, "namespace", "label"), "prefix", 1024)
 	if err != nil {
 		t.Fatalf("initKeyRegistry() returned err: %v", err)
 	}
@@ -49,9 +48,8 @@ func TestInitKeyRegistry(t *testing.T) {
 }
 
 func TestInitKeyRotation(t *testing.T) {
-	rand := testRand()
 	client := fake.NewSimpleClientset()
-	registry, err := initKeyRegistry(client, rand, "namespace", "prefix", "label", 1024)
+	registry, err := initKeyRegistry(newSecretKeyStore(client, "namespace", "label"), "prefix", 1024)
 	if err != nil {
 		t.Fatalf("initKeyRegistry() returned err: %v", err)
 	}
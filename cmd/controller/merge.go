@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+// SealedSecretsManagedKeysAnnotation lists the keys of a Secret written
+// by the controller, when its SealedSecret uses MergeStrategyMerge. The
+// other keys belong to other tools.
+const SealedSecretsManagedKeysAnnotation = "sealedsecrets.bitnami.com/managed-keys"
+
+func checkMergeStrategy(strategy string) error {
+	switch strategy {
+	case "", ssv1alpha1.MergeStrategyReplace, ssv1alpha1.MergeStrategyMerge:
+		return nil
+	default:
+		return fmt.Errorf("Unsupported merge strategy %q", strategy)
+	}
+}
+
+// managedKeys returns the keys of secret written by the controller, nil
+// if they aren't recorded, in which case it owns the whole Secret.
+func managedKeys(secret *apiv1.Secret) []string {
+	value, ok := secret.GetAnnotations()[SealedSecretsManagedKeysAnnotation]
+	if !ok {
+		return nil
+	}
+	if value == "" {
+		return []string{}
+	}
+	return strings.Split(value, ",")
+}
+
+func dataKeys(data map[string][]byte) []string {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// setManagedKeys records keys as the keys of secret written by the
+// controller.
+func setManagedKeys(secret *apiv1.Secret, keys []string) {
+	annotations := map[string]string{}
+	for k, v := range secret.GetAnnotations() {
+		annotations[k] = v
+	}
+	annotations[SealedSecretsManagedKeysAnnotation] = strings.Join(keys, ",")
+	secret.SetAnnotations(annotations)
+}
+
+// managedData returns the data of secret written by the controller.
+func managedData(secret *apiv1.Secret) map[string][]byte {
+	keys := managedKeys(secret)
+	if keys == nil {
+		return secret.Data
+	}
+	data := map[string][]byte{}
+	for _, k := range keys {
+		if v, ok := secret.Data[k]; ok {
+			data[k] = v
+		}
+	}
+	return data
+}
+
+// mergeData returns the data of existing once expected is written to it
+// with strategy. With MergeStrategyMerge, the keys of existing that
+// weren't written by the controller are kept, and those it wrote before
+// but that are no longer in expected are removed.
+func mergeData(existing, expected *apiv1.Secret, strategy string) map[string][]byte {
+	if strategy != ssv1alpha1.MergeStrategyMerge {
+		return expected.Data
+	}
+	data := map[string][]byte{}
+	for k, v := range existing.Data {
+		data[k] = v
+	}
+	for _, k := range managedKeys(existing) {
+		delete(data, k)
+	}
+	for k, v := range expected.Data {
+		data[k] = v
+	}
+	return data
+}
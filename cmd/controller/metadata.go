@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+const (
+	// SealedSecretsManagedLabelsAnnotation lists the labels of a Secret
+	// set by the controller, when its SealedSecret uses
+	// MetadataStrategyPrune.
+	SealedSecretsManagedLabelsAnnotation = "sealedsecrets.bitnami.com/managed-labels"
+	// SealedSecretsManagedAnnotationsAnnotation lists the annotations
+	// of a Secret set by the controller, when its SealedSecret uses
+	// MetadataStrategyPrune.
+	SealedSecretsManagedAnnotationsAnnotation = "sealedsecrets.bitnami.com/managed-annotations"
+)
+
+// bookkeepingAnnotations are written by the controller for itself, they
+// are never pruned.
+var bookkeepingAnnotations = map[string]bool{
+	SealedSecretsDataHashAnnotation:           true,
+	SealedSecretsManagedKeysAnnotation:        true,
+	SealedSecretsManagedLabelsAnnotation:      true,
+	SealedSecretsManagedAnnotationsAnnotation: true,
+	SealedSecretsGeneratedAtAnnotation:        true,
+}
+
+func checkMetadataStrategy(strategy string) error {
+	switch strategy {
+	case "", ssv1alpha1.MetadataStrategyPreserve, ssv1alpha1.MetadataStrategyReplace, ssv1alpha1.MetadataStrategyPrune:
+		return nil
+	default:
+		return fmt.Errorf("Unsupported metadata strategy %q", strategy)
+	}
+}
+
+// stringKeys returns the sorted keys of m, but those of skip.
+func stringKeys(m map[string]string, skip map[string]bool) []string {
+	keys := []string{}
+	for k := range m {
+		if !skip[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// setManagedMetadata records the labels and annotations of secret as
+// set by the controller.
+func setManagedMetadata(secret *apiv1.Secret) {
+	annotations := copyStrings(secret.GetAnnotations())
+	annotations[SealedSecretsManagedLabelsAnnotation] = strings.Join(stringKeys(secret.GetLabels(), nil), ",")
+	annotations[SealedSecretsManagedAnnotationsAnnotation] = strings.Join(stringKeys(annotations, bookkeepingAnnotations), ",")
+	secret.SetAnnotations(annotations)
+}
+
+func copyStrings(m map[string]string) map[string]string {
+	c := map[string]string{}
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// pruned returns a copy of existing without the comma-separated keys of
+// managed, with the entries of current added.
+func pruned(existing map[string]string, managed string, current map[string]string) map[string]string {
+	m := copyStrings(existing)
+	if managed != "" {
+		for _, k := range strings.Split(managed, ",") {
+			delete(m, k)
+		}
+	}
+	for k, v := range current {
+		m[k] = v
+	}
+	return m
+}
+
+// mergeMetadata applies the labels and annotations of newSecret to
+// existing with strategy.
+func mergeMetadata(existing, newSecret *apiv1.Secret, strategy string) {
+	annotations := existing.GetAnnotations()
+	switch strategy {
+	case ssv1alpha1.MetadataStrategyReplace:
+		existing.SetLabels(copyStrings(newSecret.GetLabels()))
+		existing.SetAnnotations(copyStrings(newSecret.GetAnnotations()))
+	case ssv1alpha1.MetadataStrategyPrune:
+		existing.SetLabels(pruned(existing.GetLabels(), annotations[SealedSecretsManagedLabelsAnnotation], newSecret.GetLabels()))
+		existing.SetAnnotations(pruned(annotations, annotations[SealedSecretsManagedAnnotationsAnnotation], newSecret.GetAnnotations()))
+	default:
+		annotations = copyStrings(annotations)
+		delete(annotations, SealedSecretsManagedLabelsAnnotation)
+		delete(annotations, SealedSecretsManagedAnnotationsAnnotation)
+		existing.SetAnnotations(annotations)
+	}
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/workqueue"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+	"github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+// The metrics are served on /metrics in the Prometheus text format.
+const metricsNamespace = "sealed_secrets_controller"
+
+// metric is a family of samples sharing a name.
+type metric interface {
+	write(w io.Writer)
+}
+
+var (
+	metricsMu         sync.Mutex
+	registeredMetrics []metric
+)
+
+// registerMetric adds m to the metrics served on /metrics.
+func registerMetric(m metric) {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	registeredMetrics = append(registeredMetrics, m)
+}
+
+func metricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		metricsMu.Lock()
+		defer metricsMu.Unlock()
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		for _, m := range registeredMetrics {
+			m.write(w)
+		}
+	})
+}
+
+func writeHeader(w io.Writer, name, help, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatLabels renders names and values as {name="value",...}.
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, name, labelValueEscaper.Replace(values[i]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// metricVec is a counter, a gauge or a summary partitioned by labels.
+type metricVec struct {
+	name   string
+	help   string
+	typ    string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]float64
+	// counts holds the number of observations of a summary, whose
+	// values are the sums of the observations.
+	counts map[string]uint64
+}
+
+func newMetricVec(typ, name, help string, labels []string) *metricVec {
+	return &metricVec{
+		name:   metricsNamespace + "_" + name,
+		help:   help,
+		typ:    typ,
+		labels: labels,
+		values: map[string]float64{},
+		counts: map[string]uint64{},
+	}
+}
+
+func newCounterVec(name, help string, labels ...string) *metricVec {
+	return newMetricVec("counter", name, help, labels)
+}
+
+func newGaugeVec(name, help string, labels ...string) *metricVec {
+	return newMetricVec("gauge", name, help, labels)
+}
+
+func newSummaryVec(name, help string, labels ...string) *metricVec {
+	return newMetricVec("summary", name, help, labels)
+}
+
+// add increments the value with the given label values by v.
+func (m *metricVec) add(v float64, labelValues ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values[formatLabels(m.labels, labelValues)] += v
+}
+
+func (m *metricVec) inc(labelValues ...string) {
+	m.add(1, labelValues...)
+}
+
+// set sets the value of a gauge with the given label values.
+func (m *metricVec) set(v float64, labelValues ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values[formatLabels(m.labels, labelValues)] = v
+}
+
+// observe adds an observation to a summary with the given label values.
+func (m *metricVec) observe(v float64, labelValues ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	series := formatLabels(m.labels, labelValues)
+	m.values[series] += v
+	m.counts[series]++
+}
+
+func (m *metricVec) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	writeHeader(w, m.name, m.help, m.typ)
+	series := make([]string, 0, len(m.values))
+	for labels := range m.values {
+		series = append(series, labels)
+	}
+	sort.Strings(series)
+	for _, labels := range series {
+		if m.typ == "summary" {
+			fmt.Fprintf(w, "%s_sum%s %g\n", m.name, labels, m.values[labels])
+			fmt.Fprintf(w, "%s_count%s %d\n", m.name, labels, m.counts[labels])
+			continue
+		}
+		fmt.Fprintf(w, "%s%s %g\n", m.name, labels, m.values[labels])
+	}
+}
+
+// gaugeFunc is a gauge whose value is computed when scraped.
+type gaugeFunc struct {
+	name string
+	help string
+	f    func() float64
+}
+
+func newGaugeFunc(name, help string, f func() float64) *gaugeFunc {
+	return &gaugeFunc{name: metricsNamespace + "_" + name, help: help, f: f}
+}
+
+func (g *gaugeFunc) write(w io.Writer) {
+	writeHeader(w, g.name, g.help, "gauge")
+	fmt.Fprintf(w, "%s %g\n", g.name, g.f())
+}
+
+// gaugeVecFunc is a gauge partitioned by labels whose values are
+// computed when scraped, by calling add for each of them.
+type gaugeVecFunc struct {
+	name    string
+	help    string
+	labels  []string
+	collect func(add func(v float64, labelValues ...string))
+}
+
+func newGaugeVecFunc(name, help string, collect func(add func(v float64, labelValues ...string)), labels ...string) *gaugeVecFunc {
+	return &gaugeVecFunc{name: name, help: help, labels: labels, collect: collect}
+}
+
+func (g *gaugeVecFunc) write(w io.Writer) {
+	m := newGaugeVec(g.name, g.help, g.labels...)
+	g.collect(m.add)
+	m.write(w)
+}
+
+// registerObjectMetrics exports the number of SealedSecrets per
+// namespace known to c, and how many of them are unsealed, to follow
+// adoption and spot namespaces whose SealedSecrets keep failing.
+func registerObjectMetrics(c *Controller) {
+	count := func(unsealed bool) func(add func(v float64, labelValues ...string)) {
+		return func(add func(v float64, labelValues ...string)) {
+			for _, obj := range c.informer.GetIndexer().List() {
+				ssecret, ok := obj.(*ssv1alpha1.SealedSecret)
+				if !ok {
+					continue
+				}
+				synced := ssecret.GetCondition(ssv1alpha1.SealedSecretSynced)
+				if !unsealed || (synced != nil && synced.Status == apiv1.ConditionTrue) {
+					add(1, ssecret.GetNamespace())
+				}
+			}
+		}
+	}
+	registerMetric(newGaugeVecFunc("sealed_secrets", "Number of SealedSecrets, by namespace.", count(false), "namespace"))
+	registerMetric(newGaugeVecFunc("unsealed_secrets", "Number of SealedSecrets successfully unsealed into their Secret, by namespace.", count(true), "namespace"))
+	registerMetric(newGaugeVecFunc("key_sealed_secrets", "Number of SealedSecrets last decrypted by each key, by key fingerprint.", func(add func(v float64, labelValues ...string)) {
+		for _, obj := range c.informer.GetIndexer().List() {
+			ssecret, ok := obj.(*ssv1alpha1.SealedSecret)
+			if !ok || ssecret.Status == nil || ssecret.Status.KeyFingerprint == "" {
+				continue
+			}
+			add(1, ssecret.Status.KeyFingerprint)
+		}
+	}, "key"))
+	registerMetric(newGaugeFunc("parked_objects", "Number of sealed objects no key could decrypt, waiting for a new key to be retried.", func() float64 {
+		return float64(c.parked.len())
+	}))
+}
+
+// registerKeyMetrics exports the state of the keys of kr, so that an
+// alert can fire before the sealing certificate expires.
+func registerKeyMetrics(kr *KeyRegistry) {
+	registerMetric(newGaugeFunc("key_age_seconds", "Age of the newest key, used for sealing.", func() float64 {
+		cert := kr.latestCert()
+		if cert == nil {
+			return 0
+		}
+		return time.Since(cert.NotBefore).Seconds()
+	}))
+	registerMetric(newGaugeFunc("cert_expiry_seconds", "Time until the published certificate expires.", func() float64 {
+		cert := kr.latestCert()
+		if cert == nil {
+			return 0
+		}
+		return time.Until(cert.NotAfter).Seconds()
+	}))
+	registerMetric(newGaugeFunc("keys", "Number of registered keys.", func() float64 {
+		return float64(len(kr.keys.Keys()))
+	}))
+}
+
+// keyDecryptions counts the objects decrypted by each key, so that a
+// key can be shown unused before it is pruned. The registered keys are
+// exported from 0.
+var keyDecryptions = newCounterVec("key_decryptions_total", "Number of objects decrypted, by fingerprint of the key that decrypted them.", "key")
+
+func init() {
+	registerMetric(keyDecryptions)
+}
+
+// countKeyDecryption counts a decryption by privKey, if known.
+func countKeyDecryption(privKey *rsa.PrivateKey) {
+	if fp := keyFingerprint(privKey); fp != "" {
+		keyDecryptions.inc(fp)
+	}
+}
+
+// Failure classes of unsealErrors.
+const (
+	unsealNoMatchingKey = "no-matching-key"
+	unsealDecryptError  = "decrypt-error"
+	unsealAPIConflict   = "api-conflict"
+	unsealForbidden     = "forbidden"
+	unsealOther         = "other"
+)
+
+var unsealErrors = newCounterVec("unseal_errors_total", "Number of failed attempts to unseal an object, by reason.", "reason")
+
+func init() {
+	// Export every class, even before it happens
+	for _, reason := range []string{unsealNoMatchingKey, unsealDecryptError, unsealAPIConflict, unsealForbidden, unsealOther} {
+		unsealErrors.add(0, reason)
+	}
+	registerMetric(unsealErrors)
+}
+
+// unsealFailureReason classifies err, returned by a sync, to tell
+// crypto problems from API problems.
+func unsealFailureReason(err error) string {
+	if _, ok := err.(*compromisedKeyError); ok {
+		return unsealNoMatchingKey
+	}
+	switch {
+	case err == seal.ErrNoKey:
+		return unsealNoMatchingKey
+	case err == crypto.ErrTooShort:
+		return unsealDecryptError
+	case errors.IsConflict(err):
+		return unsealAPIConflict
+	case errors.IsForbidden(err):
+		return unsealForbidden
+	default:
+		return unsealOther
+	}
+}
+
+// queueName names the workqueue of the controller in the workqueue
+// metrics.
+const queueName = "sealed-secrets"
+
+var (
+	workqueueDepth          = newGaugeVec("workqueue_depth", "Current depth of the workqueue.", "name")
+	workqueueAdds           = newCounterVec("workqueue_adds_total", "Number of adds handled by the workqueue.", "name")
+	workqueueLatency        = newSummaryVec("workqueue_queue_duration_seconds", "How long an item stays in the workqueue before being processed.", "name")
+	workqueueWorkDuration   = newSummaryVec("workqueue_work_duration_seconds", "How long processing an item from the workqueue takes.", "name")
+	workqueueUnfinishedWork = newGaugeVec("workqueue_unfinished_work_seconds", "Time spent on the items being processed.", "name")
+	workqueueLongestRunning = newGaugeVec("workqueue_longest_running_processor_seconds", "Time spent on the item that has been processed for the longest.", "name")
+	workqueueRetries        = newCounterVec("workqueue_retries_total", "Number of retries handled by the workqueue.", "name")
+)
+
+func init() {
+	for _, m := range []metric{workqueueDepth, workqueueAdds, workqueueLatency, workqueueWorkDuration, workqueueUnfinishedWork, workqueueLongestRunning, workqueueRetries} {
+		registerMetric(m)
+	}
+	// Only named queues, ie. the one of the controller, report metrics
+	workqueue.SetProvider(workqueueMetricsProvider{})
+}
+
+// queueMetric is the series of a metricVec for a queue. The values
+// are multiplied by scale, to convert the microseconds reported by
+// the workqueue to seconds.
+type queueMetric struct {
+	m     *metricVec
+	queue string
+	scale float64
+}
+
+func (q queueMetric) Inc()              { q.m.add(1, q.queue) }
+func (q queueMetric) Dec()              { q.m.add(-1, q.queue) }
+func (q queueMetric) Set(v float64)     { q.m.set(v*q.scale, q.queue) }
+func (q queueMetric) Observe(v float64) { q.m.observe(v*q.scale, q.queue) }
+
+// workqueueMetricsProvider implements workqueue.MetricsProvider.
+type workqueueMetricsProvider struct{}
+
+func (workqueueMetricsProvider) NewDepthMetric(name string) workqueue.GaugeMetric {
+	return queueMetric{workqueueDepth, name, 1}
+}
+
+func (workqueueMetricsProvider) NewAddsMetric(name string) workqueue.CounterMetric {
+	return queueMetric{workqueueAdds, name, 1}
+}
+
+func (workqueueMetricsProvider) NewLatencyMetric(name string) workqueue.SummaryMetric {
+	return queueMetric{workqueueLatency, name, 1e-6}
+}
+
+func (workqueueMetricsProvider) NewWorkDurationMetric(name string) workqueue.SummaryMetric {
+	return queueMetric{workqueueWorkDuration, name, 1e-6}
+}
+
+func (workqueueMetricsProvider) NewUnfinishedWorkSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return queueMetric{workqueueUnfinishedWork, name, 1}
+}
+
+func (workqueueMetricsProvider) NewLongestRunningProcessorMicrosecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return queueMetric{workqueueLongestRunning, name, 1e-6}
+}
+
+func (workqueueMetricsProvider) NewRetriesMetric(name string) workqueue.CounterMetric {
+	return queueMetric{workqueueRetries, name, 1}
+}
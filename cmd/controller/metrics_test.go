@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/workqueue"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+	"github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+func TestKeyMetrics(t *testing.T) {
+	defer func(saved []metric) { registeredMetrics = saved }(registeredMetrics)
+
+	kr := testKeys(t, "key1")
+	key, _, err := generatePrivateKeyAndCert(1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = kr.registerNewKey("key2", key, &x509.Certificate{
+		NotBefore: time.Now().Add(-time.Hour),
+		NotAfter:  time.Now().Add(24 * time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	registerKeyMetrics(kr)
+
+	rec := httptest.NewRecorder()
+	metricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		"# TYPE sealed_secrets_controller_key_age_seconds gauge\nsealed_secrets_controller_key_age_seconds 360",
+		"# TYPE sealed_secrets_controller_cert_expiry_seconds gauge\nsealed_secrets_controller_cert_expiry_seconds 86",
+		"# TYPE sealed_secrets_controller_keys gauge\nsealed_secrets_controller_keys 2\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in metrics:\n%s", want, body)
+		}
+	}
+}
+
+func TestObjectMetrics(t *testing.T) {
+	defer func(saved []metric) { registeredMetrics = saved }(registeredMetrics)
+
+	var ssecrets []*ssv1alpha1.SealedSecret
+	for _, s := range []struct {
+		namespace, name string
+		status          v1.ConditionStatus
+	}{
+		{"myns", "a", v1.ConditionTrue},
+		{"myns", "b", v1.ConditionFalse},
+		{"other", "c", v1.ConditionTrue},
+		{"new", "d", ""},
+	} {
+		ssecret := &ssv1alpha1.SealedSecret{ObjectMeta: metav1.ObjectMeta{Namespace: s.namespace, Name: s.name}}
+		if s.status != "" {
+			ssecret.SetCondition(ssv1alpha1.SealedSecretSynced, s.status, "", "")
+		}
+		ssecrets = append(ssecrets, ssecret)
+	}
+	c := newTestController(t, ssecrets[0])
+	for _, ssecret := range ssecrets[1:] {
+		c.informer.GetIndexer().Add(ssecret)
+	}
+	registerObjectMetrics(c)
+
+	rec := httptest.NewRecorder()
+	metricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		"# TYPE sealed_secrets_controller_sealed_secrets gauge\n" +
+			"sealed_secrets_controller_sealed_secrets{namespace=\"myns\"} 2\n" +
+			"sealed_secrets_controller_sealed_secrets{namespace=\"new\"} 1\n" +
+			"sealed_secrets_controller_sealed_secrets{namespace=\"other\"} 1\n",
+		"# TYPE sealed_secrets_controller_unsealed_secrets gauge\n" +
+			"sealed_secrets_controller_unsealed_secrets{namespace=\"myns\"} 1\n" +
+			"sealed_secrets_controller_unsealed_secrets{namespace=\"other\"} 1\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in metrics:\n%s", want, body)
+		}
+	}
+}
+
+func TestKeyDecryptionMetrics(t *testing.T) {
+	defer func(saved []metric) { registeredMetrics = saved }(registeredMetrics)
+
+	kr := testKeys(t, "used", "unused")
+	keys := kr.keys.Keys()
+	used, unused := keys[0].Fingerprint, keys[1].Fingerprint
+	countKeyDecryption(keys[0].PrivateKey)
+	countKeyDecryption(nil)
+
+	ssecret := &ssv1alpha1.SealedSecret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "myns", Name: "a"},
+		Status:     &ssv1alpha1.SealedSecretStatus{KeyFingerprint: used},
+	}
+	c := newTestController(t, ssecret)
+	c.informer.GetIndexer().Add(&ssv1alpha1.SealedSecret{ObjectMeta: metav1.ObjectMeta{Namespace: "myns", Name: "b"}})
+	registerObjectMetrics(c)
+
+	rec := httptest.NewRecorder()
+	metricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		fmt.Sprintf("sealed_secrets_controller_key_decryptions_total{key=%q} 1\n", used),
+		fmt.Sprintf("sealed_secrets_controller_key_decryptions_total{key=%q} 0\n", unused),
+		fmt.Sprintf("# TYPE sealed_secrets_controller_key_sealed_secrets gauge\nsealed_secrets_controller_key_sealed_secrets{key=%q} 1\n", used),
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in metrics:\n%s", want, body)
+		}
+	}
+}
+
+func TestUnsealFailureReason(t *testing.T) {
+	gr := schema.GroupResource{Resource: "secrets"}
+	testCases := []struct {
+		err    error
+		reason string
+	}{
+		{seal.ErrNoKey, unsealNoMatchingKey},
+		{crypto.ErrTooShort, unsealDecryptError},
+		{errors.NewConflict(gr, "mysecret", fmt.Errorf("modified")), unsealAPIConflict},
+		{errors.NewForbidden(gr, "mysecret", fmt.Errorf("RBAC")), unsealForbidden},
+		{fmt.Errorf("failed"), unsealOther},
+	}
+	for _, tc := range testCases {
+		if got := unsealFailureReason(tc.err); got != tc.reason {
+			t.Errorf("unsealFailureReason(%v) = %q, expected %q", tc.err, got, tc.reason)
+		}
+	}
+}
+
+func TestCounterVec(t *testing.T) {
+	c := newCounterVec("test_total", "Test counter.", "reason")
+	c.inc("b")
+	c.inc("a")
+	c.add(2, `quote"d`)
+	c.inc("a")
+
+	var buf bytes.Buffer
+	c.write(&buf)
+	want := `# HELP sealed_secrets_controller_test_total Test counter.
+# TYPE sealed_secrets_controller_test_total counter
+sealed_secrets_controller_test_total{reason="a"} 2
+sealed_secrets_controller_test_total{reason="b"} 1
+sealed_secrets_controller_test_total{reason="quote\"d"} 2
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Got:\n%s\nexpected:\n%s", got, want)
+	}
+}
+
+func TestWorkqueueMetrics(t *testing.T) {
+	q := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "test-queue")
+	defer q.ShutDown()
+
+	q.Add("a")
+	item, _ := q.Get()
+	q.Done(item)
+	q.AddRateLimited("b")
+
+	series := `{name="test-queue"}`
+	if v := workqueueAdds.values[series]; v != 1 {
+		t.Errorf("Expected 1 add, got %g", v)
+	}
+	if v := workqueueDepth.values[series]; v != 0 {
+		t.Errorf("Expected empty queue, got depth %g", v)
+	}
+	if n := workqueueLatency.counts[series]; n != 1 {
+		t.Errorf("Expected 1 latency observation, got %d", n)
+	}
+	if n := workqueueWorkDuration.counts[series]; n != 1 {
+		t.Errorf("Expected 1 work duration observation, got %d", n)
+	}
+	if v := workqueueRetries.values[series]; v != 1 {
+		t.Errorf("Expected 1 retry, got %g", v)
+	}
+}
+
+func TestSummaryVec(t *testing.T) {
+	s := newSummaryVec("test_seconds", "Test summary.", "name")
+	s.observe(0.5, "q")
+	s.observe(1.5, "q")
+
+	var buf bytes.Buffer
+	s.write(&buf)
+	want := `# HELP sealed_secrets_controller_test_seconds Test summary.
+# TYPE sealed_secrets_controller_test_seconds summary
+sealed_secrets_controller_test_seconds_sum{name="q"} 2
+sealed_secrets_controller_test_seconds_count{name="q"} 2
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Got:\n%s\nexpected:\n%s", got, want)
+	}
+}
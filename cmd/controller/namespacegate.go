@@ -0,0 +1,80 @@
+package main
+
+import (
+	"log"
+
+	flag "github.com/spf13/pflag"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+var (
+	namespaceSelector = flag.String("namespace-selector", "", "Only unseal in the namespaces matching this label selector, eg. sealedsecrets.bitnami.com/enabled=true. All namespaces if empty.")
+)
+
+// watchNamespaces restricts unsealing to the namespaces matching
+// selector. The objects of a namespace are requeued when its labels
+// change. It must be called before Run.
+func (c *Controller) watchNamespaces(clientset kubernetes.Interface, selector labels.Selector) {
+	lw := cache.NewListWatchFromClient(clientset.Core().RESTClient(), "namespaces", metav1.NamespaceAll, fields.Everything())
+	c.nsInformer = cache.NewSharedIndexInformer(lw, &apiv1.Namespace{}, 0, cache.Indexers{})
+	c.nsSelector = selector
+	c.nsInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.requeueNamespace(obj.(*apiv1.Namespace).GetName())
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if !labels.Equals(oldObj.(*apiv1.Namespace).GetLabels(), newObj.(*apiv1.Namespace).GetLabels()) {
+				c.requeueNamespace(newObj.(*apiv1.Namespace).GetName())
+			}
+		},
+	})
+}
+
+// requeueNamespace adds all the sealed objects of ns to the queue.
+func (c *Controller) requeueNamespace(ns string) {
+	informers := map[string]cache.SharedIndexInformer{
+		sealedSecretKind:    c.informer,
+		sealedConfigMapKind: c.cmInformer,
+		sealedObjectKind:    c.objInformer,
+	}
+	for kind, informer := range informers {
+		if informer == nil {
+			continue
+		}
+		cache.ListAllByNamespace(informer.GetIndexer(), ns, labels.Everything(), func(obj interface{}) {
+			if key, err := cache.MetaNamespaceKeyFunc(obj); err == nil {
+				c.queue.Add(queueKey{kind, key})
+			}
+		})
+	}
+}
+
+// namespaceEnabled reports whether objects can be unsealed in ns.
+func (c *Controller) namespaceEnabled(ns string) (bool, error) {
+	if c.nsInformer == nil {
+		return true, nil
+	}
+	obj, exists, err := c.nsInformer.GetIndexer().GetByKey(ns)
+	if err != nil || !exists {
+		return false, err
+	}
+	return c.nsSelector.Matches(labels.Set(obj.(*apiv1.Namespace).GetLabels())), nil
+}
+
+// skipDisabledNamespace reports whether ns hasn't opted in, in which
+// case the object with the given key isn't unsealed.
+func (c *Controller) skipDisabledNamespace(kind, key, ns string) (bool, error) {
+	enabled, err := c.namespaceEnabled(ns)
+	if err != nil {
+		return false, err
+	}
+	if !enabled {
+		log.Printf("Namespace %s isn't enabled, skipping %s %s", ns, kind, key)
+	}
+	return !enabled, nil
+}
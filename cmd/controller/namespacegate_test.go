@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+func TestUnsealNamespaceGate(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+
+	c := newTestController(t, ssecret)
+	c.keyRegistry = registry
+	c.nsInformer = cache.NewSharedIndexInformer(&cache.ListWatch{}, &v1.Namespace{}, 0, cache.Indexers{})
+	c.nsSelector = labels.SelectorFromSet(labels.Set{"sealedsecrets.bitnami.com/enabled": "true"})
+	ns := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "myns"}}
+	c.nsInformer.GetIndexer().Add(ns)
+
+	getCondition := func() *ssv1alpha1.SealedSecretCondition {
+		updated, err := c.ssclient.BitnamiV1alpha1().SealedSecrets("myns").Get("mysecret", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.informer.GetIndexer().Update(updated)
+		return updated.GetCondition(ssv1alpha1.SealedSecretSkipped)
+	}
+
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	if cond := getCondition(); cond == nil || cond.Status != v1.ConditionTrue || cond.Reason != "NamespaceNotEnabled" {
+		t.Errorf("Expected Skipped condition, got %v", cond)
+	}
+	if _, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected no Secret, got err %v", err)
+	}
+
+	ns = ns.DeepCopy()
+	ns.Labels = map[string]string{"sealedsecrets.bitnami.com/enabled": "true"}
+	c.nsInformer.GetIndexer().Update(ns)
+
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	if cond := getCondition(); cond == nil || cond.Status != v1.ConditionFalse {
+		t.Errorf("Expected Skipped condition to be cleared, got %v", cond)
+	}
+	if _, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected Secret to be created: %v", err)
+	}
+}
+
+func TestRequeueNamespace(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+
+	c := newTestController(t, ssecret)
+
+	c.requeueNamespace("otherns")
+	if n := c.queue.Len(); n != 0 {
+		t.Errorf("Expected empty queue, got %d items", n)
+	}
+	c.requeueNamespace("myns")
+	if item, _ := c.queue.Get(); item != (queueKey{sealedSecretKind, "myns/mysecret"}) {
+		t.Errorf("Unexpected queue item %v", item)
+	}
+}
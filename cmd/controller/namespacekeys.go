@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	flag "github.com/spf13/pflag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// SealedSecretsKeyNamespaceLabel marks the keys used for a single
+// namespace, its value is the namespace.
+const SealedSecretsKeyNamespaceLabel = "sealedsecrets.bitnami.com/key-namespace"
+
+var (
+	perNamespaceKeys = flag.Bool("per-namespace-keys", false, "Use a distinct key pair for each namespace, served on /v1/namespaces/<namespace>/cert.pem, instead of cluster-wide keys.")
+)
+
+// namespaceKeys holds the KeyRegistry of each namespace. The keys are
+// stored with the cluster-wide keys, out of reach of the tenants.
+type namespaceKeys struct {
+	mu         sync.Mutex
+	client     kubernetes.Interface
+	store      KeyStore
+	prefix     string
+	keysize    int
+	registries map[string]*KeyRegistry
+	// readOnly disables the generation of keys.
+	readOnly bool
+	// onKeyRegistered is set on the registries, see KeyRegistry.
+	onKeyRegistered func(namespace string)
+}
+
+func newNamespaceKeys(client kubernetes.Interface, store KeyStore, prefix string, keysize int) *namespaceKeys {
+	return &namespaceKeys{
+		client:     client,
+		store:      store,
+		prefix:     prefix,
+		keysize:    keysize,
+		registries: map[string]*KeyRegistry{},
+	}
+}
+
+// registry returns the KeyRegistry of ns, loading its keys on first
+// use. The first key is generated if there is none.
+func (n *namespaceKeys) registry(ns string) (*KeyRegistry, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if kr, ok := n.registries[ns]; ok {
+		return kr, nil
+	}
+
+	// Only serve existing namespaces, so that requests for the
+	// certificate of arbitrary names don't make us generate keys.
+	if _, err := n.client.Core().Namespaces().Get(ns, metav1.GetOptions{}); err != nil {
+		return nil, err
+	}
+
+	kr := NewKeyRegistry(n.store, n.prefix+"-"+ns+"-", n.keysize)
+	kr.keyNamespace = ns
+	kr.onKeyRegistered = n.onKeyRegistered
+	if _, err := kr.load(); err != nil {
+		return nil, err
+	}
+	if len(kr.PrivateKeys()) == 0 {
+		if n.readOnly {
+			return nil, fmt.Errorf("no key for namespace %s", ns)
+		}
+		if _, err := kr.generateKey(); err != nil {
+			return nil, err
+		}
+	}
+
+	n.registries[ns] = kr
+	return kr, nil
+}
+
+// rotate generates a new key for every namespace in use.
+func (n *namespaceKeys) rotate() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.readOnly {
+		return
+	}
+	for ns, kr := range n.registries {
+		if _, err := kr.generateKey(); err != nil {
+			log.Printf("Failed to generate new key for namespace %s: %v\n", ns, err)
+			notifications.notify(notifyKeyRotationFailed, "keys of namespace "+ns, err)
+		}
+	}
+}
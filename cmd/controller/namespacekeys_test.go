@@ -0,0 +1,85 @@
+package main
+
+import (
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNamespaceKeys(t *testing.T) {
+	client := fake.NewSimpleClientset(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant"}})
+	nsKeys := newNamespaceKeys(client, newSecretKeyStore(client, "kube-system", SealedSecretsKeyLabel), "prefix", 1024)
+
+	if _, err := nsKeys.registry("missing"); err == nil {
+		t.Errorf("Expected error for a missing namespace")
+	}
+
+	kr, err := nsKeys.registry("tenant")
+	if err != nil {
+		t.Fatalf("registry() returned err: %v", err)
+	}
+	if len(kr.PrivateKeys()) != 1 {
+		t.Fatalf("Expected a new key, got %d keys", len(kr.PrivateKeys()))
+	}
+	if again, _ := nsKeys.registry("tenant"); again != kr {
+		t.Errorf("Expected the same registry")
+	}
+
+	secrets, err := client.Core().Secrets("kube-system").List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secrets.Items) != 1 || secrets.Items[0].Labels[SealedSecretsKeyNamespaceLabel] != "tenant" {
+		t.Fatalf("Unexpected key secrets %v", secrets.Items)
+	}
+
+	// Keys are loaded on restart
+	client.ClearActions()
+	kr2, err := newNamespaceKeys(client, newSecretKeyStore(client, "kube-system", SealedSecretsKeyLabel), "prefix", 1024).registry("tenant")
+	if err != nil {
+		t.Fatalf("registry() returned err: %v", err)
+	}
+	if hasAction(client, "create", "secrets") || kr2.latestPrivateKey().N.Cmp(kr.latestPrivateKey().N) != 0 {
+		t.Errorf("Expected existing key to be loaded")
+	}
+
+	// Namespace keys aren't cluster-wide keys
+	global, err := initKeyRegistry(newSecretKeyStore(client, "kube-system", SealedSecretsKeyLabel), "prefix", 1024)
+	if err != nil {
+		t.Fatalf("initKeyRegistry() returned err: %v", err)
+	}
+	if len(global.PrivateKeys()) != 0 {
+		t.Errorf("Expected no cluster-wide keys, got %d", len(global.PrivateKeys()))
+	}
+}
+
+func TestNamespaceCertHandler(t *testing.T) {
+	client := fake.NewSimpleClientset(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant"}})
+	nsKeys := newNamespaceKeys(client, newSecretKeyStore(client, "kube-system", SealedSecretsKeyLabel), "prefix", 1024)
+	handler := namespaceCertHandler(func(ns string) ([]*x509.Certificate, error) {
+		kr, err := nsKeys.registry(ns)
+		if err != nil {
+			return nil, err
+		}
+		return []*x509.Certificate{kr.latestCert()}, nil
+	})
+
+	for path, code := range map[string]int{
+		"/v1/namespaces/tenant/cert.pem":  http.StatusOK,
+		"/v1/namespaces/missing/cert.pem": http.StatusNotFound,
+		"/v1/namespaces/tenant/other":     http.StatusNotFound,
+		"/v2/namespaces/tenant/cert.pem":  http.StatusOK,
+		"/v1/namespaces/cert.pem":         http.StatusNotFound,
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != code {
+			t.Errorf("%s: got status %d, expected %d", path, w.Code, code)
+		}
+	}
+}
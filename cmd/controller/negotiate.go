@@ -0,0 +1,157 @@
+package main
+
+import (
+	"compress/gzip"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	certUtil "k8s.io/client-go/util/cert"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	contentTypePEM  = "application/x-pem-file"
+	contentTypeJSON = "application/json"
+	contentTypeYAML = "application/yaml"
+)
+
+// negotiate returns the content type of offers preferred by the Accept
+// header of r, the first offer if none is acceptable, so that clients
+// which don't ask for a format keep getting the usual one.
+func negotiate(r *http.Request, offers ...string) string {
+	type accepted struct {
+		mediaRange string
+		q          float64
+	}
+	var ranges []accepted
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		params := strings.Split(part, ";")
+		mediaRange := strings.ToLower(strings.TrimSpace(params[0]))
+		if mediaRange == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, accepted{mediaRange, q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	for _, a := range ranges {
+		for _, offer := range offers {
+			if a.mediaRange == offer || a.mediaRange == "*/*" ||
+				(strings.HasSuffix(a.mediaRange, "/*") && strings.HasPrefix(offer, strings.TrimSuffix(a.mediaRange, "*"))) {
+				return offer
+			}
+		}
+	}
+	return offers[0]
+}
+
+// certBundle is the JSON and YAML representation of certificates.
+type certBundle struct {
+	Certificates []string `json:"certificates"`
+}
+
+// writeCerts writes certs in the format asked for by r, PEM by
+// default.
+func writeCerts(w http.ResponseWriter, r *http.Request, certs []*x509.Certificate) {
+	contentType := negotiate(r, contentTypePEM, contentTypeJSON, contentTypeYAML)
+	if contentType == contentTypePEM {
+		w.Header().Set("Content-Type", contentTypePEM)
+		for _, cert := range certs {
+			w.Write(certUtil.EncodeCertPEM(cert))
+		}
+		return
+	}
+
+	bundle := certBundle{Certificates: []string{}}
+	for _, cert := range certs {
+		bundle.Certificates = append(bundle.Certificates, string(certUtil.EncodeCertPEM(cert)))
+	}
+	body, err := json.Marshal(bundle)
+	if err == nil && contentType == contentTypeYAML {
+		body, err = yaml.JSONToYAML(body)
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(body)
+}
+
+// writeObject writes the JSON object content in the format asked for
+// by r, JSON by default.
+func writeObject(w http.ResponseWriter, r *http.Request, content []byte) {
+	contentType := negotiate(r, contentTypeJSON, contentTypeYAML)
+	if contentType == contentTypeYAML {
+		var err error
+		if content, err = yaml.JSONToYAML(content); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(content)
+}
+
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz *gzip.Writer
+}
+
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	w.Header().Del("Content-Length")
+	return w.gz.Write(b)
+}
+
+// gzipHandler compresses the responses of h for the clients accepting
+// gzip encoding.
+func gzipHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if !acceptsGzip(r) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		defer gz.Close()
+		h.ServeHTTP(&gzipResponseWriter{ResponseWriter: w, gz: gz}, r)
+	})
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		params := strings.Split(part, ";")
+		if strings.TrimSpace(params[0]) != "gzip" {
+			continue
+		}
+		for _, param := range params[1:] {
+			if q, err := strconv.ParseFloat(strings.TrimPrefix(strings.TrimSpace(param), "q="), 64); err == nil && q == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
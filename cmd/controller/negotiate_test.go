@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestNegotiate(t *testing.T) {
+	testCases := []struct {
+		accept   string
+		expected string
+	}{
+		{"", contentTypePEM},
+		{"*/*", contentTypePEM},
+		{"text/html", contentTypePEM},
+		{"application/json", contentTypeJSON},
+		{"application/x-pem-file, */*", contentTypePEM},
+		{"application/yaml;q=0.5, application/json;q=0.9", contentTypeJSON},
+		{"application/json;q=0, application/*", contentTypePEM},
+		{"text/plain, application/yaml", contentTypeYAML},
+	}
+	for _, tc := range testCases {
+		r := httptest.NewRequest(http.MethodGet, "/v1/cert.pem", nil)
+		r.Header.Set("Accept", tc.accept)
+		if got := negotiate(r, contentTypePEM, contentTypeJSON, contentTypeYAML); got != tc.expected {
+			t.Errorf("Accept %q: expected %s, got %s", tc.accept, tc.expected, got)
+		}
+	}
+}
+
+func TestWriteCerts(t *testing.T) {
+	_, cert, err := generatePrivateKeyAndCert(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/v1/cert.pem", nil)
+	r.Header.Set("Accept", contentTypeYAML)
+	writeCerts(rec, r, []*x509.Certificate{cert})
+	if ct := rec.Header().Get("Content-Type"); ct != contentTypeYAML {
+		t.Errorf("Expected content type %s, got %s", contentTypeYAML, ct)
+	}
+	var bundle certBundle
+	if err := yaml.Unmarshal(rec.Body.Bytes(), &bundle); err != nil {
+		t.Fatal(err)
+	}
+	if len(bundle.Certificates) != 1 || !strings.HasPrefix(bundle.Certificates[0], "-----BEGIN CERTIFICATE-----") {
+		t.Errorf("Unexpected bundle %v", bundle)
+	}
+}
+
+func TestGzipHandler(t *testing.T) {
+	body := strings.Repeat("sealed ", 100)
+	h := gzipHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/v1/cert.pem", nil)
+	r.Header.Set("Accept-Encoding", "deflate, gzip")
+	h.ServeHTTP(rec, r)
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Expected gzip encoding, got headers %v", rec.Header())
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(rec.Body.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := ioutil.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decoded) != body {
+		t.Errorf("Unexpected body %q", decoded)
+	}
+
+	rec = httptest.NewRecorder()
+	r.Header.Set("Accept-Encoding", "gzip;q=0")
+	h.ServeHTTP(rec, r)
+	if rec.Header().Get("Content-Encoding") != "" || rec.Body.String() != body {
+		t.Errorf("Expected an identity response, got headers %v", rec.Header())
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	flag "github.com/spf13/pflag"
+)
+
+const (
+	// notifyUnsealFailed is sent when an object is given up on, after
+	// maxRetries attempts, or parked until a new key is registered.
+	notifyUnsealFailed = "UnsealFailed"
+	// notifyKeyRotationFailed is sent when a new key can't be generated.
+	notifyKeyRotationFailed = "KeyRotationFailed"
+	// notifyKeyBackupFailed is sent when a new key can't be backed up,
+	// see --key-backup-secret.
+	notifyKeyBackupFailed = "KeyBackupFailed"
+
+	// defaultNotifyTemplate is understood by Slack incoming webhooks, and
+	// carries the details for generic receivers.
+	defaultNotifyTemplate = `{"text": {{json .Message}}, "event": {{json .Event}}, "object": {{json .Object}}, "error": {{json .Error}}}`
+)
+
+var (
+	notifyURL      = flag.String("notify-url", "", "URL to POST notifications to when an object can't be unsealed after retries or when key rotation fails, eg. a Slack incoming webhook. Disabled if empty.")
+	notifyTemplate = flag.String("notify-template", defaultNotifyTemplate, "Go template of the notification payloads. Fields: .Event, .Object, .Error, .Message, .Time and .Count. The json function quotes a value.")
+	notifyInterval = flag.Duration("notify-interval", time.Hour, "Minimum interval between identical notifications. The repeated ones are counted in the next one sent.")
+)
+
+// notifications sends the notifications, nil if disabled.
+var notifications *notifier
+
+// notification is the data of the payload template.
+type notification struct {
+	Event string
+	// Object is the queue key of the object, or the key registry, that
+	// failed.
+	Object  string
+	Error   string
+	Message string
+	Time    time.Time
+	// Count is the number of occurrences since the last notification
+	// of the same event, object and error.
+	Count int
+}
+
+// notifier POSTs notifications to a webhook.
+type notifier struct {
+	url    string
+	tmpl   *template.Template
+	client *http.Client
+
+	// interval is the minimum interval between identical
+	// notifications, see aggregate.
+	interval time.Duration
+	mu       sync.Mutex
+	recent   map[string]*recentNotification
+}
+
+// recentNotification is a notification sent within the interval, and
+// the number of identical ones held back since, the last one at last.
+type recentNotification struct {
+	sent  time.Time
+	last  time.Time
+	count int
+}
+
+func newNotifier(url, payload string) (*notifier, error) {
+	tmpl, err := template.New("notification").Funcs(template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Parse(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &notifier{
+		url:      url,
+		tmpl:     tmpl,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		interval: *notifyInterval,
+		recent:   map[string]*recentNotification{},
+	}, nil
+}
+
+// aggregate reports whether nt must be sent, or is held back because
+// an identical notification was sent less than the interval before.
+// The notifications sent count the ones held back, so that an object
+// stuck failing produces one notification per interval. Those without
+// occurrence for an interval are forgotten, with the count of the ones
+// held back, so that only the recent failures are kept.
+func (n *notifier) aggregate(nt *notification) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for key, r := range n.recent {
+		if nt.Time.Sub(r.last) >= n.interval {
+			delete(n.recent, key)
+		}
+	}
+
+	key := strings.Join([]string{nt.Event, nt.Object, nt.Error}, "\x00")
+	r, ok := n.recent[key]
+	if ok && nt.Time.Sub(r.sent) < n.interval {
+		r.count++
+		r.last = nt.Time
+		return false
+	}
+	nt.Count = 1
+	if ok {
+		nt.Count += r.count
+	}
+	if nt.Count > 1 {
+		nt.Message = fmt.Sprintf("%s (%d times)", nt.Message, nt.Count)
+	}
+	n.recent[key] = &recentNotification{sent: nt.Time, last: nt.Time}
+	return true
+}
+
+// notify sends a notification of event in the background. It does
+// nothing if n is nil.
+func (n *notifier) notify(event, object string, err error) {
+	if n == nil {
+		return
+	}
+	nt := notification{
+		Event:   event,
+		Object:  object,
+		Error:   err.Error(),
+		Message: fmt.Sprintf("sealed-secrets: %s %s: %v", event, object, err),
+		Time:    time.Now().UTC(),
+	}
+	if !n.aggregate(&nt) {
+		log.Printf("Not sending %s notification for %s, already sent less than %v ago", event, object, n.interval)
+		return
+	}
+	go func() {
+		if err := n.send(nt); err != nil {
+			log.Printf("Error sending %s notification: %v", event, err)
+		}
+	}()
+}
+
+func (n *notifier) send(nt notification) error {
+	var body bytes.Buffer
+	if err := n.tmpl.Execute(&body, nt); err != nil {
+		return err
+	}
+	resp, err := n.client.Post(n.url, "application/json", &body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s returned %s", n.url, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNotifierSend(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = ioutil.ReadAll(r.Body)
+		if r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	n, err := newNotifier(server.URL, defaultNotifyTemplate)
+	if err != nil {
+		t.Fatalf("newNotifier() returned err: %v", err)
+	}
+	nt := notification{
+		Event:   notifyUnsealFailed,
+		Object:  `SealedSecret myns/my"secret`,
+		Error:   "no key could decrypt secret",
+		Message: "sealed-secrets: failed",
+	}
+	if err := n.send(nt); err != nil {
+		t.Fatalf("send() returned err: %v", err)
+	}
+
+	var payload map[string]string
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("Invalid payload %s: %v", body, err)
+	}
+	if payload["text"] != nt.Message || payload["event"] != nt.Event || payload["object"] != nt.Object || payload["error"] != nt.Error {
+		t.Errorf("Unexpected payload %v", payload)
+	}
+}
+
+func TestNotifierTemplate(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	n, err := newNotifier(server.URL, `{"summary": "{{.Event}} on {{.Object}}"}`)
+	if err != nil {
+		t.Fatalf("newNotifier() returned err: %v", err)
+	}
+	if err := n.send(notification{Event: notifyKeyRotationFailed, Object: "cluster-wide keys"}); err != nil {
+		t.Fatalf("send() returned err: %v", err)
+	}
+	if got, want := string(body), `{"summary": "KeyRotationFailed on cluster-wide keys"}`; got != want {
+		t.Errorf("Got payload %s, expected %s", got, want)
+	}
+
+	if _, err := newNotifier(server.URL, "{{.Event"); err == nil {
+		t.Errorf("Expected invalid template to be rejected")
+	}
+}
+
+func TestNotifierError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	n, err := newNotifier(server.URL, defaultNotifyTemplate)
+	if err != nil {
+		t.Fatalf("newNotifier() returned err: %v", err)
+	}
+	if err := n.send(notification{Event: notifyUnsealFailed}); err == nil {
+		t.Errorf("Expected error for failed delivery")
+	}
+
+	var disabled *notifier
+	// Must not panic
+	disabled.notify(notifyUnsealFailed, "SealedSecret myns/mysecret", errors.New("failed"))
+}
+
+func TestNotifierAggregate(t *testing.T) {
+	n, err := newNotifier("http://localhost", defaultNotifyTemplate)
+	if err != nil {
+		t.Fatalf("newNotifier() returned err: %v", err)
+	}
+	n.interval = time.Hour
+	start := time.Now()
+	at := func(d time.Duration, object string) *notification {
+		return &notification{Event: notifyUnsealFailed, Object: object, Error: "failed", Message: "failed", Time: start.Add(d)}
+	}
+
+	if nt := at(0, "a"); !n.aggregate(nt) || nt.Count != 1 {
+		t.Errorf("Expected first notification to be sent once, got %v", nt)
+	}
+	for _, d := range []time.Duration{time.Minute, 2 * time.Minute, 59 * time.Minute} {
+		if n.aggregate(at(d, "a")) {
+			t.Errorf("Expected notification after %v to be held back", d)
+		}
+	}
+	if !n.aggregate(at(time.Minute, "b")) {
+		t.Error("Expected notification of another object to be sent")
+	}
+	nt := at(61*time.Minute, "a")
+	if !n.aggregate(nt) || nt.Count != 4 || nt.Message != "failed (4 times)" {
+		t.Errorf("Expected notification counting the held back ones, got %v", nt)
+	}
+
+	// Forgotten after a quiet interval
+	if nt := at(3*time.Hour, "a"); !n.aggregate(nt) || nt.Count != 1 {
+		t.Errorf("Expected notification to be sent once, got %v", nt)
+	}
+	if len(n.recent) != 1 {
+		t.Errorf("Expected old notifications to be forgotten, got %v", n.recent)
+	}
+}
+
+func TestNotifierForgetsHeldBack(t *testing.T) {
+	n, err := newNotifier("http://localhost", defaultNotifyTemplate)
+	if err != nil {
+		t.Fatalf("newNotifier() returned err: %v", err)
+	}
+	n.interval = time.Hour
+	start := time.Now()
+	at := func(d time.Duration, object, msg string) *notification {
+		return &notification{Event: notifyUnsealFailed, Object: object, Error: msg, Message: msg, Time: start.Add(d)}
+	}
+
+	// Failures with changing errors, each held back once
+	for i := 0; i < 10; i++ {
+		msg := fmt.Sprintf("failed %d", i)
+		n.aggregate(at(0, "a", msg))
+		n.aggregate(at(30*time.Minute, "a", msg))
+	}
+	if len(n.recent) != 10 {
+		t.Fatalf("Expected 10 recent notifications, got %d", len(n.recent))
+	}
+	n.aggregate(at(91*time.Minute, "b", "failed"))
+	if len(n.recent) != 1 {
+		t.Errorf("Expected the notifications held back an interval ago to be forgotten, got %d", len(n.recent))
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	"github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+var (
+	sealedObjectKinds = flag.String("sealed-object-kinds", "", "Comma separated list of Kind.group (just Kind for the core group) that SealedObjects may unseal into. SealedObjects are ignored if empty.")
+)
+
+// objectKinds is the set of kinds SealedObjects may unseal into. The
+// controller must also be granted the RBAC permissions to manage them.
+type objectKinds map[schema.GroupKind]bool
+
+func parseObjectKinds(s string) objectKinds {
+	kinds := objectKinds{}
+	for _, k := range strings.Split(s, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			kinds[schema.ParseGroupKind(k)] = true
+		}
+	}
+	return kinds
+}
+
+// objectApplier creates or replaces arbitrary namespaced objects.
+type objectApplier interface {
+	Apply(obj *unstructured.Unstructured) error
+}
+
+// restObjectApplier applies objects through the REST API, finding the
+// resource of their kind with the discovery API.
+type restObjectApplier struct {
+	client discovery.DiscoveryInterface
+}
+
+func (a restObjectApplier) resourcePath(obj *unstructured.Unstructured) (string, error) {
+	gvk := obj.GroupVersionKind()
+	resources, err := a.client.ServerResourcesForGroupVersion(gvk.GroupVersion().String())
+	if err != nil {
+		return "", err
+	}
+	for _, r := range resources.APIResources {
+		if r.Kind != gvk.Kind || strings.Contains(r.Name, "/") {
+			continue
+		}
+		if !r.Namespaced {
+			return "", fmt.Errorf("%s is not a namespaced kind", gvk.Kind)
+		}
+		prefix := "/apis/" + gvk.GroupVersion().String()
+		if gvk.Group == "" {
+			prefix = "/api/" + gvk.Version
+		}
+		return fmt.Sprintf("%s/namespaces/%s/%s", prefix, obj.GetNamespace(), r.Name), nil
+	}
+	return "", fmt.Errorf("no resource found for %s", gvk)
+}
+
+func (a restObjectApplier) Apply(obj *unstructured.Unstructured) error {
+	path, err := a.resourcePath(obj)
+	if err != nil {
+		return err
+	}
+	body, err := obj.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	client := a.client.RESTClient()
+	err = client.Post().AbsPath(path).Body(body).Do().Error()
+	if err == nil || !errors.IsAlreadyExists(err) {
+		return err
+	}
+
+	// Object already exists so replace it, keeping its metadata
+	raw, err := client.Get().AbsPath(path, obj.GetName()).DoRaw()
+	if err != nil {
+		return fmt.Errorf("failed to read existing object: %s", err)
+	}
+	existing := &unstructured.Unstructured{}
+	if err := existing.UnmarshalJSON(raw); err != nil {
+		return err
+	}
+	// Objects without a controller are adopted, but those managed by
+	// something else, say a Deployment or an operator, are left alone.
+	if ref := metav1.GetControllerOf(existing); ref != nil {
+		if own := metav1.GetControllerOf(obj); own == nil || own.UID != ref.UID {
+			return fmt.Errorf("%s %s/%s already exists and is controlled by %s %s, not replacing it", obj.GetKind(), obj.GetNamespace(), obj.GetName(), ref.Kind, ref.Name)
+		}
+	}
+	updated := obj.DeepCopy()
+	updated.SetResourceVersion(existing.GetResourceVersion())
+	updated.SetOwnerReferences(mergeOwnerReferences(existing.GetOwnerReferences(), obj.GetOwnerReferences()))
+	if body, err = updated.MarshalJSON(); err != nil {
+		return err
+	}
+	return client.Put().AbsPath(path, obj.GetName()).Body(body).Do().Error()
+}
+
+func (c *Controller) unsealObject(key string) error {
+	obj, exists, err := c.objInformer.GetIndexer().GetByKey(key)
+	if err != nil {
+		log.Printf("Error fetching object with key %s from store: %v", key, err)
+		return err
+	}
+
+	if !exists {
+		// The kind of the unsealed object is only known from the
+		// ciphertext; it is garbage collected via its owner reference.
+		log.Printf("SealedObject %s has gone", key)
+		return nil
+	}
+
+	so := obj.(*ssv1alpha1.SealedObject)
+	if skip, err := c.skipDisabledNamespace(sealedObjectKind, key, so.GetNamespace()); err != nil || skip {
+		return err
+	}
+	log.Printf("Updating SealedObject %s", key)
+
+	keys, err := c.keysFor(so.GetNamespace())
+	if err != nil {
+		return err
+	}
+	o, err := seal.UnsealObject(so, keys)
+	if err != nil {
+		return err
+	}
+
+	// The controller is usually more privileged than whoever created
+	// the SealedObject, so only explicitly allowed kinds are applied.
+	if !c.objectKinds[o.GroupVersionKind().GroupKind()] {
+		return fmt.Errorf("SealedObject %s has a kind that is not allowed by --sealed-object-kinds", key)
+	}
+
+	if c.readOnly() {
+		log.Printf("Dry run: %s %s/%s would be applied", o.GetKind(), o.GetNamespace(), o.GetName())
+		return nil
+	}
+	return c.applier.Apply(o)
+}
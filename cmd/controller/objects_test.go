@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	"github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+type fakeApplier []*unstructured.Unstructured
+
+func (a *fakeApplier) Apply(obj *unstructured.Unstructured) error {
+	*a = append(*a, obj)
+	return nil
+}
+
+func TestParseObjectKinds(t *testing.T) {
+	kinds := parseObjectKinds("Database.example.com, ServiceAccount,")
+	if len(kinds) != 2 {
+		t.Errorf("Unexpected kinds %v", kinds)
+	}
+	if !kinds[schema.GroupKind{Group: "example.com", Kind: "Database"}] || !kinds[schema.GroupKind{Kind: "ServiceAccount"}] {
+		t.Errorf("Unexpected kinds %v", kinds)
+	}
+	if len(parseObjectKinds("")) != 0 {
+		t.Errorf("Expected no kinds")
+	}
+}
+
+func TestUnsealObject(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	registry, err := initKeyRegistry(newSecretKeyStore(client, "namespace", "label"), "prefix", 1024)
+	if err != nil {
+		t.Fatalf("initKeyRegistry() returned err: %v", err)
+	}
+	if _, err := registry.generateKey(); err != nil {
+		t.Fatalf("generateKey() returned err: %v", err)
+	}
+
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &ssv1alpha1.SealedObject{}, 0, cache.Indexers{})
+	applier := &fakeApplier{}
+	controller := &Controller{
+		objInformer: informer,
+		applier:     applier,
+		objectKinds: parseObjectKinds("Database.example.com"),
+		keyRegistry: registry,
+	}
+
+	sealObject := func(apiVersion, kind string) {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata": map[string]interface{}{
+				"name":      "mydb",
+				"namespace": "myns",
+			},
+		}}
+		so, err := seal.SealObject(obj, &registry.latestPrivateKey().PublicKey, seal.StrictScope)
+		if err != nil {
+			t.Fatalf("SealObject() returned err: %v", err)
+		}
+		if err := informer.GetIndexer().Update(so); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sealObject("example.com/v1", "Database")
+	if err := controller.unsealObject("myns/mydb"); err != nil {
+		t.Fatalf("unsealObject() returned err: %v", err)
+	}
+	if len(*applier) != 1 || (*applier)[0].GetKind() != "Database" {
+		t.Errorf("Unexpected applied objects %v", *applier)
+	}
+
+	sealObject("rbac.authorization.k8s.io/v1", "RoleBinding")
+	if err := controller.unsealObject("myns/mydb"); err == nil {
+		t.Errorf("Expected error for a kind that is not allowed")
+	}
+	if len(*applier) != 1 {
+		t.Errorf("Unexpected applied objects %v", *applier)
+	}
+
+	// Deleted SealedObjects are left to the garbage collector
+	if err := controller.unsealObject("myns/gone"); err != nil {
+		t.Errorf("unsealObject() returned err: %v", err)
+	}
+}
+
+func TestRestObjectApplierControlled(t *testing.T) {
+	controllerRef := func(kind string, uid types.UID) []interface{} {
+		return []interface{}{map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       kind,
+			"name":       "owner",
+			"uid":        string(uid),
+			"controller": true,
+		}}
+	}
+	existing := map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Database",
+		"metadata": map[string]interface{}{
+			"name":            "mydb",
+			"namespace":       "myns",
+			"resourceVersion": "1",
+		},
+	}
+
+	var replaced []byte
+	mux := http.NewServeMux()
+	mux.HandleFunc("/apis/example.com/v1", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(metav1.APIResourceList{
+			GroupVersion: "example.com/v1",
+			APIResources: []metav1.APIResource{{Name: "databases", Kind: "Database", Namespaced: true}},
+		})
+	})
+	mux.HandleFunc("/apis/example.com/v1/namespaces/myns/databases", func(w http.ResponseWriter, r *http.Request) {
+		status := errors.NewAlreadyExists(schema.GroupResource{Group: "example.com", Resource: "databases"}, "mydb").ErrStatus
+		status.Kind, status.APIVersion = "Status", "v1"
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(status)
+	})
+	mux.HandleFunc("/apis/example.com/v1/namespaces/myns/databases/mydb", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			replaced, _ = ioutil.ReadAll(r.Body)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(existing)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	applier := restObjectApplier{discovery.NewDiscoveryClientForConfigOrDie(&rest.Config{Host: server.URL})}
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Database",
+		"metadata": map[string]interface{}{
+			"name":            "mydb",
+			"namespace":       "myns",
+			"ownerReferences": controllerRef("SealedObject", "so-uid"),
+		},
+	}}
+
+	// Not controlled yet: adopted
+	if err := applier.Apply(obj); err != nil {
+		t.Fatalf("Apply() returned err: %v", err)
+	}
+	if replaced == nil {
+		t.Fatalf("Expected the existing object to be replaced")
+	}
+
+	// Controlled by the same SealedObject
+	replaced = nil
+	existing["metadata"].(map[string]interface{})["ownerReferences"] = controllerRef("SealedObject", "so-uid")
+	if err := applier.Apply(obj); err != nil {
+		t.Fatalf("Apply() returned err: %v", err)
+	}
+	if replaced == nil {
+		t.Fatalf("Expected the existing object to be replaced")
+	}
+
+	// Controlled by something else
+	replaced = nil
+	existing["metadata"].(map[string]interface{})["ownerReferences"] = controllerRef("Operator", "operator-uid")
+	err := applier.Apply(obj)
+	if err == nil || !strings.Contains(err.Error(), "controlled by Operator owner") {
+		t.Errorf("Expected an error for an object controlled by something else, got %v", err)
+	}
+	if replaced != nil {
+		t.Errorf("Unexpected replacement of an object controlled by something else: %s", replaced)
+	}
+}
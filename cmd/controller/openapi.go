@@ -0,0 +1,258 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// openAPIOperation describes an endpoint of the HTTP API.
+type openAPIOperation struct {
+	path        string
+	method      string
+	summary     string
+	consumes    string
+	produces    []string
+	bearer      bool
+	parameters  []map[string]interface{}
+	request     interface{}
+	response    interface{}
+	responses   map[int]string
+	description string
+	// structuredErrors is set on the /v2 endpoints, see apiError.
+	structuredErrors bool
+}
+
+// jsonSchema returns the JSON schema of the values of t, as encoded by
+// encoding/json, registering the structs in definitions.
+func jsonSchema(t reflect.Type, definitions map[string]interface{}) map[string]interface{} {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == reflect.TypeOf(time.Time{}):
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return map[string]interface{}{"type": "string", "format": "byte"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": jsonSchema(t.Elem(), definitions)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": jsonSchema(t.Elem(), definitions)}
+	case reflect.Struct:
+		ref := map[string]interface{}{"$ref": "#/definitions/" + t.Name()}
+		if _, ok := definitions[t.Name()]; ok {
+			return ref
+		}
+		properties := map[string]interface{}{}
+		definitions[t.Name()] = map[string]interface{}{"type": "object", "properties": properties}
+		for i := 0; i < t.NumField(); i++ {
+			name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			properties[name] = jsonSchema(t.Field(i).Type, definitions)
+		}
+		return ref
+	}
+	return map[string]interface{}{}
+}
+
+// apiOperations returns the endpoints served by httpserver, given which
+// of the optional ones are enabled.
+func apiOperations(seal, items, namespaceCerts, admin bool) []openAPIOperation {
+	ops := []openAPIOperation{
+		{
+			path: "/v1/cert.pem", method: "get", summary: "Certificates of the sealing keys, the latest last",
+			produces: []string{contentTypePEM, contentTypeJSON, contentTypeYAML}, responses: map[int]string{200: "PEM encoded certificates"},
+		},
+		{
+			path: "/v1/verify", method: "post", summary: "Check that a SealedSecret can be decrypted",
+			consumes: "application/json", request: "SealedSecret",
+			responses: map[int]string{200: "The SealedSecret is valid", 409: "The SealedSecret can't be decrypted", 500: "Internal error"},
+		},
+		{
+			path: "/v1/rotate", method: "post", summary: "Reseal a SealedSecret with the latest key",
+			consumes: "application/json", produces: []string{contentTypeJSON, contentTypeYAML}, request: "SealedSecret", response: "SealedSecret",
+			responses: map[int]string{200: "The resealed SealedSecret", 500: "Internal error"},
+		},
+	}
+	if seal {
+		ops = append(ops, openAPIOperation{
+			path: "/v1/seal", method: "post", summary: "Seal a Secret",
+			description: "The user must be allowed to create SealedSecrets in the namespace of the Secret.",
+			consumes:    "application/json", produces: []string{"application/json"}, bearer: true, request: "Secret", response: "SealedSecret",
+			responses: map[int]string{200: "The SealedSecret", 400: "Invalid Secret", 401: "No bearer token", 403: "Forbidden", 500: "Internal error"},
+		})
+	}
+	if items {
+		ops = append(ops, openAPIOperation{
+			path: "/v1/items", method: "post", summary: "Add or remove sealed items of a SealedSecret",
+			description: "The user must be allowed to update SealedSecrets in the namespace.",
+			consumes:    "application/json", bearer: true, request: itemsRequest{},
+			responses: map[int]string{200: "The SealedSecret is updated", 400: "Invalid request", 401: "No bearer token", 403: "Forbidden", 500: "Internal error"},
+		})
+	}
+	if namespaceCerts {
+		ops = append(ops, openAPIOperation{
+			path: "/v1/namespaces/{namespace}/cert.pem", method: "get", summary: "Certificates of the sealing keys of a namespace",
+			produces: []string{contentTypePEM, contentTypeJSON, contentTypeYAML},
+			parameters: []map[string]interface{}{
+				{"name": "namespace", "in": "path", "required": true, "type": "string"},
+			},
+			responses: map[int]string{200: "PEM encoded certificates", 404: "The namespace has no key"},
+		})
+	}
+	if admin {
+		adminResponses := func(ok string) map[int]string {
+			return map[int]string{200: ok, 401: "No bearer token", 403: "Forbidden", 500: "Internal error"}
+		}
+		blacklistResponses := adminResponses("The manifests of the SealedSecrets resealed with the latest key, not updated in the cluster")
+		blacklistResponses[400] = "Name and reason are required"
+		blacklistResponses[404] = "No such key"
+		impactResponses := adminResponses("The SealedSecrets sealed with the key")
+		impactResponses[400] = "The fingerprint is required"
+		ops = append(ops,
+			openAPIOperation{
+				path: "/admin/keys", method: "get", summary: "Describe the keys",
+				produces: []string{"application/json"}, bearer: true, response: []keyInfo{},
+				responses: adminResponses("The keys, oldest first"),
+			},
+			openAPIOperation{
+				path: "/admin/keys/blacklist", method: "post", summary: "Blacklist a key and reseal the manifests of its SealedSecrets",
+				consumes: "application/json", produces: []string{"application/json"}, bearer: true,
+				request: blacklistRequest{}, response: resealReport{},
+				responses: blacklistResponses,
+			},
+			openAPIOperation{
+				path: "/admin/keys/impact", method: "get", summary: "List the SealedSecrets sealed with a key",
+				description: "Only their namespaced names are listed.",
+				produces:    []string{"application/json"}, bearer: true, response: impactReport{},
+				parameters: []map[string]interface{}{
+					{"name": "fingerprint", "in": "query", "required": true, "type": "string"},
+				},
+				responses: impactResponses,
+			},
+			openAPIOperation{
+				path: "/admin/keys/rotate", method: "post", summary: "Generate a new key",
+				description: "The key is generated asynchronously, as on SIGUSR1.",
+				bearer:      true, responses: map[int]string{202: "A new key is requested", 401: "No bearer token", 403: "Forbidden", 500: "Internal error"},
+			},
+			openAPIOperation{
+				path: "/admin/promote", method: "post", summary: "Promote a standby controller",
+				bearer: true, responses: adminResponses("The controller is active"),
+			},
+			openAPIOperation{
+				path: "/admin/reencrypt-all", method: "post", summary: "Reseal every SealedSecret with the latest key",
+				description: "Streams a reencryptProgress line per SealedSecret and a reencryptSummary line last.",
+				produces:    []string{"application/x-ndjson"}, bearer: true, response: reencryptSummary{},
+				responses: adminResponses("The progress of the resealing"),
+			},
+		)
+	}
+
+	for _, op := range ops {
+		if strings.HasPrefix(op.path, "/v1/") {
+			op.path = "/v2/" + strings.TrimPrefix(op.path, "/v1/")
+			op.structuredErrors = true
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
+// openAPIDocument returns the OpenAPI 2.0 description of ops.
+func openAPIDocument(ops []openAPIOperation) map[string]interface{} {
+	definitions := map[string]interface{}{
+		"Secret":       map[string]interface{}{"type": "object", "description": "A v1 Secret"},
+		"SealedSecret": map[string]interface{}{"type": "object", "description": "A bitnami.com/v1alpha1 SealedSecret"},
+	}
+	jsonSchema(reflect.TypeOf(reencryptProgress{}), definitions)
+
+	schema := func(v interface{}) map[string]interface{} {
+		if name, ok := v.(string); ok {
+			return map[string]interface{}{"$ref": "#/definitions/" + name}
+		}
+		return jsonSchema(reflect.TypeOf(v), definitions)
+	}
+
+	paths := map[string]interface{}{}
+	for _, op := range ops {
+		operation := map[string]interface{}{"summary": op.summary}
+		if op.description != "" {
+			operation["description"] = op.description
+		}
+		if op.consumes != "" {
+			operation["consumes"] = []string{op.consumes}
+		}
+		if len(op.produces) > 0 {
+			operation["produces"] = op.produces
+		}
+		if op.bearer {
+			operation["security"] = []map[string][]string{{"bearer": {}}}
+		}
+		parameters := op.parameters
+		if op.request != nil {
+			parameters = append(parameters, map[string]interface{}{"name": "body", "in": "body", "required": true, "schema": schema(op.request)})
+		}
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+		responses := map[string]interface{}{}
+		for code, description := range op.responses {
+			response := map[string]interface{}{"description": description}
+			if code == http.StatusOK && op.response != nil {
+				response["schema"] = schema(op.response)
+			}
+			if code >= http.StatusBadRequest && op.structuredErrors {
+				response["schema"] = schema(apiError{})
+			}
+			responses[strconv.Itoa(code)] = response
+		}
+		operation["responses"] = responses
+
+		item, _ := paths[op.path].(map[string]interface{})
+		if item == nil {
+			item = map[string]interface{}{}
+			paths[op.path] = item
+		}
+		item[op.method] = operation
+	}
+
+	return map[string]interface{}{
+		"swagger": "2.0",
+		"info": map[string]interface{}{
+			"title":   "Sealed Secrets controller",
+			"version": VERSION,
+		},
+		"securityDefinitions": map[string]interface{}{
+			"bearer": map[string]interface{}{"type": "apiKey", "name": "Authorization", "in": "header"},
+		},
+		"paths":       paths,
+		"definitions": definitions,
+	}
+}
+
+// openAPIHandler serves the OpenAPI description of ops.
+func openAPIHandler(ops []openAPIOperation) http.Handler {
+	doc, err := json.MarshalIndent(openAPIDocument(ops), "", "  ")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(doc)
+	})
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestOpenAPIHandler(t *testing.T) {
+	testCases := []struct {
+		admin    bool
+		expected []string
+		missing  []string
+	}{
+		{false, []string{"/v1/cert.pem", "/v1/verify", "/v1/rotate", "/v2/verify"}, []string{"/admin/keys", "/v1/seal", "/v2/seal"}},
+		{true, []string{"/v1/cert.pem", "/admin/keys", "/admin/keys/blacklist", "/admin/keys/impact", "/admin/keys/rotate", "/admin/reencrypt-all"}, []string{"/v1/seal", "/v2/admin/keys"}},
+	}
+	for _, tc := range testCases {
+		rec := httptest.NewRecorder()
+		openAPIHandler(apiOperations(false, false, false, tc.admin)).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rec.Code)
+		}
+
+		var doc struct {
+			Swagger     string                            `json:"swagger"`
+			Paths       map[string]map[string]interface{} `json:"paths"`
+			Definitions map[string]interface{}            `json:"definitions"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+			t.Fatalf("Invalid document: %v", err)
+		}
+		if doc.Swagger != "2.0" {
+			t.Errorf("Expected swagger 2.0, got %q", doc.Swagger)
+		}
+		for _, path := range tc.expected {
+			if _, ok := doc.Paths[path]; !ok {
+				t.Errorf("Expected path %s (admin %v)", path, tc.admin)
+			}
+		}
+		for _, path := range tc.missing {
+			if _, ok := doc.Paths[path]; ok {
+				t.Errorf("Unexpected path %s (admin %v)", path, tc.admin)
+			}
+		}
+		if _, ok := doc.Definitions["keyInfo"]; tc.admin && !ok {
+			t.Error("Expected the keyInfo definition")
+		}
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	definitions := map[string]interface{}{}
+	schema := jsonSchema(reflect.TypeOf(itemsRequest{}), definitions)
+	if schema["$ref"] != "#/definitions/itemsRequest" {
+		t.Fatalf("Expected a reference, got %v", schema)
+	}
+	properties := definitions["itemsRequest"].(map[string]interface{})["properties"].(map[string]interface{})
+	set := properties["set"].(map[string]interface{})
+	if set["type"] != "object" || set["additionalProperties"].(map[string]interface{})["format"] != "byte" {
+		t.Errorf("Unexpected schema of set: %v", set)
+	}
+	if remove := properties["remove"].(map[string]interface{}); remove["type"] != "array" {
+		t.Errorf("Unexpected schema of remove: %v", remove)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"strings"
+
+	flag "github.com/spf13/pflag"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+const (
+	// SealedSecretsNoOwnerReferencesAnnotation, when "true" on a
+	// SealedSecret, makes its Secret stand alone as with
+	// --no-owner-references.
+	SealedSecretsNoOwnerReferencesAnnotation = "sealedsecrets.bitnami.com/no-owner-references"
+	// SealedSecretsUIDLabel holds the UID of the SealedSecret of a
+	// Secret without owner references.
+	SealedSecretsUIDLabel = "sealedsecrets.bitnami.com/sealed-secret-uid"
+)
+
+var (
+	noOwnerReferences = flag.Bool("no-owner-references", false, "Don't set owner references on unsealed Secrets, for backup and cloning tools that can't handle them. The Secrets are labelled with the UID of their SealedSecret instead, and are only deleted with it while the controller runs.")
+)
+
+// standalone reports whether the Secret of ssecret must not have owner
+// references.
+func standalone(ssecret *ssv1alpha1.SealedSecret) bool {
+	return *noOwnerReferences || ssecret.GetAnnotations()[SealedSecretsNoOwnerReferencesAnnotation] == "true"
+}
+
+// isSealedSecretReference reports whether ref points to a SealedSecret.
+func isSealedSecretReference(ref metav1.OwnerReference) bool {
+	return ref.Kind == "SealedSecret" && strings.HasPrefix(ref.APIVersion, ssv1alpha1.GroupName+"/")
+}
+
+// ownerUID returns the UID of the SealedSecret of secret, from its
+// owner reference or label, empty if it has none.
+func ownerUID(secret *apiv1.Secret) types.UID {
+	for _, ref := range secret.GetOwnerReferences() {
+		if isSealedSecretReference(ref) {
+			return ref.UID
+		}
+	}
+	return types.UID(secret.GetLabels()[SealedSecretsUIDLabel])
+}
+
+// ownerExists reports whether the SealedSecret of secret still exists,
+// although the informer no longer has it: it was relabelled out of the
+// --label-selector of c into the shard of another controller, which
+// manages secret now.
+func (c *Controller) ownerExists(secret *apiv1.Secret) (bool, error) {
+	uid := ownerUID(secret)
+	if uid == "" {
+		return false, nil
+	}
+	ssecret, err := c.ssclient.BitnamiV1alpha1().SealedSecrets(secret.GetNamespace()).Get(secret.GetName(), metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return ssecret.GetUID() == uid, nil
+}
+
+// detach replaces the references of secret to SealedSecrets with the
+// label holding uid.
+func detach(secret *apiv1.Secret, uid string) {
+	var refs []metav1.OwnerReference
+	for _, ref := range secret.GetOwnerReferences() {
+		if !isSealedSecretReference(ref) {
+			refs = append(refs, ref)
+		}
+	}
+	secret.SetOwnerReferences(refs)
+
+	labels := copyStrings(secret.GetLabels())
+	labels[SealedSecretsUIDLabel] = uid
+	secret.SetLabels(labels)
+}
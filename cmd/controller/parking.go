@@ -0,0 +1,92 @@
+package main
+
+import (
+	"log"
+	"sync"
+)
+
+// parkedObjects are the objects no key could decrypt. Retrying them is
+// pointless until a key is registered, when they are all queued again.
+type parkedObjects struct {
+	mu   sync.Mutex
+	keys map[queueKey]bool
+	// generation counts the keys registered, so that an object whose
+	// sync failed while a key was being registered isn't parked after
+	// the others were queued again.
+	generation int
+}
+
+// current returns the generation to park the objects synced from now
+// with.
+func (p *parkedObjects) current() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.generation
+}
+
+// park adds key, whose sync started at generation, to the parked
+// objects. It returns false if a key has been registered since, in
+// which case key must be retried instead.
+func (p *parkedObjects) park(key queueKey, generation int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if generation != p.generation {
+		return false
+	}
+	if p.keys == nil {
+		p.keys = map[queueKey]bool{}
+	}
+	p.keys[key] = true
+	return true
+}
+
+// remove removes key from the parked objects, once synced.
+func (p *parkedObjects) remove(key queueKey) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.keys, key)
+}
+
+// unparkAll removes and returns all the parked objects, and starts a
+// new generation.
+func (p *parkedObjects) unparkAll() []queueKey {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.generation++
+	keys := make([]queueKey, 0, len(p.keys))
+	for key := range p.keys {
+		keys = append(keys, key)
+	}
+	p.keys = nil
+	return keys
+}
+
+func (p *parkedObjects) len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.keys)
+}
+
+// keyRegistered queues the parked objects again, since the key
+// registered for namespace, empty for the cluster-wide keys, may
+// decrypt them. The SealedSecrets it may decrypt are queued too, so
+// that those which failed before parking, or while the controller was
+// down, converge without waiting for an update. It is called by the
+// key registries.
+func (c *Controller) keyRegistered(namespace string) {
+	keys := c.parked.unparkAll()
+	if len(keys) > 0 {
+		log.Printf("New key registered, retrying %d objects no key could decrypt", len(keys))
+	}
+	for _, key := range keys {
+		c.queue.Add(key)
+	}
+
+	if namespace != "" {
+		c.requeueNamespace(namespace)
+		return
+	}
+	for _, key := range c.informer.GetIndexer().ListKeys() {
+		c.queue.Add(queueKey{sealedSecretKind, key})
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParkNoMatchingKey(t *testing.T) {
+	sealing := testRegistry(t)
+	ssecret := testSealedSecret(t, sealing)
+	c := newTestController(t, ssecret)
+	c.keyRegistry = testRegistry(t)
+	c.keyRegistry.onKeyRegistered = c.keyRegistered
+
+	key := queueKey{sealedSecretKind, "myns/mysecret"}
+	c.queue.Add(key)
+	c.processNextItem()
+	if c.parked.len() != 1 || c.queue.Len() != 0 || c.queue.NumRequeues(key) != 0 {
+		t.Fatalf("Expected %s to be parked, got %d parked, %d queued", key, c.parked.len(), c.queue.Len())
+	}
+
+	// The key is restored
+	cert, err := sealing.getCert(sealing.keyNames()[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.keyRegistry.registerNewKey("restored", sealing.latestPrivateKey(), cert); err != nil {
+		t.Fatal(err)
+	}
+	if c.parked.len() != 0 || c.queue.Len() != 1 {
+		t.Fatalf("Expected %s to be queued again, got %d parked, %d queued", key, c.parked.len(), c.queue.Len())
+	}
+	c.processNextItem()
+	if _, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected Secret to be created, got %v", err)
+	}
+}
+
+func TestParkAfterKeyRegistered(t *testing.T) {
+	var p parkedObjects
+	key := queueKey{sealedSecretKind, "myns/mysecret"}
+	generation := p.current()
+	// A key is registered while key is synced
+	p.unparkAll()
+	if p.park(key, generation) || p.len() != 0 {
+		t.Errorf("Expected %s not to be parked after a key was registered", key)
+	}
+	if !p.park(key, p.current()) || p.len() != 1 {
+		t.Errorf("Expected %s to be parked", key)
+	}
+}
+
+func TestRequeueOnKeyRegistered(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+	c := newTestController(t, ssecret)
+	other := ssecret.DeepCopy()
+	other.Namespace = "other"
+	if err := c.informer.GetIndexer().Add(other); err != nil {
+		t.Fatal(err)
+	}
+
+	c.keyRegistered("other")
+	if c.queue.Len() != 1 {
+		t.Fatalf("Expected the SealedSecrets of the namespace to be queued, got %d queued", c.queue.Len())
+	}
+	key, _ := c.queue.Get()
+	if key != (queueKey{sealedSecretKind, "other/mysecret"}) {
+		t.Errorf("Expected other/mysecret to be queued, got %v", key)
+	}
+	c.queue.Done(key)
+
+	c.keyRegistered("")
+	if c.queue.Len() != 2 {
+		t.Errorf("Expected all the SealedSecrets to be queued, got %d queued", c.queue.Len())
+	}
+}
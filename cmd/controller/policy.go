@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	flag "github.com/spf13/pflag"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	ssv1alpha1informers "github.com/bitnami-labs/sealed-secrets/pkg/client/informers/externalversions/sealed-secrets/v1alpha1"
+)
+
+var (
+	enablePolicies = flag.Bool("enable-policies", false, "Only unseal the Secrets satisfying all the SealedSecretPolicies.")
+)
+
+// watchPolicies makes c enforce the SealedSecretPolicies, see
+// checkPolicies. All the SealedSecrets are requeued when a policy
+// changes. It must be called before Run.
+func (c *Controller) watchPolicies() {
+	// Not from the shared informer factory, whose --label-selector
+	// is meant for SealedSecrets.
+	c.policyInformer = ssv1alpha1informers.NewSealedSecretPolicyInformer(c.ssclient, 0, cache.Indexers{})
+	requeue := func(interface{}) { c.requeueNamespace(metav1.NamespaceAll) }
+	c.policyInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    requeue,
+		UpdateFunc: func(oldObj, newObj interface{}) { requeue(newObj) },
+		DeleteFunc: requeue,
+	})
+}
+
+// checkPolicy returns the reason why secret violates policy, if it does.
+// Be careful not to reveal the content of the Secret in it.
+func checkPolicy(policy *ssv1alpha1.SealedSecretPolicy, secret *apiv1.Secret) string {
+	spec := policy.Spec
+	if len(spec.AllowedNamespaces) > 0 {
+		allowed := false
+		for _, ns := range spec.AllowedNamespaces {
+			allowed = allowed || ns == secret.GetNamespace()
+		}
+		if !allowed {
+			return fmt.Sprintf("namespace %s is not allowed", secret.GetNamespace())
+		}
+	}
+	if len(spec.AllowedTypes) > 0 {
+		allowed := false
+		for _, t := range spec.AllowedTypes {
+			allowed = allowed || t == secret.Type
+		}
+		if !allowed {
+			return fmt.Sprintf("type %s is not allowed", secret.Type)
+		}
+	}
+	if spec.MaxSize > 0 {
+		size := 0
+		for _, v := range secret.Data {
+			size += len(v)
+		}
+		if int64(size) > spec.MaxSize {
+			return fmt.Sprintf("data is larger than %d bytes", spec.MaxSize)
+		}
+	}
+	for _, l := range spec.RequiredLabels {
+		if _, ok := secret.GetLabels()[l]; !ok {
+			return fmt.Sprintf("label %s is missing", l)
+		}
+	}
+	return ""
+}
+
+// policies returns the SealedSecretPolicies sorted by name, none
+// without --enable-policies.
+func (c *Controller) policies() []*ssv1alpha1.SealedSecretPolicy {
+	if c.policyInformer == nil {
+		return nil
+	}
+	var policies []*ssv1alpha1.SealedSecretPolicy
+	for _, obj := range c.policyInformer.GetIndexer().List() {
+		policies = append(policies, obj.(*ssv1alpha1.SealedSecretPolicy))
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].GetName() < policies[j].GetName()
+	})
+	return policies
+}
+
+// checkPolicies returns the first violation of a SealedSecretPolicy by
+// secret, if any.
+func (c *Controller) checkPolicies(secret *apiv1.Secret) string {
+	for _, p := range c.policies() {
+		if reason := checkPolicy(p, secret); reason != "" {
+			return fmt.Sprintf("Denied by SealedSecretPolicy %s: %s", p.GetName(), reason)
+		}
+	}
+	return ""
+}
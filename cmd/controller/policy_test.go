@@ -0,0 +1,89 @@
+package main
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+func TestCheckPolicy(t *testing.T) {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mysecret",
+			Namespace: "myns",
+			Labels:    map[string]string{"team": "payments"},
+		},
+		Type: v1.SecretTypeOpaque,
+		Data: map[string][]byte{"foo": []byte("bar")},
+	}
+
+	testCases := []struct {
+		spec    ssv1alpha1.SealedSecretPolicySpec
+		allowed bool
+	}{
+		{ssv1alpha1.SealedSecretPolicySpec{}, true},
+		{ssv1alpha1.SealedSecretPolicySpec{AllowedNamespaces: []string{"otherns", "myns"}}, true},
+		{ssv1alpha1.SealedSecretPolicySpec{AllowedNamespaces: []string{"otherns"}}, false},
+		{ssv1alpha1.SealedSecretPolicySpec{AllowedTypes: []v1.SecretType{v1.SecretTypeOpaque}}, true},
+		{ssv1alpha1.SealedSecretPolicySpec{AllowedTypes: []v1.SecretType{v1.SecretTypeTLS}}, false},
+		{ssv1alpha1.SealedSecretPolicySpec{MaxSize: 3}, true},
+		{ssv1alpha1.SealedSecretPolicySpec{MaxSize: 2}, false},
+		{ssv1alpha1.SealedSecretPolicySpec{RequiredLabels: []string{"team"}}, true},
+		{ssv1alpha1.SealedSecretPolicySpec{RequiredLabels: []string{"team", "env"}}, false},
+	}
+	for _, tc := range testCases {
+		policy := &ssv1alpha1.SealedSecretPolicy{Spec: tc.spec}
+		if reason := checkPolicy(policy, secret); (reason == "") != tc.allowed {
+			t.Errorf("checkPolicy(%+v) = %q, want allowed=%v", tc.spec, reason, tc.allowed)
+		}
+	}
+}
+
+func TestUnsealPolicyDenied(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+
+	c := newTestController(t, ssecret)
+	c.keyRegistry = registry
+	c.policyInformer = cache.NewSharedIndexInformer(&cache.ListWatch{}, &ssv1alpha1.SealedSecretPolicy{}, 0, cache.Indexers{})
+	policy := &ssv1alpha1.SealedSecretPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+		Spec:       ssv1alpha1.SealedSecretPolicySpec{AllowedNamespaces: []string{"prod"}},
+	}
+	c.policyInformer.GetIndexer().Add(policy)
+
+	getCondition := func() *ssv1alpha1.SealedSecretCondition {
+		updated, err := c.ssclient.BitnamiV1alpha1().SealedSecrets("myns").Get("mysecret", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.informer.GetIndexer().Update(updated)
+		return updated.GetCondition(ssv1alpha1.SealedSecretPolicyDenied)
+	}
+
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	if cond := getCondition(); cond == nil || cond.Status != v1.ConditionTrue || cond.Message != "Denied by SealedSecretPolicy prod: namespace myns is not allowed" {
+		t.Errorf("Expected PolicyDenied condition, got %v", cond)
+	}
+	if _, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected no Secret, got err %v", err)
+	}
+
+	c.policyInformer.GetIndexer().Delete(policy)
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	if cond := getCondition(); cond == nil || cond.Status != v1.ConditionFalse {
+		t.Errorf("Expected PolicyDenied condition to be cleared, got %v", cond)
+	}
+	if _, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected Secret to be created: %v", err)
+	}
+}
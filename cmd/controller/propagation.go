@@ -0,0 +1,125 @@
+package main
+
+import (
+	"strings"
+
+	flag "github.com/spf13/pflag"
+	apiv1 "k8s.io/api/core/v1"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+// sealedSecretsAnnotationPrefix prefixes the labels and annotations
+// read or written by the controller. Such annotations are always
+// copied to the Secrets, such labels never are.
+const sealedSecretsAnnotationPrefix = "sealedsecrets.bitnami.com/"
+
+var (
+	propagateAnnotations = flag.StringSlice("propagate-annotations", nil, "Annotations of the SealedSecrets copied to their Secrets, and kept in sync whatever their metadata strategy, eg. reloader.stakater.com/*, a trailing * matching any suffix. Adds to spec.template.propagateAnnotations. All the annotations are copied when the Secrets are created if both are empty.")
+	propagateLabels      = flag.StringSlice("propagate-labels", nil, "Labels of the SealedSecrets copied to their Secrets, and kept in sync whatever their metadata strategy, eg. app.kubernetes.io/*, a trailing * matching any suffix, * for all. Adds to spec.template.propagateLabels.")
+)
+
+// matchesAny returns whether name is one of patterns, a trailing *
+// matching any suffix.
+func matchesAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if p == name || (strings.HasSuffix(p, "*") && strings.HasPrefix(name, strings.TrimSuffix(p, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// annotationAllowlist returns the patterns of the annotations of
+// ssecret propagated to its Secret, nil if they all are.
+func annotationAllowlist(ssecret *ssv1alpha1.SealedSecret) []string {
+	allowlist := append([]string{}, *propagateAnnotations...)
+	if ssecret.Spec.Template != nil {
+		allowlist = append(allowlist, ssecret.Spec.Template.PropagateAnnotations...)
+	}
+	if len(allowlist) == 0 {
+		return nil
+	}
+	return allowlist
+}
+
+// labelAllowlist returns the patterns of the labels of ssecret
+// propagated to its Secret, nil if none is.
+func labelAllowlist(ssecret *ssv1alpha1.SealedSecret) []string {
+	allowlist := append([]string{}, *propagateLabels...)
+	if ssecret.Spec.Template != nil {
+		allowlist = append(allowlist, ssecret.Spec.Template.PropagateLabels...)
+	}
+	if len(allowlist) == 0 {
+		return nil
+	}
+	return allowlist
+}
+
+// filterAnnotations removes the annotations of secret not matching
+// allowlist, but those of the controller. It keeps them all if
+// allowlist is nil.
+func filterAnnotations(secret *apiv1.Secret, allowlist []string) {
+	if allowlist == nil {
+		return
+	}
+	annotations := map[string]string{}
+	for k, v := range secret.GetAnnotations() {
+		if strings.HasPrefix(k, sealedSecretsAnnotationPrefix) || matchesAny(allowlist, k) {
+			annotations[k] = v
+		}
+	}
+	secret.SetAnnotations(annotations)
+}
+
+// copyLabels sets the labels of ssecret matching allowlist on secret,
+// but those of the controller.
+func copyLabels(ssecret *ssv1alpha1.SealedSecret, secret *apiv1.Secret, allowlist []string) {
+	if allowlist == nil {
+		return
+	}
+	labels := copyStrings(secret.GetLabels())
+	for k, v := range ssecret.GetLabels() {
+		if propagated(allowlist, k) {
+			labels[k] = v
+		}
+	}
+	if len(labels) > 0 {
+		secret.SetLabels(labels)
+	}
+}
+
+// propagated returns whether the label or annotation k matches
+// allowlist and isn't one of the controller.
+func propagated(allowlist []string, k string) bool {
+	return !strings.HasPrefix(k, sealedSecretsAnnotationPrefix) && matchesAny(allowlist, k)
+}
+
+// synced returns a copy of existing where the entries matching
+// allowlist are those of current. Those of the controller are left
+// alone.
+func synced(existing, current map[string]string, allowlist []string) map[string]string {
+	m := copyStrings(existing)
+	for k := range m {
+		if propagated(allowlist, k) {
+			delete(m, k)
+		}
+	}
+	for k, v := range current {
+		if propagated(allowlist, k) {
+			m[k] = v
+		}
+	}
+	return m
+}
+
+// syncMetadata sets the annotations and labels of newSecret matching
+// their allowlist on existing, and removes those it no longer has.
+func syncMetadata(existing, newSecret *apiv1.Secret, annotations, labels []string) {
+	if annotations != nil {
+		existing.SetAnnotations(synced(existing.GetAnnotations(), newSecret.GetAnnotations(), annotations))
+	}
+	if labels != nil {
+		existing.SetLabels(synced(existing.GetLabels(), newSecret.GetLabels(), labels))
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	flag "github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+var (
+	quotaCount = flag.Int("namespace-max-sealed-secrets", 0, "Maximum number of SealedSecrets unsealed in each namespace. Unlimited if 0.")
+	quotaBytes = flag.Int("namespace-max-sealed-secret-bytes", 0, "Maximum total size of the encrypted data of the SealedSecrets unsealed in each namespace. Unlimited if 0.")
+)
+
+// quota limits the SealedSecrets unsealed in each namespace, a zero
+// value means no limit.
+type quota struct {
+	count int
+	bytes int
+}
+
+func (q quota) enabled() bool {
+	return q.count > 0 || q.bytes > 0
+}
+
+func sealedSecretSize(s *ssv1alpha1.SealedSecret) int {
+	size := len(s.Spec.Data)
+	for k, v := range s.Spec.EncryptedData {
+		size += len(k) + len(v)
+	}
+	return size
+}
+
+// checkQuota reports whether ssecret is within the quota of its
+// namespace, and why not. The SealedSecrets of a namespace are admitted
+// oldest first, so that new ones can't evict those already unsealed.
+func (c *Controller) checkQuota(ssecret *ssv1alpha1.SealedSecret) (bool, string) {
+	if !c.quota.enabled() {
+		return true, ""
+	}
+
+	var all []*ssv1alpha1.SealedSecret
+	cache.ListAllByNamespace(c.informer.GetIndexer(), ssecret.GetNamespace(), labels.Everything(), func(obj interface{}) {
+		all = append(all, obj.(*ssv1alpha1.SealedSecret))
+	})
+	sort.Slice(all, func(i, j int) bool {
+		ti, tj := all[i].GetCreationTimestamp(), all[j].GetCreationTimestamp()
+		if !ti.Equal(&tj) {
+			return ti.Before(&tj)
+		}
+		return all[i].GetName() < all[j].GetName()
+	})
+
+	count, bytes := 0, 0
+	for _, s := range all {
+		size := sealedSecretSize(s)
+		isCurrent := s.GetName() == ssecret.GetName()
+		if c.quota.count > 0 && count+1 > c.quota.count {
+			if isCurrent {
+				return false, fmt.Sprintf("Namespace %s already has %d SealedSecrets", ssecret.GetNamespace(), count)
+			}
+			continue
+		}
+		if c.quota.bytes > 0 && bytes+size > c.quota.bytes {
+			if isCurrent {
+				return false, fmt.Sprintf("Size %d would exceed the %d bytes of namespace %s (%d used)", size, c.quota.bytes, ssecret.GetNamespace(), bytes)
+			}
+			continue
+		}
+		if isCurrent {
+			return true, ""
+		}
+		count++
+		bytes += size
+	}
+	return true, ""
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+func TestCheckQuota(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+	ssecret.CreationTimestamp = metav1.NewTime(time.Unix(1000, 0))
+
+	c := newTestController(t, ssecret)
+	older := ssecret.DeepCopy()
+	older.Name = "older"
+	older.CreationTimestamp = metav1.NewTime(time.Unix(500, 0))
+	c.informer.GetIndexer().Add(older)
+	newer := ssecret.DeepCopy()
+	newer.Name = "newer"
+	newer.CreationTimestamp = metav1.NewTime(time.Unix(2000, 0))
+	c.informer.GetIndexer().Add(newer)
+	other := ssecret.DeepCopy()
+	other.Namespace = "otherns"
+	c.informer.GetIndexer().Add(other)
+
+	size := sealedSecretSize(ssecret)
+	testCases := []struct {
+		quota quota
+		want  []bool
+	}{
+		{quota{}, []bool{true, true, true}},
+		{quota{count: 2}, []bool{true, true, false}},
+		{quota{bytes: 2 * size}, []bool{true, true, false}},
+		{quota{count: 1, bytes: 3 * size}, []bool{true, false, false}},
+	}
+	for _, tc := range testCases {
+		c.quota = tc.quota
+		for i, s := range []*ssv1alpha1.SealedSecret{older, ssecret, newer} {
+			if ok, msg := c.checkQuota(s); ok != tc.want[i] {
+				t.Errorf("checkQuota(%s) with %+v = %v (%q), want %v", s.Name, tc.quota, ok, msg, tc.want[i])
+			}
+		}
+		if ok, _ := c.checkQuota(other); !ok {
+			t.Errorf("Expected %+v to be per namespace", tc.quota)
+		}
+	}
+}
+
+func TestUnsealQuotaExceeded(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+
+	c := newTestController(t, ssecret)
+	c.keyRegistry = registry
+	c.quota = quota{bytes: 1}
+
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	updated, err := c.ssclient.BitnamiV1alpha1().SealedSecrets("myns").Get("mysecret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cond := updated.GetCondition(ssv1alpha1.SealedSecretQuotaExceeded); cond == nil || cond.Status != v1.ConditionTrue {
+		t.Errorf("Expected QuotaExceeded condition, got %v", cond)
+	}
+	if _, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected no Secret, got err %v", err)
+	}
+}
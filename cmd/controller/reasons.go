@@ -0,0 +1,107 @@
+package main
+
+import (
+	"log"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+	"github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+// syncFailed records why ssecret couldn't be unsealed in its Synced
+// condition, and returns err so that it is retried.
+func (c *Controller) syncFailed(ssecret *ssv1alpha1.SealedSecret, err error) error {
+	if uerr := c.updateResult(ssecret, ssv1alpha1.SealedSecretSynced, apiv1.ConditionFalse, c.syncFailureReason(ssecret, err), err.Error()); uerr != nil {
+		log.Printf("Error updating status of SealedSecret %s/%s: %v", ssecret.GetNamespace(), ssecret.GetName(), uerr)
+	}
+	return err
+}
+
+// namespaceTerminatingCause is the cause the API server gives when it
+// rejects an object created in a terminating namespace.
+const namespaceTerminatingCause = "NamespaceTerminating"
+
+// isNamespaceTerminating reports whether err was returned because the
+// namespace of the written object is being deleted.
+func isNamespaceTerminating(err error) bool {
+	if !errors.IsForbidden(err) {
+		return false
+	}
+	if status, ok := err.(errors.APIStatus); ok && status.Status().Details != nil {
+		for _, cause := range status.Status().Details.Causes {
+			if string(cause.Type) == namespaceTerminatingCause {
+				return true
+			}
+		}
+	}
+	// Older API servers only tell it in the message
+	return strings.Contains(err.Error(), "because it is being terminated")
+}
+
+// syncFailureReason classifies err, returned while unsealing ssecret,
+// into the reasons of the conditions.
+func (c *Controller) syncFailureReason(ssecret *ssv1alpha1.SealedSecret, err error) string {
+	if _, ok := err.(*compromisedKeyError); ok {
+		return ssv1alpha1.ReasonCompromisedKey
+	}
+	switch {
+	case err == seal.ErrNoKey:
+		if c.decryptsWithOtherScope(ssecret) {
+			return ssv1alpha1.ReasonWrongNamespace
+		}
+		return ssv1alpha1.ReasonNoMatchingKey
+	case isTemplateError(err):
+		return ssv1alpha1.ReasonTemplateFailed
+	case err == crypto.ErrTooShort, err == crypto.ErrTooLarge, err == crypto.ErrNotFIPSApproved, err == ssv1alpha1.ErrUnsupportedAlgorithm:
+		return ssv1alpha1.ReasonDecryptFailed
+	case isNamespaceTerminating(err):
+		return ssv1alpha1.ReasonNamespaceTerminating
+	case errors.IsForbidden(err) && strings.Contains(err.Error(), "exceeded quota"):
+		// Rejected by a ResourceQuota
+		return ssv1alpha1.ReasonQuota
+	case errors.IsForbidden(err):
+		return ssv1alpha1.ReasonForbidden
+	case errors.IsConflict(err), errors.IsAlreadyExists(err):
+		return ssv1alpha1.ReasonSecretConflict
+	default:
+		return ssv1alpha1.ReasonUnsealFailed
+	}
+}
+
+// decryptsWithOtherScope tells whether ssecret can be decrypted once
+// annotated with another scope than its own, ie. whether it was sealed
+// for another namespace or name than it is applied to.
+func (c *Controller) decryptsWithOtherScope(ssecret *ssv1alpha1.SealedSecret) bool {
+	keys, err := c.keysFor(ssecret.GetNamespace())
+	if err != nil {
+		return false
+	}
+	scope := seal.ScopeOf(ssecret)
+	for _, other := range []seal.Scope{seal.StrictScope, seal.NamespaceWideScope, seal.ClusterWideScope} {
+		if other == scope {
+			continue
+		}
+		rescoped := ssecret.DeepCopy()
+		annotations := map[string]string{}
+		for k, v := range rescoped.GetAnnotations() {
+			annotations[k] = v
+		}
+		delete(annotations, ssv1alpha1.SealedSecretClusterWideAnnotation)
+		delete(annotations, ssv1alpha1.SealedSecretNamespaceWideAnnotation)
+		switch other {
+		case seal.NamespaceWideScope:
+			annotations[ssv1alpha1.SealedSecretNamespaceWideAnnotation] = "true"
+		case seal.ClusterWideScope:
+			annotations[ssv1alpha1.SealedSecretClusterWideAnnotation] = "true"
+		}
+		rescoped.SetAnnotations(annotations)
+		if _, err := seal.Unseal(rescoped, keys); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+	"github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+func TestSyncFailureReason(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+	c := newTestController(t, ssecret)
+	c.keyRegistry = registry
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "mysecret",
+			Namespace:   "myns",
+			Annotations: map[string]string{ssv1alpha1.SealedSecretClusterWideAnnotation: "true"},
+		},
+		Data: map[string][]byte{"foo": []byte("bar")},
+	}
+	clusterWide, err := seal.Seal(secret, &registry.latestPrivateKey().PublicKey, seal.ClusterWideScope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Annotated as strict, sealed cluster-wide
+	clusterWide.SetAnnotations(nil)
+
+	gr := schema.GroupResource{Resource: "secrets"}
+	testCases := []struct {
+		ssecret *ssv1alpha1.SealedSecret
+		err     error
+		reason  string
+	}{
+		{ssecret, seal.ErrNoKey, ssv1alpha1.ReasonNoMatchingKey},
+		{clusterWide, seal.ErrNoKey, ssv1alpha1.ReasonWrongNamespace},
+		{ssecret, crypto.ErrTooShort, ssv1alpha1.ReasonDecryptFailed},
+		{ssecret, errors.NewForbidden(gr, "mysecret", fmt.Errorf("RBAC")), ssv1alpha1.ReasonForbidden},
+		{ssecret, errors.NewForbidden(gr, "mysecret", fmt.Errorf("exceeded quota: secrets")), ssv1alpha1.ReasonQuota},
+		{ssecret, errors.NewConflict(gr, "mysecret", fmt.Errorf("modified")), ssv1alpha1.ReasonSecretConflict},
+		{ssecret, &templateError{"dsn", fmt.Errorf("bad")}, ssv1alpha1.ReasonTemplateFailed},
+		{ssecret, fmt.Errorf("failed"), ssv1alpha1.ReasonUnsealFailed},
+	}
+	for _, tc := range testCases {
+		if got := c.syncFailureReason(tc.ssecret, tc.err); got != tc.reason {
+			t.Errorf("syncFailureReason(%v) = %q, expected %q", tc.err, got, tc.reason)
+		}
+	}
+}
+
+func TestUnsealSynced(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+	ssecret.Generation = 3
+
+	syncedCondition := func(c *Controller) *ssv1alpha1.SealedSecretCondition {
+		updated, err := c.ssclient.BitnamiV1alpha1().SealedSecrets("myns").Get("mysecret", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if updated.Status == nil || updated.Status.ObservedGeneration != 3 {
+			t.Errorf("Expected observed generation 3, got status %v", updated.Status)
+		}
+		return updated.GetCondition(ssv1alpha1.SealedSecretSynced)
+	}
+
+	c := newTestController(t, ssecret)
+	c.keyRegistry = registry
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	if cond := syncedCondition(c); cond == nil || cond.Status != v1.ConditionTrue || cond.Reason != ssv1alpha1.ReasonUnsealed {
+		t.Errorf("Expected Synced condition, got %v", cond)
+	}
+
+	c = newTestController(t, ssecret)
+	c.keyRegistry = testRegistry(t)
+	if err := c.unseal("myns/mysecret"); err != seal.ErrNoKey {
+		t.Fatalf("Expected ErrNoKey, got %v", err)
+	}
+	if cond := syncedCondition(c); cond == nil || cond.Status != v1.ConditionFalse || cond.Reason != ssv1alpha1.ReasonNoMatchingKey {
+		t.Errorf("Expected Synced condition with reason NoMatchingKey, got %v", cond)
+	}
+}
+
+func TestUnsealCompromisedKey(t *testing.T) {
+	registry := testKeys(t, "key1")
+	ssecret := testSealedSecret(t, registry)
+	if _, err := registry.blacklist("key1", "leaked"); err != nil {
+		t.Fatalf("blacklist() returned err: %v", err)
+	}
+
+	c := newTestController(t, ssecret)
+	c.keyRegistry = registry
+	events := record.NewFakeRecorder(10)
+	c.recorder = events
+	err := c.unseal("myns/mysecret")
+	if cerr, ok := err.(*compromisedKeyError); !ok || cerr.key != "key1" || cerr.reason != "leaked" {
+		t.Fatalf("Expected a compromisedKeyError, got %v", err)
+	}
+	if len(events.Events) != 1 {
+		t.Errorf("Expected a KeyBlacklisted event, got %d", len(events.Events))
+	} else if event := <-events.Events; event != "Warning KeyBlacklisted "+err.Error() {
+		t.Errorf("Expected a KeyBlacklisted event, got %q", event)
+	}
+	updated, err := c.ssclient.BitnamiV1alpha1().SealedSecrets("myns").Get("mysecret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cond := updated.GetCondition(ssv1alpha1.SealedSecretSynced); cond == nil || cond.Reason != ssv1alpha1.ReasonCompromisedKey {
+		t.Errorf("Expected Synced condition with reason CompromisedKey, got %v", cond)
+	}
+	if cond := updated.GetCondition(ssv1alpha1.SealedSecretSealedWithCompromisedKey); cond == nil || cond.Status != v1.ConditionTrue {
+		t.Errorf("Expected SealedWithCompromisedKey condition, got %v", cond)
+	}
+}
+
+func TestNamespaceTerminating(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+	c := newTestController(t, ssecret)
+	c.keyRegistry = registry
+
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "secrets", func(action ktesting.Action) (bool, runtime.Object, error) {
+		err := errors.NewForbidden(schema.GroupResource{Resource: "secrets"}, "mysecret", fmt.Errorf("unable to create new content in namespace myns because it is being terminated"))
+		return true, nil, err
+	})
+	c.sclient = client.Core()
+
+	key := queueKey{sealedSecretKind, "myns/mysecret"}
+	c.queue.Add(key)
+	if !c.processNextItem() {
+		t.Fatal("processNextItem() returned false")
+	}
+	if c.queue.Len() != 0 || c.queue.NumRequeues(key) != 0 {
+		t.Errorf("Expected %s not to be retried, got %d items queued, %d requeues", key, c.queue.Len(), c.queue.NumRequeues(key))
+	}
+
+	updated, err := c.ssclient.BitnamiV1alpha1().SealedSecrets("myns").Get("mysecret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cond := updated.GetCondition(ssv1alpha1.SealedSecretSynced); cond == nil || cond.Status != v1.ConditionFalse || cond.Reason != ssv1alpha1.ReasonNamespaceTerminating {
+		t.Errorf("Expected NamespaceTerminating condition, got %v", updated.Status)
+	}
+
+	cause := errors.NewForbidden(schema.GroupResource{Resource: "secrets"}, "mysecret", fmt.Errorf("terminating"))
+	cause.ErrStatus.Details.Causes = []metav1.StatusCause{{Type: namespaceTerminatingCause}}
+	if !isNamespaceTerminating(cause) {
+		t.Errorf("Expected the %s cause to be recognized", namespaceTerminatingCause)
+	}
+}
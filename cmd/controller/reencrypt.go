@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+
+	apiv1 "k8s.io/api/core/v1"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+// Results of the re-encryption of a SealedSecret.
+const (
+	reencryptResealed = "resealed"
+	reencryptCurrent  = "current"
+	reencryptFailed   = "failed"
+	reencryptDryRun   = "dry-run"
+)
+
+// reencryptProgress is a line of the /admin/reencrypt-all response,
+// about a single SealedSecret.
+type reencryptProgress struct {
+	Object string `json:"object"`
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+// reencryptSummary is the last line of the /admin/reencrypt-all
+// response.
+type reencryptSummary struct {
+	Done  bool `json:"done"`
+	Total int  `json:"total"`
+	// Current were already sealed with the latest key.
+	Current int `json:"current"`
+	// Resealed have been sealed again with the latest key. Their
+	// manifests must still be updated wherever they are stored.
+	Resealed []string `json:"resealed"`
+	// Failed couldn't be decrypted or updated.
+	Failed []string `json:"failed"`
+}
+
+// Called on every request to /admin/reencrypt-all, see
+// Controller.ReencryptAll.
+type reencrypter func(progress func(reencryptProgress)) *reencryptSummary
+
+// ReencryptAll reseals with the latest key every SealedSecret sealed
+// with an older one, calling progress after each of them, so that the
+// older keys can be retired.
+func (c *Controller) ReencryptAll(progress func(reencryptProgress)) *reencryptSummary {
+	summary := &reencryptSummary{Done: true, Resealed: []string{}, Failed: []string{}}
+
+	var ssecrets []*ssv1alpha1.SealedSecret
+	for _, obj := range c.informer.GetIndexer().List() {
+		ssecrets = append(ssecrets, obj.(*ssv1alpha1.SealedSecret))
+	}
+	sort.Slice(ssecrets, func(i, j int) bool {
+		return ssecrets[i].GetNamespace()+"/"+ssecrets[i].GetName() < ssecrets[j].GetNamespace()+"/"+ssecrets[j].GetName()
+	})
+
+	for _, ssecret := range ssecrets {
+		p := c.reencrypt(ssecret)
+		summary.Total++
+		switch p.Result {
+		case reencryptCurrent:
+			summary.Current++
+		case reencryptFailed:
+			log.Printf("Failed to re-encrypt SealedSecret %s: %s", p.Object, p.Error)
+			summary.Failed = append(summary.Failed, p.Object)
+		default:
+			summary.Resealed = append(summary.Resealed, p.Object)
+		}
+		progress(p)
+	}
+	return summary
+}
+
+// reencrypt reseals ssecret with the latest key, unless it is already
+// sealed with it.
+func (c *Controller) reencrypt(ssecret *ssv1alpha1.SealedSecret) reencryptProgress {
+	p := reencryptProgress{Object: fmt.Sprintf("%s/%s", ssecret.GetNamespace(), ssecret.GetName())}
+	fail := func(err error) reencryptProgress {
+		p.Result = reencryptFailed
+		p.Error = err.Error()
+		return p
+	}
+
+	keys, err := c.keysFor(ssecret.GetNamespace())
+	if err != nil {
+		return fail(err)
+	}
+	secret, privKey, err := c.attemptUnsealWithKey(ssecret)
+	if err != nil {
+		return fail(err)
+	}
+	// No private key is returned when unsealed by the external key
+	current := privKey == keys.latestPrivateKey()
+	if keys.external != nil {
+		current = privKey == nil
+	}
+	if current {
+		p.Result = reencryptCurrent
+		return p
+	}
+	if c.readOnly() {
+		log.Printf("Dry run: SealedSecret %s would be resealed with the latest key", p.Object)
+		p.Result = reencryptDryRun
+		return p
+	}
+	if err := c.updateSealedWith(ssecret, secret); err != nil {
+		return fail(err)
+	}
+	log.Printf("Resealed SealedSecret %s with the latest key", p.Object)
+	p.Result = reencryptResealed
+	return p
+}
+
+// updateSealedWith replaces the encrypted data of ssecret with secret,
+// sealed with the latest key.
+func (c *Controller) updateSealedWith(ssecret *ssv1alpha1.SealedSecret, secret *apiv1.Secret) error {
+	resealed, err := c.reseal(ssecret, secret)
+	if err != nil {
+		return err
+	}
+	updated := ssecret.DeepCopy()
+	updated.Spec.Data = resealed.Spec.Data
+	updated.Spec.EncryptedData = resealed.Spec.EncryptedData
+	_, err = c.ssclient.BitnamiV1alpha1().SealedSecrets(updated.GetNamespace()).Update(updated)
+	return err
+}
+
+// reencryptHandler serves /admin/reencrypt-all, streaming a JSON line
+// per SealedSecret and the summary last.
+func reencryptHandler(re reencrypter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		flusher, _ := w.(http.Flusher)
+		enc := json.NewEncoder(w)
+		summary := re(func(p reencryptProgress) {
+			enc.Encode(p)
+			if flusher != nil {
+				flusher.Flush()
+			}
+		})
+		enc.Encode(summary)
+
+		auditLog.record(auditEvent{
+			Operation: "reencrypt-all",
+			Caller:    httpCaller(r),
+			Result:    fmt.Sprintf("%d resealed, %d failed", len(summary.Resealed), len(summary.Failed)),
+		})
+	})
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	"github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+func TestReencryptAll(t *testing.T) {
+	registry := testKeys(t, "key1")
+	old := testSealedSecret(t, registry)
+	if _, err := registry.generateKey(); err != nil {
+		t.Fatal(err)
+	}
+	sealAs := func(name string, kr *KeyRegistry) *ssv1alpha1.SealedSecret {
+		secret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "myns"},
+			Data:       map[string][]byte{"foo": []byte("bar")},
+		}
+		ssecret, err := seal.Seal(secret, &kr.latestPrivateKey().PublicKey, seal.StrictScope)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ssecret
+	}
+	current := sealAs("current", registry)
+	lost := sealAs("lost", testKeys(t, "other"))
+
+	c := newTestController(t, old)
+	c.keyRegistry = registry
+	for _, ssecret := range []*ssv1alpha1.SealedSecret{current, lost} {
+		if _, err := c.ssclient.BitnamiV1alpha1().SealedSecrets("myns").Create(ssecret); err != nil {
+			t.Fatal(err)
+		}
+		c.informer.GetIndexer().Add(ssecret)
+	}
+
+	var progress []reencryptProgress
+	summary := c.ReencryptAll(func(p reencryptProgress) {
+		p.Error = ""
+		progress = append(progress, p)
+	})
+
+	expected := []reencryptProgress{
+		{Object: "myns/current", Result: reencryptCurrent},
+		{Object: "myns/lost", Result: reencryptFailed},
+		{Object: "myns/mysecret", Result: reencryptResealed},
+	}
+	if !reflect.DeepEqual(progress, expected) {
+		t.Errorf("Got progress %+v, expected %+v", progress, expected)
+	}
+	if summary.Total != 3 || summary.Current != 1 || !reflect.DeepEqual(summary.Resealed, []string{"myns/mysecret"}) || !reflect.DeepEqual(summary.Failed, []string{"myns/lost"}) {
+		t.Errorf("Unexpected summary %+v", summary)
+	}
+
+	updated, err := c.ssclient.BitnamiV1alpha1().SealedSecrets("myns").Get("mysecret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, err := seal.Unseal(updated, seal.PrivateKeys{registry.latestPrivateKey()})
+	if err != nil {
+		t.Fatalf("Resealed SealedSecret can't be unsealed with the latest key: %v", err)
+	}
+	if string(secret.Data["foo"]) != "bar" {
+		t.Errorf("Unexpected data %v", secret.Data)
+	}
+}
+
+func TestReencryptHandler(t *testing.T) {
+	re := func(progress func(reencryptProgress)) *reencryptSummary {
+		progress(reencryptProgress{Object: "myns/mysecret", Result: reencryptResealed})
+		return &reencryptSummary{Done: true, Total: 1, Resealed: []string{"myns/mysecret"}, Failed: []string{}}
+	}
+	handler := reencryptHandler(re)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/admin/reencrypt-all", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: got status %d, expected %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", "/admin/reencrypt-all", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST: got status %d, expected %d", rec.Code, http.StatusOK)
+	}
+	scanner := bufio.NewScanner(rec.Body)
+	var lines []map[string]interface{}
+	for scanner.Scan() {
+		var line map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != 2 || lines[0]["object"] != "myns/mysecret" || lines[1]["done"] != true {
+		t.Errorf("Unexpected response %v", lines)
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	apiv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+const (
+	// SealedSecretsReplicaOfLabel identifies the replicas of the Secret
+	// of a SealedSecret, by a hash of its namespace/name.
+	SealedSecretsReplicaOfLabel = "sealedsecrets.bitnami.com/replica-of"
+	// SealedSecretsReplicaSourceAnnotation holds the namespace/name of
+	// the SealedSecret of a replica.
+	SealedSecretsReplicaSourceAnnotation = "sealedsecrets.bitnami.com/replica-source"
+)
+
+var errTargetsNotClusterWide = errors.New("only cluster-wide SealedSecrets can have targets")
+
+func checkTargets(ssecret *ssv1alpha1.SealedSecret) error {
+	if ssecret.Spec.Targets != nil && ssecret.GetAnnotations()[ssv1alpha1.SealedSecretClusterWideAnnotation] != "true" {
+		return errTargetsNotClusterWide
+	}
+	return nil
+}
+
+// replicaLabel returns the value of the replica-of label for the
+// SealedSecret key, which may be too long for a label value.
+func replicaLabel(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:16])
+}
+
+// targetNamespaces returns the namespaces, other than its own, the
+// Secret of ssecret is replicated to.
+func (c *Controller) targetNamespaces(ssecret *ssv1alpha1.SealedSecret) ([]string, error) {
+	targets := ssecret.Spec.Targets
+	if targets == nil {
+		return nil, nil
+	}
+	selected := map[string]bool{}
+	for _, ns := range targets.Namespaces {
+		selected[ns] = true
+	}
+	if targets.NamespaceSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(targets.NamespaceSelector)
+		if err != nil {
+			return nil, err
+		}
+		list, err := c.nsclient.Namespaces().List(metav1.ListOptions{LabelSelector: selector.String()})
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range list.Items {
+			selected[ns.GetName()] = true
+		}
+	}
+	delete(selected, ssecret.GetNamespace())
+
+	var namespaces []string
+	for ns := range selected {
+		// Targets must opt in like any other namespace
+		enabled, err := c.namespaceEnabled(ns)
+		if err != nil {
+			return nil, err
+		}
+		if enabled {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces, nil
+}
+
+// newReplica returns the copy of secret, unsealed from ssecret, written
+// to ns.
+func newReplica(ssecret *ssv1alpha1.SealedSecret, secret *apiv1.Secret, ns string) *apiv1.Secret {
+	key := fmt.Sprintf("%s/%s", ssecret.GetNamespace(), ssecret.GetName())
+	replica := &apiv1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        secret.GetName(),
+			Namespace:   ns,
+			Labels:      copyStrings(secret.GetLabels()),
+			Annotations: copyStrings(secret.GetAnnotations()),
+		},
+		Type: secret.Type,
+		Data: secret.Data,
+	}
+	// Owner references can't cross namespaces
+	detach(replica, string(ssecret.GetUID()))
+	replica.Labels[SealedSecretsReplicaOfLabel] = replicaLabel(key)
+	replica.Annotations[SealedSecretsReplicaSourceAnnotation] = key
+	return replica
+}
+
+// writeReplica creates or updates replica. It returns false if its
+// namespace doesn't exist.
+func (c *Controller) writeReplica(replica *apiv1.Secret) (bool, error) {
+	secrets := c.sclient.Secrets(replica.GetNamespace())
+	_, err := secrets.Create(replica)
+	switch {
+	case err == nil:
+		return true, nil
+	case k8serrors.IsNotFound(err):
+		log.Printf("Namespace %s doesn't exist, not replicating %s to it", replica.GetNamespace(), replica.Annotations[SealedSecretsReplicaSourceAnnotation])
+		return false, nil
+	case !k8serrors.IsAlreadyExists(err):
+		return false, err
+	}
+
+	existing, err := secrets.Get(replica.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+	if existing.GetLabels()[SealedSecretsReplicaOfLabel] != replica.Labels[SealedSecretsReplicaOfLabel] {
+		return false, fmt.Errorf("Secret %s/%s exists and isn't a replica of %s", replica.GetNamespace(), replica.GetName(), replica.Annotations[SealedSecretsReplicaSourceAnnotation])
+	}
+	replica.SetResourceVersion(existing.GetResourceVersion())
+	_, err = secrets.Update(replica)
+	return err == nil, err
+}
+
+// deleteReplicas deletes the replicas of the Secret of the SealedSecret
+// key, but those of the namespaces of keep.
+func (c *Controller) deleteReplicas(key string, keep []string) error {
+	list, err := c.sclient.Secrets(metav1.NamespaceAll).List(metav1.ListOptions{LabelSelector: SealedSecretsReplicaOfLabel + "=" + replicaLabel(key)})
+	if err != nil {
+		return err
+	}
+	kept := map[string]bool{}
+	for _, ns := range keep {
+		kept[ns] = true
+	}
+	for _, replica := range list.Items {
+		if kept[replica.GetNamespace()] {
+			continue
+		}
+		log.Printf("Deleting replica %s/%s of %s", replica.GetNamespace(), replica.GetName(), key)
+		err := c.sclient.Secrets(replica.GetNamespace()).Delete(replica.GetName(), &metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// replicate writes secret, unsealed from ssecret, to its target
+// namespaces, deletes the replicas of the namespaces no longer
+// targeted, and records the replicas in the status of ssecret.
+func (c *Controller) replicate(ssecret *ssv1alpha1.SealedSecret, secret *apiv1.Secret) error {
+	if ssecret.Spec.Targets == nil && (ssecret.Status == nil || len(ssecret.Status.ReplicaNamespaces) == 0) {
+		return nil
+	}
+	namespaces, err := c.targetNamespaces(ssecret)
+	if err != nil {
+		return err
+	}
+
+	var replicated []string
+	for _, ns := range namespaces {
+		ok, err := c.writeReplica(newReplica(ssecret, secret, ns))
+		if err != nil {
+			return err
+		}
+		if ok {
+			replicated = append(replicated, ns)
+		}
+	}
+	if err := c.deleteReplicas(fmt.Sprintf("%s/%s", ssecret.GetNamespace(), ssecret.GetName()), replicated); err != nil {
+		return err
+	}
+
+	_, err = c.updateStatus(ssecret, func(s *ssv1alpha1.SealedSecret) bool {
+		return s.SetReplicaNamespaces(replicated)
+	})
+	return err
+}
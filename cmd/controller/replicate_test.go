@@ -0,0 +1,111 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	"github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+func TestUnsealTargets(t *testing.T) {
+	registry := testRegistry(t)
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "pull", Namespace: "myns"},
+		Data:       map[string][]byte{"foo": []byte("bar")},
+	}
+	ssecret, err := seal.Seal(secret, &registry.latestPrivateKey().PublicKey, seal.ClusterWideScope)
+	if err != nil {
+		t.Fatalf("Seal() returned err: %v", err)
+	}
+	ssecret.UID = "uid"
+	ssecret.Spec.Targets = &ssv1alpha1.SealedSecretTargets{
+		Namespaces: []string{"a", "myns"},
+		NamespaceSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"team": "x"},
+		},
+	}
+
+	teamX := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "b", Labels: map[string]string{"team": "x"}}}
+	teamY := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "c", Labels: map[string]string{"team": "y"}}}
+	c := newTestController(t, ssecret, teamX, teamY)
+	c.keyRegistry = registry
+
+	if err := c.unseal("myns/pull"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	for _, ns := range []string{"myns", "a", "b"} {
+		replica, err := c.sclient.Secrets(ns).Get("pull", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Expected Secret in %s: %v", ns, err)
+		}
+		if got := string(replica.Data["foo"]); got != "bar" {
+			t.Errorf("Expected data %q in %s, got %q", "bar", ns, got)
+		}
+	}
+	if _, err := c.sclient.Secrets("c").Get("pull", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected no Secret in c, got err %v", err)
+	}
+	updated, err := c.ssclient.BitnamiV1alpha1().SealedSecrets("myns").Get("pull", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"a", "b"}; updated.Status == nil || !reflect.DeepEqual(updated.Status.ReplicaNamespaces, expected) {
+		t.Errorf("Expected replicas %v, got status %v", expected, updated.Status)
+	}
+
+	// Targets removed
+	updated.Spec.Targets = &ssv1alpha1.SealedSecretTargets{Namespaces: []string{"a"}}
+	c.informer.GetIndexer().Update(updated)
+	if err := c.unseal("myns/pull"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	if _, err := c.sclient.Secrets("b").Get("pull", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected replica in b to be deleted, got err %v", err)
+	}
+	if _, err := c.sclient.Secrets("a").Get("pull", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected replica in a to be kept, got err %v", err)
+	}
+
+	// SealedSecret deleted
+	c.informer.GetIndexer().Delete(updated)
+	if err := c.ssclient.BitnamiV1alpha1().SealedSecrets("myns").Delete("pull", &metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.unseal("myns/pull"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	if _, err := c.sclient.Secrets("a").Get("pull", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected replica in a to be deleted, got err %v", err)
+	}
+}
+
+func TestUnsealTargetsConflict(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+	ssecret.Spec.Targets = &ssv1alpha1.SealedSecretTargets{Namespaces: []string{"a"}}
+
+	c := newTestController(t, ssecret)
+	c.keyRegistry = registry
+
+	// Strict scope
+	if err := c.unseal("myns/mysecret"); err == nil {
+		t.Fatal("Expected unseal() to fail")
+	}
+
+	if err := checkTargets(ssecret); err != errTargetsNotClusterWide {
+		t.Errorf("Expected %v, got %v", errTargetsNotClusterWide, err)
+	}
+
+	existing := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "a"}}
+	if _, err := c.sclient.Secrets("a").Create(existing); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.writeReplica(newReplica(ssecret, existing, "a")); err == nil {
+		t.Error("Expected writeReplica() to refuse overwriting a Secret")
+	}
+}
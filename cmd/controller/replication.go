@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	flag "github.com/spf13/pflag"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// replicationKubeconfigKey is the key of the kubeconfig in the Secrets
+// of --replicate-to.
+const replicationKubeconfigKey = "kubeconfig"
+
+var (
+	replicateTo     = flag.StringSlice("replicate-to", nil, "Secrets, in the controller namespace, holding the kubeconfig of follower clusters to replicate the keys to, under the "+replicationKubeconfigKey+" key.")
+	replicatePeriod = flag.Duration("replicate-period", time.Minute, "How often the keys are replicated to the follower clusters.")
+)
+
+// follower is a cluster the keys are replicated to.
+type follower struct {
+	name   string
+	client kubernetes.Interface
+}
+
+// loadFollowers builds the clients of the follower clusters from the
+// kubeconfigs stored in the Secrets names of namespace.
+func loadFollowers(client kubernetes.Interface, namespace string, names []string) ([]follower, error) {
+	var followers []follower
+	for _, name := range names {
+		secret, err := client.Core().Secrets(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		config, err := clientcmd.RESTConfigFromKubeConfig(secret.Data[replicationKubeconfigKey])
+		if err != nil {
+			return nil, fmt.Errorf("invalid kubeconfig in Secret %s/%s: %v", namespace, name, err)
+		}
+		fclient, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		followers = append(followers, follower{name: name, client: fclient})
+	}
+	return followers, nil
+}
+
+// keyReplicator copies the key Secrets of a namespace to the same
+// namespace of the follower clusters, whose controllers can then
+// decrypt the same SealedSecrets, eg. started with --standby.
+type keyReplicator struct {
+	client    kubernetes.Interface
+	namespace string
+	followers []follower
+}
+
+// replicate copies the cluster-wide and per-namespace keys, including
+// their blacklist status, to every follower. The followers are all
+// tried, the last error is returned.
+func (r *keyReplicator) replicate() error {
+	secretList, err := r.client.Core().Secrets(r.namespace).List(metav1.ListOptions{
+		LabelSelector: SealedSecretsKeyLabel,
+	})
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, f := range r.followers {
+		for i := range secretList.Items {
+			if err := r.replicateKey(f, &secretList.Items[i]); err != nil {
+				log.Printf("Error replicating key %s to %s: %v", secretList.Items[i].Name, f.name, err)
+				lastErr = err
+			}
+		}
+	}
+	return lastErr
+}
+
+func (r *keyReplicator) replicateKey(f follower, key *apiv1.Secret) error {
+	secrets := f.client.Core().Secrets(r.namespace)
+	existing, err := secrets.Get(key.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		replica := &apiv1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        key.Name,
+				Namespace:   r.namespace,
+				Labels:      key.Labels,
+				Annotations: key.Annotations,
+			},
+			Type: key.Type,
+			Data: key.Data,
+		}
+		if _, err := secrets.Create(replica); err != nil {
+			return err
+		}
+		log.Printf("Replicated key %s to %s", key.Name, f.name)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if equalStringMaps(existing.Labels, key.Labels) && equalStringMaps(existing.Annotations, key.Annotations) {
+		return nil
+	}
+	updated := existing.DeepCopy()
+	updated.Labels = key.Labels
+	updated.Annotations = key.Annotations
+	_, err = secrets.Update(updated)
+	return err
+}
+
+// run replicates the keys every period.
+func (r *keyReplicator) run(period time.Duration) {
+	for {
+		r.replicate()
+		time.Sleep(period)
+	}
+}
+
+func equalStringMaps(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || v != w {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestReplicate(t *testing.T) {
+	key := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "key1",
+			Namespace: "kube-system",
+			Labels:    map[string]string{SealedSecretsKeyLabel: "active"},
+		},
+		Type: v1.SecretTypeTLS,
+		Data: map[string][]byte{v1.TLSPrivateKeyKey: []byte("private"), v1.TLSCertKey: []byte("cert")},
+	}
+	other := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "kube-system"}}
+	primary := fake.NewSimpleClientset(key, other)
+	followers := []follower{
+		{name: "eu", client: fake.NewSimpleClientset()},
+		{name: "us", client: fake.NewSimpleClientset()},
+	}
+	r := &keyReplicator{client: primary, namespace: "kube-system", followers: followers}
+
+	if err := r.replicate(); err != nil {
+		t.Fatalf("replicate() returned err: %v", err)
+	}
+	for _, f := range followers {
+		replica, err := f.client.Core().Secrets("kube-system").Get("key1", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Expected key to be replicated to %s, got %v", f.name, err)
+		}
+		if string(replica.Data[v1.TLSPrivateKeyKey]) != "private" || replica.Labels[SealedSecretsKeyLabel] != "active" {
+			t.Errorf("Unexpected replica in %s: %v", f.name, replica)
+		}
+		if _, err := f.client.Core().Secrets("kube-system").Get("other", metav1.GetOptions{}); err == nil {
+			t.Errorf("Expected only keys to be replicated to %s", f.name)
+		}
+	}
+
+	// Blacklisting is replicated
+	blacklisted := key.DeepCopy()
+	blacklisted.Labels[SealedSecretsKeyLabel] = compromised
+	if _, err := primary.Core().Secrets("kube-system").Update(blacklisted); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.replicate(); err != nil {
+		t.Fatalf("replicate() returned err: %v", err)
+	}
+	replica, err := followers[0].client.Core().Secrets("kube-system").Get("key1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replica.Labels[SealedSecretsKeyLabel] != compromised {
+		t.Errorf("Expected blacklisting to be replicated, got %v", replica.Labels)
+	}
+}
+
+func TestLoadFollowersInvalid(t *testing.T) {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "eu", Namespace: "kube-system"},
+		Data:       map[string][]byte{replicationKubeconfigKey: []byte("not a kubeconfig")},
+	}
+	client := fake.NewSimpleClientset(secret)
+	if _, err := loadFollowers(client, "kube-system", []string{"eu"}); err == nil {
+		t.Errorf("Expected invalid kubeconfig to be rejected")
+	}
+	if _, err := loadFollowers(client, "kube-system", []string{"missing"}); err == nil {
+		t.Errorf("Expected missing Secret to be rejected")
+	}
+}
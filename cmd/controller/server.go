@@ -1,31 +1,73 @@
 package main
 
 import (
+	"crypto/tls"
 	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	flag "github.com/spf13/pflag"
 	"github.com/throttled/throttled"
 	"github.com/throttled/throttled/store/memstore"
-	certUtil "k8s.io/client-go/util/cert"
 )
 
 var (
 	listenAddr   = flag.String("listen-addr", ":8080", "HTTP serving address.")
 	readTimeout  = flag.Duration("read-timeout", 2*time.Minute, "HTTP request timeout.")
 	writeTimeout = flag.Duration("write-timeout", 2*time.Minute, "HTTP response timeout.")
+	sealEndpoint = flag.Bool("enable-seal-endpoint", false, "Serve /v1/seal, sealing Secrets on behalf of clients authorized to create SealedSecrets.")
+	tlsCert      = flag.String("tls-cert", "", "Certificate file for serving HTTP over TLS.")
+	tlsKey       = flag.String("tls-key", "", "Private key file for serving HTTP over TLS.")
+	tlsClientCA  = flag.String("tls-client-ca", "", "CA bundle used to verify the HTTP client certificates, eg. the SPIFFE trust bundle. The clients without certificate are still served.")
 )
 
 // Called on every request to /cert.  Errors will be logged and return a 500.
 type certProvider func() []*x509.Certificate
+
+// namespaceCertProvider returns the certificates of the keys of a
+// namespace, see --per-namespace-keys.
+type namespaceCertProvider func(namespace string) ([]*x509.Certificate, error)
 type secretChecker func([]byte) (bool, error)
 type secretRotator func([]byte) ([]byte, error)
+type secretSealer func([]byte) ([]byte, error)
+type keyBlacklister func(name, reason string) (*resealReport, error)
+type keyDumper func() ([]keyInfo, error)
+type promoter func() error
+
+// Called on every request to /admin/keys/rotate, requests a new key
+// like SIGUSR1.
+type keyRotator func()
+
+// serverHandlers are the operations served by httpserver and
+// grpcserver. The endpoints of the optional operations are only served
+// when their authorizer, or namespaceCerts, is set.
+type serverHandlers struct {
+	certs          certProvider
+	namespaceCerts namespaceCertProvider
+	check          secretChecker
+	rotate         secretRotator
+	seal           secretSealer
+	updateItems    itemsUpdater
+	blacklistKey   keyBlacklister
+	dumpKeys       keyDumper
+	keyImpact      keyImpactReporter
+	rotateKey      keyRotator
+	promote        promoter
+	reencryptAll   reencrypter
+
+	sealAuth  sealAuthorizer
+	itemsAuth sealAuthorizer
+	adminAuth adminAuthorizer
+}
 
-func httpserver(cp certProvider, sc secretChecker, sr secretRotator) {
+func httpserver(h serverHandlers) {
 	httpRateLimiter := rateLimter()
 
 	mux := http.NewServeMux()
@@ -35,16 +77,129 @@ func httpserver(cp certProvider, sc secretChecker, sr secretRotator) {
 		io.WriteString(w, "ok\n")
 	})
 
-	mux.Handle("/v1/verify", httpRateLimiter.RateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	mux.Handle("/metrics", metricsHandler())
+
+	mux.Handle("/v1/verify", httpRateLimiter.RateLimit(verifyHandler(h.check)))
+	mux.Handle("/v2/verify", v2Handler(httpRateLimiter.RateLimit(verifyHandler(h.check)), map[int]string{http.StatusConflict: "InvalidSealedSecret"}))
+
+	mux.Handle("/v1/rotate", identityHandler(gzipHandler(rotateHandler(h.rotate))))
+	mux.Handle("/v2/rotate", identityHandler(gzipHandler(v2Handler(rotateHandler(h.rotate), nil))))
+
+	if h.sealAuth != nil {
+		mux.Handle("/v1/seal", identityHandler(httpRateLimiter.RateLimit(sealHandler(h.seal, h.sealAuth))))
+		mux.Handle("/v2/seal", identityHandler(v2Handler(httpRateLimiter.RateLimit(sealHandler(h.seal, h.sealAuth)), nil)))
+	}
+
+	if h.itemsAuth != nil {
+		mux.Handle("/v1/items", httpRateLimiter.RateLimit(itemsHandler(h.updateItems, h.itemsAuth)))
+		mux.Handle("/v2/items", v2Handler(httpRateLimiter.RateLimit(itemsHandler(h.updateItems, h.itemsAuth)), nil))
+	}
+
+	certs := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeCerts(w, r, h.certs())
+	})
+	mux.Handle("/v1/cert.pem", gzipHandler(certs))
+	mux.Handle("/v2/cert.pem", gzipHandler(v2Handler(certs, nil)))
+
+	if h.namespaceCerts != nil {
+		mux.Handle("/v1/namespaces/", httpRateLimiter.RateLimit(gzipHandler(namespaceCertHandler(h.namespaceCerts))))
+		mux.Handle("/v2/namespaces/", gzipHandler(v2Handler(httpRateLimiter.RateLimit(namespaceCertHandler(h.namespaceCerts)), nil)))
+	}
+
+	if h.adminAuth != nil {
+		mux.Handle("/admin/keys", httpRateLimiter.RateLimit(adminHandler(h.adminAuth, keysHandler(h.dumpKeys))))
+		mux.Handle("/admin/keys/blacklist", httpRateLimiter.RateLimit(adminHandler(h.adminAuth, blacklistHandler(h.blacklistKey))))
+		mux.Handle("/admin/keys/impact", httpRateLimiter.RateLimit(adminHandler(h.adminAuth, keyImpactHandler(h.keyImpact))))
+		mux.Handle("/admin/keys/rotate", httpRateLimiter.RateLimit(adminHandler(h.adminAuth, keyRotateHandler(h.rotateKey))))
+		mux.Handle("/admin/promote", httpRateLimiter.RateLimit(adminHandler(h.adminAuth, promoteHandler(h.promote))))
+		mux.Handle("/admin/reencrypt-all", httpRateLimiter.RateLimit(adminHandler(h.adminAuth, reencryptHandler(h.reencryptAll))))
+	}
+
+	mux.Handle("/openapi.json", openAPIHandler(apiOperations(h.sealAuth != nil, h.itemsAuth != nil, h.namespaceCerts != nil, h.adminAuth != nil)))
+
+	config, err := httpTLSConfig()
+	if err != nil {
+		log.Printf("HTTP server not started: %v", err)
+		return
+	}
+	server := http.Server{
+		Addr:         *listenAddr,
+		Handler:      mux,
+		ReadTimeout:  *readTimeout,
+		WriteTimeout: *writeTimeout,
+		TLSConfig:    config,
+	}
+
+	log.Printf("HTTP server serving on %s", server.Addr)
+	if config != nil {
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+	log.Printf("HTTP server exiting: %v", err)
+}
+
+// serverTLSConfig returns the TLS configuration serving certFile and
+// keyFile, verifying the client certificates with clientCAFile if set.
+func serverTLSConfig(certFile, keyFile, clientCAFile string, clientAuth tls.ClientAuthType) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if clientCAFile != "" {
+		pem, err := ioutil.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", clientCAFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = clientAuth
+	}
+	return config, nil
+}
+
+// httpTLSConfig returns the TLS configuration selected by the --tls-*
+// flags, nil to serve plain HTTP.
+func httpTLSConfig() (*tls.Config, error) {
+	if *tlsCert == "" && *tlsKey == "" {
+		if *tlsClientCA != "" {
+			return nil, errors.New("--tls-client-ca requires --tls-cert and --tls-key")
+		}
+		return nil, nil
+	}
+	// The certificates are public, only the endpoints restricted to
+	// --allowed-client-identities require a client certificate
+	return serverTLSConfig(*tlsCert, *tlsKey, *tlsClientCA, tls.VerifyClientCertIfGiven)
+}
+
+func verifyHandler(sc secretChecker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		content, err := ioutil.ReadAll(r.Body)
 
 		if err != nil {
-			log.Printf("Error handling /v1/verify request: %v", err)
+			log.Printf("Error handling verify request: %v", err)
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
 
 		valid, err := sc(content)
+		result := "valid"
+		if !valid {
+			result = "invalid"
+		}
+		auditLog.record(auditResult(auditEvent{
+			Operation: "verify",
+			Object:    sealedSecretID(content),
+			Caller:    httpCaller(r),
+			Identity:  httpIdentity(r),
+		}, result, err))
 
 		if err != nil {
 			log.Printf("Error validating secret: %v", err)
@@ -57,18 +212,26 @@ func httpserver(cp certProvider, sc secretChecker, sr secretRotator) {
 		} else {
 			w.WriteHeader(http.StatusConflict)
 		}
-	})))
+	})
+}
 
-	mux.HandleFunc("/v1/rotate", func(w http.ResponseWriter, r *http.Request) {
+func rotateHandler(sr secretRotator) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		content, err := ioutil.ReadAll(r.Body)
 
 		if err != nil {
-			log.Printf("Error handling /v1/rotate request: %v", err)
+			log.Printf("Error handling rotate request: %v", err)
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
 
 		newSecret, err := sr(content)
+		auditLog.record(auditResult(auditEvent{
+			Operation: "rotate",
+			Object:    sealedSecretID(content),
+			Caller:    httpCaller(r),
+			Identity:  httpIdentity(r),
+		}, "success", err))
 
 		if err != nil {
 			log.Printf("Error rotating secret: %v", err)
@@ -76,29 +239,170 @@ func httpserver(cp certProvider, sc secretChecker, sr secretRotator) {
 			return
 		}
 
-		w.WriteHeader(http.StatusOK)
+		writeObject(w, r, newSecret)
+	})
+}
+
+func sealHandler(ss secretSealer, sa sealAuthorizer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		token := bearerToken(r)
+		if token == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		content, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			log.Printf("Error handling /v1/seal request: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		namespace, err := secretNamespace(content)
+		if err != nil {
+			log.Printf("Error handling /v1/seal request: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		allowed, err := sa(token, namespace)
+		if err != nil {
+			log.Printf("Error authorizing /v1/seal request: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		sealedSecret, err := ss(content)
+		auditLog.record(auditResult(auditEvent{
+			Operation: "seal",
+			Object:    secretID(content),
+			Caller:    httpCaller(r),
+			Identity:  httpIdentity(r),
+		}, "success", err))
+		if err != nil {
+			log.Printf("Error sealing secret: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
 		w.Header().Set("Content-Type", "application/json")
-		w.Write(newSecret)
+		w.WriteHeader(http.StatusOK)
+		w.Write(sealedSecret)
 	})
+}
 
-	mux.HandleFunc("/v1/cert.pem", func(w http.ResponseWriter, r *http.Request) {
-		certs := cp()
-		w.Header().Set("Content-Type", "application/x-pem-file")
-		for _, cert := range certs {
-			w.Write(certUtil.EncodeCertPEM(cert))
+// namespaceCertHandler serves /<version>/namespaces/<namespace>/cert.pem.
+func namespaceCertHandler(ncp namespaceCertProvider) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		if len(parts) != 4 || parts[1] != "namespaces" || parts[2] == "" || parts[3] != "cert.pem" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		certs, err := ncp(parts[2])
+		if err != nil {
+			log.Printf("Error fetching certificate of namespace %s: %v", parts[2], err)
+			w.WriteHeader(http.StatusNotFound)
+			return
 		}
+		writeCerts(w, r, certs)
 	})
+}
 
-	server := http.Server{
-		Addr:         *listenAddr,
-		Handler:      mux,
-		ReadTimeout:  *readTimeout,
-		WriteTimeout: *writeTimeout,
-	}
+// adminHandler only lets the requests authorized by aa through to h.
+func adminHandler(aa adminAuthorizer, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := bearerToken(r)
+		if token == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		allowed, err := aa(token)
+		if err != nil {
+			log.Printf("Error authorizing %s request: %v", r.URL.Path, err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
 
-	log.Printf("HTTP server serving on %s", server.Addr)
-	err := server.ListenAndServe()
-	log.Printf("HTTP server exiting: %v", err)
+// blacklistRequest is the body of /admin/keys/blacklist requests.
+type blacklistRequest struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+func blacklistHandler(kb keyBlacklister) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req blacklistRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" || req.Reason == "" {
+			log.Printf("Error handling /admin/keys/blacklist request: name and reason are required (%v)", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		report, err := kb(req.Name, req.Reason)
+		switch err {
+		case nil:
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(report)
+		case errKeyNotFound:
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			log.Printf("Error blacklisting key %s: %v", req.Name, err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+}
+
+// promoteHandler serves /admin/promote, see Controller.Promote.
+func promoteHandler(pr promoter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if err := pr(); err != nil {
+			log.Printf("Error promoting controller: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// keyRotateHandler serves /admin/keys/rotate. The new key is generated
+// asynchronously, as with SIGUSR1.
+func keyRotateHandler(kg keyRotator) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		log.Printf("New key requested through /admin/keys/rotate")
+		kg()
+		w.WriteHeader(http.StatusAccepted)
+	})
 }
 
 func rateLimter() throttled.HTTPRateLimiter {
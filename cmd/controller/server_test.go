@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSealHandler(t *testing.T) {
+	secret := `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"mysecret","namespace":"myns"},"data":{"foo":"YmFy"}}`
+
+	ss := func(content []byte) ([]byte, error) {
+		return []byte(`{"kind":"SealedSecret"}`), nil
+	}
+	sa := func(token, namespace string) (bool, error) {
+		if token == "broken" {
+			return false, errors.New("TokenReview failed")
+		}
+		return token == "good" && namespace == "myns", nil
+	}
+	handler := sealHandler(ss, sa)
+
+	testCases := []struct {
+		method string
+		token  string
+		body   string
+		status int
+	}{
+		{"GET", "good", secret, http.StatusMethodNotAllowed},
+		{"POST", "", secret, http.StatusUnauthorized},
+		{"POST", "bad", secret, http.StatusForbidden},
+		{"POST", "broken", secret, http.StatusInternalServerError},
+		{"POST", "good", `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"mysecret"}}`, http.StatusBadRequest},
+		{"POST", "good", secret, http.StatusOK},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest(tc.method, "/v1/seal", strings.NewReader(tc.body))
+		if tc.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tc.status {
+			t.Errorf("%s with token %q: got status %d, expected %d", tc.method, tc.token, rec.Code, tc.status)
+		}
+	}
+}
+
+func TestBlacklistHandler(t *testing.T) {
+	aa := func(token string) (bool, error) {
+		return token == "admin", nil
+	}
+	kb := func(name, reason string) (*resealReport, error) {
+		if name != "key1" {
+			return nil, errKeyNotFound
+		}
+		return &resealReport{Key: name}, nil
+	}
+	handler := adminHandler(aa, blacklistHandler(kb))
+
+	testCases := []struct {
+		method string
+		token  string
+		body   string
+		status int
+	}{
+		{"POST", "", `{"name":"key1","reason":"leaked"}`, http.StatusUnauthorized},
+		{"POST", "user", `{"name":"key1","reason":"leaked"}`, http.StatusForbidden},
+		{"GET", "admin", "", http.StatusMethodNotAllowed},
+		{"POST", "admin", `{"name":"key1"}`, http.StatusBadRequest},
+		{"POST", "admin", `{"name":"key3","reason":"leaked"}`, http.StatusNotFound},
+		{"POST", "admin", `{"name":"key1","reason":"leaked"}`, http.StatusOK},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest(tc.method, "/admin/keys/blacklist", strings.NewReader(tc.body))
+		if tc.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tc.status {
+			t.Errorf("%s %s with token %q: got status %d, expected %d", tc.method, tc.body, tc.token, rec.Code, tc.status)
+		}
+	}
+}
+
+func TestPromoteHandler(t *testing.T) {
+	promoted := 0
+	handler := promoteHandler(func() error {
+		promoted++
+		return nil
+	})
+
+	for _, tc := range []struct {
+		method string
+		status int
+	}{
+		{"GET", http.StatusMethodNotAllowed},
+		{"POST", http.StatusOK},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(tc.method, "/admin/promote", nil))
+		if rec.Code != tc.status {
+			t.Errorf("%s: got status %d, expected %d", tc.method, rec.Code, tc.status)
+		}
+	}
+	if promoted != 1 {
+		t.Errorf("Expected 1 promotion, got %d", promoted)
+	}
+}
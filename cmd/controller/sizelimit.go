@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+
+	flag "github.com/spf13/pflag"
+	v1 "k8s.io/api/core/v1"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+var (
+	maxSealedSecretBytes = flag.Int("max-sealed-secret-bytes", 0, "Maximum size of the encrypted data of a SealedSecret to unseal. Unlimited if 0.")
+	maxSecretBytes       = flag.Int("max-secret-bytes", 1<<20, "Maximum size of the data of an unsealed Secret, 1MiB by default as for the API server. Unlimited if 0.")
+)
+
+// sizeLimits bounds the SealedSecrets and the Secrets unsealed from
+// them, a zero value means no limit.
+type sizeLimits struct {
+	sealed int
+	secret int
+}
+
+func secretSize(s *v1.Secret) int {
+	size := 0
+	for k, v := range s.Data {
+		size += len(k) + len(v)
+	}
+	return size
+}
+
+// checkSealed returns why ssecret is too large to be unsealed, if it
+// is.
+func (l sizeLimits) checkSealed(ssecret *ssv1alpha1.SealedSecret) string {
+	if size := sealedSecretSize(ssecret); l.sealed > 0 && size > l.sealed {
+		return fmt.Sprintf("The encrypted data is %d bytes, more than the limit of %d", size, l.sealed)
+	}
+	return ""
+}
+
+// checkSecret returns why secret is too large to be written, if it is.
+func (l sizeLimits) checkSecret(secret *v1.Secret) string {
+	if size := secretSize(secret); l.secret > 0 && size > l.secret {
+		return fmt.Sprintf("The unsealed data is %d bytes, more than the limit of %d", size, l.secret)
+	}
+	return ""
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+func TestUnsealTooLarge(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+	size := sealedSecretSize(ssecret)
+
+	testCases := []struct {
+		limits sizeLimits
+		reason string
+	}{
+		{sizeLimits{sealed: size - 1}, "SealedSecretTooLarge"},
+		{sizeLimits{secret: 1}, "SecretTooLarge"},
+		{sizeLimits{sealed: size, secret: 1 << 20}, ""},
+	}
+	for _, tc := range testCases {
+		c := newTestController(t, ssecret)
+		c.keyRegistry = registry
+		c.sizeLimits = tc.limits
+
+		if err := c.unseal("myns/mysecret"); err != nil {
+			t.Fatalf("unseal() with %+v returned err: %v", tc.limits, err)
+		}
+		updated, err := c.ssclient.BitnamiV1alpha1().SealedSecrets("myns").Get("mysecret", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{})
+		cond := updated.GetCondition(ssv1alpha1.SealedSecretTooLarge)
+		if tc.reason == "" {
+			if cond != nil {
+				t.Errorf("Unexpected TooLarge condition with %+v: %v", tc.limits, cond)
+			}
+			if err != nil {
+				t.Errorf("Expected a Secret with %+v, got err %v", tc.limits, err)
+			}
+			continue
+		}
+		if cond == nil || cond.Status != v1.ConditionTrue || cond.Reason != tc.reason {
+			t.Errorf("Expected TooLarge condition with reason %s, got %v", tc.reason, cond)
+		}
+		if !errors.IsNotFound(err) {
+			t.Errorf("Expected no Secret with %+v, got err %v", tc.limits, err)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"log"
+	"sync/atomic"
+	"time"
+
+	flag "github.com/spf13/pflag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	standby          = flag.Bool("standby", false, "Start as a standby: use the keys replicated from the primary cluster to validate the sealed objects, as with --dry-run, without generating keys, until promoted with /admin/promote.")
+	standbyKeyReload = flag.Duration("standby-key-reload-period", time.Minute, "How often a standby loads the keys replicated since it started.")
+)
+
+// standbyState tells whether the controller is a standby, see
+// --standby. A nil standbyState is active.
+type standbyState struct {
+	standby int32
+}
+
+func newStandbyState() *standbyState {
+	return &standbyState{standby: 1}
+}
+
+func (s *standbyState) isStandby() bool {
+	return s != nil && atomic.LoadInt32(&s.standby) == 1
+}
+
+// promote makes s active, it reports whether s was a standby.
+func (s *standbyState) promote() bool {
+	return s != nil && atomic.CompareAndSwapInt32(&s.standby, 1, 0)
+}
+
+// readOnly reports whether the unsealed objects mustn't be written,
+// with --dry-run or in standby.
+func (c *Controller) readOnly() bool {
+	return c.dryRun || c.standby.isStandby()
+}
+
+// loadNewKeys registers the keys stored since kr was loaded, eg. by
+// the replication from the primary cluster, oldest first.
+func (kr *KeyRegistry) loadNewKeys() (int, error) {
+	return kr.load()
+}
+
+// reloadKeysWhileStandby loads the new keys every period, until c is
+// promoted. The SealedSecrets are validated again when keys are added.
+func (c *Controller) reloadKeysWhileStandby(period time.Duration) {
+	for c.standby.isStandby() {
+		time.Sleep(period)
+		n, err := c.keyRegistry.loadNewKeys()
+		if err != nil {
+			log.Printf("Error loading new keys: %v", err)
+			continue
+		}
+		if n > 0 {
+			c.requeueNamespace(metav1.NamespaceAll)
+		}
+	}
+}
+
+// Promote turns a standby controller into the active one: the latest
+// keys are loaded, a new key is generated and the sealed objects are
+// unsealed. It does nothing if the controller is already active.
+func (c *Controller) Promote() error {
+	if !c.standby.promote() {
+		return nil
+	}
+	log.Printf("Promoting standby controller to active")
+
+	if _, err := c.keyRegistry.loadNewKeys(); err != nil {
+		return err
+	}
+	if !c.dryRun && c.keyRegistry.external == nil {
+		if _, err := c.keyRegistry.generateKey(); err != nil {
+			return err
+		}
+	}
+	if c.nsKeys != nil {
+		c.nsKeys.mu.Lock()
+		c.nsKeys.readOnly = c.dryRun
+		c.nsKeys.mu.Unlock()
+	}
+	c.requeueNamespace(metav1.NamespaceAll)
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	certUtil "k8s.io/client-go/util/cert"
+)
+
+func TestStandbyPromote(t *testing.T) {
+	registry := testKeys(t, "key1")
+	ssecret := testSealedSecret(t, registry)
+	c := newTestController(t, ssecret)
+	c.keyRegistry = registry
+	c.standby = newStandbyState()
+
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	if _, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected no Secret to be written by a standby, got %v", err)
+	}
+
+	if err := c.Promote(); err != nil {
+		t.Fatalf("Promote() returned err: %v", err)
+	}
+	if c.standby.isStandby() || c.readOnly() {
+		t.Errorf("Expected controller to be active")
+	}
+	if len(registry.keyNames()) != 2 {
+		t.Errorf("Expected a new key to be generated, got %v", registry.keyNames())
+	}
+	if c.queue.Len() != 1 {
+		t.Errorf("Expected SealedSecret to be requeued, got %d items", c.queue.Len())
+	}
+
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	if _, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected Secret to be created once promoted, got %v", err)
+	}
+
+	// Promoting again does nothing
+	if err := c.Promote(); err != nil {
+		t.Fatalf("Promote() returned err: %v", err)
+	}
+	if len(registry.keyNames()) != 2 {
+		t.Errorf("Expected no new key, got %v", registry.keyNames())
+	}
+}
+
+func TestLoadNewKeys(t *testing.T) {
+	kr := testKeys(t, "key1")
+
+	key, cert, err := generatePrivateKeyAndCert(1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replicated := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "key2",
+			Namespace: "kube-system",
+			Labels:    map[string]string{SealedSecretsKeyLabel: "active"},
+		},
+		Data: map[string][]byte{
+			v1.TLSPrivateKeyKey: certUtil.EncodePrivateKeyPEM(key),
+			v1.TLSCertKey:       certUtil.EncodeCertPEM(cert),
+		},
+	}
+	if _, err := kr.store.(*secretKeyStore).client.Core().Secrets("kube-system").Create(replicated); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := kr.loadNewKeys()
+	if err != nil {
+		t.Fatalf("loadNewKeys() returned err: %v", err)
+	}
+	if n != 1 || len(kr.keyNames()) != 2 || kr.keyNames()[1] != "key2" || kr.latestPrivateKey().N.Cmp(key.N) != 0 {
+		t.Errorf("Expected key2 to be loaded, got %d: %v", n, kr.keyNames())
+	}
+
+	if n, err := kr.loadNewKeys(); err != nil || n != 0 {
+		t.Errorf("Expected no new key, got %d, %v", n, err)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"text/template"
+
+	apiv1 "k8s.io/api/core/v1"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+// templateError is returned when the template of a SealedSecret can't
+// be rendered. It never includes decrypted values.
+type templateError struct {
+	key string
+	err error
+}
+
+func (e *templateError) Error() string {
+	return fmt.Sprintf("Error rendering template of item %q: %v", e.key, e.err)
+}
+
+func isTemplateError(err error) bool {
+	_, ok := err.(*templateError)
+	return ok
+}
+
+// templateFuncs are the functions of templates, in addition to the
+// builtin ones.
+var templateFuncs = template.FuncMap{
+	"dockerConfigJSON": dockerConfigJSON,
+}
+
+type dockerConfigEntry struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Auth     string `json:"auth"`
+}
+
+type dockerConfig struct {
+	Auths map[string]dockerConfigEntry `json:"auths"`
+}
+
+// dockerConfigJSON returns the .dockerconfigjson item of an image pull
+// secret for registry.
+func dockerConfigJSON(registry, username, password string) (string, error) {
+	b, err := json.Marshal(dockerConfig{Auths: map[string]dockerConfigEntry{
+		registry: {
+			Username: username,
+			Password: password,
+			Auth:     base64.StdEncoding.EncodeToString([]byte(username + ":" + password)),
+		},
+	}})
+	return string(b), err
+}
+
+// renderTemplate adds the items of the template of ssecret to secret,
+// rendered with its decrypted items. Secrets with a rendered
+// .dockerconfigjson item are image pull secrets, unless ssecret has a
+// type.
+func renderTemplate(ssecret *ssv1alpha1.SealedSecret, secret *apiv1.Secret) error {
+	if ssecret.Spec.Template == nil || len(ssecret.Spec.Template.Data) == 0 {
+		return nil
+	}
+
+	values := map[string]string{}
+	for k, v := range secret.Data {
+		values[k] = string(v)
+	}
+
+	keys := make([]string, 0, len(ssecret.Spec.Template.Data))
+	for k := range ssecret.Spec.Template.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	rendered := map[string][]byte{}
+	for _, k := range keys {
+		if _, ok := secret.Data[k]; ok {
+			return &templateError{k, fmt.Errorf("item is also sealed")}
+		}
+		tmpl, err := template.New(k).Funcs(templateFuncs).Option("missingkey=error").Parse(ssecret.Spec.Template.Data[k])
+		if err != nil {
+			return &templateError{k, err}
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, values); err != nil {
+			return &templateError{k, err}
+		}
+		rendered[k] = buf.Bytes()
+	}
+
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	for k, v := range rendered {
+		secret.Data[k] = v
+	}
+	if _, ok := rendered[apiv1.DockerConfigJsonKey]; ok && secret.Type == "" {
+		secret.Type = apiv1.SecretTypeDockerConfigJson
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	testCases := []struct {
+		template map[string]string
+		dsn      string
+		fails    bool
+	}{
+		{map[string]string{"dsn": "postgres://{{ .user }}:{{ .password }}@db/app"}, "postgres://app:s3cr3t@db/app", false},
+		{map[string]string{"dsn": `{{ index . "user" }}`}, "app", false},
+		{map[string]string{"dsn": `{{ dockerConfigJSON "ghcr.io" .user .password }}`}, `{"auths":{"ghcr.io":{"username":"app","password":"s3cr3t","auth":"YXBwOnMzY3IzdA=="}}}`, false},
+		{map[string]string{"dsn": "{{ .missing }}"}, "", true},
+		{map[string]string{"dsn": "{{ .user"}, "", true},
+		{map[string]string{"user": "{{ .password }}"}, "", true},
+	}
+	for _, tc := range testCases {
+		ssecret := &ssv1alpha1.SealedSecret{
+			Spec: ssv1alpha1.SealedSecretSpec{
+				Template: &ssv1alpha1.SealedSecretTemplate{Data: tc.template},
+			},
+		}
+		secret := &v1.Secret{
+			Data: map[string][]byte{"user": []byte("app"), "password": []byte("s3cr3t")},
+		}
+		err := renderTemplate(ssecret, secret)
+		if tc.fails {
+			if !isTemplateError(err) {
+				t.Errorf("%v: expected a template error, got %v", tc.template, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: renderTemplate() returned err: %v", tc.template, err)
+			continue
+		}
+		if got := string(secret.Data["dsn"]); got != tc.dsn {
+			t.Errorf("%v: expected %q, got %q", tc.template, tc.dsn, got)
+		}
+	}
+}
+
+func TestUnsealTemplate(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+	ssecret.Spec.Template = &ssv1alpha1.SealedSecretTemplate{
+		Data: map[string]string{"config": "foo={{ .foo }}"},
+	}
+
+	c := newTestController(t, ssecret)
+	c.keyRegistry = registry
+
+	if err := c.unseal("myns/mysecret"); err != nil {
+		t.Fatalf("unseal() returned err: %v", err)
+	}
+	secret, err := c.sclient.Secrets("myns").Get("mysecret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(secret.Data["config"]); got != "foo=bar" {
+		t.Errorf("Expected rendered item %q, got %q", "foo=bar", got)
+	}
+	if got := string(secret.Data["foo"]); got != "bar" {
+		t.Errorf("Expected sealed item %q, got %q", "bar", got)
+	}
+}
+
+func TestRenderDockerConfigJSON(t *testing.T) {
+	ssecret := &ssv1alpha1.SealedSecret{
+		Spec: ssv1alpha1.SealedSecretSpec{
+			Template: &ssv1alpha1.SealedSecretTemplate{Data: map[string]string{
+				v1.DockerConfigJsonKey: `{{ dockerConfigJSON .registry .username .password }}`,
+			}},
+		},
+	}
+	secret := &v1.Secret{
+		Data: map[string][]byte{"registry": []byte("ghcr.io"), "username": []byte("app"), "password": []byte("s3cr3t")},
+	}
+	if err := renderTemplate(ssecret, secret); err != nil {
+		t.Fatalf("renderTemplate() returned err: %v", err)
+	}
+	if secret.Type != v1.SecretTypeDockerConfigJson {
+		t.Errorf("Expected type %q, got %q", v1.SecretTypeDockerConfigJson, secret.Type)
+	}
+}
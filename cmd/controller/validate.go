@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+
+	flag "github.com/spf13/pflag"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	"github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+var (
+	validateOnStart = flag.Bool("validate-on-start", false, "Check that every SealedSecret can be decrypted by the registered keys on start, and report those which can't.")
+	validateReport  = flag.String("validate-report-configmap", "", "Name of a ConfigMap, in the controller namespace, to store the report of --validate-on-start in.")
+)
+
+var undecryptableSealedSecrets = newGaugeFunc("undecryptable_sealed_secrets", "Number of SealedSecrets that none of the keys could decrypt, on start. Set with --validate-on-start.", func() float64 {
+	return float64(lastValidation)
+})
+
+// lastValidation is the number of undecryptable SealedSecrets found by
+// the last validation.
+var lastValidation int
+
+// validationReport summarises the decryptability of the SealedSecrets.
+type validationReport struct {
+	Total int `json:"total"`
+	// Undecryptable lists the namespace/name of the SealedSecrets
+	// that none of the keys could decrypt.
+	Undecryptable []string `json:"undecryptable"`
+}
+
+// validate checks that each SealedSecret in the store can be decrypted
+// by at least one key.
+func (c *Controller) validate() *validationReport {
+	report := &validationReport{Undecryptable: []string{}}
+	for _, obj := range c.informer.GetIndexer().List() {
+		ssecret := obj.(*ssv1alpha1.SealedSecret)
+		report.Total++
+		id := fmt.Sprintf("%s/%s", ssecret.GetNamespace(), ssecret.GetName())
+		keys, err := c.keysFor(ssecret.GetNamespace())
+		if err == nil {
+			_, err = seal.Unseal(ssecret, keys)
+		}
+		if err != nil {
+			log.Printf("SealedSecret %s can't be decrypted: %v", id, err)
+			report.Undecryptable = append(report.Undecryptable, id)
+		}
+	}
+	sort.Strings(report.Undecryptable)
+	return report
+}
+
+// validateOnStart validates the SealedSecrets once the caches are
+// synced, and stores the report in the ConfigMap namespace/name if name
+// isn't empty.
+func (c *Controller) validateOnStart(stopCh <-chan struct{}, namespace, name string) {
+	if !cache.WaitForCacheSync(stopCh, c.HasSynced) {
+		return
+	}
+
+	report := c.validate()
+	lastValidation = len(report.Undecryptable)
+	registerMetric(undecryptableSealedSecrets)
+	log.Printf("Validated %d SealedSecrets, %d can't be decrypted", report.Total, len(report.Undecryptable))
+
+	if name != "" {
+		if err := c.storeReport(namespace, name, report); err != nil {
+			log.Printf("Error storing validation report in ConfigMap %s/%s: %v", namespace, name, err)
+		}
+	}
+}
+
+// storeReport creates or updates the ConfigMap namespace/name with
+// report.
+func (c *Controller) storeReport(namespace, name string, report *validationReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	cm := &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Data:       map[string]string{"report.json": string(data)},
+	}
+	_, err = c.cmclient.ConfigMaps(namespace).Create(cm)
+	if errors.IsAlreadyExists(err) {
+		_, err = c.cmclient.ConfigMaps(namespace).Update(cm)
+	}
+	return err
+}
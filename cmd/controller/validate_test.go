@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestValidate(t *testing.T) {
+	registry := testRegistry(t)
+	ssecret := testSealedSecret(t, registry)
+	c := newTestController(t, ssecret)
+	c.keyRegistry = registry
+
+	lost := testSealedSecret(t, testRegistry(t))
+	lost.Name = "lost"
+	if err := c.informer.GetIndexer().Add(lost); err != nil {
+		t.Fatal(err)
+	}
+
+	report := c.validate()
+	if report.Total != 2 || !reflect.DeepEqual(report.Undecryptable, []string{"myns/lost"}) {
+		t.Errorf("Unexpected report %+v", report)
+	}
+}
+
+func TestStoreReport(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	c := &Controller{cmclient: client.Core()}
+
+	for _, report := range []*validationReport{
+		{Total: 1, Undecryptable: []string{}},
+		{Total: 2, Undecryptable: []string{"myns/lost"}},
+	} {
+		if err := c.storeReport("kube-system", "report", report); err != nil {
+			t.Fatalf("storeReport() returned err: %v", err)
+		}
+		cm, err := client.Core().ConfigMaps("kube-system").Get("report", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var stored validationReport
+		if err := json.Unmarshal([]byte(cm.Data["report.json"]), &stored); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(&stored, report) {
+			t.Errorf("Stored %+v, expected %+v", stored, report)
+		}
+	}
+}
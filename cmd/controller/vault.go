@@ -0,0 +1,408 @@
+package main
+
+import (
+	"bytes"
+	stdcrypto "crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	flag "github.com/spf13/pflag"
+	certUtil "k8s.io/client-go/util/cert"
+
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+	"github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+var (
+	vaultAddr         = flag.String("vault-addr", os.Getenv("VAULT_ADDR"), "Address of the Vault server of --vault-transit-key and --key-store=vault-kv.")
+	vaultTransitKey   = flag.String("vault-transit-key", "", "Name of an RSA key of the Vault transit engine to seal with instead of the keys of the controller: the session keys are decrypted by Vault, so the private key never enters the cluster. Requires --vault-addr and --vault-role.")
+	vaultTransitMount = flag.String("vault-transit-mount", "transit", "Path of the Vault transit engine of --vault-transit-key.")
+	vaultAuthMount    = flag.String("vault-auth-mount", "kubernetes", "Path of the Vault Kubernetes auth method the controller logs in with.")
+	vaultRole         = flag.String("vault-role", "", "Role of the Vault Kubernetes auth method the controller logs in with, using its service account token.")
+	vaultCACert       = flag.String("vault-ca-cert", "", "PEM file of the CA certificates of the Vault server, the system ones if empty.")
+)
+
+const (
+	// vaultService marks the certificates of the Vault transit keys,
+	// see seal.NewExternalKeyExtension.
+	vaultService = "vault-transit"
+	// vaultKeyRefresh is how often the versions of the transit key are
+	// read, to pick up its rotations.
+	vaultKeyRefresh = time.Minute
+	// serviceAccountTokenFile is the token the controller logs in to
+	// Vault with.
+	serviceAccountTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+)
+
+// vaultClient uses the HTTP API of Vault. The controller logs in with
+// the Kubernetes auth method, so that every access is audited by Vault.
+type vaultClient struct {
+	addr      string
+	authMount string
+	role      string
+	jwtFile   string
+	client    *http.Client
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+func newVaultClient(addr, authMount, role, jwtFile, caFile string) (*vaultClient, error) {
+	if addr == "" || role == "" {
+		return nil, fmt.Errorf("--vault-addr and --vault-role are required")
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	if caFile != "" {
+		data, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificate found in %s", caFile)
+		}
+		client.Transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}
+	}
+	return &vaultClient{
+		addr:      strings.TrimSuffix(addr, "/"),
+		authMount: strings.Trim(authMount, "/"),
+		role:      role,
+		jwtFile:   jwtFile,
+		client:    client,
+	}, nil
+}
+
+// vaultClientFromFlags returns the vaultClient of the --vault-* flags.
+func vaultClientFromFlags() (*vaultClient, error) {
+	return newVaultClient(*vaultAddr, *vaultAuthMount, *vaultRole, serviceAccountTokenFile, *vaultCACert)
+}
+
+// vaultTransit is an RSA key of the Vault transit engine, so that every
+// decryption is audited by Vault.
+type vaultTransit struct {
+	c     *vaultClient
+	mount string
+	key   string
+
+	mu sync.Mutex
+	// versions are the public keys of the versions of the key.
+	versions  map[int]*rsa.PublicKey
+	latest    int
+	cert      *x509.Certificate
+	refreshed time.Time
+}
+
+func newVaultTransit(c *vaultClient, mount, key string) (*vaultTransit, error) {
+	v := &vaultTransit{
+		c:     c,
+		mount: strings.Trim(mount, "/"),
+		key:   key,
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if err := v.refresh(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// vaultResponse is the envelope of the responses of Vault.
+type vaultResponse struct {
+	Data json.RawMessage `json:"data"`
+	Auth *struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int    `json:"lease_duration"`
+	} `json:"auth"`
+	Errors []string `json:"errors"`
+}
+
+// vaultNotFoundError is returned for the paths Vault has nothing at.
+type vaultNotFoundError struct {
+	error
+}
+
+// do sends a request to Vault with token, and decodes the response.
+func (v *vaultClient) do(method, path, token string, body interface{}) (*vaultResponse, int, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, 0, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, v.addr+"/v1/"+path, reader)
+	if err != nil {
+		return nil, 0, err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	var vr vaultResponse
+	if err := json.NewDecoder(resp.Body).Decode(&vr); err != nil && err != io.EOF {
+		return nil, resp.StatusCode, fmt.Errorf("vault %s %s: %s: %v", method, path, resp.Status, err)
+	}
+	if resp.StatusCode >= 300 {
+		err := fmt.Errorf("vault %s %s: %s: %s", method, path, resp.Status, strings.Join(vr.Errors, "; "))
+		if resp.StatusCode == http.StatusNotFound {
+			err = vaultNotFoundError{err}
+		}
+		return nil, resp.StatusCode, err
+	}
+	return &vr, resp.StatusCode, nil
+}
+
+// login gets a Vault token with the service account token of the
+// controller. It must be called with v.mu held.
+func (v *vaultClient) login() error {
+	jwt, err := ioutil.ReadFile(v.jwtFile)
+	if err != nil {
+		return err
+	}
+	vr, _, err := v.do(http.MethodPost, "auth/"+v.authMount+"/login", "", map[string]string{
+		"role": v.role,
+		"jwt":  strings.TrimSpace(string(jwt)),
+	})
+	if err != nil {
+		return err
+	}
+	if vr.Auth == nil || vr.Auth.ClientToken == "" {
+		return fmt.Errorf("vault login returned no token")
+	}
+	v.token = vr.Auth.ClientToken
+	// Log in again before the token expires
+	v.tokenExpiry = time.Now().Add(time.Duration(vr.Auth.LeaseDuration) * time.Second * 9 / 10)
+	return nil
+}
+
+// currentToken returns a valid Vault token, logging in if needed.
+func (v *vaultClient) currentToken(renew bool) (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if renew || v.token == "" || (!v.tokenExpiry.IsZero() && time.Now().After(v.tokenExpiry)) {
+		if err := v.login(); err != nil {
+			return "", err
+		}
+	}
+	return v.token, nil
+}
+
+// call sends an authenticated request to Vault and decodes the data of
+// the response into out. It logs in again once if the token is denied.
+func (v *vaultClient) call(method, path string, body, out interface{}) error {
+	t, err := v.currentToken(false)
+	if err != nil {
+		return err
+	}
+	vr, status, err := v.do(method, path, t, body)
+	if status == http.StatusForbidden {
+		if t, err = v.currentToken(true); err != nil {
+			return err
+		}
+		vr, _, err = v.do(method, path, t, body)
+	}
+	if err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(vr.Data, out)
+}
+
+// refresh reads the versions of the key, and issues the certificate of
+// the latest one if it changed. It must be called with v.mu held.
+func (v *vaultTransit) refresh() error {
+	var info struct {
+		Type          string `json:"type"`
+		LatestVersion int    `json:"latest_version"`
+		Keys          map[string]struct {
+			PublicKey string `json:"public_key"`
+		} `json:"keys"`
+	}
+	if err := v.c.call(http.MethodGet, v.mount+"/keys/"+v.key, nil, &info); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(info.Type, "rsa-") {
+		return fmt.Errorf("vault transit key %s is a %s key, an RSA key is required", v.key, info.Type)
+	}
+
+	versions := map[int]*rsa.PublicKey{}
+	for name, k := range info.Keys {
+		version, err := strconv.Atoi(name)
+		if err != nil {
+			return fmt.Errorf("invalid version %q of vault transit key %s", name, v.key)
+		}
+		block, _ := pem.Decode([]byte(k.PublicKey))
+		if block == nil {
+			return fmt.Errorf("no public key for version %d of vault transit key %s", version, v.key)
+		}
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return err
+		}
+		rsaPub, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("version %d of vault transit key %s isn't an RSA key", version, v.key)
+		}
+		versions[version] = rsaPub
+	}
+	latest, ok := versions[info.LatestVersion]
+	if !ok {
+		return fmt.Errorf("no latest version of vault transit key %s", v.key)
+	}
+	if err := crypto.CheckFIPSKey(latest); err != nil {
+		return err
+	}
+
+	if v.cert == nil || info.LatestVersion != v.latest {
+		cert, err := v.issueCert(info.LatestVersion, latest)
+		if err != nil {
+			return err
+		}
+		log.Printf("Using version %d of vault transit key %s, certificate is \n%s\n", info.LatestVersion, v.key, certUtil.EncodeCertPEM(cert))
+		v.cert = cert
+	}
+	v.versions = versions
+	v.latest = info.LatestVersion
+	v.refreshed = time.Now()
+	return nil
+}
+
+// issueCert returns the certificate of version of the key, self-signed
+// by Vault. It must be called with v.mu held.
+func (v *vaultTransit) issueCert(version int, pub *rsa.PublicKey) (*x509.Certificate, error) {
+	ext, err := seal.NewExternalKeyExtension(vaultService)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := certTemplate(rand.Reader, []pkix.Extension{ext})
+	if err != nil {
+		return nil, err
+	}
+	signer := &vaultSigner{v: v, version: version, pub: pub}
+	data, err := x509.CreateCertificate(rand.Reader, cert, cert, pub, signer)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(data)
+}
+
+// fresh refreshes the versions of the key every vaultKeyRefresh. The
+// known versions are kept if Vault can't be reached.
+func (v *vaultTransit) fresh() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if time.Since(v.refreshed) < vaultKeyRefresh {
+		return
+	}
+	if err := v.refresh(); err != nil {
+		log.Printf("Error reading vault transit key %s: %v", v.key, err)
+		// Don't retry on every call
+		v.refreshed = time.Now()
+	}
+}
+
+// certificate implements externalKey.
+func (v *vaultTransit) certificate() *x509.Certificate {
+	v.fresh()
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.cert
+}
+
+// decrypters implements externalKey, the latest version first.
+func (v *vaultTransit) decrypters() []crypto.SessionKeyDecrypter {
+	v.fresh()
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	versions := make([]int, 0, len(v.versions))
+	for version := range v.versions {
+		versions = append(versions, version)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+	var ds []crypto.SessionKeyDecrypter
+	for _, version := range versions {
+		ds = append(ds, &vaultDecrypter{v: v, version: version})
+	}
+	return ds
+}
+
+// vaultDecrypter decrypts session keys with a version of the key.
+type vaultDecrypter struct {
+	v       *vaultTransit
+	version int
+}
+
+func (d *vaultDecrypter) DecryptSessionKey(rsaCiphertext []byte) ([]byte, error) {
+	var out struct {
+		Plaintext string `json:"plaintext"`
+	}
+	body := map[string]string{
+		"ciphertext": fmt.Sprintf("vault:v%d:%s", d.version, base64.StdEncoding.EncodeToString(rsaCiphertext)),
+	}
+	if err := d.v.c.call(http.MethodPost, d.v.mount+"/decrypt/"+d.v.key, body, &out); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(out.Plaintext)
+}
+
+// vaultSigner signs with a version of the key, to self-sign its
+// certificate. It is used with v.mu held.
+type vaultSigner struct {
+	v       *vaultTransit
+	version int
+	pub     *rsa.PublicKey
+}
+
+func (s *vaultSigner) Public() stdcrypto.PublicKey {
+	return s.pub
+}
+
+func (s *vaultSigner) Sign(_ io.Reader, digest []byte, opts stdcrypto.SignerOpts) ([]byte, error) {
+	if opts.HashFunc() != stdcrypto.SHA256 {
+		return nil, fmt.Errorf("unsupported signature hash %v", opts.HashFunc())
+	}
+	var out struct {
+		Signature string `json:"signature"`
+	}
+	body := map[string]interface{}{
+		"input":               base64.StdEncoding.EncodeToString(digest),
+		"prehashed":           true,
+		"signature_algorithm": "pkcs1v15",
+		"key_version":         s.version,
+	}
+	if err := s.v.c.call(http.MethodPost, s.v.mount+"/sign/"+s.v.key+"/sha2-256", body, &out); err != nil {
+		return nil, err
+	}
+	// The signature is prefixed with vault:v<version>:
+	parts := strings.SplitN(out.Signature, ":", 3)
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("unexpected vault signature %q", out.Signature)
+	}
+	return base64.StdEncoding.DecodeString(parts[2])
+}
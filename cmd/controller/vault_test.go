@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	stdcrypto "crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	certUtil "k8s.io/client-go/util/cert"
+
+	"github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+// fakeVault serves the transit engine API for an RSA key, counting the
+// decryptions.
+type fakeVault struct {
+	key      *rsa.PrivateKey
+	logins   int
+	decrypts int
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&body)
+	reply := func(data interface{}) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}
+	fail := func(status int, msg string) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{msg}})
+	}
+
+	if r.URL.Path == "/v1/auth/kubernetes/login" {
+		if body["role"] != "sealed-secrets" || body["jwt"] != "sa-token" {
+			fail(http.StatusBadRequest, "invalid role or jwt")
+			return
+		}
+		f.logins++
+		json.NewEncoder(w).Encode(map[string]interface{}{"auth": map[string]interface{}{"client_token": "token", "lease_duration": 3600}})
+		return
+	}
+	if r.Header.Get("X-Vault-Token") != "token" {
+		fail(http.StatusForbidden, "permission denied")
+		return
+	}
+	switch r.URL.Path {
+	case "/v1/transit/keys/sealed-secrets":
+		der, _ := x509.MarshalPKIXPublicKey(&f.key.PublicKey)
+		reply(map[string]interface{}{
+			"type":           "rsa-2048",
+			"latest_version": 1,
+			"keys": map[string]interface{}{
+				"1": map[string]string{"public_key": string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))},
+			},
+		})
+	case "/v1/transit/sign/sealed-secrets/sha2-256":
+		digest, _ := base64.StdEncoding.DecodeString(body["input"].(string))
+		sig, err := rsa.SignPKCS1v15(rand.Reader, f.key, stdcrypto.SHA256, digest)
+		if err != nil {
+			fail(http.StatusBadRequest, err.Error())
+			return
+		}
+		reply(map[string]string{"signature": "vault:v1:" + base64.StdEncoding.EncodeToString(sig)})
+	case "/v1/transit/decrypt/sealed-secrets":
+		f.decrypts++
+		ciphertext := body["ciphertext"].(string)
+		if !strings.HasPrefix(ciphertext, "vault:v1:") {
+			fail(http.StatusBadRequest, "invalid key version")
+			return
+		}
+		data, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(ciphertext, "vault:v1:"))
+		plaintext, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, f.key, data, nil)
+		if err != nil {
+			fail(http.StatusBadRequest, "invalid ciphertext")
+			return
+		}
+		reply(map[string]string{"plaintext": base64.StdEncoding.EncodeToString(plaintext)})
+	default:
+		fail(http.StatusNotFound, fmt.Sprintf("no handler for route %q", r.URL.Path))
+	}
+}
+
+func TestVaultTransit(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vault := &fakeVault{key: key}
+	server := httptest.NewServer(vault)
+	defer server.Close()
+
+	jwt, err := ioutil.TempFile("", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(jwt.Name())
+	jwt.WriteString("sa-token\n")
+	jwt.Close()
+
+	c, err := newVaultClient(server.URL, "kubernetes", "sealed-secrets", jwt.Name(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := newVaultTransit(c, "transit", "sealed-secrets")
+	if err != nil {
+		t.Fatalf("newVaultTransit() returned err: %v", err)
+	}
+	cert := v.certificate()
+	if err := cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature); err != nil {
+		t.Errorf("The certificate isn't self-signed by the transit key: %v", err)
+	}
+	if service, err := seal.ParseExternalKey(bytes.NewReader(certUtil.EncodeCertPEM(cert))); err != nil || service != vaultService {
+		t.Errorf("ParseExternalKey() = %q, %v", service, err)
+	}
+
+	kr := NewKeyRegistry(nil, "prefix", 2048)
+	kr.external = v
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "myns"},
+		Data:       map[string][]byte{"foo": []byte("bar")},
+	}
+	ssecret, err := kr.seal(secret, seal.StrictScope)
+	if err != nil {
+		t.Fatalf("seal() returned err: %v", err)
+	}
+	unsealed, privKey, err := seal.UnsealWithKey(ssecret, kr)
+	if err != nil {
+		t.Fatalf("UnsealWithKey() returned err: %v", err)
+	}
+	if privKey != nil || string(unsealed.Data["foo"]) != "bar" {
+		t.Errorf("Unexpected unsealed data: %v", unsealed.Data)
+	}
+	if vault.decrypts != 1 {
+		t.Errorf("Expected a decryption by vault, got %d", vault.decrypts)
+	}
+
+	// A revoked token is replaced
+	c.token = "revoked"
+	if _, err := seal.Unseal(ssecret, kr); err != nil {
+		t.Fatalf("Unseal() with a revoked token returned err: %v", err)
+	}
+	if vault.logins != 2 {
+		t.Errorf("Expected to log in again, got %d logins", vault.logins)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+)
+
+var (
+	vaultKVMount = flag.String("vault-kv-mount", "secret", "Path of the Vault KV engine (version 2) of --key-store=vault-kv.")
+)
+
+// vaultKVKeyStore stores each key in a secret of the version 2 KV engine
+// of Vault, for the clusters whose private keys mustn't be in etcd.
+// Vault keeps the previous versions of the secrets when they change,
+// eg. when they are blacklisted.
+type vaultKVKeyStore struct {
+	c     *vaultClient
+	mount string
+	path  string
+}
+
+func newVaultKVKeyStore(c *vaultClient, mount, path string) *vaultKVKeyStore {
+	return &vaultKVKeyStore{c: c, mount: strings.Trim(mount, "/"), path: strings.Trim(path, "/")}
+}
+
+func (s *vaultKVKeyStore) String() string {
+	return "Vault KV " + s.mount + "/" + s.path
+}
+
+// vaultKVSecret is the data of the KV secrets read from Vault.
+type vaultKVSecret struct {
+	Data     *keyRecord `json:"data"`
+	Metadata struct {
+		Version int `json:"version"`
+	} `json:"metadata"`
+}
+
+func (s *vaultKVKeyStore) read(name string) (*vaultKVSecret, error) {
+	var secret vaultKVSecret
+	if err := s.c.call(http.MethodGet, s.mount+"/data/"+s.path+"/"+name, nil, &secret); err != nil {
+		return nil, err
+	}
+	if secret.Data == nil {
+		// Deleted, only the metadata is left
+		return nil, vaultNotFoundError{fmt.Errorf("vault KV secret %s was deleted", name)}
+	}
+	return &secret, nil
+}
+
+// write stores k as a new version of the secret called name, provided
+// its current version is still version, 0 to create it.
+func (s *vaultKVKeyStore) write(name string, k *keyRecord, version int) error {
+	body := map[string]interface{}{
+		"options": map[string]int{"cas": version},
+		"data":    k,
+	}
+	return s.c.call(http.MethodPost, s.mount+"/data/"+s.path+"/"+name, body, nil)
+}
+
+func (s *vaultKVKeyStore) List(keyNamespace string) ([]storedKey, error) {
+	var list struct {
+		Keys []string `json:"keys"`
+	}
+	err := s.c.call("LIST", s.mount+"/metadata/"+s.path, nil, &list)
+	if _, ok := err.(vaultNotFoundError); ok {
+		// No key yet
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []storedKey
+	for _, name := range list.Keys {
+		if strings.HasSuffix(name, "/") {
+			continue
+		}
+		secret, err := s.read(name)
+		if _, ok := err.(vaultNotFoundError); ok {
+			continue
+		}
+		if err != nil {
+			keys = append(keys, storedKey{Name: name, Err: err})
+			continue
+		}
+		if secret.Data.Namespace != keyNamespace {
+			continue
+		}
+		keys = append(keys, secret.Data.storedKey(name))
+	}
+	sortStoredKeys(keys)
+	return keys, nil
+}
+
+func (s *vaultKVKeyStore) Create(keyNamespace, prefix string, key *rsa.PrivateKey, certs []*x509.Certificate) (string, error) {
+	name := prefix + randomSuffix()
+	// Check-and-set 0 fails rather than replace an existing key
+	if err := s.write(name, newKeyRecord(keyNamespace, key, certs), 0); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+func (s *vaultKVKeyStore) Blacklist(name, reason string) error {
+	secret, err := s.read(name)
+	if err != nil {
+		return err
+	}
+	secret.Data.blacklist(reason)
+	return s.write(name, secret.Data, secret.Metadata.Version)
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// fakeVaultKV serves the version 2 KV engine API mounted at secret,
+// keeping the versions of each secret.
+type fakeVaultKV struct {
+	secrets map[string][]json.RawMessage
+}
+
+func (f *fakeVaultKV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Options map[string]int  `json:"options"`
+		Data    json.RawMessage `json:"data"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+	reply := func(data interface{}) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}
+	fail := func(status int, msg string) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{msg}})
+	}
+
+	if r.URL.Path == "/v1/auth/kubernetes/login" {
+		json.NewEncoder(w).Encode(map[string]interface{}{"auth": map[string]interface{}{"client_token": "token", "lease_duration": 3600}})
+		return
+	}
+	if r.Header.Get("X-Vault-Token") != "token" {
+		fail(http.StatusForbidden, "permission denied")
+		return
+	}
+	switch {
+	case r.Method == "LIST" && strings.HasPrefix(r.URL.Path, "/v1/secret/metadata/"):
+		prefix := strings.TrimPrefix(r.URL.Path, "/v1/secret/metadata/") + "/"
+		var keys []string
+		for path := range f.secrets {
+			if strings.HasPrefix(path, prefix) {
+				keys = append(keys, strings.TrimPrefix(path, prefix))
+			}
+		}
+		if len(keys) == 0 {
+			fail(http.StatusNotFound, "")
+			return
+		}
+		sort.Strings(keys)
+		reply(map[string]interface{}{"keys": keys})
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v1/secret/data/"):
+		versions := f.secrets[strings.TrimPrefix(r.URL.Path, "/v1/secret/data/")]
+		if len(versions) == 0 {
+			fail(http.StatusNotFound, "")
+			return
+		}
+		reply(map[string]interface{}{
+			"data":     versions[len(versions)-1],
+			"metadata": map[string]int{"version": len(versions)},
+		})
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/v1/secret/data/"):
+		path := strings.TrimPrefix(r.URL.Path, "/v1/secret/data/")
+		if cas, ok := body.Options["cas"]; ok && cas != len(f.secrets[path]) {
+			fail(http.StatusBadRequest, "check-and-set parameter did not match the current version")
+			return
+		}
+		f.secrets[path] = append(f.secrets[path], body.Data)
+		reply(map[string]int{"version": len(f.secrets[path])})
+	default:
+		fail(http.StatusNotFound, "")
+	}
+}
+
+func TestVaultKVKeyStore(t *testing.T) {
+	vault := &fakeVaultKV{secrets: map[string][]json.RawMessage{}}
+	server := httptest.NewServer(vault)
+	defer server.Close()
+
+	jwt, err := ioutil.TempFile("", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(jwt.Name())
+	jwt.WriteString("sa-token\n")
+	jwt.Close()
+
+	c, err := newVaultClient(server.URL, "kubernetes", "sealed-secrets", jwt.Name(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := newVaultKVKeyStore(c, "secret", "/sealed-secrets/")
+
+	if keys, err := store.List(""); err != nil || len(keys) != 0 {
+		t.Fatalf("List() of an empty path = %v, %v", keys, err)
+	}
+
+	key, cert, err := generatePrivateKeyAndCert(1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, err := store.Create("", "prefix-", key, []*x509.Certificate{cert})
+	if err != nil {
+		t.Fatalf("Create() returned err: %v", err)
+	}
+	if _, err := store.Create("tenant", "prefix-tenant-", key, []*x509.Certificate{cert}); err != nil {
+		t.Fatalf("Create() returned err: %v", err)
+	}
+	keys, err := store.List("")
+	if err != nil {
+		t.Fatalf("List() returned err: %v", err)
+	}
+	if len(keys) != 1 || keys[0].Name != name || keys[0].Err != nil || keys[0].PrivateKey.N.Cmp(key.N) != 0 || keys[0].Blacklisted {
+		t.Fatalf("Unexpected keys: %+v", keys)
+	}
+
+	if err := store.Blacklist(name, "leaked"); err != nil {
+		t.Fatalf("Blacklist() returned err: %v", err)
+	}
+	if versions := vault.secrets["sealed-secrets/"+name]; len(versions) != 2 {
+		t.Errorf("Expected the blacklisting to add a version, got %d versions", len(versions))
+	}
+	keys, err = store.List("")
+	if err != nil {
+		t.Fatalf("List() returned err: %v", err)
+	}
+	if len(keys) != 1 || !keys[0].Blacklisted || keys[0].BlacklistReason != "leaked" {
+		t.Errorf("Expected a blacklisted key, got %+v", keys)
+	}
+
+	registry, err := initKeyRegistry(store, "prefix-", 1024)
+	if err != nil {
+		t.Fatalf("initKeyRegistry() returned err: %v", err)
+	}
+	generated, err := registry.generateKey()
+	if err != nil {
+		t.Fatalf("generateKey() returned err: %v", err)
+	}
+	reloaded, err := initKeyRegistry(store, "prefix-", 1024)
+	if err != nil {
+		t.Fatalf("initKeyRegistry() returned err: %v", err)
+	}
+	if names := reloaded.keyNames(); len(names) != 1 || names[0] != generated {
+		t.Errorf("Expected to load %s, got %v", generated, names)
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/typed/core/v1"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+// SealedSecretsBreakGlassAnnotation allows direct edits of a Secret
+// owned by a SealedSecret, despite the webhook.
+const SealedSecretsBreakGlassAnnotation = "sealedsecrets.bitnami.com/break-glass"
+
+var (
+	webhookListenAddr   = flag.String("webhook-listen-addr", "", "HTTPS serving address of the admission webhook protecting Secrets owned by SealedSecrets. Disabled if empty.")
+	webhookTLSCert      = flag.String("webhook-tls-cert", "", "Certificate file for serving the admission webhook.")
+	webhookTLSKey       = flag.String("webhook-tls-key", "", "Private key file for serving the admission webhook.")
+	webhookAllowedUsers = flag.StringSlice("webhook-allowed-users", []string{
+		"system:serviceaccount:kube-system:sealed-secrets-controller",
+		"system:serviceaccount:kube-system:generic-garbage-collector",
+		"system:serviceaccount:kube-system:namespace-controller",
+	}, "Users allowed to modify Secrets owned by SealedSecrets, eg. the controller itself, the garbage collector and the namespace controller deleting the Secrets of deleted namespaces.")
+)
+
+// admissionReview is the subset of an admission.k8s.io/v1beta1
+// AdmissionReview used by the webhook.
+type admissionReview struct {
+	metav1.TypeMeta `json:",inline"`
+	Request         *admissionRequest  `json:"request,omitempty"`
+	Response        *admissionResponse `json:"response,omitempty"`
+}
+
+type admissionRequest struct {
+	UID       types.UID                 `json:"uid"`
+	Name      string                    `json:"name,omitempty"`
+	Namespace string                    `json:"namespace,omitempty"`
+	Operation string                    `json:"operation"`
+	UserInfo  authenticationv1.UserInfo `json:"userInfo"`
+	Object    runtime.RawExtension      `json:"object,omitempty"`
+	OldObject runtime.RawExtension      `json:"oldObject,omitempty"`
+}
+
+type admissionResponse struct {
+	UID     types.UID      `json:"uid"`
+	Allowed bool           `json:"allowed"`
+	Result  *metav1.Status `json:"status,omitempty"`
+	// Patch is the JSON patch of a mutating webhook.
+	Patch     []byte  `json:"patch,omitempty"`
+	PatchType *string `json:"patchType,omitempty"`
+}
+
+// ownedBySealedSecret reports whether secret is managed by the controller.
+func ownedBySealedSecret(secret *apiv1.Secret) bool {
+	for _, ref := range secret.GetOwnerReferences() {
+		if isSealedSecretReference(ref) {
+			return true
+		}
+	}
+	return secret.GetLabels()[SealedSecretsUIDLabel] != ""
+}
+
+// admitSecret decides whether req, an update or deletion of a Secret,
+// is allowed. The Secret is read with sclient if the request doesn't
+// carry it: the request is allowed if the Secret is already gone, and
+// denied with the error if it can't be read, since it may be owned by
+// a SealedSecret. The client is told to retry then.
+func admitSecret(sclient v1.SecretsGetter, allowedUsers []string, req *admissionRequest) (bool, string, error) {
+	for _, u := range allowedUsers {
+		if req.UserInfo.Username == u {
+			return true, "", nil
+		}
+	}
+
+	var old apiv1.Secret
+	if len(req.OldObject.Raw) > 0 {
+		if err := json.Unmarshal(req.OldObject.Raw, &old); err != nil {
+			return false, "", err
+		}
+	} else {
+		s, err := sclient.Secrets(req.Namespace).Get(req.Name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return true, "", nil
+		}
+		if err != nil {
+			return false, "", fmt.Errorf("Error reading Secret %s/%s to check its owner, retry later: %v", req.Namespace, req.Name, err)
+		}
+		old = *s
+	}
+	if !ownedBySealedSecret(&old) {
+		return true, "", nil
+	}
+
+	// The break-glass annotation is taken from the new object on
+	// update, so that it must be added explicitly.
+	annotations := old.GetAnnotations()
+	if req.Operation == "UPDATE" {
+		var secret apiv1.Secret
+		if err := json.Unmarshal(req.Object.Raw, &secret); err != nil {
+			return false, "", err
+		}
+		annotations = secret.GetAnnotations()
+	}
+	if annotations[SealedSecretsBreakGlassAnnotation] == "true" {
+		log.Printf("Break-glass %s of Secret %s/%s by %s", strings.ToLower(req.Operation), req.Namespace, req.Name, req.UserInfo.Username)
+		return true, "", nil
+	}
+
+	return false, fmt.Sprintf("Secret %s/%s is managed by a SealedSecret, change the SealedSecret instead or set the %s annotation", req.Namespace, req.Name, SealedSecretsBreakGlassAnnotation), nil
+}
+
+func webhookHandler(sclient v1.SecretsGetter, allowedUsers []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		var review admissionReview
+		content, err := ioutil.ReadAll(r.Body)
+		if err == nil {
+			err = json.Unmarshal(content, &review)
+		}
+		if err != nil || review.Request == nil {
+			log.Printf("Error handling admission review: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		resp := &admissionResponse{UID: review.Request.UID}
+		allowed, reason, err := admitSecret(sclient, allowedUsers, review.Request)
+		switch {
+		case err != nil:
+			log.Printf("Error handling admission review: %v", err)
+			resp.Result = &metav1.Status{Message: err.Error(), Reason: metav1.StatusReasonInternalError, Code: http.StatusInternalServerError}
+		case !allowed:
+			resp.Result = &metav1.Status{Message: reason, Reason: metav1.StatusReasonForbidden, Code: http.StatusForbidden}
+		default:
+			resp.Allowed = true
+		}
+
+		review.Request = nil
+		review.Response = resp
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(review)
+	})
+}
+
+// sealedSecretValidationHandler serves the validating webhook denying
+// the created and updated SealedSecrets whose annotations break the
+// constraints the CRD schema can't express, see ValidateMetadata.
+func sealedSecretValidationHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		var review admissionReview
+		content, err := ioutil.ReadAll(r.Body)
+		if err == nil {
+			err = json.Unmarshal(content, &review)
+		}
+		if err != nil || review.Request == nil {
+			log.Printf("Error handling admission review: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		resp := &admissionResponse{UID: review.Request.UID}
+		var ssecret ssv1alpha1.SealedSecret
+		if err := json.Unmarshal(review.Request.Object.Raw, &ssecret); err != nil {
+			log.Printf("Error handling admission review: %v", err)
+			resp.Result = &metav1.Status{Message: err.Error(), Reason: metav1.StatusReasonBadRequest, Code: http.StatusBadRequest}
+		} else if err := ssecret.ValidateMetadata(); err != nil {
+			resp.Result = &metav1.Status{Message: err.Error(), Reason: metav1.StatusReasonInvalid, Code: http.StatusUnprocessableEntity}
+		} else {
+			resp.Allowed = true
+		}
+
+		review.Request = nil
+		review.Response = resp
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(review)
+	})
+}
+
+func webhookserver(sclient v1.SecretsGetter, policies func() []*ssv1alpha1.SealedSecretPolicy) {
+	mux := http.NewServeMux()
+	mux.Handle("/v1/validate-secret", webhookHandler(sclient, *webhookAllowedUsers))
+	mux.Handle("/v1/default-sealedsecret", defaultingHandler(policies))
+	mux.Handle("/v1/validate-sealedsecret", sealedSecretValidationHandler())
+
+	server := http.Server{
+		Addr:         *webhookListenAddr,
+		Handler:      mux,
+		ReadTimeout:  *readTimeout,
+		WriteTimeout: *writeTimeout,
+	}
+
+	log.Printf("Admission webhook serving on %s", server.Addr)
+	err := server.ListenAndServeTLS(*webhookTLSCert, *webhookTLSKey)
+	log.Printf("Admission webhook exiting: %v", err)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+func rawExt(b []byte) runtime.RawExtension {
+	return runtime.RawExtension{Raw: b}
+}
+
+func TestWebhookHandler(t *testing.T) {
+	owned := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mysecret",
+			Namespace: "myns",
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "bitnami.com/v1alpha1", Kind: "SealedSecret", Name: "mysecret"},
+			},
+		},
+	}
+	unowned := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "myns"}}
+	brokenGlass := owned.DeepCopy()
+	brokenGlass.Annotations = map[string]string{SealedSecretsBreakGlassAnnotation: "true"}
+
+	client := fake.NewSimpleClientset(owned)
+	handler := webhookHandler(client.Core(), []string{"controller"})
+
+	raw := func(s *v1.Secret) []byte {
+		b, err := json.Marshal(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	testCases := []struct {
+		name    string
+		req     admissionRequest
+		allowed bool
+	}{
+		{"update owned", admissionRequest{Operation: "UPDATE", Name: "mysecret", Namespace: "myns", Object: rawExt(raw(owned)), OldObject: rawExt(raw(owned))}, false},
+		{"update unowned", admissionRequest{Operation: "UPDATE", Name: "other", Namespace: "myns", Object: rawExt(raw(unowned)), OldObject: rawExt(raw(unowned))}, true},
+		{"break glass", admissionRequest{Operation: "UPDATE", Name: "mysecret", Namespace: "myns", Object: rawExt(raw(brokenGlass)), OldObject: rawExt(raw(owned))}, true},
+		{"delete owned without old object", admissionRequest{Operation: "DELETE", Name: "mysecret", Namespace: "myns"}, false},
+		{"delete missing without old object", admissionRequest{Operation: "DELETE", Name: "missing", Namespace: "myns"}, true},
+		{"controller", admissionRequest{Operation: "DELETE", Name: "mysecret", Namespace: "myns", OldObject: rawExt(raw(owned))}, true},
+	}
+	testCases[5].req.UserInfo.Username = "controller"
+
+	for _, tc := range testCases {
+		req := tc.req
+		req.UID = "42"
+		body, err := json.Marshal(admissionReview{Request: &req})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/validate-secret", bytes.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: unexpected status %d", tc.name, w.Code)
+			continue
+		}
+		var review admissionReview
+		if err := json.Unmarshal(w.Body.Bytes(), &review); err != nil {
+			t.Fatal(err)
+		}
+		if review.Response == nil || review.Response.UID != "42" {
+			t.Fatalf("%s: unexpected response %v", tc.name, review.Response)
+		}
+		if review.Response.Allowed != tc.allowed {
+			t.Errorf("%s: allowed = %v, expected %v", tc.name, review.Response.Allowed, tc.allowed)
+		}
+	}
+}
+
+func TestWebhookDefaultAllowedUsers(t *testing.T) {
+	owned := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mysecret",
+			Namespace: "myns",
+			Labels:    map[string]string{SealedSecretsUIDLabel: "uid"},
+		},
+	}
+	client := fake.NewSimpleClientset(owned)
+	for _, user := range []string{
+		"system:serviceaccount:kube-system:sealed-secrets-controller",
+		"system:serviceaccount:kube-system:generic-garbage-collector",
+		"system:serviceaccount:kube-system:namespace-controller",
+	} {
+		req := &admissionRequest{Operation: "DELETE", Name: "mysecret", Namespace: "myns"}
+		req.UserInfo.Username = user
+		allowed, _, err := admitSecret(client.Core(), *webhookAllowedUsers, req)
+		if err != nil || !allowed {
+			t.Errorf("Expected %s to be allowed, got %v, %v", user, allowed, err)
+		}
+	}
+}
+
+func TestWebhookReadError(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("get", "secrets", func(action ktesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.NewServiceUnavailable("etcd is down")
+	})
+	handler := webhookHandler(client.Core(), nil)
+
+	body, err := json.Marshal(admissionReview{Request: &admissionRequest{UID: "42", Operation: "DELETE", Name: "mysecret", Namespace: "myns"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/validate-secret", bytes.NewReader(body)))
+	var review admissionReview
+	if err := json.Unmarshal(w.Body.Bytes(), &review); err != nil {
+		t.Fatal(err)
+	}
+	if review.Response == nil || review.Response.Allowed {
+		t.Fatalf("Expected the deletion to be denied when the Secret can't be read, got %v", review.Response)
+	}
+	if review.Response.Result == nil || review.Response.Result.Code != http.StatusInternalServerError {
+		t.Errorf("Expected an internal error to retry, got %v", review.Response.Result)
+	}
+}
+
+func TestSealedSecretValidationHandler(t *testing.T) {
+	handler := sealedSecretValidationHandler()
+	testCases := []struct {
+		annotations map[string]string
+		allowed     bool
+	}{
+		{map[string]string{ssv1alpha1.SealedSecretNamespaceWideAnnotation: "true"}, true},
+		{map[string]string{ssv1alpha1.SealedSecretNamespaceWideAnnotation: "true", ssv1alpha1.SealedSecretClusterWideAnnotation: "true"}, false},
+	}
+	for _, tc := range testCases {
+		ssecret := ssv1alpha1.SealedSecret{
+			ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "myns", Annotations: tc.annotations},
+			Spec:       ssv1alpha1.SealedSecretSpec{EncryptedData: map[string][]byte{"foo": []byte("bar")}},
+		}
+		object, err := json.Marshal(ssecret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := json.Marshal(admissionReview{Request: &admissionRequest{UID: "42", Operation: "CREATE", Object: rawExt(object)}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/validate-sealedsecret", bytes.NewReader(body)))
+		var review admissionReview
+		if err := json.Unmarshal(w.Body.Bytes(), &review); err != nil {
+			t.Fatal(err)
+		}
+		if review.Response == nil || review.Response.UID != "42" {
+			t.Fatalf("Unexpected response %+v", review.Response)
+		}
+		if review.Response.Allowed != tc.allowed {
+			t.Errorf("%v: expected allowed %v, got %+v", tc.annotations, tc.allowed, review.Response)
+		}
+		if !tc.allowed && (review.Response.Result == nil || review.Response.Result.Reason != metav1.StatusReasonInvalid) {
+			t.Errorf("%v: expected an Invalid status, got %+v", tc.annotations, review.Response.Result)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	flag "github.com/spf13/pflag"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto/mlkem"
+	sealing "github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+var (
+	sealAlgorithm = flag.String("algorithm", "rsa", "Algorithm to seal Secrets with: rsa; x25519, which is faster and more compact; or the experimental rsa+mlkem768, which also resists quantum computers. The latter two require a key generated by a controller supporting them.")
+	sealCipher    = flag.String("cipher", "aes-gcm", "Cipher encrypting the values of Secrets sealed with --algorithm=rsa: aes-gcm, or chacha20-poly1305 for hosts without AES instructions.")
+	sealOAEPHash  = flag.String("oaep-hash", "sha256", "Hash of RSA-OAEP for Secrets sealed with --algorithm=rsa: sha256, or sha1 for interoperability with tools that lack SHA-256.")
+	sealCompress  = flag.String("compress", "none", "Compression of the values of Secrets sealed with --algorithm=rsa before encryption: none, or gzip for large values such as kubeconfigs, or zstd which is faster but requires a controller supporting it.")
+	sealChunkSize = flag.Int("chunk-size", 0, "Split the values of Secrets sealed with --algorithm=rsa larger than this many bytes, once compressed, in chunks encrypted separately, which requires a controller supporting it. Disabled if 0.")
+)
+
+// cipherNames and oaepHashNames map the --cipher and --oaep-hash values
+// to their SealedSecret names.
+var (
+	cipherNames = map[string]string{
+		"aes-gcm":           ssv1alpha1.CipherAESGCM,
+		"chacha20-poly1305": ssv1alpha1.CipherChaCha20Poly1305,
+	}
+	oaepHashNames = map[string]string{
+		"sha256": ssv1alpha1.OAEPHashSHA256,
+		"sha1":   ssv1alpha1.OAEPHashSHA1,
+	}
+)
+
+// sealParams returns the SealedSecret primitives selected by --cipher,
+// --oaep-hash, --compress and --chunk-size, empty for the defaults.
+func sealParams() (ssv1alpha1.SealingParams, error) {
+	var params ssv1alpha1.SealingParams
+	if *sealCipher != "aes-gcm" {
+		var ok bool
+		if params.Cipher, ok = cipherNames[*sealCipher]; !ok {
+			return params, fmt.Errorf("Unknown --cipher %q, expected aes-gcm or chacha20-poly1305", *sealCipher)
+		}
+	}
+	if *sealOAEPHash != "sha256" {
+		var ok bool
+		if params.OAEPHash, ok = oaepHashNames[*sealOAEPHash]; !ok {
+			return params, fmt.Errorf("Unknown --oaep-hash %q, expected sha256 or sha1", *sealOAEPHash)
+		}
+	}
+	switch *sealCompress {
+	case "none":
+	case "gzip":
+		params.Compression = ssv1alpha1.CompressionGzip
+	case "zstd":
+		params.Compression = ssv1alpha1.CompressionZstd
+	default:
+		return params, fmt.Errorf("Unknown --compress %q, expected none, gzip or zstd", *sealCompress)
+	}
+	if *sealChunkSize != 0 && (*sealChunkSize < crypto.MinChunkSize || *sealChunkSize > crypto.MaxChunkSize) {
+		return params, fmt.Errorf("--chunk-size must be between %d and %d", crypto.MinChunkSize, crypto.MaxChunkSize)
+	}
+	params.ChunkSize = *sealChunkSize
+	if params != (ssv1alpha1.SealingParams{}) && *sealAlgorithm != "rsa" {
+		return params, fmt.Errorf("--cipher, --oaep-hash, --compress and --chunk-size require --algorithm=rsa")
+	}
+	return params, nil
+}
+
+// defaultSealParams reports whether --cipher, --oaep-hash, --compress
+// and --chunk-size have their defaults, which is required to seal other
+// objects than Secrets.
+func defaultSealParams() bool {
+	return *sealCipher == "aes-gcm" && *sealOAEPHash == "sha256" && *sealCompress == "none" && *sealChunkSize == 0
+}
+
+var (
+	// x25519PubKey is the key Secrets are sealed with, nil unless
+	// --algorithm=x25519.
+	x25519PubKey *[crypto.X25519KeySize]byte
+	// mlkemPubKey is the key Secrets are sealed with, along with the
+	// RSA key, nil unless --algorithm=rsa+mlkem768.
+	mlkemPubKey *mlkem.EncapsulationKey768
+	// externalKeyService is the service holding the key, such as the
+	// Vault transit engine, empty for the keys of the controller.
+	externalKeyService string
+)
+
+// parsePubKeys reads the RSA public key of the certificate read from r
+// and, with the other algorithms, the X25519 or ML-KEM key published with
+// it.
+func parsePubKeys(r io.Reader) (*rsa.PublicKey, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	x25519PubKey, mlkemPubKey = nil, nil
+	if externalKeyService, err = sealing.ParseExternalKey(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	if externalKeyService != "" && *sealAlgorithm != "rsa" {
+		return nil, fmt.Errorf("The key is held by %s, which only supports --algorithm=rsa", externalKeyService)
+	}
+	switch *sealAlgorithm {
+	case "rsa":
+	case "rsa+mlkem768":
+		if mlkemPubKey, err = sealing.ParseMLKEMPublicKey(bytes.NewReader(data)); err != nil {
+			return nil, err
+		}
+	case "x25519":
+		if x25519PubKey, err = sealing.ParseX25519PublicKey(bytes.NewReader(data)); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("Unknown --algorithm %q, expected rsa, x25519 or rsa+mlkem768", *sealAlgorithm)
+	}
+	pubKey, err := parseKey(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if err := crypto.CheckFIPSKey(pubKey); err != nil {
+		return nil, err
+	}
+	return pubKey, nil
+}
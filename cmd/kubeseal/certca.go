@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	flag "github.com/spf13/pflag"
+	certUtil "k8s.io/client-go/util/cert"
+)
+
+var (
+	certCAFile = flag.String("cert-ca-file", "", "PEM file of the CA certificates the sealing certificate must chain to. Sealing is refused against any other certificate.")
+	forceCert  = flag.Bool("force", false, "Seal with a certificate that is expired, not yet valid or not meant for encryption. The controller may not be able to unseal the result.")
+)
+
+// checkCertValidity checks that cert is valid at now and can be used
+// for encryption.
+func checkCertValidity(cert *x509.Certificate, now time.Time) error {
+	switch {
+	case now.Before(cert.NotBefore):
+		return fmt.Errorf("Sealing certificate is not valid before %s, check your clock or use --force", cert.NotBefore.UTC().Format(time.RFC3339))
+	case now.After(cert.NotAfter):
+		return fmt.Errorf("Sealing certificate expired on %s, fetch the current one from the controller or use --force", cert.NotAfter.UTC().Format(time.RFC3339))
+	}
+	// The controller certificates are marked for encipherment only
+	usages := x509.KeyUsageKeyEncipherment | x509.KeyUsageDataEncipherment | x509.KeyUsageEncipherOnly
+	if cert.KeyUsage != 0 && cert.KeyUsage&usages == 0 {
+		return fmt.Errorf("Sealing certificate is not meant for encryption, use --force to seal with it anyway")
+	}
+	return nil
+}
+
+// verifyCert checks that the first certificate of the PEM data chains
+// to one of the certificates of the PEM caData. The other certificates
+// of data are intermediates.
+func verifyCert(data, caData []byte) error {
+	certs, err := certUtil.ParseCertsPEM(data)
+	if err != nil {
+		return err
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caData) {
+		return fmt.Errorf("No CA certificates found")
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err = certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		// Sealing certificates have no particular usage
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return fmt.Errorf("Untrusted sealing certificate, refusing to use it: %v", err)
+	}
+	return nil
+}
+
+// openCert opens the configured certificate source, checking that the
+// certificate is valid, unless --force or --fetch-cert, and verifying
+// it against --cert-ca-file if set.
+func openCert() (io.ReadCloser, error) {
+	check := !*forceCert && !*dumpCert
+	f, err := openCertSource()
+	if err != nil || (!check && *certCAFile == "") {
+		return f, err
+	}
+	defer f.Close()
+
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	if check {
+		certs, err := certUtil.ParseCertsPEM(data)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkCertValidity(certs[0], time.Now()); err != nil {
+			return nil, &certificateError{err}
+		}
+	}
+	if *certCAFile != "" {
+		caData, err := ioutil.ReadFile(*certCAFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := verifyCert(data, caData); err != nil {
+			return nil, &certificateError{err}
+		}
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
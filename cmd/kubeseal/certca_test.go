@@ -0,0 +1,155 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	certUtil "k8s.io/client-go/util/cert"
+)
+
+func testCertificate(t *testing.T, cn string, isCA bool, parent *x509.Certificate, parentKey *rsa.PrivateKey) (*x509.Certificate, *rsa.PrivateKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageCertSign,
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func TestVerifyCert(t *testing.T) {
+	ca, caKey := testCertificate(t, "ca", true, nil, nil)
+	leaf, _ := testCertificate(t, "sealed-secrets", false, ca, caKey)
+	other, _ := testCertificate(t, "other", true, nil, nil)
+	caPEM := certUtil.EncodeCertPEM(ca)
+
+	if err := verifyCert(certUtil.EncodeCertPEM(leaf), caPEM); err != nil {
+		t.Errorf("Expected certificate signed by the CA to be trusted: %v", err)
+	}
+	if err := verifyCert(certUtil.EncodeCertPEM(other), caPEM); err == nil {
+		t.Error("Expected certificate of another CA to be refused")
+	}
+	if err := verifyCert([]byte(testCert), caPEM); err == nil {
+		t.Error("Expected self-signed certificate to be refused")
+	}
+	// Pinning a self-signed certificate
+	if err := verifyCert(certUtil.EncodeCertPEM(other), certUtil.EncodeCertPEM(other)); err != nil {
+		t.Errorf("Expected pinned certificate to be trusted: %v", err)
+	}
+	if err := verifyCert(certUtil.EncodeCertPEM(leaf), []byte("garbage")); err == nil {
+		t.Error("Expected CA file without certificates to fail")
+	}
+}
+
+func TestOpenCertCA(t *testing.T) {
+	ca, caKey := testCertificate(t, "ca", true, nil, nil)
+	leaf, _ := testCertificate(t, "sealed-secrets", false, ca, caKey)
+	leafPEM := certUtil.EncodeCertPEM(leaf)
+
+	*certFile = tmpfile(t, leafPEM)
+	*certCAFile = tmpfile(t, certUtil.EncodeCertPEM(ca))
+	defer func() {
+		os.Remove(*certFile)
+		os.Remove(*certCAFile)
+		*certFile = ""
+		*certCAFile = ""
+	}()
+
+	f, err := openCert()
+	if err != nil {
+		t.Fatalf("openCert() returned err: %v", err)
+	}
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(leafPEM) {
+		t.Errorf("Read incorrect data from cert file")
+	}
+
+	if err := ioutil.WriteFile(*certFile, []byte(testCert), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openCert(); err == nil {
+		t.Error("Expected openCert() to refuse an untrusted certificate")
+	}
+}
+
+func TestCheckCertValidity(t *testing.T) {
+	now := time.Now()
+	valid := func(usage x509.KeyUsage) *x509.Certificate {
+		return &x509.Certificate{NotBefore: now.Add(-time.Hour), NotAfter: now.Add(time.Hour), KeyUsage: usage}
+	}
+
+	for _, usage := range []x509.KeyUsage{0, x509.KeyUsageEncipherOnly, x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature} {
+		if err := checkCertValidity(valid(usage), now); err != nil {
+			t.Errorf("Expected certificate with usage %v to be valid: %v", usage, err)
+		}
+	}
+	if err := checkCertValidity(valid(x509.KeyUsageDigitalSignature|x509.KeyUsageCertSign), now); err == nil {
+		t.Error("Expected signing certificate to be refused")
+	}
+	if err := checkCertValidity(valid(0), now.Add(2*time.Hour)); err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Errorf("Expected expired certificate to be refused, got %v", err)
+	}
+	if err := checkCertValidity(valid(0), now.Add(-2*time.Hour)); err == nil || !strings.Contains(err.Error(), "not valid before") {
+		t.Errorf("Expected not yet valid certificate to be refused, got %v", err)
+	}
+}
+
+func TestOpenCertExpired(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-2 * time.Hour),
+		NotAfter:     time.Now().Add(-time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	*certFile = tmpfile(t, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	defer func() {
+		os.Remove(*certFile)
+		*certFile = ""
+		*forceCert = false
+	}()
+
+	if _, err := openCert(); err == nil {
+		t.Error("Expected openCert() to refuse an expired certificate")
+	}
+	*forceCert = true
+	if _, err := openCert(); err != nil {
+		t.Errorf("Expected openCert() to accept an expired certificate with --force: %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	flag "github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/net"
+)
+
+var (
+	clientCertFile   = flag.String("controller-client-cert", "", "Certificate file presented to the controller at --controller-url, eg. a SPIFFE SVID, when it restricts the client identities.")
+	clientKeyFile    = flag.String("controller-client-key", "", "Private key file of --controller-client-cert.")
+	controllerCAFile = flag.String("controller-ca", "", "PEM file of the CA certificates of the controller at --controller-url, the system ones if empty.")
+)
+
+// controllerClient sends the requests to --controller-url.
+var controllerClient = http.DefaultClient
+
+// newControllerClient returns the client presenting certFile and
+// keyFile, and verifying the controller with caFile, if set.
+func newControllerClient(certFile, keyFile, caFile string) (*http.Client, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load --controller-client-cert: %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	if caFile != "" {
+		data, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+		config.RootCAs = pool
+	}
+	return &http.Client{
+		Transport: net.SetTransportDefaults(&http.Transport{TLSClientConfig: config}),
+		Timeout:   5 * time.Minute,
+	}, nil
+}
+
+// postController posts body to url on the controller and returns the
+// body of the response.
+func postController(url string, body []byte) ([]byte, error) {
+	resp, err := controllerClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s %s", url, resp.Status, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
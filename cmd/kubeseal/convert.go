@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	runtimeserializer "k8s.io/apimachinery/pkg/runtime/serializer"
+)
+
+// sopsDecrypt returns the plaintext of a SOPS encrypted file. It relies
+// on the sops binary and whatever decryption access (KMS, PGP, age) the
+// local user has.
+var sopsDecrypt = func(file string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("sops", "--decrypt", file)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("sops failed to decrypt %s: %s", file, strings.TrimSpace(stderr.String()))
+		}
+		return nil, fmt.Errorf("Error running sops: %v", err)
+	}
+	return out, nil
+}
+
+// convertFromSOPS seals the Secrets of a SOPS encrypted manifest,
+// writing them to out or, if dir is set, to one file per secret.
+func convertFromSOPS(file string, out io.Writer, dir string, codecs runtimeserializer.CodecFactory, pubKey *rsa.PublicKey) error {
+	plaintext, err := sopsDecrypt(file)
+	if err != nil {
+		return err
+	}
+
+	// sops strips its own metadata when decrypting, so the
+	// plaintext is a regular Secret manifest.
+	if escrowEnabled() {
+		if err := writeEscrow(*escrowFile, plaintext, *escrowAgeRecipients, *escrowPGPRecipients); err != nil {
+			return err
+		}
+	}
+
+	in := bytes.NewReader(plaintext)
+	if dir != "" {
+		return sealToDir(in, dir, *outputName, codecs, pubKey)
+	}
+	return seal(in, out, codecs, pubKey)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+func TestConvertFromSOPS(t *testing.T) {
+	key, err := parseKey(strings.NewReader(testCert))
+	if err != nil {
+		t.Fatalf("Failed to parse test key: %v", err)
+	}
+
+	defer func(f func(string) ([]byte, error)) { sopsDecrypt = f }(sopsDecrypt)
+	sopsDecrypt = func(file string) ([]byte, error) {
+		if file != "secret.enc.yaml" {
+			return nil, fmt.Errorf("unexpected file %s", file)
+		}
+		return []byte(`
+apiVersion: v1
+kind: Secret
+metadata:
+  name: mysecret
+  namespace: myns
+data:
+  foo: c2VrcmV0
+`), nil
+	}
+
+	var out bytes.Buffer
+	if err := convertFromSOPS("secret.enc.yaml", &out, "", scheme.Codecs, key); err != nil {
+		t.Fatalf("convertFromSOPS() returned error: %v", err)
+	}
+
+	var result ssv1alpha1.SealedSecret
+	if err = runtime.DecodeInto(scheme.Codecs.UniversalDecoder(), out.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	if result.GetName() != "mysecret" || result.GetNamespace() != "myns" {
+		t.Errorf("Unexpected result %s/%s", result.GetNamespace(), result.GetName())
+	}
+	if _, ok := result.Spec.EncryptedData["foo"]; !ok {
+		t.Errorf("Missing encrypted item: %v", result.Spec.EncryptedData)
+	}
+}
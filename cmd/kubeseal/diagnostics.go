@@ -0,0 +1,116 @@
+package main
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+
+	flag "github.com/spf13/pflag"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	sealing "github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+var (
+	diagnosticsFormat = flag.String("output", "text", "Format of the diagnostics written to stderr, text or json. With json, a result object is written on success and failure.")
+)
+
+// diagnostic is the result of a kubeseal run written with --output json.
+type diagnostic struct {
+	Sealed bool `json:"sealed"`
+	// CertFingerprint identifies the public key of the sealing
+	// certificate, see seal.Fingerprint.
+	CertFingerprint string `json:"certFingerprint,omitempty"`
+	// Reason is a machine readable CamelCase reason of the failure.
+	Reason string `json:"reason,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// certificateError is returned when the sealing certificate can't be
+// used.
+type certificateError struct {
+	err error
+}
+
+func (e *certificateError) Error() string {
+	return e.err.Error()
+}
+
+// errorReason classifies err for the diagnostics.
+func errorReason(err error) string {
+	switch err.(type) {
+	case *certificateError, x509.CertificateInvalidError, x509.UnknownAuthorityError:
+		return "InvalidCertificate"
+	case *os.PathError:
+		return "FileError"
+	case *url.Error, net.Error:
+		return "ConnectionError"
+	case k8serrors.APIStatus:
+		if reason := k8serrors.ReasonForError(err); reason != metav1.StatusReasonUnknown {
+			return string(reason)
+		}
+		return "APIError"
+	}
+	if err == ssv1alpha1.ErrEmptyData {
+		return "EmptyData"
+	}
+	return "Error"
+}
+
+func jsonDiagnostics() bool {
+	return *diagnosticsFormat == "json"
+}
+
+// currentDiagnostic is completed as kubeseal runs.
+var currentDiagnostic diagnostic
+
+// recordCertificate adds the fingerprint of pubKey to the diagnostics.
+func recordCertificate(pubKey *rsa.PublicKey) {
+	if fingerprint, err := sealing.Fingerprint(pubKey); err == nil {
+		currentDiagnostic.CertFingerprint = fingerprint
+	}
+}
+
+// writeDiagnostic writes d as a JSON line to w.
+func writeDiagnostic(w io.Writer, d diagnostic) {
+	json.NewEncoder(w).Encode(d)
+}
+
+// reportSealed records a successful run, written to stderr with --output
+// json.
+func reportSealed() {
+	currentDiagnostic.Sealed = true
+	if jsonDiagnostics() {
+		writeDiagnostic(os.Stderr, currentDiagnostic)
+	}
+}
+
+// fatal reports err and exits. Without --output json it panics, as
+// kubeseal always did.
+func fatal(err error) {
+	if !jsonDiagnostics() {
+		panic(err.Error())
+	}
+	d := currentDiagnostic
+	d.Sealed = false
+	d.Reason = errorReason(err)
+	d.Error = err.Error()
+	writeDiagnostic(os.Stderr, d)
+	os.Exit(1)
+}
+
+// checkDiagnosticsFormat validates --output.
+func checkDiagnosticsFormat() error {
+	switch *diagnosticsFormat {
+	case "text", "json":
+		return nil
+	}
+	return fmt.Errorf("unsupported --output %q, expected text or json", *diagnosticsFormat)
+}
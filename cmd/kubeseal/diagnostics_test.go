@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+func TestErrorReason(t *testing.T) {
+	_, fileErr := os.Open("/does/not/exist")
+	testCases := []struct {
+		err      error
+		expected string
+	}{
+		{errors.New("boom"), "Error"},
+		{&certificateError{errors.New("expired")}, "InvalidCertificate"},
+		{fileErr, "FileError"},
+		{ssv1alpha1.ErrEmptyData, "EmptyData"},
+		{k8serrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, "mysecret"), "NotFound"},
+	}
+	for _, tc := range testCases {
+		if got := errorReason(tc.err); got != tc.expected {
+			t.Errorf("%v: expected %s, got %s", tc.err, tc.expected, got)
+		}
+	}
+}
+
+func TestWriteDiagnostic(t *testing.T) {
+	var buf bytes.Buffer
+	writeDiagnostic(&buf, diagnostic{Sealed: true, CertFingerprint: "abc"})
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["sealed"] != true || got["certFingerprint"] != "abc" {
+		t.Errorf("Unexpected diagnostic %v", got)
+	}
+	if _, ok := got["error"]; ok {
+		t.Errorf("Unexpected error in %v", got)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// escrowEncrypt encrypts plaintext for the given age or PGP recipients,
+// returning ASCII armored output. It relies on the age and gpg binaries
+// respectively, in the same way convert relies on sops.
+var escrowEncrypt = func(plaintext []byte, ageRecipients, pgpRecipients []string) ([]byte, error) {
+	var cmd *exec.Cmd
+	if len(ageRecipients) > 0 {
+		args := []string{"--armor"}
+		for _, r := range ageRecipients {
+			args = append(args, "--recipient", r)
+		}
+		cmd = exec.Command("age", args...)
+	} else {
+		args := []string{"--batch", "--yes", "--armor", "--encrypt"}
+		for _, r := range pgpRecipients {
+			args = append(args, "--recipient", r)
+		}
+		cmd = exec.Command("gpg", args...)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(plaintext)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("%s failed to encrypt escrow copy: %s", cmd.Args[0], strings.TrimSpace(stderr.String()))
+		}
+		return nil, fmt.Errorf("Error running %s: %v", cmd.Args[0], err)
+	}
+	return out, nil
+}
+
+// escrowEnabled reports whether an escrow copy is requested, by a file
+// or recipients, so that writeEscrow fails if the other is missing.
+func escrowEnabled() bool {
+	return *escrowFile != "" || len(*escrowAgeRecipients) > 0 || len(*escrowPGPRecipients) > 0
+}
+
+// writeEscrow stores an encrypted copy of plaintext in file. The file is
+// only readable by its owner, even though its content is encrypted, and
+// an existing file is restricted too.
+func writeEscrow(file string, plaintext []byte, ageRecipients, pgpRecipients []string) error {
+	if len(ageRecipients) > 0 && len(pgpRecipients) > 0 {
+		return errors.New("escrow recipients must be either age or PGP, not both")
+	}
+	if len(ageRecipients) == 0 && len(pgpRecipients) == 0 {
+		return errors.New("--escrow-age-recipient or --escrow-pgp-recipient is required with --escrow-file")
+	}
+	if file == "" {
+		return errors.New("--escrow-file is required when escrow recipients are given")
+	}
+
+	ciphertext, err := escrowEncrypt(plaintext, ageRecipients, pgpRecipients)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(ciphertext); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// escrowInput writes the escrow copy of everything read from in and
+// returns a reader replaying the same input for sealing. The escrow copy
+// is written first so that no sealed secret is produced without it.
+func escrowInput(in io.Reader) (io.Reader, error) {
+	plaintext, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeEscrow(*escrowFile, plaintext, *escrowAgeRecipients, *escrowPGPRecipients); err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(plaintext), nil
+}
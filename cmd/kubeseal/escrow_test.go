@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteEscrow(t *testing.T) {
+	dir, err := ioutil.TempDir("", "escrow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer func(f func([]byte, []string, []string) ([]byte, error)) { escrowEncrypt = f }(escrowEncrypt)
+	var gotRecipients []string
+	escrowEncrypt = func(plaintext []byte, ageRecipients, pgpRecipients []string) ([]byte, error) {
+		gotRecipients = ageRecipients
+		return append([]byte("encrypted:"), plaintext...), nil
+	}
+
+	file := filepath.Join(dir, "escrow.age")
+	// An existing file is restricted too
+	if err := ioutil.WriteFile(file, []byte("old escrow copy"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	recipients := []string{"age1abc", "age1def"}
+	if err := writeEscrow(file, []byte("sekret"), recipients, nil); err != nil {
+		t.Fatalf("writeEscrow() returned error: %v", err)
+	}
+	if !reflect.DeepEqual(gotRecipients, recipients) {
+		t.Errorf("Unexpected recipients %v", gotRecipients)
+	}
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(content, []byte("encrypted:sekret")) {
+		t.Errorf("Unexpected escrow content %q", content)
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Unexpected escrow file mode %v", info.Mode())
+	}
+
+	if err := writeEscrow(file, []byte("sekret"), recipients, []string{"ops@example.com"}); err == nil {
+		t.Errorf("Expected error when mixing age and PGP recipients")
+	}
+	if err := writeEscrow("", []byte("sekret"), recipients, nil); err == nil {
+		t.Errorf("Expected error without escrow file")
+	}
+	if err := writeEscrow(file, []byte("sekret"), nil, nil); err == nil {
+		t.Errorf("Expected error without escrow recipients")
+	}
+}
+
+func TestEscrowEnabled(t *testing.T) {
+	defer func(file string) { *escrowFile = file }(*escrowFile)
+	*escrowFile = "escrow.age"
+	if !escrowEnabled() {
+		t.Errorf("Expected --escrow-file alone to enable the escrow, to fail without recipients")
+	}
+	if _, err := escrowInput(bytes.NewReader([]byte("sekret"))); err == nil {
+		t.Errorf("Expected an error without escrow recipients")
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// lastAppliedAnnotation holds the previous manifest applied by kubectl,
+// including the plaintext data of Secrets.
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+var (
+	fetchSecretRef = flag.String("fetch-secret", "", "Seal the Secret [namespace/]name read from the cluster with your kubeconfig credentials, instead of reading Secrets from stdin.")
+)
+
+// fetchSecret reads the Secret ref, namespace/name or a name in the
+// namespace ns, and returns it as a manifest to seal. Only its name,
+// namespace, labels, annotations, type and data are kept.
+func fetchSecret(c corev1.SecretsGetter, ref, ns string) (*v1.Secret, error) {
+	name := ref
+	if i := strings.Index(ref, "/"); i != -1 {
+		ns, name = ref[:i], ref[i+1:]
+	}
+	if ns == "" || name == "" || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("Invalid --fetch-secret %q, expected namespace/name", ref)
+	}
+
+	secret, err := c.Secrets(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching Secret %s/%s: %v", ns, name, err)
+	}
+
+	annotations := map[string]string{}
+	for k, v := range secret.GetAnnotations() {
+		// Never copy plaintext into the SealedSecret
+		if k != lastAppliedAnnotation {
+			annotations[k] = v
+		}
+	}
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	return &v1.Secret{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        secret.GetName(),
+			Namespace:   secret.GetNamespace(),
+			Labels:      secret.GetLabels(),
+			Annotations: annotations,
+		},
+		Type: secret.Type,
+		Data: secret.Data,
+	}, nil
+}
+
+// fetchSecretInput returns the Secret ref read from the cluster, see
+// fetchSecret, as the input of kubeseal.
+func fetchSecretInput(c corev1.SecretsGetter, ref, ns string) (io.Reader, error) {
+	secret, err := fetchSecret(c, ref, ns)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(secret)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(b), nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+func TestFetchSecret(t *testing.T) {
+	client := fake.NewSimpleClientset(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "mysecret",
+			Namespace:       "myns",
+			UID:             "uid",
+			ResourceVersion: "42",
+			Labels:          map[string]string{"app": "db"},
+			Annotations: map[string]string{
+				"owner":               "team-db",
+				lastAppliedAnnotation: `{"data":{"password":"c2VrcmV0"}}`,
+			},
+		},
+		Type: v1.SecretTypeOpaque,
+		Data: map[string][]byte{"password": []byte("sekret")},
+	})
+
+	secret, err := fetchSecret(client.CoreV1(), "mysecret", "myns")
+	if err != nil {
+		t.Fatalf("fetchSecret() returned err: %v", err)
+	}
+	if secret.GetUID() != "" || secret.GetResourceVersion() != "" {
+		t.Errorf("Expected server fields to be dropped, got %v", secret.ObjectMeta)
+	}
+	if expected := map[string]string{"owner": "team-db"}; !reflect.DeepEqual(secret.GetAnnotations(), expected) {
+		t.Errorf("Expected annotations %v, got %v", expected, secret.GetAnnotations())
+	}
+
+	in, err := fetchSecretInput(client.CoreV1(), "myns/mysecret", "default")
+	if err != nil {
+		t.Fatalf("fetchSecretInput() returned err: %v", err)
+	}
+	key, err := parseKey(strings.NewReader(testCert))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := seal(in, &out, scheme.Codecs, key); err != nil {
+		t.Fatalf("seal() returned err: %v", err)
+	}
+	var ssecret ssv1alpha1.SealedSecret
+	if err := runtime.DecodeInto(scheme.Codecs.UniversalDecoder(), out.Bytes(), &ssecret); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	if ssecret.GetNamespace() != "myns" || ssecret.GetName() != "mysecret" || len(ssecret.Spec.EncryptedData) != 1 {
+		t.Errorf("Unexpected SealedSecret %v", ssecret)
+	}
+
+	for _, ref := range []string{"myns/missing", "a/b/c", "myns/"} {
+		if _, err := fetchSecret(client.CoreV1(), ref, "myns"); err == nil {
+			t.Errorf("Expected fetchSecret(%q) to fail", ref)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+)
+
+var (
+	fips = flag.Bool("fips", false, "Only seal with FIPS 140-2 approved algorithms and keys: --algorithm=rsa with the default cipher and OAEP hash, and certificates of at least 2048 bits.")
+)
+
+// initFIPS enables the FIPS mode with --fips, checking the sealing
+// flags.
+func initFIPS() error {
+	if !*fips {
+		return nil
+	}
+	if *sealAlgorithm != "rsa" || *sealCipher != "aes-gcm" || *sealOAEPHash != "sha256" {
+		return fmt.Errorf("--fips requires --algorithm=rsa, --cipher=aes-gcm and --oaep-hash=sha256")
+	}
+	crypto.SetFIPS(true)
+	return nil
+}
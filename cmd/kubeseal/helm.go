@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	sealing "github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+func isHelmOutput() bool {
+	return strings.ToLower(*outputFormat) == "helm"
+}
+
+// helmTemplate turns the YAML manifest doc of the sealed object obj
+// into a Helm template. The ciphertexts are kept literal, while the
+// name and namespace are parameterized as far as the scope of obj
+// allows: the namespace on the namespace of the release, the name
+// prefixed with the name of the release.
+func helmTemplate(doc []byte, obj metav1.Object) []byte {
+	// Templates of SealedSecrets must reach the controller unrendered
+	lines := strings.Split(strings.Replace(string(doc), "{{", `{{"{{"}}`, -1), "\n")
+
+	values := map[string]string{}
+	header := "# Sealed with strict scope: the name and namespace can't be changed"
+	switch sealing.ScopeOf(obj) {
+	case sealing.ClusterWideScope:
+		values["name"] = fmt.Sprintf("{{ .Release.Name }}-%s", obj.GetName())
+		values["namespace"] = "{{ .Release.Namespace }}"
+		header = ""
+	case sealing.NamespaceWideScope:
+		values["name"] = fmt.Sprintf("{{ .Release.Name }}-%s", obj.GetName())
+		header = "# Sealed with namespace-wide scope: the namespace can't be changed"
+	}
+
+	if start, end, ok := blockRange(lines, 0, len(lines), 0, []string{"metadata"}); ok {
+		indent := -1
+		for i := start; i < end; i++ {
+			if indent == -1 {
+				indent = indentOf(lines[i])
+			}
+			m := yamlEntryRegexp.FindStringSubmatch(lines[i])
+			if m == nil || len(m[1]) != indent {
+				continue
+			}
+			if v, ok := values[m[2]]; ok {
+				lines[i] = m[1] + m[2] + ": " + v
+			}
+		}
+	}
+
+	out := strings.Join(lines, "\n")
+	if header != "" {
+		out = header + "\n" + out
+	}
+	return []byte(out)
+}
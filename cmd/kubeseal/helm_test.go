@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	sealing "github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+func TestHelmOutput(t *testing.T) {
+	key, err := parseKey(strings.NewReader(testCert))
+	if err != nil {
+		t.Fatalf("Failed to parse test key: %v", err)
+	}
+	*outputFormat = "helm"
+	defer func() { *outputFormat = "json" }()
+
+	testCases := []struct {
+		scope      sealing.Scope
+		name       string
+		namespace  string
+		unexpected string
+	}{
+		{sealing.StrictScope, "name: mysecret", "namespace: myns", "{{ .Release"},
+		{sealing.NamespaceWideScope, "name: {{ .Release.Name }}-mysecret", "namespace: myns", "strict"},
+		{sealing.ClusterWideScope, "name: {{ .Release.Name }}-mysecret", "namespace: {{ .Release.Namespace }}", "# Sealed"},
+	}
+	for _, tc := range testCases {
+		secret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "myns"},
+			Data:       map[string][]byte{"foo": []byte("bar")},
+		}
+		ssecret, err := sealing.Seal(secret, key, tc.scope)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ssecret.Spec.Template = &ssv1alpha1.SealedSecretTemplate{Data: map[string]string{"url": "https://{{ .foo }}@host"}}
+
+		var out bytes.Buffer
+		if err := sealedSecretOutput(&out, scheme.Codecs, ssecret); err != nil {
+			t.Fatalf("sealedSecretOutput() returned error: %v", err)
+		}
+		for _, expected := range []string{"\n  " + tc.name + "\n", "\n  " + tc.namespace + "\n", `url: https://{{"{{"}} .foo }}@host`} {
+			if !strings.Contains(out.String(), expected) {
+				t.Errorf("Expected %q in output for scope %v:\n%s", expected, tc.scope, out.String())
+			}
+		}
+		if strings.Contains(out.String(), tc.unexpected) {
+			t.Errorf("Unexpected %q in output for scope %v:\n%s", tc.unexpected, tc.scope, out.String())
+		}
+		if ciphertext := base64.StdEncoding.EncodeToString(ssecret.Spec.EncryptedData["foo"]); !strings.Contains(out.String(), "foo: "+ciphertext) {
+			t.Errorf("Expected ciphertext to be kept literal")
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+	v1 "k8s.io/api/core/v1"
+	runtimeserializer "k8s.io/apimachinery/pkg/runtime/serializer"
+)
+
+var (
+	mergeIntoCluster = flag.Bool("merge-into-cluster", false, "Add the sealed items of the input Secrets to their SealedSecrets in the cluster, through the controller, instead of writing SealedSecrets.")
+	removeItems      = flag.StringSlice("remove-item", nil, "Remove the item <name>/<key> from the SealedSecret <name> of the current namespace in the cluster, through the controller. Can be repeated.")
+	controllerURL    = flag.String("controller-url", "", "URL of the controller used by --merge-into-cluster, --remove-item, --reencrypt-all and --rotate, which otherwise goes through the API server proxy. Defaults to http://<controller-name>.<controller-namespace>:8080.")
+)
+
+// itemsRequest is the body of the /v1/items requests of the controller.
+type itemsRequest struct {
+	Namespace string            `json:"namespace"`
+	Name      string            `json:"name"`
+	Set       map[string][]byte `json:"set,omitempty"`
+	Remove    []string          `json:"remove,omitempty"`
+}
+
+// controllerEndpoint returns the URL of path on the controller.
+func controllerEndpoint(path string) string {
+	base := *controllerURL
+	if base == "" {
+		base = fmt.Sprintf("http://%s.%s:8080", *controllerName, *controllerNs)
+	}
+	return strings.TrimSuffix(base, "/") + path
+}
+
+// bearerToken returns the token of the current kubeconfig context, or
+// the --token flag, to authenticate to the controller.
+func bearerToken() (string, error) {
+	conf, err := clientConfig.ClientConfig()
+	if err != nil {
+		return "", err
+	}
+	if conf.BearerToken == "" {
+		return "", fmt.Errorf("Calling the controller requires a bearer token, see --token")
+	}
+	return conf.BearerToken, nil
+}
+
+// postItems sends req to the controller.
+func postItems(url, token string, req itemsRequest) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := controllerClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("Error updating items of SealedSecret %s/%s: %s %s", req.Namespace, req.Name, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// mergeSecretsIntoCluster seals the Secrets read from in and adds their
+// items to the SealedSecrets of the same name in the cluster.
+func mergeSecretsIntoCluster(in io.Reader, codecs runtimeserializer.CodecFactory, pubKey *rsa.PublicKey, url, token string) error {
+	objs, err := readObjects(codecs, in)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		secret, ok := obj.(*v1.Secret)
+		if !ok {
+			return fmt.Errorf("--merge-into-cluster only supports Secrets, got %s", obj.GetObjectKind().GroupVersionKind().Kind)
+		}
+		ssecret, err := sealSecret(codecs, pubKey, secret)
+		if err != nil {
+			return err
+		}
+		err = postItems(url, token, itemsRequest{
+			Namespace: ssecret.GetNamespace(),
+			Name:      ssecret.GetName(),
+			Set:       ssecret.Spec.EncryptedData,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeItemsFromCluster removes the items, given as <name>/<key>, of
+// the SealedSecrets of namespace in the cluster.
+func removeItemsFromCluster(items []string, namespace, url, token string) error {
+	var names []string
+	keys := map[string][]string{}
+	for _, item := range items {
+		parts := strings.Split(item, "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("Invalid item %q, expected <name>/<key>", item)
+		}
+		if _, ok := keys[parts[0]]; !ok {
+			names = append(names, parts[0])
+		}
+		keys[parts[0]] = append(keys[parts[0]], parts[1])
+	}
+	for _, name := range names {
+		err := postItems(url, token, itemsRequest{
+			Namespace: namespace,
+			Name:      name,
+			Remove:    keys[name],
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+func testItemsServer(t *testing.T, reqs *[]itemsRequest) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer mytoken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req itemsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Error decoding request: %v", err)
+		}
+		if req.Name == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		*reqs = append(*reqs, req)
+	}))
+}
+
+func TestMergeSecretsIntoCluster(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reqs []itemsRequest
+	server := testItemsServer(t, &reqs)
+	defer server.Close()
+
+	in := bytes.NewBufferString(`{"apiVersion":"v1","kind":"Secret","metadata":{"name":"mysecret","namespace":"myns"},"data":{"foo":"YmFy"}}`)
+	if err := mergeSecretsIntoCluster(in, scheme.Codecs, &key.PublicKey, server.URL+"/v1/items", "mytoken"); err != nil {
+		t.Fatalf("mergeSecretsIntoCluster() returned err: %v", err)
+	}
+	if len(reqs) != 1 || reqs[0].Namespace != "myns" || reqs[0].Name != "mysecret" || len(reqs[0].Set) != 1 || len(reqs[0].Set["foo"]) == 0 {
+		t.Errorf("Unexpected requests: %+v", reqs)
+	}
+
+	in = bytes.NewBufferString(`{"apiVersion":"v1","kind":"Secret","metadata":{"name":"mysecret","namespace":"myns"},"data":{"foo":"YmFy"}}`)
+	if err := mergeSecretsIntoCluster(in, scheme.Codecs, &key.PublicKey, server.URL+"/v1/items", "badtoken"); err == nil {
+		t.Errorf("Expected an error when the controller rejects the request")
+	}
+}
+
+func TestRemoveItemsFromCluster(t *testing.T) {
+	var reqs []itemsRequest
+	server := testItemsServer(t, &reqs)
+	defer server.Close()
+
+	items := []string{"mysecret/foo", "other/baz", "mysecret/bar"}
+	if err := removeItemsFromCluster(items, "myns", server.URL, "mytoken"); err != nil {
+		t.Fatalf("removeItemsFromCluster() returned err: %v", err)
+	}
+	expected := []itemsRequest{
+		{Namespace: "myns", Name: "mysecret", Remove: []string{"foo", "bar"}},
+		{Namespace: "myns", Name: "other", Remove: []string{"baz"}},
+	}
+	if !reflect.DeepEqual(reqs, expected) {
+		t.Errorf("Got requests %+v, expected %+v", reqs, expected)
+	}
+
+	if err := removeItemsFromCluster([]string{"foo"}, "myns", server.URL, "mytoken"); err == nil {
+		t.Errorf("Expected an error for an item without a key")
+	}
+	if err := removeItemsFromCluster([]string{"missing/foo"}, "myns", server.URL, "mytoken"); err == nil {
+		t.Errorf("Expected an error for a missing SealedSecret")
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	flag "github.com/spf13/pflag"
+	certUtil "k8s.io/client-go/util/cert"
+
+	sealing "github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+var (
+	backupPrivateKey = flag.String("backup-private-key", "", "PEM private key of the operator certificate given to the controller with --key-backup-rsa-recipient, used with the decrypt-key-backup command.")
+)
+
+// decryptKeyBackup decrypts the key backup read from in, made by the
+// controller for the operator holding keyFile, and writes the PEM
+// encoded sealing key and certificate to out.
+func decryptKeyBackup(in io.Reader, out io.Writer, keyFile string) error {
+	if keyFile == "" {
+		return errors.New("--backup-private-key is required")
+	}
+	data, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return err
+	}
+	key, err := certUtil.ParsePrivateKeyPEM(data)
+	if err != nil {
+		return fmt.Errorf("Invalid private key: %v", err)
+	}
+	privKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return errors.New("--backup-private-key must be an RSA key")
+	}
+
+	backup, err := ioutil.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	pem, err := sealing.UnwrapKeyBackup(rand.Reader, privKey, backup)
+	if err != nil {
+		return fmt.Errorf("Cannot decrypt key backup: %v", err)
+	}
+	_, err = out.Write(pem)
+	return err
+}
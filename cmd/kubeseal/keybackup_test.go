@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	certUtil "k8s.io/client-go/util/cert"
+
+	sealing "github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+func TestDecryptKeyBackup(t *testing.T) {
+	operator, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := ioutil.TempFile("", "operator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.Write(certUtil.EncodePrivateKeyPEM(operator))
+	f.Close()
+
+	backup, err := sealing.WrapKeyBackup(rand.Reader, &operator.PublicKey, []byte("KEY\nCERT\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := decryptKeyBackup(bytes.NewReader(backup), &out, f.Name()); err != nil {
+		t.Fatalf("decryptKeyBackup() returned err: %v", err)
+	}
+	if out.String() != "KEY\nCERT\n" {
+		t.Errorf("Unexpected output: %q", out.String())
+	}
+
+	backup[len(backup)-1] ^= 1
+	if err := decryptKeyBackup(bytes.NewReader(backup), &out, f.Name()); err == nil {
+		t.Errorf("Expected an error for a corrupted backup")
+	}
+}
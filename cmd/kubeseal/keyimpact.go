@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// impactReport is the response of the /admin/keys/impact endpoint of the
+// controller.
+type impactReport struct {
+	Fingerprint   string   `json:"fingerprint"`
+	Key           string   `json:"key"`
+	SealedSecrets []string `json:"sealedSecrets"`
+	Unknown       []string `json:"unknown"`
+}
+
+// keyImpact asks the controller at endpoint which SealedSecrets are
+// sealed with the key of fingerprint, and writes their namespaced names
+// to out, one per line.
+func keyImpact(endpoint, token, fingerprint string, out io.Writer) error {
+	req, err := http.NewRequest(http.MethodGet, endpoint+"?fingerprint="+url.QueryEscape(fingerprint), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := controllerClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("Error reporting the impact of key %s: %s %s", fingerprint, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var report impactReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return fmt.Errorf("Unexpected response from the controller: %v", err)
+	}
+	for _, name := range report.SealedSecrets {
+		fmt.Fprintln(out, name)
+	}
+	if len(report.Unknown) > 0 {
+		fmt.Fprintf(out, "# %d SealedSecrets never decrypted couldn't be checked without the key:\n", len(report.Unknown))
+		for _, name := range report.Unknown {
+			fmt.Fprintf(out, "# %s\n", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestKeyImpact(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.Header.Get("Authorization") != "Bearer mytoken" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Query().Get("fingerprint") != "ab:cd" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		io.WriteString(w, `{"fingerprint":"ab:cd","key":"mykey","sealedSecrets":["myns/a","myns/b"],"unknown":["other/c"]}`)
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	if err := keyImpact(server.URL, "mytoken", "ab:cd", &out); err != nil {
+		t.Fatalf("keyImpact() failed: %v", err)
+	}
+	expected := "myns/a\nmyns/b\n# 1 SealedSecrets never decrypted couldn't be checked without the key:\n# other/c\n"
+	if got := out.String(); got != expected {
+		t.Errorf("Got %q, expected %q", got, expected)
+	}
+
+	if err := keyImpact(server.URL, "badtoken", "ab:cd", &out); err == nil {
+		t.Errorf("Expected an error with a bad token")
+	}
+	if err := keyImpact(server.URL, "mytoken", "ef:01", &out); err == nil {
+		t.Errorf("Expected an error for a bad request")
+	}
+}
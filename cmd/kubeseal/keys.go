@@ -0,0 +1,49 @@
+package main
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"text/tabwriter"
+	"time"
+
+	sealing "github.com/bitnami-labs/sealed-secrets/pkg/seal"
+	certUtil "k8s.io/client-go/util/cert"
+)
+
+// listKeys writes the fingerprint, creation and expiry times of the PEM
+// certificates of r to out, to tell which key kubeseal seals with. The
+// first one is the sealing certificate, the others its intermediates.
+func listKeys(r io.Reader, out io.Writer, now time.Time) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	certs, err := certUtil.ParseCertsPEM(data)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "FINGERPRINT\tCREATED\tEXPIRES\tSTATUS")
+	for i, cert := range certs {
+		fingerprint := "-"
+		if pubKey, ok := cert.PublicKey.(*rsa.PublicKey); ok {
+			if fp, err := sealing.Fingerprint(pubKey); err == nil {
+				fingerprint = fp
+			}
+		}
+		status := "active"
+		switch {
+		case now.Before(cert.NotBefore):
+			status = "not yet valid"
+		case now.After(cert.NotAfter):
+			status = "expired"
+		case i > 0:
+			status = "intermediate"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", fingerprint, cert.NotBefore.UTC().Format(time.RFC3339), cert.NotAfter.UTC().Format(time.RFC3339), status)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"strings"
+	"testing"
+	"time"
+
+	sealing "github.com/bitnami-labs/sealed-secrets/pkg/seal"
+	certUtil "k8s.io/client-go/util/cert"
+)
+
+func TestListKeys(t *testing.T) {
+	ca, caKey := testCertificate(t, "ca", true, nil, nil)
+	leaf, _ := testCertificate(t, "sealed-secrets", false, ca, caKey)
+	data := append(certUtil.EncodeCertPEM(leaf), certUtil.EncodeCertPEM(ca)...)
+	fp, err := sealing.Fingerprint(leaf.PublicKey.(*rsa.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := listKeys(bytes.NewReader(data), &out, time.Now()); err != nil {
+		t.Fatalf("listKeys() failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected a header and 2 certificates, got %q", out.String())
+	}
+	expires := leaf.NotAfter.UTC().Format(time.RFC3339)
+	if !strings.HasPrefix(lines[1], fp) || !strings.Contains(lines[1], expires) || !strings.HasSuffix(lines[1], "active") {
+		t.Errorf("Unexpected sealing certificate line %q", lines[1])
+	}
+	if !strings.HasSuffix(lines[2], "intermediate") {
+		t.Errorf("Unexpected intermediate certificate line %q", lines[2])
+	}
+
+	out.Reset()
+	if err := listKeys(bytes.NewReader(data), &out, time.Now().Add(2*time.Hour)); err != nil {
+		t.Fatalf("listKeys() failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "expired") {
+		t.Errorf("Expected expired certificates, got %q", out.String())
+	}
+
+	if err := listKeys(strings.NewReader("garbage"), &out, time.Now()); err == nil {
+		t.Error("Expected an error without certificates")
+	}
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto/mlkem"
+)
+
+const (
+	// Bounds for the RSA-OAEP part of the hybrid ciphertext, covering
+	// 1024 to 8192 bit keys.
+	minRSACiphertextBytes = 1024 / 8
+	maxRSACiphertextBytes = 8192 / 8
+
+	// Every AES-GCM ciphertext carries at least the authentication tag.
+	gcmTagBytes = 16
+)
+
+// lintIssue is a single problem found in a SealedSecret manifest.
+type lintIssue struct {
+	file    string
+	object  string
+	message string
+}
+
+func (i lintIssue) String() string {
+	if i.object == "" {
+		return fmt.Sprintf("%s: %s", i.file, i.message)
+	}
+	return fmt.Sprintf("%s: %s: %s", i.file, i.object, i.message)
+}
+
+// lintCiphertext performs structural checks on a ciphertext of the
+// given algorithm without decrypting it.
+func lintCiphertext(algorithm string, ciphertext []byte) error {
+	if len(ciphertext) == 0 {
+		return errors.New("is empty")
+	}
+	if algorithm == ssv1alpha1.AlgorithmX25519 {
+		if len(ciphertext) < crypto.X25519KeySize+gcmTagBytes {
+			return errors.New("is truncated")
+		}
+		return nil
+	}
+	if len(ciphertext) < 2 {
+		return errors.New("is too short")
+	}
+	rsaLen := crypto.RSACiphertextLen(ciphertext)
+	if rsaLen < minRSACiphertextBytes || rsaLen > maxRSACiphertextBytes {
+		return fmt.Errorf("has an implausible RSA ciphertext length (%d bytes)", rsaLen)
+	}
+	kemLen := 0
+	if algorithm == ssv1alpha1.AlgorithmRSAMLKEM {
+		kemLen = mlkem.CiphertextSize768
+	}
+	if len(ciphertext) < crypto.HeaderLen(ciphertext)+rsaLen+kemLen+gcmTagBytes {
+		return errors.New("is truncated")
+	}
+	return nil
+}
+
+func sortedKeys(m map[string][]byte) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// mapSliceGet returns the value stored under key in m.
+func mapSliceGet(m yaml.MapSlice, key string) (interface{}, bool) {
+	for _, item := range m {
+		if k, ok := item.Key.(string); ok && k == key {
+			return item.Value, true
+		}
+	}
+	return nil, false
+}
+
+// duplicateKeys returns the keys of spec.encryptedData that appear
+// more than once in the raw document. These are silently collapsed by
+// the regular decoder, losing one of the values.
+func duplicateKeys(doc yaml.MapSlice) []string {
+	spec, _ := mapSliceGet(doc, "spec")
+	specMap, ok := spec.(yaml.MapSlice)
+	if !ok {
+		return nil
+	}
+	data, _ := mapSliceGet(specMap, "encryptedData")
+	dataMap, ok := data.(yaml.MapSlice)
+	if !ok {
+		return nil
+	}
+
+	var dups []string
+	seen := map[string]bool{}
+	for _, item := range dataMap {
+		k := fmt.Sprint(item.Key)
+		if seen[k] {
+			dups = append(dups, k)
+		}
+		seen[k] = true
+	}
+	return dups
+}
+
+func lintDocument(file string, doc []byte) []lintIssue {
+	var raw yaml.MapSlice
+	if err := yaml.Unmarshal(doc, &raw); err != nil {
+		return []lintIssue{{file: file, message: fmt.Sprintf("cannot parse document: %v", err)}}
+	}
+	if kind, _ := mapSliceGet(raw, "kind"); kind != "SealedSecret" {
+		// Not ours, sealed manifests are often mixed with other resources.
+		return nil
+	}
+
+	var ssecret ssv1alpha1.SealedSecret
+	if err := runtime.DecodeInto(scheme.Codecs.UniversalDecoder(), doc, &ssecret); err != nil {
+		return []lintIssue{{file: file, message: fmt.Sprintf("cannot decode SealedSecret: %v", err)}}
+	}
+
+	object := fmt.Sprintf("%s/%s", ssecret.GetNamespace(), ssecret.GetName())
+	var issues []lintIssue
+	report := func(format string, args ...interface{}) {
+		issues = append(issues, lintIssue{file: file, object: object, message: fmt.Sprintf(format, args...)})
+	}
+
+	if ssecret.GetName() == "" {
+		report("metadata.name is missing")
+	}
+
+	annotations := ssecret.GetAnnotations()
+	for _, anno := range []string{ssv1alpha1.SealedSecretClusterWideAnnotation, ssv1alpha1.SealedSecretNamespaceWideAnnotation} {
+		if v, ok := annotations[anno]; ok && v != "true" {
+			report("annotation %s is %q, only \"true\" has any effect", anno, v)
+		}
+	}
+	clusterWide := annotations[ssv1alpha1.SealedSecretClusterWideAnnotation] == "true"
+	namespaceWide := annotations[ssv1alpha1.SealedSecretNamespaceWideAnnotation] == "true"
+	if clusterWide && namespaceWide {
+		report("both cluster-wide and namespace-wide annotations are set, cluster-wide takes precedence")
+	}
+	if !clusterWide && ssecret.GetNamespace() == "" {
+		report("metadata.namespace is missing, this secret can only be unsealed in the namespace it was sealed for")
+	}
+
+	if ssecret.Empty() && annotations[ssv1alpha1.SealedSecretAllowEmptyDataAnnotation] != "true" {
+		report("spec.encryptedData is empty, annotate with %s=\"true\" if intended", ssv1alpha1.SealedSecretAllowEmptyDataAnnotation)
+	}
+	algorithm := ssecret.Spec.Algorithm
+	switch algorithm {
+	case "", ssv1alpha1.AlgorithmRSA, ssv1alpha1.AlgorithmX25519, ssv1alpha1.AlgorithmRSAMLKEM, ssv1alpha1.AlgorithmRSAExternal:
+	default:
+		report("spec.algorithm %q is not supported", algorithm)
+		return issues
+	}
+	for _, key := range sortedKeys(ssecret.Spec.EncryptedData) {
+		if err := lintCiphertext(algorithm, ssecret.Spec.EncryptedData[key]); err != nil {
+			report("encryptedData[%q] %v", key, err)
+		}
+	}
+	if len(ssecret.Spec.EncryptedData) == 0 && len(ssecret.Spec.Data) > 0 {
+		if err := lintCiphertext(algorithm, ssecret.Spec.Data); err != nil {
+			report("spec.data %v", err)
+		}
+	}
+	for _, key := range duplicateKeys(raw) {
+		report("encryptedData key %q is defined more than once", key)
+	}
+
+	return issues
+}
+
+func lintSealedSecrets(file string, r io.Reader) ([]lintIssue, error) {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(r))
+	var issues []lintIssue
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+		issues = append(issues, lintDocument(file, doc)...)
+	}
+	return issues, nil
+}
+
+// manifestFiles expands the given paths to the list of manifest files
+// they contain, descending into directories.
+func manifestFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			switch strings.ToLower(filepath.Ext(p)) {
+			case ".yaml", ".yml", ".json":
+				files = append(files, p)
+			default:
+				if p == path {
+					// Explicitly named files are always included
+					files = append(files, p)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// runLint lints the given files and directories (or stdin if none),
+// printing all problems to out. It returns false if any were found.
+func runLint(in io.Reader, out io.Writer, paths []string) (bool, error) {
+	if len(paths) == 0 {
+		issues, err := lintSealedSecrets("<stdin>", in)
+		if err != nil {
+			return false, err
+		}
+		return printLintIssues(out, issues), nil
+	}
+
+	files, err := manifestFiles(paths)
+	if err != nil {
+		return false, err
+	}
+	var issues []lintIssue
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return false, err
+		}
+		fileIssues, err := lintSealedSecrets(file, f)
+		f.Close()
+		if err != nil {
+			return false, err
+		}
+		issues = append(issues, fileIssues...)
+	}
+	return printLintIssues(out, issues), nil
+}
+
+func printLintIssues(out io.Writer, issues []lintIssue) bool {
+	for _, issue := range issues {
+		fmt.Fprintln(out, issue)
+	}
+	return len(issues) == 0
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+)
+
+func sealTestSecret(t *testing.T) *ssv1alpha1.SealedSecret {
+	key, err := parseKey(strings.NewReader(testCert))
+	if err != nil {
+		t.Fatalf("Failed to parse test key: %v", err)
+	}
+	secret := v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mysecret",
+			Namespace: "myns",
+		},
+		Data: map[string][]byte{
+			"foo": []byte("sekret"),
+		},
+	}
+	ssecret, err := ssv1alpha1.NewSealedSecret(scheme.Codecs, key, &secret)
+	if err != nil {
+		t.Fatalf("NewSealedSecret() returned error: %v", err)
+	}
+	return ssecret
+}
+
+func TestLintValid(t *testing.T) {
+	ssecret := sealTestSecret(t)
+
+	var buf bytes.Buffer
+	if err := sealedSecretOutput(&buf, scheme.Codecs, ssecret); err != nil {
+		t.Fatalf("sealedSecretOutput() returned error: %v", err)
+	}
+
+	issues, err := lintSealedSecrets("test.json", &buf)
+	if err != nil {
+		t.Fatalf("lintSealedSecrets() returned error: %v", err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("Unexpected issues: %v", issues)
+	}
+}
+
+func TestLintProblems(t *testing.T) {
+	ciphertext := base64.StdEncoding.EncodeToString(sealTestSecret(t).Spec.EncryptedData["foo"])
+	truncated := base64.StdEncoding.EncodeToString(sealTestSecret(t).Spec.EncryptedData["foo"][:100])
+
+	input := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ignored
+---
+apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: mysecret
+  annotations:
+    sealedsecrets.bitnami.com/namespace-wide: "yes"
+spec:
+  encryptedData:
+    foo: ` + ciphertext + `
+    bar: ` + truncated + `
+    foo: ` + ciphertext + `
+`
+	issues, err := lintSealedSecrets("test.yaml", strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("lintSealedSecrets() returned error: %v", err)
+	}
+
+	expected := []string{
+		"namespace-wide is \"yes\"",
+		"metadata.namespace is missing",
+		"encryptedData[\"bar\"] is truncated",
+		"encryptedData key \"foo\" is defined more than once",
+	}
+	if len(issues) != len(expected) {
+		t.Fatalf("Expected %d issues, got %v", len(expected), issues)
+	}
+	for i, want := range expected {
+		if !strings.Contains(issues[i].String(), want) {
+			t.Errorf("Issue %d is %q, expected it to contain %q", i, issues[i], want)
+		}
+	}
+}
+
+func TestLintCiphertext(t *testing.T) {
+	if err := lintCiphertext("", nil); err == nil {
+		t.Errorf("Expected error for empty ciphertext")
+	}
+	if err := lintCiphertext("", []byte{0xff, 0xff, 0}); err == nil {
+		t.Errorf("Expected error for implausible RSA length")
+	}
+	if err := lintCiphertext("", sealTestSecret(t).Spec.EncryptedData["foo"]); err != nil {
+		t.Errorf("lintCiphertext() returned error for valid ciphertext: %v", err)
+	}
+
+	key, err := parseKey(strings.NewReader(testCert))
+	if err != nil {
+		t.Fatalf("Failed to parse test key: %v", err)
+	}
+	for _, compression := range []crypto.Compression{crypto.CompressionGzip, crypto.CompressionZstd} {
+		params := crypto.Params{Cipher: crypto.CipherChaCha20Poly1305, OAEPHash: crypto.OAEPSHA1, Compression: compression}
+		tagged, err := crypto.HybridEncryptParams(rand.Reader, key, params, []byte("sekret"), nil)
+		if err != nil {
+			t.Fatalf("HybridEncryptParams() returned error: %v", err)
+		}
+		if err := lintCiphertext("", tagged); err != nil {
+			t.Errorf("lintCiphertext() returned error for tagged ciphertext: %v", err)
+		}
+	}
+	if err := lintCiphertext(ssv1alpha1.AlgorithmX25519, make([]byte, 16)); err == nil {
+		t.Errorf("Expected error for truncated X25519 ciphertext")
+	}
+}
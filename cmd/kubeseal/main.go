@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"crypto/rsa"
 	"encoding/json"
 	"errors"
@@ -9,21 +10,26 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	flag "github.com/spf13/pflag"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	runtimeserializer "k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/client-go/kubernetes/scheme"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/clientcmd"
-	"k8s.io/client-go/util/cert"
 
 	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	sealing "github.com/bitnami-labs/sealed-secrets/pkg/seal"
 
 	// Register Auth providers
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
@@ -39,14 +45,26 @@ const (
 
 var (
 	// TODO: Verify k8s server signature against cert in kube client config.
-	certFile       = flag.String("cert", "", "Certificate / public key to use for encryption. Overrides --controller-*")
+	certFile       = flag.String("cert", "", "Certificate / public key to use for encryption. Either a local file, an http(s) URL or a secret://namespace/name[#key] or configmap://namespace/name[#key] reference. Overrides --controller-*")
 	controllerNs   = flag.String("controller-namespace", metav1.NamespaceSystem, "Namespace of sealed-secrets controller.")
 	controllerName = flag.String("controller-name", "sealed-secrets-controller", "Name of sealed-secrets controller.")
-	outputFormat   = flag.String("format", "json", "Output format for sealed secret. Either json or yaml")
+	outputFormat   = flag.String("format", "json", "Output format for sealed secret. Either json, yaml or helm, a YAML Helm template with the name and namespace parameterized")
 	rotate         = flag.Bool("rotate", false, "Re-encrypt the given sealed secret to use the latest cluster key.")
 	dumpCert       = flag.Bool("fetch-cert", false, "Write certificate to stdout. Useful for later use with --cert")
 	printVersion   = flag.Bool("version", false, "Print version information and exit")
 	validateSecret = flag.Bool("validate", false, "Validate that the sealed secret can be decrypted")
+	outputDir      = flag.String("output-dir", "", "Write each sealed secret to its own file in this directory instead of stdout.")
+	fromSOPS       = flag.String("from-sops", "", "SOPS encrypted Secret manifest to convert, used with the convert command.")
+	fetchTimeout   = flag.Duration("timeout", 30*time.Second, "Timeout for fetching the certificate. Zero means no timeout.")
+	fetchRetries   = flag.Int("retries", 0, "Number of times to retry fetching the certificate on failure.")
+	outputName     = flag.String("output-name", "{namespace}-{name}.{format}", "File name template used with --output-dir. Supports {namespace}, {name} and {format}.")
+	namespaceCert  = flag.Bool("namespace-cert", false, "Fetch the certificate of the current namespace, for controllers started with --per-namespace-keys.")
+	asSealedObject = flag.Bool("sealed-object", false, "Seal every input object, of any namespaced kind, into a SealedObject.")
+	allowEmptyData = flag.Bool("allow-empty-data", false, "Seal Secrets without data, eg. placeholders, instead of failing.")
+
+	escrowFile          = flag.String("escrow-file", "", "Write an encrypted copy of the plaintext input to this file for the escrow recipients.")
+	escrowAgeRecipients = flag.StringSlice("escrow-age-recipient", nil, "age recipient to encrypt the escrow copy for. Can be repeated.")
+	escrowPGPRecipients = flag.StringSlice("escrow-pgp-recipient", nil, "PGP key ID or email to encrypt the escrow copy for, using gpg. Can be repeated.")
 
 	// VERSION set from Makefile
 	VERSION = "UNKNOWN"
@@ -73,41 +91,58 @@ func init() {
 }
 
 func parseKey(r io.Reader) (*rsa.PublicKey, error) {
-	data, err := ioutil.ReadAll(r)
-	if err != nil {
-		return nil, err
-	}
+	return sealing.ParsePublicKey(r)
+}
 
-	certs, err := cert.ParseCertsPEM(data)
-	if err != nil {
-		return nil, err
-	}
+// readObjects decodes the stream of Secrets and ConfigMaps in r, or
+// of objects of any kind with --sealed-object. Documents without a kind
+// are taken to be Secrets.
+func readObjects(codecs runtimeserializer.CodecFactory, r io.Reader) ([]runtime.Object, error) {
+	decoder := yaml.NewYAMLOrJSONDecoder(r, 4096)
+	defaultGVK := v1.SchemeGroupVersion.WithKind("Secret")
+
+	var ret []runtime.Object
+	for {
+		var doc runtime.RawExtension
+		if err := decoder.Decode(&doc); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if len(doc.Raw) == 0 {
+			// Empty YAML document, eg: a trailing "---"
+			continue
+		}
 
-	// ParseCertsPem returns error if len(certs) == 0, but best to be sure...
-	if len(certs) == 0 {
-		return nil, errors.New("Failed to read any certificates")
-	}
+		if *asSealedObject {
+			obj := &unstructured.Unstructured{}
+			if err := obj.UnmarshalJSON(doc.Raw); err != nil {
+				return nil, err
+			}
+			ret = append(ret, obj)
+			continue
+		}
 
-	cert, ok := certs[0].PublicKey.(*rsa.PublicKey)
-	if !ok {
-		return nil, fmt.Errorf("Expected RSA public key but found %v", certs[0].PublicKey)
+		obj, gvk, err := codecs.UniversalDeserializer().Decode(doc.Raw, &defaultGVK, nil)
+		if err != nil && !runtime.IsNotRegisteredError(err) {
+			return nil, err
+		}
+		switch obj.(type) {
+		case *v1.Secret, *v1.ConfigMap:
+		default:
+			return nil, fmt.Errorf("Unsupported input kind %s, expected Secret or ConfigMap (use --sealed-object for other kinds)", gvk.Kind)
+		}
+		ret = append(ret, obj)
 	}
 
-	return cert, nil
-}
-
-func readSecret(codec runtime.Decoder, r io.Reader) (*v1.Secret, error) {
-	data, err := ioutil.ReadAll(r)
-	if err != nil {
-		return nil, err
+	if len(ret) == 0 {
+		return nil, errors.New("No Secret found in input")
 	}
-
-	var ret v1.Secret
-	if err = runtime.DecodeInto(codec, data, &ret); err != nil {
+	if err := applyOverrides(ret, *sealName, namespaceOverride()); err != nil {
 		return nil, err
 	}
-
-	return &ret, nil
+	return ret, nil
 }
 
 func prettyEncoder(codecs runtimeserializer.CodecFactory, mediaType string, gv runtime.GroupVersioner) (runtime.Encoder, error) {
@@ -133,10 +168,10 @@ func openCertFile(certFile string) (io.ReadCloser, error) {
 	return f, nil
 }
 
-func openCertHTTP(c corev1.CoreV1Interface, namespace, name string) (io.ReadCloser, error) {
+func openCertHTTP(c corev1.CoreV1Interface, namespace, name, path string) (io.ReadCloser, error) {
 	f, err := c.
 		Services(namespace).
-		ProxyGet("http", name, "", "/v1/cert.pem", nil).
+		ProxyGet("http", name, "", path, nil).
 		Stream()
 	if err != nil {
 		return nil, fmt.Errorf("Error fetching certificate: %v", err)
@@ -144,8 +179,119 @@ func openCertHTTP(c corev1.CoreV1Interface, namespace, name string) (io.ReadClos
 	return f, nil
 }
 
-func openCert() (io.ReadCloser, error) {
-	if *certFile != "" {
+// openCertURL fetches the certificate from a plain http(s) URL,
+// honouring the HTTP_PROXY/HTTPS_PROXY/NO_PROXY environment variables.
+func openCertURL(certURL string, timeout time.Duration) (io.ReadCloser, error) {
+	client := http.Client{
+		Transport: net.SetTransportDefaults(&http.Transport{}),
+		Timeout:   timeout,
+	}
+	resp, err := client.Get(certURL)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching certificate: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("Error fetching certificate from %s: %s", certURL, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// retryBackoff is the delay before the first retry; it grows linearly
+// with each further attempt.
+var retryBackoff = time.Second
+
+// withRetries calls fetch up to retries+1 times until it succeeds.
+func withRetries(retries int, fetch func() (io.ReadCloser, error)) (io.ReadCloser, error) {
+	var err error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * retryBackoff)
+		}
+		var f io.ReadCloser
+		if f, err = fetch(); err == nil {
+			return f, nil
+		}
+	}
+	if retries > 0 {
+		return nil, fmt.Errorf("%v (giving up after %d attempts)", err, retries+1)
+	}
+	return nil, err
+}
+
+// certRef points to a certificate stored in a cluster object, written
+// as secret://namespace/name[#key] or configmap://namespace/name[#key].
+type certRef struct {
+	kind      string
+	namespace string
+	name      string
+	key       string
+}
+
+// parseCertRef returns the certRef described by s, or nil if s is not
+// a cluster object reference (ie: a plain file name).
+func parseCertRef(s string) (*certRef, error) {
+	if !strings.HasPrefix(s, "secret://") && !strings.HasPrefix(s, "configmap://") {
+		return nil, nil
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid certificate reference %q: %v", s, err)
+	}
+	ref := &certRef{
+		kind:      u.Scheme,
+		namespace: u.Host,
+		name:      strings.TrimPrefix(u.Path, "/"),
+		key:       u.Fragment,
+	}
+	if ref.namespace == "" || ref.name == "" || strings.Contains(ref.name, "/") {
+		return nil, fmt.Errorf("Invalid certificate reference %q, expected %s://namespace/name[#key]", s, ref.kind)
+	}
+	if ref.key == "" {
+		ref.key = v1.TLSCertKey
+	}
+	return ref, nil
+}
+
+func openCertRef(c corev1.CoreV1Interface, ref *certRef) (io.ReadCloser, error) {
+	var data []byte
+	switch ref.kind {
+	case "secret":
+		secret, err := c.Secrets(ref.namespace).Get(ref.name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("Error fetching certificate: %v", err)
+		}
+		data = secret.Data[ref.key]
+	case "configmap":
+		cm, err := c.ConfigMaps(ref.namespace).Get(ref.name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("Error fetching certificate: %v", err)
+		}
+		if v, ok := cm.Data[ref.key]; ok {
+			data = []byte(v)
+		} else {
+			data = cm.BinaryData[ref.key]
+		}
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("No certificate found under key %q in %s %s/%s", ref.key, ref.kind, ref.namespace, ref.name)
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+// openCertSource opens the certificate of --cert, or of the controller.
+func openCertSource() (io.ReadCloser, error) {
+	if strings.HasPrefix(*certFile, "http://") || strings.HasPrefix(*certFile, "https://") {
+		return withRetries(*fetchRetries, func() (io.ReadCloser, error) {
+			return openCertURL(*certFile, *fetchTimeout)
+		})
+	}
+
+	ref, err := parseCertRef(*certFile)
+	if err != nil {
+		return nil, err
+	}
+	if ref == nil && *certFile != "" {
 		return openCertFile(*certFile)
 	}
 
@@ -153,60 +299,289 @@ func openCert() (io.ReadCloser, error) {
 	if err != nil {
 		return nil, err
 	}
+	conf.Timeout = *fetchTimeout
+	if ref != nil {
+		restClient, err := corev1.NewForConfig(conf)
+		if err != nil {
+			return nil, err
+		}
+		return withRetries(*fetchRetries, func() (io.ReadCloser, error) {
+			return openCertRef(restClient, ref)
+		})
+	}
+
+	certPath := "/v1/cert.pem"
+	if *namespaceCert {
+		ns, _, err := clientConfig.Namespace()
+		if err != nil {
+			return nil, err
+		}
+		certPath = fmt.Sprintf("/v1/namespaces/%s/cert.pem", ns)
+	}
+
 	conf.AcceptContentTypes = "application/x-pem-file, */*"
 	restClient, err := corev1.NewForConfig(conf)
 	if err != nil {
 		return nil, err
 	}
-	return openCertHTTP(restClient, *controllerNs, *controllerName)
+	return withRetries(*fetchRetries, func() (io.ReadCloser, error) {
+		return openCertHTTP(restClient, *controllerNs, *controllerName, certPath)
+	})
 }
 
-func seal(in io.Reader, out io.Writer, codecs runtimeserializer.CodecFactory, pubKey *rsa.PublicKey) error {
-	secret, err := readSecret(codecs.UniversalDecoder(), in)
+// loadPubKey reads the public key to seal with from the configured
+// certificate source.
+func loadPubKey() (*rsa.PublicKey, error) {
+	f, err := openCert()
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer f.Close()
+	return parsePubKeys(f)
+}
 
-	if len(secret.Data) == 0 {
+func sealSecret(codecs runtimeserializer.CodecFactory, pubKey *rsa.PublicKey, secret *v1.Secret) (*ssv1alpha1.SealedSecret, error) {
+	empty := len(secret.Data) == 0 && len(secret.StringData) == 0
+	if empty && !*allowEmptyData {
 		// No data. This is _theoretically_ just fine, but
 		// almost certainly indicates a misuse of the tools.
-		// If you _really_ want to encrypt an empty secret,
-		// then a PR to skip this check with some sort of
-		// --force flag would be welcomed.
-		return fmt.Errorf("Secret.data is empty in input Secret, assuming this is an error and aborting")
+		return nil, fmt.Errorf("Secret.data and Secret.stringData are empty in input Secret, assuming this is an error and aborting. Use --allow-empty-data to seal a placeholder Secret")
 	}
 
 	if secret.GetName() == "" {
-		return fmt.Errorf("Missing metadata.name in input Secret")
+		return nil, fmt.Errorf("Missing metadata.name in input Secret")
 	}
 
 	if secret.GetNamespace() == "" {
 		ns, _, err := clientConfig.Namespace()
 		if err != nil {
-			return err
+			return nil, err
 		}
 		secret.SetNamespace(ns)
 	}
 
-	// Strip read-only server-side ObjectMeta (if present)
-	secret.SetSelfLink("")
-	secret.SetUID("")
-	secret.SetResourceVersion("")
-	secret.Generation = 0
-	secret.SetCreationTimestamp(metav1.Time{})
-	secret.SetDeletionTimestamp(nil)
-	secret.DeletionGracePeriodSeconds = nil
+	if err := checkSecretSize(secret); err != nil {
+		return nil, err
+	}
+	ssecret, err := sealSecretWithAlgorithm(pubKey, secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSealedSecretSize(ssecret); err != nil {
+		return nil, err
+	}
+	if empty {
+		// The controller refuses empty SealedSecrets otherwise
+		annotations := map[string]string{}
+		for k, v := range ssecret.GetAnnotations() {
+			annotations[k] = v
+		}
+		annotations[ssv1alpha1.SealedSecretAllowEmptyDataAnnotation] = "true"
+		ssecret.SetAnnotations(annotations)
+	}
+	return ssecret, nil
+}
+
+// sealSecretWithAlgorithm seals secret with the algorithm and
+// parameters selected by the flags.
+func sealSecretWithAlgorithm(pubKey *rsa.PublicKey, secret *v1.Secret) (*ssv1alpha1.SealedSecret, error) {
+	params, err := sealParams()
+	if err != nil {
+		return nil, err
+	}
+	if externalKeyService != "" {
+		if params != (ssv1alpha1.SealingParams{}) {
+			return nil, fmt.Errorf("The key is held by %s, which doesn't support --cipher, --oaep-hash, --compress and --chunk-size", externalKeyService)
+		}
+		return sealing.SealExternal(secret, pubKey, sealing.ScopeOf(secret))
+	}
+	if params != (ssv1alpha1.SealingParams{}) {
+		return sealing.SealParams(secret, pubKey, params, sealing.ScopeOf(secret))
+	}
+	if x25519PubKey != nil {
+		return sealing.SealX25519(secret, x25519PubKey, sealing.ScopeOf(secret))
+	}
+	if mlkemPubKey != nil {
+		return sealing.SealRSAMLKEM(secret, pubKey, mlkemPubKey, sealing.ScopeOf(secret))
+	}
+	return sealing.Seal(secret, pubKey, sealing.ScopeOf(secret))
+}
+
+func sealConfigMap(pubKey *rsa.PublicKey, cm *v1.ConfigMap) (*ssv1alpha1.SealedConfigMap, error) {
+	if len(cm.Data) == 0 && len(cm.BinaryData) == 0 {
+		return nil, fmt.Errorf("ConfigMap.data is empty in input ConfigMap, assuming this is an error and aborting")
+	}
+
+	if cm.GetName() == "" {
+		return nil, fmt.Errorf("Missing metadata.name in input ConfigMap")
+	}
+
+	if *sealAlgorithm != "rsa" {
+		return nil, fmt.Errorf("--algorithm=%s can only seal Secrets", *sealAlgorithm)
+	}
+	if externalKeyService != "" {
+		return nil, fmt.Errorf("The key is held by %s, which can only seal Secrets", externalKeyService)
+	}
+	if !defaultSealParams() {
+		return nil, fmt.Errorf("--cipher, --oaep-hash, --compress and --chunk-size can only seal Secrets")
+	}
+
+	if cm.GetNamespace() == "" {
+		ns, _, err := clientConfig.Namespace()
+		if err != nil {
+			return nil, err
+		}
+		cm.SetNamespace(ns)
+	}
+
+	return sealing.SealConfigMap(cm, pubKey, sealing.ScopeOf(cm))
+}
+
+// sealedObject is a sealed resource, ready for output.
+type sealedObject interface {
+	runtime.Object
+	metav1.Object
+}
+
+func sealUnstructured(pubKey *rsa.PublicKey, obj *unstructured.Unstructured) (*ssv1alpha1.SealedObject, error) {
+	if obj.GetName() == "" {
+		return nil, fmt.Errorf("Missing metadata.name in input %s", obj.GetKind())
+	}
+
+	if *sealAlgorithm != "rsa" {
+		return nil, fmt.Errorf("--algorithm=%s can only seal Secrets", *sealAlgorithm)
+	}
+	if externalKeyService != "" {
+		return nil, fmt.Errorf("The key is held by %s, which can only seal Secrets", externalKeyService)
+	}
+	if !defaultSealParams() {
+		return nil, fmt.Errorf("--cipher, --oaep-hash, --compress and --chunk-size can only seal Secrets")
+	}
+
+	if obj.GetNamespace() == "" {
+		ns, _, err := clientConfig.Namespace()
+		if err != nil {
+			return nil, err
+		}
+		obj.SetNamespace(ns)
+	}
+
+	return sealing.SealObject(obj, pubKey, sealing.ScopeOf(obj))
+}
+
+// sealObject seals a Secret into a SealedSecret, a ConfigMap into a
+// SealedConfigMap or anything else into a SealedObject.
+func sealObject(codecs runtimeserializer.CodecFactory, pubKey *rsa.PublicKey, obj runtime.Object) (sealedObject, error) {
+	switch o := obj.(type) {
+	case *v1.Secret:
+		ssecret, err := sealSecret(codecs, pubKey, o)
+		if err != nil || previousSealedSecrets == nil {
+			return ssecret, err
+		}
+		return ssecret, reuseCiphertexts(ssecret, o, pubKey)
+	case *v1.ConfigMap:
+		return sealConfigMap(pubKey, o)
+	case *unstructured.Unstructured:
+		return sealUnstructured(pubKey, o)
+	default:
+		return nil, fmt.Errorf("Cannot seal %T", obj)
+	}
+}
 
-	ssecret, err := ssv1alpha1.NewSealedSecret(codecs, pubKey, secret)
+func seal(in io.Reader, out io.Writer, codecs runtimeserializer.CodecFactory, pubKey *rsa.PublicKey) error {
+	objs, err := readObjects(codecs, in)
 	if err != nil {
 		return err
 	}
-	if err = sealedSecretOutput(out, codecs, ssecret); err != nil {
+
+	for i, obj := range objs {
+		ssecret, err := sealObject(codecs, pubKey, obj)
+		if err != nil {
+			return err
+		}
+		if err := recordSealed(pubKey, obj, ssecret); err != nil {
+			return err
+		}
+		if i > 0 && (isYAMLOutput() || isHelmOutput()) {
+			fmt.Fprint(out, "---\n")
+		}
+		if err = sealedSecretOutput(out, codecs, ssecret); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// outputFileName expands the --output-name template for the given
+// sealed secret.
+func outputFileName(template string, ssecret metav1.Object) string {
+	format := strings.ToLower(*outputFormat)
+	if format == "helm" {
+		// Helm templates are YAML files
+		format = "yaml"
+	}
+	r := strings.NewReplacer(
+		"{namespace}", ssecret.GetNamespace(),
+		"{name}", ssecret.GetName(),
+		"{format}", format,
+	)
+	return r.Replace(template)
+}
+
+// sealToDir seals every Secret in the input stream and writes each
+// resulting SealedSecret to its own file below dir.
+func sealToDir(in io.Reader, dir, template string, codecs runtimeserializer.CodecFactory, pubKey *rsa.PublicKey) error {
+	objs, err := readObjects(codecs, in)
+	if err != nil {
 		return err
 	}
+
+	written := map[string]bool{}
+	for _, obj := range objs {
+		ssecret, err := sealObject(codecs, pubKey, obj)
+		if err != nil {
+			return err
+		}
+		if err := recordSealed(pubKey, obj, ssecret); err != nil {
+			return err
+		}
+
+		path := filepath.Join(dir, outputFileName(template, ssecret))
+		if written[path] {
+			return fmt.Errorf("More than one sealed secret maps to %s, check --output-name", path)
+		}
+		written[path] = true
+
+		if err := writeSealedSecretFile(path, codecs, ssecret); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+func writeSealedSecretFile(path string, codecs runtimeserializer.CodecFactory, ssecret runtime.Object) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if ss, ok := ssecret.(*ssv1alpha1.SealedSecret); ok && isYAMLOutput() {
+		// Keep the comments of existing files
+		if original, err := ioutil.ReadFile(path); err == nil {
+			if patched, ok := preserveLayout(original, ss, codecs); ok {
+				return ioutil.WriteFile(path, patched, 0666)
+			}
+		}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := sealedSecretOutput(f, codecs, ssecret); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
 func validateSealedSecret(in io.Reader, namespace, name string) error {
 	conf, err := clientConfig.ClientConfig()
 	if err != nil {
@@ -222,6 +597,12 @@ func validateSealedSecret(in io.Reader, namespace, name string) error {
 		return err
 	}
 
+	return verifyWithController(restClient, namespace, name, content)
+}
+
+// verifyWithController asks the controller whether it can decrypt the
+// SealedSecret content.
+func verifyWithController(restClient *corev1.CoreV1Client, namespace, name string, content []byte) error {
 	req := restClient.RESTClient().Post().
 		Namespace(namespace).
 		Resource("services").
@@ -242,18 +623,49 @@ func validateSealedSecret(in io.Reader, namespace, name string) error {
 }
 
 func rotateSealedSecret(in io.Reader, out io.Writer, codecs runtimeserializer.CodecFactory, namespace, name string) error {
-	conf, err := clientConfig.ClientConfig()
+	content, err := ioutil.ReadAll(in)
 	if err != nil {
 		return err
 	}
-	restClient, err := corev1.NewForConfig(conf)
-	if err != nil {
+
+	var body []byte
+	if *controllerURL != "" {
+		// Directly, so that the controller sees the client certificate
+		if body, err = postController(controllerEndpoint("/v1/rotate"), content); err != nil {
+			return fmt.Errorf("Error occurred while rotating secret: %v", err)
+		}
+	} else if body, err = rotateThroughProxy(content, namespace, name); err != nil {
+		return err
+	}
+	ssecret := &ssv1alpha1.SealedSecret{}
+	if err = json.Unmarshal(body, ssecret); err != nil {
+		return err
+	}
+	ssecret.SetCreationTimestamp(metav1.Time{})
+	ssecret.SetDeletionTimestamp(nil)
+	ssecret.Generation = 0
+	if isYAMLOutput() {
+		if patched, ok := preserveLayout(content, ssecret, codecs); ok {
+			_, err := out.Write(patched)
+			return err
+		}
+	}
+	if err = sealedSecretOutput(out, codecs, ssecret); err != nil {
 		return err
 	}
+	return nil
+}
 
-	content, err := ioutil.ReadAll(in)
+// rotateThroughProxy rotates content with the controller service name
+// of namespace, through the API server proxy.
+func rotateThroughProxy(content []byte, namespace, name string) ([]byte, error) {
+	conf, err := clientConfig.ClientConfig()
 	if err != nil {
-		return err
+		return nil, err
+	}
+	restClient, err := corev1.NewForConfig(conf)
+	if err != nil {
+		return nil, err
 	}
 
 	req := restClient.RESTClient().Post().
@@ -267,33 +679,23 @@ func rotateSealedSecret(in io.Reader, out io.Writer, codecs runtimeserializer.Co
 	res := req.Do()
 	if err := res.Error(); err != nil {
 		if status, ok := err.(*k8serrors.StatusError); ok && status.Status().Code == http.StatusConflict {
-			return fmt.Errorf("Unable to rotate secret")
+			return nil, fmt.Errorf("Unable to rotate secret")
 		}
-		return fmt.Errorf("Error occurred while rotating secret")
-	}
-	body, err := res.Raw()
-	if err != nil {
-		return err
+		return nil, fmt.Errorf("Error occurred while rotating secret")
 	}
-	ssecret := &ssv1alpha1.SealedSecret{}
-	if err = json.Unmarshal(body, ssecret); err != nil {
-		return err
-	}
-	ssecret.SetCreationTimestamp(metav1.Time{})
-	ssecret.SetDeletionTimestamp(nil)
-	ssecret.Generation = 0
-	if err = sealedSecretOutput(out, codecs, ssecret); err != nil {
-		return err
-	}
-	return nil
+	return res.Raw()
 }
 
-func sealedSecretOutput(out io.Writer, codecs runtimeserializer.CodecFactory, ssecret *ssv1alpha1.SealedSecret) error {
+func isYAMLOutput() bool {
+	return strings.ToLower(*outputFormat) == "yaml"
+}
+
+func sealedSecretOutput(out io.Writer, codecs runtimeserializer.CodecFactory, ssecret runtime.Object) error {
 	var contentType string
 	switch strings.ToLower(*outputFormat) {
 	case "json", "":
 		contentType = runtime.ContentTypeJSON
-	case "yaml":
+	case "yaml", "helm":
 		contentType = "application/yaml"
 	default:
 		return fmt.Errorf("unsupported output format: %s", *outputFormat)
@@ -306,6 +708,9 @@ func sealedSecretOutput(out io.Writer, codecs runtimeserializer.CodecFactory, ss
 	if err != nil {
 		return err
 	}
+	if obj, ok := ssecret.(metav1.Object); ok && isHelmOutput() {
+		buf = helmTemplate(buf, obj)
+	}
 	out.Write(buf)
 	fmt.Fprint(out, "\n")
 	return nil
@@ -320,40 +725,251 @@ func main() {
 		return
 	}
 
+	if err := initFIPS(); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(2)
+	}
+	if err := checkDiagnosticsFormat(); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(2)
+	}
+
+	if *clientCertFile != "" || *clientKeyFile != "" || *controllerCAFile != "" {
+		client, err := newControllerClient(*clientCertFile, *clientKeyFile, *controllerCAFile)
+		if err != nil {
+			fatal(err)
+		}
+		controllerClient = client
+	}
+
+	if flag.NArg() > 0 {
+		switch cmd, args := flag.Arg(0), flag.Args()[1:]; cmd {
+		case "lint":
+			ok, err := runLint(os.Stdin, os.Stdout, args)
+			if err != nil {
+				fatal(err)
+			}
+			if !ok {
+				os.Exit(1)
+			}
+		case "scan":
+			var pubKey *rsa.PublicKey
+			ok, err := runScan(os.Stdout, args, *scanFix, scheme.Codecs, func() (*rsa.PublicKey, error) {
+				var err error
+				if pubKey == nil {
+					pubKey, err = loadPubKey()
+				}
+				return pubKey, err
+			})
+			if err != nil {
+				fatal(err)
+			}
+			if !ok {
+				os.Exit(1)
+			}
+		case "convert":
+			if *fromSOPS == "" {
+				fmt.Fprintf(os.Stderr, "convert requires --from-sops\n")
+				os.Exit(2)
+			}
+			pubKey, err := loadPubKey()
+			if err != nil {
+				fatal(err)
+			}
+			if err := convertFromSOPS(*fromSOPS, os.Stdout, *outputDir, scheme.Codecs, pubKey); err != nil {
+				fatal(err)
+			}
+		case "verify":
+			verify, err := offlineVerifier(*verifyPrivateKeys)
+			if err == nil && len(*verifyPrivateKeys) == 0 {
+				verify, err = controllerVerifier(*controllerNs, *controllerName)
+			}
+			if err != nil {
+				fatal(err)
+			}
+			ok, err := runVerify(os.Stdout, args, *verifyRecursive, scheme.Codecs, verify)
+			if err != nil {
+				fatal(err)
+			}
+			if !ok {
+				os.Exit(1)
+			}
+		case "split-key":
+			if err := splitKey(os.Stdin, os.Stdout, *keyShares, *keyThreshold); err != nil {
+				fatal(err)
+			}
+		case "combine-key":
+			if err := combineKey(os.Stdin, os.Stdout); err != nil {
+				fatal(err)
+			}
+		case "decrypt-key-backup":
+			if err := decryptKeyBackup(os.Stdin, os.Stdout, *backupPrivateKey); err != nil {
+				fatal(err)
+			}
+		case "keys":
+			f, err := openCertSource()
+			if err != nil {
+				fatal(err)
+			}
+			defer f.Close()
+			if err := listKeys(f, os.Stdout, time.Now()); err != nil {
+				fatal(err)
+			}
+		case "key-impact":
+			if len(args) != 1 {
+				fmt.Fprintf(os.Stderr, "key-impact requires the fingerprint of a key\n")
+				os.Exit(2)
+			}
+			token, err := bearerToken()
+			if err != nil {
+				fatal(err)
+			}
+			if err := keyImpact(controllerEndpoint("/admin/keys/impact"), token, args[0], os.Stdout); err != nil {
+				fatal(err)
+			}
+		case "post-render":
+			pubKey, err := loadPubKey()
+			if err != nil {
+				fatal(err)
+			}
+			if err := postRender(os.Stdin, os.Stdout, scheme.Codecs, pubKey); err != nil {
+				fatal(err)
+			}
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown command %q\n", cmd)
+			os.Exit(2)
+		}
+		return
+	}
+
 	if *validateSecret {
 		err := validateSealedSecret(os.Stdin, *controllerNs, *controllerName)
 		if err != nil {
-			panic(err.Error())
+			fatal(err)
 		}
 		return
 	}
 
 	if *rotate {
 		if err := rotateSealedSecret(os.Stdin, os.Stdout, scheme.Codecs, *controllerNs, *controllerName); err != nil {
-			panic(err.Error())
+			fatal(err)
+		}
+		return
+	}
+
+	if *reencryptAllFlag {
+		token, err := bearerToken()
+		if err != nil {
+			fatal(err)
+		}
+		ok, err := reencryptAll(controllerEndpoint("/admin/reencrypt-all"), token, os.Stdout)
+		if err != nil {
+			fatal(err)
+		}
+		if !ok {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(*removeItems) > 0 {
+		token, err := bearerToken()
+		if err != nil {
+			fatal(err)
+		}
+		ns, _, err := clientConfig.Namespace()
+		if err != nil {
+			fatal(err)
+		}
+		if err := removeItemsFromCluster(*removeItems, ns, controllerEndpoint("/v1/items"), token); err != nil {
+			fatal(err)
 		}
 		return
 	}
 
 	f, err := openCert()
 	if err != nil {
-		panic(err.Error())
+		fatal(err)
 	}
 	defer f.Close()
 
 	if *dumpCert {
 		if _, err := io.Copy(os.Stdout, f); err != nil {
-			panic(err.Error())
+			fatal(err)
 		}
 		return
 	}
 
-	pubKey, err := parseKey(f)
+	pubKey, err := parsePubKeys(f)
 	if err != nil {
-		panic(err.Error())
+		fatal(&certificateError{err})
+	}
+	recordCertificate(pubKey)
+
+	if *reuseFrom != "" {
+		if reuseKey, err = readReuseKey(*reuseKeyFile); err != nil {
+			fatal(err)
+		}
+		if previousSealedSecrets, err = loadPreviousSealedSecrets(*reuseFrom); err != nil {
+			fatal(err)
+		}
+	}
+
+	if *recordFile != "" {
+		records, err := openRecordFile(*recordFile)
+		if err != nil {
+			fatal(err)
+		}
+		defer records.Close()
+		sealRecords = records
+	}
+
+	var in io.Reader = os.Stdin
+	if *fetchSecretRef != "" {
+		conf, err := clientConfig.ClientConfig()
+		if err != nil {
+			fatal(err)
+		}
+		ns, _, err := clientConfig.Namespace()
+		if err != nil {
+			fatal(err)
+		}
+		restClient, err := corev1.NewForConfig(conf)
+		if err != nil {
+			fatal(err)
+		}
+		if in, err = fetchSecretInput(restClient, *fetchSecretRef, ns); err != nil {
+			fatal(err)
+		}
+	}
+	if escrowEnabled() {
+		if in, err = escrowInput(in); err != nil {
+			fatal(err)
+		}
+	}
+
+	if *mergeIntoCluster {
+		token, err := bearerToken()
+		if err != nil {
+			fatal(err)
+		}
+		if err := mergeSecretsIntoCluster(in, scheme.Codecs, pubKey, controllerEndpoint("/v1/items"), token); err != nil {
+			fatal(err)
+		}
+		reportSealed()
+		return
+	}
+
+	if *outputDir != "" {
+		if err := sealToDir(in, *outputDir, *outputName, scheme.Codecs, pubKey); err != nil {
+			fatal(err)
+		}
+		reportSealed()
+		return
 	}
 
-	if err := seal(os.Stdin, os.Stdout, scheme.Codecs, pubKey); err != nil {
-		panic(err.Error())
+	if err := seal(in, os.Stdout, scheme.Codecs, pubKey); err != nil {
+		fatal(err)
 	}
+	reportSealed()
 }
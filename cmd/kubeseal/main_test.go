@@ -2,21 +2,34 @@ package main
 
 import (
 	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"math/big"
 	mathrand "math/rand"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/kubernetes/scheme"
+	certUtil "k8s.io/client-go/util/cert"
 
 	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+	sealing "github.com/bitnami-labs/sealed-secrets/pkg/seal"
 )
 
 const testCert = `
@@ -172,3 +185,555 @@ func TestSeal(t *testing.T) {
 	}
 	// NB: See sealedsecret_test.go for e2e crypto test
 }
+
+func TestSealX25519(t *testing.T) {
+	defer func() {
+		*sealAlgorithm = "rsa"
+		x25519PubKey = nil
+	}()
+
+	*sealAlgorithm = "x25519"
+	if _, err := parsePubKeys(strings.NewReader(testCert)); err != sealing.ErrNoX25519Key {
+		t.Errorf("Expected ErrNoX25519Key for an old certificate, got %v", err)
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, pub, err := crypto.X25519KeyFromRSA(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ext, err := sealing.NewX25519Extension(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{SerialNumber: big.NewInt(1), ExtraExtensions: []pkix.Extension{ext}}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubKey, err := parsePubKeys(bytes.NewReader(certUtil.EncodeCertPEM(&x509.Certificate{Raw: der})))
+	if err != nil {
+		t.Fatalf("parsePubKeys() returned error: %v", err)
+	}
+
+	result, err := sealSecret(scheme.Codecs, pubKey, &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "myns"},
+		Data:       map[string][]byte{"foo": []byte("sekret")},
+	})
+	if err != nil {
+		t.Fatalf("sealSecret() returned error: %v", err)
+	}
+	if result.Spec.Algorithm != ssv1alpha1.AlgorithmX25519 {
+		t.Errorf("Unexpected algorithm %q", result.Spec.Algorithm)
+	}
+	secret, err := sealing.Unseal(result, sealing.PrivateKeys{key})
+	if err != nil {
+		t.Fatalf("Unseal() returned error: %v", err)
+	}
+	if string(secret.Data["foo"]) != "sekret" {
+		t.Errorf("Unexpected data: %v", secret.Data)
+	}
+
+	cm := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "mycm", Namespace: "myns"}, Data: map[string]string{"foo": "bar"}}
+	if _, err := sealConfigMap(pubKey, cm); err == nil {
+		t.Errorf("Expected an error sealing a ConfigMap with X25519")
+	}
+}
+
+const testMultiDocSecrets = `
+apiVersion: v1
+kind: Secret
+metadata:
+  name: first
+  namespace: ns1
+data:
+  foo: c2VrcmV0
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: second
+  namespace: ns2
+data:
+  bar: c2VrcmV0
+---
+`
+
+func TestReadObjectsMultiDoc(t *testing.T) {
+	objs, err := readObjects(scheme.Codecs, strings.NewReader(testMultiDocSecrets))
+	if err != nil {
+		t.Fatalf("readObjects() returned error: %v", err)
+	}
+	if len(objs) != 2 {
+		t.Fatalf("Expected 2 secrets, got %d", len(objs))
+	}
+	first, ok1 := objs[0].(*v1.Secret)
+	second, ok2 := objs[1].(*v1.Secret)
+	if !ok1 || !ok2 {
+		t.Fatalf("Expected Secrets, got %T, %T", objs[0], objs[1])
+	}
+	if first.GetName() != "first" || second.GetName() != "second" {
+		t.Errorf("Unexpected secrets: %s, %s", first.GetName(), second.GetName())
+	}
+}
+
+func TestSealConfigMap(t *testing.T) {
+	key, err := parseKey(strings.NewReader(testCert))
+	if err != nil {
+		t.Fatalf("Failed to parse test key: %v", err)
+	}
+
+	input := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: myconfig
+  namespace: myns
+data:
+  license: sekret
+`
+	var out bytes.Buffer
+	if err := seal(strings.NewReader(input), &out, scheme.Codecs, key); err != nil {
+		t.Fatalf("seal() returned error: %v", err)
+	}
+
+	var result ssv1alpha1.SealedConfigMap
+	if err := runtime.DecodeInto(scheme.Codecs.UniversalDecoder(), out.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	if result.GetName() != "myconfig" || result.GetNamespace() != "myns" {
+		t.Errorf("Unexpected result %s/%s", result.GetNamespace(), result.GetName())
+	}
+	if _, ok := result.Spec.EncryptedData["license"]; !ok {
+		t.Errorf("Missing encrypted item: %v", result.Spec.EncryptedData)
+	}
+
+	if err := seal(strings.NewReader("apiVersion: v1\nkind: Pod\nmetadata:\n  name: foo\n"), &out, scheme.Codecs, key); err == nil {
+		t.Errorf("Expected error sealing a Pod")
+	}
+}
+
+func TestSealObject(t *testing.T) {
+	key, err := parseKey(strings.NewReader(testCert))
+	if err != nil {
+		t.Fatalf("Failed to parse test key: %v", err)
+	}
+
+	input := `
+apiVersion: example.com/v1
+kind: Database
+metadata:
+  name: mydb
+  namespace: myns
+spec:
+  password: hunter2
+`
+	var out bytes.Buffer
+	err = seal(strings.NewReader(input), &out, scheme.Codecs, key)
+	if err == nil || !strings.Contains(err.Error(), "--sealed-object") {
+		t.Errorf("Expected error suggesting --sealed-object, got %v", err)
+	}
+
+	*asSealedObject = true
+	defer func() { *asSealedObject = false }()
+
+	out.Reset()
+	if err := seal(strings.NewReader(input), &out, scheme.Codecs, key); err != nil {
+		t.Fatalf("seal() returned error: %v", err)
+	}
+
+	var result ssv1alpha1.SealedObject
+	if err := runtime.DecodeInto(scheme.Codecs.UniversalDecoder(), out.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	if result.GetName() != "mydb" || result.GetNamespace() != "myns" {
+		t.Errorf("Unexpected result %s/%s", result.GetNamespace(), result.GetName())
+	}
+	if len(result.Spec.EncryptedObject) == 0 || bytes.Contains(out.Bytes(), []byte("hunter2")) {
+		t.Errorf("Unexpected encrypted object in %s", out.String())
+	}
+}
+
+func TestSealToDir(t *testing.T) {
+	key, err := parseKey(strings.NewReader(testCert))
+	if err != nil {
+		t.Fatalf("Failed to parse test key: %v", err)
+	}
+
+	dir, err := ioutil.TempDir("", "kubeseal")
+	if err != nil {
+		t.Fatalf("Failed to create tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	in := strings.NewReader(testMultiDocSecrets)
+	if err := sealToDir(in, dir, "{namespace}-{name}.{format}", scheme.Codecs, key); err != nil {
+		t.Fatalf("sealToDir() returned error: %v", err)
+	}
+
+	for _, name := range []string{"ns1-first.json", "ns2-second.json"} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("Expected output file %s: %v", name, err)
+		}
+		var result ssv1alpha1.SealedSecret
+		if err = runtime.DecodeInto(scheme.Codecs.UniversalDecoder(), data, &result); err != nil {
+			t.Fatalf("Failed to parse %s: %v", name, err)
+		}
+	}
+
+	in = strings.NewReader(testMultiDocSecrets)
+	if err := sealToDir(in, dir, "sealed.{format}", scheme.Codecs, key); err == nil {
+		t.Errorf("Expected error for colliding output file names")
+	}
+}
+
+func TestParseCertRef(t *testing.T) {
+	testCases := []struct {
+		in      string
+		want    *certRef
+		wantErr bool
+	}{
+		{in: "mycert.pem"},
+		{in: "secret://kube-system/sealed-secrets-key", want: &certRef{"secret", "kube-system", "sealed-secrets-key", "tls.crt"}},
+		{in: "configmap://myns/certs#cert.pem", want: &certRef{"configmap", "myns", "certs", "cert.pem"}},
+		{in: "secret://kube-system", wantErr: true},
+		{in: "secret://kube-system/a/b", wantErr: true},
+	}
+	for _, tc := range testCases {
+		got, err := parseCertRef(tc.in)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("parseCertRef(%q) returned err: %v", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("parseCertRef(%q) = %#v, want %#v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestOpenCertRef(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "mykey", Namespace: "kube-system"},
+			Data:       map[string][]byte{v1.TLSCertKey: []byte(testCert)},
+		},
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "certs", Namespace: "myns"},
+			Data:       map[string]string{"cert.pem": testCert},
+		},
+	)
+
+	for _, ref := range []*certRef{
+		{"secret", "kube-system", "mykey", v1.TLSCertKey},
+		{"configmap", "myns", "certs", "cert.pem"},
+	} {
+		f, err := openCertRef(client.CoreV1(), ref)
+		if err != nil {
+			t.Fatalf("openCertRef(%v) returned err: %v", ref, err)
+		}
+		data, err := ioutil.ReadAll(f)
+		if err != nil {
+			t.Fatalf("Error reading certificate: %v", err)
+		}
+		if string(data) != testCert {
+			t.Errorf("Read incorrect data from %s", ref.kind)
+		}
+	}
+
+	if _, err := openCertRef(client.CoreV1(), &certRef{"secret", "kube-system", "mykey", "missing"}); err == nil {
+		t.Errorf("Expected error for missing key")
+	}
+}
+
+func TestOpenCertURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/cert.pem" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, testCert)
+	}))
+	defer srv.Close()
+
+	f, err := openCertURL(srv.URL+"/v1/cert.pem", time.Second)
+	if err != nil {
+		t.Fatalf("openCertURL() returned err: %v", err)
+	}
+	data, err := ioutil.ReadAll(f)
+	f.Close()
+	if err != nil {
+		t.Fatalf("Error reading certificate: %v", err)
+	}
+	if string(data) != testCert {
+		t.Errorf("Read incorrect data from URL")
+	}
+
+	if _, err := openCertURL(srv.URL+"/missing", time.Second); err == nil {
+		t.Errorf("Expected error for missing certificate")
+	}
+}
+
+func TestWithRetries(t *testing.T) {
+	defer func(d time.Duration) { retryBackoff = d }(retryBackoff)
+	retryBackoff = 0
+
+	attempts := 0
+	failing := func() (io.ReadCloser, error) {
+		attempts++
+		return nil, fmt.Errorf("boom")
+	}
+	if _, err := withRetries(2, failing); err == nil {
+		t.Errorf("Expected error from withRetries()")
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+
+	attempts = 0
+	flaky := func() (io.ReadCloser, error) {
+		attempts++
+		if attempts < 2 {
+			return nil, fmt.Errorf("boom")
+		}
+		return ioutil.NopCloser(strings.NewReader(testCert)), nil
+	}
+	if _, err := withRetries(2, flaky); err != nil {
+		t.Errorf("withRetries() returned err: %v", err)
+	}
+}
+
+func TestSealCipher(t *testing.T) {
+	defer func() {
+		*sealAlgorithm = "rsa"
+		*sealCipher = "aes-gcm"
+		*sealOAEPHash = "sha256"
+		*sealCompress = "none"
+	}()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := func() *v1.Secret {
+		return &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "myns"},
+			Data:       map[string][]byte{"foo": []byte("sekret")},
+		}
+	}
+
+	*sealCipher = "chacha20-poly1305"
+	result, err := sealSecret(scheme.Codecs, &key.PublicKey, secret())
+	if err != nil {
+		t.Fatalf("sealSecret() returned error: %v", err)
+	}
+	if result.Spec.Cipher != ssv1alpha1.CipherChaCha20Poly1305 {
+		t.Errorf("Unexpected cipher %q", result.Spec.Cipher)
+	}
+	unsealed, err := sealing.Unseal(result, sealing.PrivateKeys{key})
+	if err != nil {
+		t.Fatalf("Unseal() returned error: %v", err)
+	}
+	if string(unsealed.Data["foo"]) != "sekret" {
+		t.Errorf("Unexpected data: %v", unsealed.Data)
+	}
+
+	*sealAlgorithm = "x25519"
+	if _, err := sealSecret(scheme.Codecs, &key.PublicKey, secret()); err == nil {
+		t.Errorf("Expected an error with --algorithm=x25519")
+	}
+
+	*sealAlgorithm = "rsa"
+	*sealCipher = "rot13"
+	if _, err := sealSecret(scheme.Codecs, &key.PublicKey, secret()); err == nil {
+		t.Errorf("Expected an error for an unknown cipher")
+	}
+
+	*sealCipher = "aes-gcm"
+	*sealOAEPHash = "sha1"
+	result, err = sealSecret(scheme.Codecs, &key.PublicKey, secret())
+	if err != nil {
+		t.Fatalf("sealSecret() returned error: %v", err)
+	}
+	if result.Spec.OAEPHash != ssv1alpha1.OAEPHashSHA1 || result.Spec.Cipher != "" {
+		t.Errorf("Unexpected cipher %q and OAEP hash %q", result.Spec.Cipher, result.Spec.OAEPHash)
+	}
+	if _, err := sealing.Unseal(result, sealing.PrivateKeys{key}); err != nil {
+		t.Fatalf("Unseal() returned error: %v", err)
+	}
+	*sealOAEPHash = "sha256"
+	for _, compression := range []string{"gzip", "zstd"} {
+		*sealCompress = compression
+		result, err = sealSecret(scheme.Codecs, &key.PublicKey, secret())
+		if err != nil {
+			t.Fatalf("sealSecret() returned error: %v", err)
+		}
+		if result.Spec.Compression != compression {
+			t.Errorf("Unexpected compression %q", result.Spec.Compression)
+		}
+		unsealed, err := sealing.Unseal(result, sealing.PrivateKeys{key})
+		if err != nil {
+			t.Fatalf("Unseal() returned error: %v", err)
+		}
+		if string(unsealed.Data["foo"]) != "sekret" {
+			t.Errorf("Unexpected data: %v", unsealed.Data)
+		}
+	}
+	cm := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "mycm", Namespace: "myns"}, Data: map[string]string{"foo": "bar"}}
+	if _, err := sealConfigMap(&key.PublicKey, cm); err == nil {
+		t.Errorf("Expected an error sealing a ConfigMap with --compress")
+	}
+}
+
+func TestSealChunkSize(t *testing.T) {
+	defer func() {
+		*sealAlgorithm = "rsa"
+		*sealChunkSize = 0
+	}()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	large := bytes.Repeat([]byte("0123456789"), 2000)
+	secret := func() *v1.Secret {
+		return &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "myns"},
+			Data:       map[string][]byte{"large": large, "small": []byte("sekret")},
+		}
+	}
+
+	*sealChunkSize = 100
+	if _, err := sealSecret(scheme.Codecs, &key.PublicKey, secret()); err == nil {
+		t.Errorf("Expected an error for a chunk size below %d", crypto.MinChunkSize)
+	}
+
+	*sealChunkSize = crypto.MinChunkSize
+	result, err := sealSecret(scheme.Codecs, &key.PublicKey, secret())
+	if err != nil {
+		t.Fatalf("sealSecret() returned error: %v", err)
+	}
+	if result.Spec.ChunkSize != crypto.MinChunkSize {
+		t.Errorf("Unexpected chunk size %d", result.Spec.ChunkSize)
+	}
+	unsealed, err := sealing.Unseal(result, sealing.PrivateKeys{key})
+	if err != nil {
+		t.Fatalf("Unseal() returned error: %v", err)
+	}
+	if !bytes.Equal(unsealed.Data["large"], large) || string(unsealed.Data["small"]) != "sekret" {
+		t.Errorf("Unexpected data: %v", unsealed.Data)
+	}
+
+	*sealAlgorithm = "x25519"
+	if _, err := sealSecret(scheme.Codecs, &key.PublicKey, secret()); err == nil {
+		t.Errorf("Expected an error with --algorithm=x25519")
+	}
+}
+
+func TestSealStringData(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in := strings.NewReader(`apiVersion: v1
+kind: Secret
+metadata:
+  name: mysecret
+  namespace: myns
+data:
+  foo: c2VrcmV0
+  bar: b2xk
+stringData:
+  bar: new
+`)
+	outbuf := bytes.Buffer{}
+	if err := seal(in, &outbuf, scheme.Codecs, &key.PublicKey); err != nil {
+		t.Fatalf("seal() returned error: %v", err)
+	}
+	var result ssv1alpha1.SealedSecret
+	if err := runtime.DecodeInto(scheme.Codecs.UniversalDecoder(), outbuf.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	secret, err := sealing.Unseal(&result, sealing.PrivateKeys{key})
+	if err != nil {
+		t.Fatalf("Unseal() returned error: %v", err)
+	}
+	if string(secret.Data["foo"]) != "sekret" || string(secret.Data["bar"]) != "new" {
+		t.Errorf("Unexpected data: %v", secret.Data)
+	}
+
+	onlyStringData := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "myns"},
+		StringData: map[string]string{"foo": "sekret"},
+	}
+	if _, err := sealSecret(scheme.Codecs, &key.PublicKey, onlyStringData); err != nil {
+		t.Errorf("sealSecret() returned error for a Secret with only stringData: %v", err)
+	}
+}
+
+func TestSealEmptyData(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	empty := func() *v1.Secret {
+		return &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "myns"}}
+	}
+
+	if _, err := sealSecret(scheme.Codecs, &key.PublicKey, empty()); err == nil || !strings.Contains(err.Error(), "--allow-empty-data") {
+		t.Errorf("Expected sealSecret() to refuse an empty Secret, got %v", err)
+	}
+
+	*allowEmptyData = true
+	defer func() { *allowEmptyData = false }()
+	ssecret, err := sealSecret(scheme.Codecs, &key.PublicKey, empty())
+	if err != nil {
+		t.Fatalf("sealSecret() returned error: %v", err)
+	}
+	if ssecret.GetAnnotations()[ssv1alpha1.SealedSecretAllowEmptyDataAnnotation] != "true" {
+		t.Errorf("Expected SealedSecret to allow empty data, got annotations %v", ssecret.GetAnnotations())
+	}
+	secret, err := sealing.Unseal(ssecret, sealing.PrivateKeys{key})
+	if err != nil {
+		t.Fatalf("Unseal() returned error: %v", err)
+	}
+	if len(secret.Data) != 0 {
+		t.Errorf("Expected no data, got %v", secret.Data)
+	}
+}
+
+func TestSealSizeLimits(t *testing.T) {
+	defer func() {
+		*maxSecretBytes = 1 << 20
+		*maxSealedSecretBytes = 1 << 20
+	}()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := func() *v1.Secret {
+		return &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "myns"},
+			Data:       map[string][]byte{"foo": []byte("sekret")},
+		}
+	}
+
+	*maxSecretBytes = 8
+	if _, err := sealSecret(scheme.Codecs, &key.PublicKey, secret()); err == nil {
+		t.Errorf("Expected an error for a Secret over --max-secret-bytes")
+	}
+	*maxSecretBytes = 0
+	*maxSealedSecretBytes = 64
+	if _, err := sealSecret(scheme.Codecs, &key.PublicKey, secret()); err == nil {
+		t.Errorf("Expected an error for a SealedSecret over --max-sealed-secret-bytes")
+	}
+	*maxSealedSecretBytes = 0
+	if _, err := sealSecret(scheme.Codecs, &key.PublicKey, secret()); err != nil {
+		t.Errorf("sealSecret() returned error without limits: %v", err)
+	}
+}
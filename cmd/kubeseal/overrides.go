@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+
+	flag "github.com/spf13/pflag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+var (
+	sealName = flag.String("name", "", "Name to seal the input object for, overriding its metadata.name. Requires a single input object. Use --namespace to override its namespace.")
+)
+
+// namespaceOverride returns the namespace explicitly set with
+// --namespace, which takes precedence over the namespace of the input
+// objects. Otherwise the namespace of the context is only a default.
+func namespaceOverride() string {
+	if f := flag.CommandLine.Lookup("namespace"); f != nil && f.Changed {
+		return f.Value.String()
+	}
+	return ""
+}
+
+// applyOverrides sets the name and namespace of objs to name and
+// namespace, if not empty. They are sealed for their new identity.
+func applyOverrides(objs []runtime.Object, name, namespace string) error {
+	if name != "" && len(objs) > 1 {
+		return fmt.Errorf("--name requires a single input object, got %d", len(objs))
+	}
+	for _, obj := range objs {
+		o, ok := obj.(metav1.Object)
+		if !ok {
+			return fmt.Errorf("Cannot rename %T", obj)
+		}
+		if name != "" {
+			o.SetName(name)
+		}
+		if namespace != "" {
+			o.SetNamespace(namespace)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	sealing "github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+func TestApplyOverrides(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	objs, err := readObjects(scheme.Codecs, strings.NewReader(testMultiDocSecrets))
+	if err != nil {
+		t.Fatalf("readObjects() returned error: %v", err)
+	}
+	if err := applyOverrides(objs, "renamed", ""); err == nil {
+		t.Error("Expected --name to require a single input object")
+	}
+
+	objs = objs[:1]
+	if err := applyOverrides(objs, "renamed", "otherns"); err != nil {
+		t.Fatalf("applyOverrides() returned error: %v", err)
+	}
+	secret := objs[0].(*v1.Secret)
+	if secret.GetName() != "renamed" || secret.GetNamespace() != "otherns" {
+		t.Fatalf("Expected otherns/renamed, got %s/%s", secret.GetNamespace(), secret.GetName())
+	}
+
+	ssecret, err := sealSecret(scheme.Codecs, &key.PublicKey, secret)
+	if err != nil {
+		t.Fatalf("sealSecret() returned error: %v", err)
+	}
+	if ssecret.GetName() != "renamed" || ssecret.GetNamespace() != "otherns" {
+		t.Errorf("Expected SealedSecret otherns/renamed, got %s/%s", ssecret.GetNamespace(), ssecret.GetName())
+	}
+	if _, err := sealing.Unseal(ssecret, sealing.PrivateKeys{key}); err != nil {
+		t.Errorf("Expected SealedSecret to unseal with its new identity: %v", err)
+	}
+	ssecret.SetName("first")
+	if _, err := sealing.Unseal(ssecret, sealing.PrivateKeys{key}); err == nil {
+		t.Error("Expected SealedSecret not to unseal with its original name")
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rsa"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	runtimeserializer "k8s.io/apimachinery/pkg/runtime/serializer"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+// isSecretDocument reports whether doc is a core/v1 Secret.
+func isSecretDocument(doc []byte) (bool, error) {
+	var meta struct {
+		APIVersion string `yaml:"apiVersion"`
+		Kind       string `yaml:"kind"`
+	}
+	if err := yaml.Unmarshal(doc, &meta); err != nil {
+		return false, err
+	}
+	return meta.APIVersion == "v1" && meta.Kind == "Secret", nil
+}
+
+// leadingComments returns the comment lines at the start of doc, such
+// as the "# Source:" lines Helm adds to every rendered template.
+func leadingComments(doc []byte) []byte {
+	var buf bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(doc))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			break
+		}
+		fmt.Fprintln(&buf, line)
+	}
+	return buf.Bytes()
+}
+
+// sealDocument seals a single Secret manifest, returning it as YAML.
+func sealDocument(doc []byte, codecs runtimeserializer.CodecFactory, pubKey *rsa.PublicKey) ([]byte, error) {
+	var secret v1.Secret
+	if err := runtime.DecodeInto(codecs.UniversalDecoder(), doc, &secret); err != nil {
+		return nil, err
+	}
+
+	// Charts commonly use stringData, which pkg/seal merges into data
+	// like for any other sealed Secret.
+	ssecret, err := sealSecret(codecs, pubKey, &secret)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", secret.GetName(), err)
+	}
+
+	enc, err := prettyEncoder(codecs, "application/yaml", ssv1alpha1.SchemeGroupVersion)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := runtime.Encode(enc, ssecret)
+	if err != nil {
+		return nil, err
+	}
+	return append(leadingComments(doc), buf...), nil
+}
+
+// postRender implements the Helm post-renderer protocol: rendered
+// manifests are read from in and written to out with every Secret
+// replaced by the equivalent SealedSecret. Everything else is passed
+// through unchanged.
+func postRender(in io.Reader, out io.Writer, codecs runtimeserializer.CodecFactory, pubKey *rsa.PublicKey) error {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(in))
+	first := true
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		// The reader keeps a separator found at the very start of
+		// the stream, which Helm always emits.
+		doc = bytes.TrimPrefix(doc, []byte("---\n"))
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		secret, err := isSecretDocument(doc)
+		if err != nil {
+			return err
+		}
+		if secret {
+			if doc, err = sealDocument(doc, codecs, pubKey); err != nil {
+				return err
+			}
+		}
+
+		if !first {
+			fmt.Fprint(out, "---\n")
+		}
+		first = false
+		out.Write(doc)
+		if !bytes.HasSuffix(doc, []byte("\n")) {
+			fmt.Fprint(out, "\n")
+		}
+	}
+	return nil
+}
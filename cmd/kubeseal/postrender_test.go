@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+func TestPostRender(t *testing.T) {
+	key, err := parseKey(strings.NewReader(testCert))
+	if err != nil {
+		t.Fatalf("Failed to parse test key: %v", err)
+	}
+
+	input := `---
+# Source: mychart/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: myconfig
+data:
+  foo: bar
+---
+# Source: mychart/templates/secret.yaml
+apiVersion: v1
+kind: Secret
+metadata:
+  name: mysecret
+  namespace: myns
+stringData:
+  password: sekret
+`
+	var out bytes.Buffer
+	if err := postRender(strings.NewReader(input), &out, scheme.Codecs, key); err != nil {
+		t.Fatalf("postRender() returned error: %v", err)
+	}
+
+	docs := strings.Split(out.String(), "---\n")
+	if len(docs) != 2 {
+		t.Fatalf("Expected 2 documents, got %d:\n%s", len(docs), out.String())
+	}
+	if !strings.Contains(docs[0], "kind: ConfigMap") || !strings.Contains(docs[0], "foo: bar") {
+		t.Errorf("ConfigMap was not passed through:\n%s", docs[0])
+	}
+	if !strings.HasPrefix(docs[1], "# Source: mychart/templates/secret.yaml\n") {
+		t.Errorf("Source comment was not preserved:\n%s", docs[1])
+	}
+
+	raw, err := utilyaml.ToJSON([]byte(docs[1]))
+	if err != nil {
+		t.Fatalf("Failed to parse sealed document: %v", err)
+	}
+	var ssecret ssv1alpha1.SealedSecret
+	if err := runtime.DecodeInto(scheme.Codecs.UniversalDecoder(), raw, &ssecret); err != nil {
+		t.Fatalf("Failed to decode SealedSecret: %v", err)
+	}
+	if ssecret.GetName() != "mysecret" || ssecret.GetNamespace() != "myns" {
+		t.Errorf("Unexpected result %s/%s", ssecret.GetNamespace(), ssecret.GetName())
+	}
+	if _, ok := ssecret.Spec.EncryptedData["password"]; !ok {
+		t.Errorf("stringData was not sealed: %v", ssecret.Spec.EncryptedData)
+	}
+	if strings.Contains(out.String(), "sekret") {
+		t.Errorf("Plaintext leaked into output:\n%s", out.String())
+	}
+}
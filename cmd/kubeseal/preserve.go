@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/runtime"
+	runtimeserializer "k8s.io/apimachinery/pkg/runtime/serializer"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+var (
+	// A "key:" line opening a block, with an optional comment.
+	yamlBlockRegexp = regexp.MustCompile(`^(\s*)("[^"]*"|'[^']*'|[^\s"'#:][^:#]*):\s*(#.*)?$`)
+	// A "key: value" line, with an optional comment.
+	yamlEntryRegexp = regexp.MustCompile(`^(\s*)("[^"]*"|'[^']*'|[^\s"'#:][^:#]*):\s+([^\s#|>&*!{\[][^#]*?)(\s+#.*)?$`)
+)
+
+// indentOf returns the indentation of line, -1 for blank and comment
+// lines.
+func indentOf(line string) int {
+	trimmed := strings.TrimLeft(line, " ")
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return -1
+	}
+	return len(line) - len(trimmed)
+}
+
+// unquoteYAMLKey returns the key of a map entry as written in YAML.
+func unquoteYAMLKey(key string) string {
+	var s string
+	if err := yaml.Unmarshal([]byte(key), &s); err != nil {
+		return key
+	}
+	return s
+}
+
+// yamlScalar returns s as a single line YAML scalar.
+func yamlScalar(s string) (string, bool) {
+	b, err := yaml.Marshal(s)
+	if err != nil {
+		return "", false
+	}
+	scalar := strings.TrimSuffix(string(b), "\n")
+	return scalar, !strings.Contains(scalar, "\n")
+}
+
+// blockRange returns the lines [start, end) of the block of the map
+// entry at path, starting from lines[from:to] of the given indentation.
+func blockRange(lines []string, from, to, indent int, path []string) (int, int, bool) {
+	for i := from; i < to; i++ {
+		if indentOf(lines[i]) != indent {
+			continue
+		}
+		m := yamlBlockRegexp.FindStringSubmatch(lines[i])
+		if m == nil || unquoteYAMLKey(strings.TrimSpace(m[2])) != path[0] {
+			continue
+		}
+		end := i + 1
+		for end < to && (indentOf(lines[end]) == -1 || indentOf(lines[end]) > indent) {
+			end++
+		}
+		if len(path) == 1 {
+			return i + 1, end, true
+		}
+		for j := i + 1; j < end; j++ {
+			if child := indentOf(lines[j]); child != -1 {
+				return blockRange(lines, j, end, child, path[1:])
+			}
+		}
+		return 0, 0, false
+	}
+	return 0, 0, false
+}
+
+// patchStringMap rewrites the single line entries of the YAML map at
+// path to values, keeping their order and the comments around them.
+// New entries are added at the end of the map.
+func patchStringMap(lines []string, path []string, values map[string]string) ([]string, bool) {
+	start, end, ok := blockRange(lines, 0, len(lines), 0, path)
+	if !ok {
+		return lines, len(values) == 0
+	}
+
+	var patched []string
+	indent := -1
+	seen := map[string]bool{}
+	for _, line := range lines[start:end] {
+		if indentOf(line) == -1 {
+			patched = append(patched, line)
+			continue
+		}
+		m := yamlEntryRegexp.FindStringSubmatch(line)
+		if m == nil || (indent != -1 && len(m[1]) != indent) {
+			// Multi-line or nested values
+			return nil, false
+		}
+		indent = len(m[1])
+		key := unquoteYAMLKey(strings.TrimSpace(m[2]))
+		seen[key] = true
+		value, ok := values[key]
+		if !ok {
+			continue
+		}
+		scalar, ok := yamlScalar(value)
+		if !ok {
+			return nil, false
+		}
+		patched = append(patched, m[1]+m[2]+": "+scalar+m[4])
+	}
+	if indent == -1 {
+		return nil, false
+	}
+
+	// Trailing comments and blank lines stay after the new entries
+	insert := len(patched)
+	for insert > 0 && indentOf(patched[insert-1]) == -1 {
+		insert--
+	}
+	var added []string
+	for _, key := range sortedStringKeys(values) {
+		if seen[key] {
+			continue
+		}
+		k, ok := yamlScalar(key)
+		v, ok2 := yamlScalar(values[key])
+		if !ok || !ok2 {
+			return nil, false
+		}
+		added = append(added, strings.Repeat(" ", indent)+k+": "+v)
+	}
+	patched = append(patched[:insert], append(added, patched[insert:]...)...)
+
+	result := append([]string{}, lines[:start]...)
+	result = append(result, patched...)
+	return append(result, lines[end:]...), true
+}
+
+func sortedStringKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// preserveLayout returns the YAML manifest original of a SealedSecret
+// updated to ssecret, keeping its comments and the order of its keys,
+// so that resealing GitOps files only changes the sealed values and
+// annotations. It returns false if that isn't possible, eg. for
+// multi-line values or other changes to the SealedSecret.
+func preserveLayout(original []byte, ssecret *ssv1alpha1.SealedSecret, codecs runtimeserializer.CodecFactory) ([]byte, bool) {
+	if trimmed := bytes.TrimSpace(original); len(trimmed) == 0 || trimmed[0] == '{' {
+		// JSON has no comments
+		return nil, false
+	}
+	lines := strings.Split(strings.TrimSuffix(string(original), "\n"), "\n")
+	content := false
+	for _, line := range lines {
+		if strings.HasPrefix(line, "---") && content {
+			// Several documents
+			return nil, false
+		}
+		content = content || (indentOf(line) != -1 && !strings.HasPrefix(line, "---"))
+	}
+
+	encryptedData := map[string]string{}
+	for k, v := range ssecret.Spec.EncryptedData {
+		encryptedData[k] = base64.StdEncoding.EncodeToString(v)
+	}
+	lines, ok := patchStringMap(lines, []string{"spec", "encryptedData"}, encryptedData)
+	if !ok {
+		return nil, false
+	}
+	if lines, ok = patchStringMap(lines, []string{"metadata", "annotations"}, ssecret.GetAnnotations()); !ok {
+		return nil, false
+	}
+	patched := []byte(strings.Join(lines, "\n") + "\n")
+
+	// Anything else changed is rewritten in full
+	var result ssv1alpha1.SealedSecret
+	if err := runtime.DecodeInto(codecs.UniversalDecoder(), patched, &result); err != nil {
+		return nil, false
+	}
+	if result.GetName() != ssecret.GetName() || result.GetNamespace() != ssecret.GetNamespace() || result.Type != ssecret.Type ||
+		!equalStringMaps(result.GetAnnotations(), ssecret.GetAnnotations()) ||
+		!equalStringMaps(result.GetLabels(), ssecret.GetLabels()) ||
+		!reflect.DeepEqual(result.Spec, ssecret.Spec) {
+		return nil, false
+	}
+	return patched, true
+}
+
+func equalStringMaps(a, b map[string]string) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
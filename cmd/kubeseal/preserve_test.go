@@ -0,0 +1,86 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/scheme"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+const testCommentedSealedSecret = `# Database credentials, owned by team-db
+kind: SealedSecret
+apiVersion: bitnami.com/v1alpha1
+metadata:
+  namespace: myns
+  name: mysecret
+  annotations:
+    owner: team-db # who to ask
+spec:
+  encryptedData:
+    # rotated every quarter
+    password: b2xk
+    user: b2xk # not really secret
+    removed: b2xk
+
+  # keep the type for the app
+  template: {}
+`
+
+func TestPreserveLayout(t *testing.T) {
+	ssecret := &ssv1alpha1.SealedSecret{}
+	ssecret.SetName("mysecret")
+	ssecret.SetNamespace("myns")
+	ssecret.SetAnnotations(map[string]string{"owner": "team-db", "new": "yes: really"})
+	ssecret.Spec.EncryptedData = map[string][]byte{
+		"password": []byte("new"),
+		"user":     []byte("new"),
+		"added":    []byte("new"),
+	}
+	ssecret.Spec.Template = &ssv1alpha1.SealedSecretTemplate{}
+
+	patched, ok := preserveLayout([]byte(testCommentedSealedSecret), ssecret, scheme.Codecs)
+	if !ok {
+		t.Fatal("Expected the layout to be preserved")
+	}
+	expected := `# Database credentials, owned by team-db
+kind: SealedSecret
+apiVersion: bitnami.com/v1alpha1
+metadata:
+  namespace: myns
+  name: mysecret
+  annotations:
+    owner: team-db # who to ask
+    new: 'yes: really'
+spec:
+  encryptedData:
+    # rotated every quarter
+    password: bmV3
+    user: bmV3 # not really secret
+    added: bmV3
+
+  # keep the type for the app
+  template: {}
+`
+	if string(patched) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, patched)
+	}
+
+	// Other changes can't be patched in
+	ssecret.Spec.Template = nil
+	if _, ok := preserveLayout([]byte(testCommentedSealedSecret), ssecret, scheme.Codecs); ok {
+		t.Error("Expected a changed template not to be patched")
+	}
+	ssecret.Spec.Template = &ssv1alpha1.SealedSecretTemplate{}
+
+	for _, original := range []string{
+		`{"kind": "SealedSecret"}`,
+		strings.Replace(testCommentedSealedSecret, "password: b2xk", "password: >-\n      b2xk", 1),
+		testCommentedSealedSecret + "---\n" + testCommentedSealedSecret,
+	} {
+		if _, ok := preserveLayout([]byte(original), ssecret, scheme.Codecs); ok {
+			t.Errorf("Expected the layout of %q not to be preserved", original)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+
+	flag "github.com/spf13/pflag"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	sealing "github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+var (
+	recordFile = flag.String("record-file", "", "Append a JSON record of each sealed object to this file: its target kind, namespace and name, the certificate fingerprint and a digest of the plaintext.")
+)
+
+// sealRecords receives the records of the sealed objects, nil without
+// --record-file.
+var sealRecords io.Writer
+
+// recordTarget identifies the object a sealed object unseals to.
+type recordTarget struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace"`
+	Name       string `json:"name"`
+}
+
+// sealRecord describes a sealed object for tooling. The format is
+// stable: fields may be added, but never renamed or removed.
+type sealRecord struct {
+	Target     recordTarget `json:"target"`
+	SealedKind string       `json:"sealedKind"`
+	// CertFingerprint identifies the public key of the sealing
+	// certificate, see seal.Fingerprint.
+	CertFingerprint string `json:"certFingerprint"`
+	// PlaintextDigest only changes when the plaintext or the
+	// certificate change, so that resealing can be skipped otherwise.
+	PlaintextDigest string `json:"plaintextDigest"`
+}
+
+// plaintextDigest returns the HMAC-SHA256 of the content of obj, keyed
+// with the certificate fingerprint. The metadata of obj is left out,
+// since it isn't sealed.
+func plaintextDigest(fingerprint string, obj runtime.Object) (string, error) {
+	if secret, ok := obj.(*v1.Secret); ok && len(secret.StringData) > 0 {
+		// Same digest whichever way the values are given, merged
+		// as they are sealed
+		secret = secret.DeepCopy()
+		secret.Data = ssv1alpha1.SecretData(secret)
+		secret.StringData = nil
+		obj = secret
+	}
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	var content map[string]interface{}
+	if err := json.Unmarshal(data, &content); err != nil {
+		return "", err
+	}
+	for _, field := range []string{"apiVersion", "kind", "metadata", "status"} {
+		delete(content, field)
+	}
+	// Maps are encoded with sorted keys
+	canonical, err := json.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, []byte(fingerprint))
+	mac.Write(canonical)
+	return "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// targetKind returns the kind of obj, which decoded objects don't
+// always carry.
+func targetKind(obj runtime.Object) schema.GroupVersionKind {
+	switch obj.(type) {
+	case *v1.Secret:
+		return v1.SchemeGroupVersion.WithKind("Secret")
+	case *v1.ConfigMap:
+		return v1.SchemeGroupVersion.WithKind("ConfigMap")
+	}
+	return obj.GetObjectKind().GroupVersionKind()
+}
+
+// newSealRecord returns the record of sealed, sealed from obj with
+// pubKey.
+func newSealRecord(pubKey *rsa.PublicKey, obj runtime.Object, sealed sealedObject) (*sealRecord, error) {
+	fingerprint, err := sealing.Fingerprint(pubKey)
+	if err != nil {
+		return nil, err
+	}
+	digest, err := plaintextDigest(fingerprint, obj)
+	if err != nil {
+		return nil, err
+	}
+	apiVersion, kind := targetKind(obj).ToAPIVersionAndKind()
+	return &sealRecord{
+		Target: recordTarget{
+			APIVersion: apiVersion,
+			Kind:       kind,
+			Namespace:  sealed.GetNamespace(),
+			Name:       sealed.GetName(),
+		},
+		SealedKind:      sealedKind(sealed),
+		CertFingerprint: fingerprint,
+		PlaintextDigest: digest,
+	}, nil
+}
+
+// sealedKind returns the kind of a sealed object.
+func sealedKind(sealed sealedObject) string {
+	if kind := sealed.GetObjectKind().GroupVersionKind().Kind; kind != "" {
+		return kind
+	}
+	// The kind is only set when the object is encoded
+	kinds, _, err := scheme.Scheme.ObjectKinds(sealed)
+	if err != nil || len(kinds) == 0 {
+		return ""
+	}
+	return kinds[0].Kind
+}
+
+// recordSealed writes the record of sealed to --record-file, if set.
+func recordSealed(pubKey *rsa.PublicKey, obj runtime.Object, sealed sealedObject) error {
+	if sealRecords == nil {
+		return nil
+	}
+	record, err := newSealRecord(pubKey, obj, sealed)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(sealRecords).Encode(record)
+}
+
+// openRecordFile opens --record-file for appending.
+func openRecordFile(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+}
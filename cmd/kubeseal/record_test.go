@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+func TestRecordSealed(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	sealRecords = &buf
+	defer func() { sealRecords = nil }()
+
+	input := `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"mysecret","namespace":"myns"},"data":{"foo":"YmFy"}}
+{"apiVersion":"v1","kind":"Secret","metadata":{"name":"mysecret","namespace":"myns","labels":{"a":"b"}},"stringData":{"foo":"bar"}}
+{"apiVersion":"v1","kind":"Secret","metadata":{"name":"mysecret","namespace":"myns"},"stringData":{"foo":"baz"}}`
+	if err := seal(strings.NewReader(input), &bytes.Buffer{}, scheme.Codecs, &key.PublicKey); err != nil {
+		t.Fatal(err)
+	}
+
+	dec := json.NewDecoder(&buf)
+	var records []sealRecord
+	for dec.More() {
+		var r sealRecord
+		if err := dec.Decode(&r); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, r)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expected 3 records, got %d", len(records))
+	}
+
+	expected := recordTarget{APIVersion: "v1", Kind: "Secret", Namespace: "myns", Name: "mysecret"}
+	if records[0].Target != expected || records[0].SealedKind != "SealedSecret" || records[0].CertFingerprint == "" {
+		t.Errorf("Unexpected record %+v", records[0])
+	}
+	if !strings.HasPrefix(records[0].PlaintextDigest, "hmac-sha256:") {
+		t.Errorf("Unexpected digest %q", records[0].PlaintextDigest)
+	}
+	// Metadata and stringData don't change the digest, values do
+	if records[0].PlaintextDigest != records[1].PlaintextDigest {
+		t.Errorf("Expected the same digest, got %q and %q", records[0].PlaintextDigest, records[1].PlaintextDigest)
+	}
+	if records[0].PlaintextDigest == records[2].PlaintextDigest {
+		t.Error("Expected a different digest for a different value")
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+)
+
+var (
+	reencryptAllFlag = flag.Bool("reencrypt-all", false, "Make the controller reseal every SealedSecret of the cluster not sealed with its latest key, and print its progress. Requires --enable-admin-endpoints on the controller.")
+)
+
+// reencryptLine is a line of the /admin/reencrypt-all response of the
+// controller: the result for a SealedSecret, or the summary.
+type reencryptLine struct {
+	Object string `json:"object"`
+	Result string `json:"result"`
+	Error  string `json:"error"`
+
+	Done     bool     `json:"done"`
+	Total    int      `json:"total"`
+	Current  int      `json:"current"`
+	Resealed []string `json:"resealed"`
+	Failed   []string `json:"failed"`
+}
+
+// reencryptAll triggers the re-encryption of every SealedSecret by the
+// controller at url and writes its progress to out. Returns false if
+// some SealedSecrets couldn't be resealed.
+func reencryptAll(url, token string, out io.Writer) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, url, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := controllerClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return false, fmt.Errorf("Error re-encrypting SealedSecrets: %s %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var line reencryptLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return false, fmt.Errorf("Unexpected response from the controller: %v", err)
+		}
+		if line.Done {
+			fmt.Fprintf(out, "%d SealedSecrets: %d resealed, %d already current, %d failed\n", line.Total, len(line.Resealed), line.Current, len(line.Failed))
+			if len(line.Resealed) > 0 {
+				fmt.Fprintf(out, "Update the manifests of the resealed SealedSecrets wherever they are stored.\n")
+			}
+			return len(line.Failed) == 0, nil
+		}
+		if line.Error != "" {
+			fmt.Fprintf(out, "%s: %s (%s)\n", line.Object, line.Result, line.Error)
+		} else {
+			fmt.Fprintf(out, "%s: %s\n", line.Object, line.Result)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, err
+	}
+	return false, fmt.Errorf("Re-encryption interrupted before completion, run it again to resume")
+}
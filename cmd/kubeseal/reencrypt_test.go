@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReencryptAll(t *testing.T) {
+	response := `{"object":"myns/current","result":"current"}
+{"object":"myns/mysecret","result":"resealed"}
+`
+	summary := `{"done":true,"total":2,"current":1,"resealed":["myns/mysecret"],"failed":[]}
+`
+	failedSummary := `{"done":true,"total":2,"current":1,"resealed":[],"failed":["myns/mysecret"]}
+`
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Authorization") != "Bearer mytoken" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		io.WriteString(w, body)
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		body  string
+		token string
+		ok    bool
+		err   bool
+	}{
+		{response + summary, "mytoken", true, false},
+		{response + failedSummary, "mytoken", false, false},
+		{response, "mytoken", false, true},
+		{response + summary, "badtoken", false, true},
+	}
+	for i, tc := range testCases {
+		body = tc.body
+		var out bytes.Buffer
+		ok, err := reencryptAll(server.URL, tc.token, &out)
+		if (err != nil) != tc.err || ok != tc.ok {
+			t.Errorf("Case %d: got ok=%v err=%v, expected ok=%v err=%v", i, ok, err, tc.ok, tc.err)
+		}
+		if !tc.err && !strings.Contains(out.String(), "myns/mysecret: resealed\n") {
+			t.Errorf("Case %d: unexpected output %q", i, out.String())
+		}
+	}
+}
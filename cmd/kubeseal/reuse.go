@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	flag "github.com/spf13/pflag"
+	"gopkg.in/yaml.v2"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	sealing "github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+// plaintextHashesAnnotation records, for each value, a hash binding
+// its plaintext to its ciphertext and the certificate it was sealed
+// with, see plaintextHash.
+const plaintextHashesAnnotation = "sealedsecrets.bitnami.com/plaintext-hashes"
+
+// minReuseKeyBytes is the minimum length of the --reuse-key.
+const minReuseKeyBytes = 32
+
+var (
+	reuseFrom    = flag.String("reuse-from", "", "SealedSecret manifest file or directory previously written with --reuse-from. The values whose plaintext and certificate are unchanged keep their ciphertext, so that resealing doesn't churn Git. A missing path is ignored. Requires --reuse-key.")
+	reuseKeyFile = flag.String("reuse-key", "", "File holding the secret key, of at least 32 bytes, of the plaintext hashes recorded with --reuse-from. It must be kept out of Git, like the plaintexts: the same key is needed to reuse the ciphertexts.")
+)
+
+// previousSealedSecrets are the SealedSecrets read from --reuse-from by
+// namespace/name, nil without --reuse-from.
+var previousSealedSecrets map[string]*ssv1alpha1.SealedSecret
+
+// reuseKey is the key of the plaintext hashes, read from --reuse-key.
+var reuseKey []byte
+
+// readReuseKey reads the key of the plaintext hashes from file.
+func readReuseKey(file string) ([]byte, error) {
+	if file == "" {
+		return nil, fmt.Errorf("--reuse-from requires --reuse-key")
+	}
+	key, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	key = bytes.TrimSpace(key)
+	if len(key) < minReuseKeyBytes {
+		return nil, fmt.Errorf("--reuse-key %s is too short, it must hold at least %d bytes", file, minReuseKeyBytes)
+	}
+	return key, nil
+}
+
+// plaintextHash is the HMAC of the fingerprint of the certificate, the
+// ciphertext and the plaintext, keyed with the reuse key. Only those
+// holding the key, like the plaintexts, can tell from the hashes which
+// plaintext a ciphertext seals, even for guessable values.
+func plaintextHash(key []byte, fingerprint string, ciphertext, plaintext []byte) string {
+	mac := hmac.New(sha256.New, key)
+	io.WriteString(mac, fingerprint)
+	mac.Write([]byte{0})
+	io.WriteString(mac, base64.StdEncoding.EncodeToString(ciphertext))
+	mac.Write([]byte{0})
+	mac.Write(plaintext)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// loadPreviousSealedSecrets reads the SealedSecrets of the manifests
+// found at path.
+func loadPreviousSealedSecrets(path string) (map[string]*ssv1alpha1.SealedSecret, error) {
+	prev := map[string]*ssv1alpha1.SealedSecret{}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return prev, nil
+	}
+	files, err := manifestFiles([]string{path})
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if err := readPreviousSealedSecrets(file, prev); err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+	}
+	return prev, nil
+}
+
+func readPreviousSealedSecrets(file string, prev map[string]*ssv1alpha1.SealedSecret) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(f))
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var meta struct {
+			Kind string `yaml:"kind"`
+		}
+		if len(bytes.TrimSpace(doc)) == 0 || yaml.Unmarshal(doc, &meta) != nil || meta.Kind != "SealedSecret" {
+			continue
+		}
+		ssecret := &ssv1alpha1.SealedSecret{}
+		if err := runtime.DecodeInto(scheme.Codecs.UniversalDecoder(), doc, ssecret); err != nil {
+			return err
+		}
+		prev[ssecret.GetNamespace()+"/"+ssecret.GetName()] = ssecret
+	}
+}
+
+// sameSealing reports whether the ciphertexts of a can be used in b.
+func sameSealing(a, b *ssv1alpha1.SealedSecret) bool {
+	for _, anno := range []string{ssv1alpha1.SealedSecretClusterWideAnnotation, ssv1alpha1.SealedSecretNamespaceWideAnnotation} {
+		if a.GetAnnotations()[anno] != b.GetAnnotations()[anno] {
+			return false
+		}
+	}
+	return a.Spec.Algorithm == b.Spec.Algorithm && a.Spec.SealingParams() == b.Spec.SealingParams()
+}
+
+// reuseCiphertexts replaces the ciphertexts of ssecret, sealed from
+// secret with pubKey, by those of the previous SealedSecret when their
+// plaintext and certificate are unchanged, and records the plaintext
+// hashes for the next run.
+func reuseCiphertexts(ssecret *ssv1alpha1.SealedSecret, secret *v1.Secret, pubKey *rsa.PublicKey) error {
+	fingerprint, err := sealing.Fingerprint(pubKey)
+	if err != nil {
+		return err
+	}
+
+	var prevHashes map[string]string
+	prev := previousSealedSecrets[ssecret.GetNamespace()+"/"+ssecret.GetName()]
+	if prev != nil && sameSealing(prev, ssecret) {
+		// An invalid annotation only prevents reusing the ciphertexts.
+		json.Unmarshal([]byte(prev.GetAnnotations()[plaintextHashesAnnotation]), &prevHashes)
+	}
+
+	hashes := map[string]string{}
+	for key, plaintext := range ssv1alpha1.SecretData(secret) {
+		if prevHash, ok := prevHashes[key]; ok {
+			old := prev.Spec.EncryptedData[key]
+			if len(old) > 0 && hmac.Equal([]byte(prevHash), []byte(plaintextHash(reuseKey, fingerprint, old, plaintext))) {
+				ssecret.Spec.EncryptedData[key] = old
+			}
+		}
+		hashes[key] = plaintextHash(reuseKey, fingerprint, ssecret.Spec.EncryptedData[key], plaintext)
+	}
+
+	data, err := json.Marshal(hashes)
+	if err != nil {
+		return err
+	}
+	annotations := map[string]string{}
+	for k, v := range ssecret.GetAnnotations() {
+		annotations[k] = v
+	}
+	annotations[plaintextHashesAnnotation] = string(data)
+	ssecret.SetAnnotations(annotations)
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+func TestReuseCiphertexts(t *testing.T) {
+	defer func() { previousSealedSecrets, reuseKey = nil, nil }()
+	reuseKey = bytes.Repeat([]byte("k"), minReuseKeyBytes)
+
+	dir, err := ioutil.TempDir("", "kubeseal-reuse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "sealed.yaml")
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reseal := func(pubKey *rsa.PublicKey, data map[string][]byte) *ssv1alpha1.SealedSecret {
+		if previousSealedSecrets, err = loadPreviousSealedSecrets(path); err != nil {
+			t.Fatalf("loadPreviousSealedSecrets() returned error: %v", err)
+		}
+		secret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "myns"},
+			Data:       data,
+		}
+		obj, err := sealObject(scheme.Codecs, pubKey, secret)
+		if err != nil {
+			t.Fatalf("sealObject() returned error: %v", err)
+		}
+		if err := writeSealedSecretFile(path, scheme.Codecs, obj); err != nil {
+			t.Fatalf("writeSealedSecretFile() returned error: %v", err)
+		}
+		return obj.(*ssv1alpha1.SealedSecret)
+	}
+
+	first := reseal(&key.PublicKey, map[string][]byte{"foo": []byte("bar"), "baz": []byte("qux")})
+	if first.GetAnnotations()[plaintextHashesAnnotation] == "" {
+		t.Fatalf("No plaintext hashes recorded")
+	}
+
+	second := reseal(&key.PublicKey, map[string][]byte{"foo": []byte("bar"), "baz": []byte("changed")})
+	if !bytes.Equal(second.Spec.EncryptedData["foo"], first.Spec.EncryptedData["foo"]) {
+		t.Errorf("Unchanged value was sealed again")
+	}
+	if bytes.Equal(second.Spec.EncryptedData["baz"], first.Spec.EncryptedData["baz"]) {
+		t.Errorf("Changed value kept its ciphertext")
+	}
+
+	// Without the key, the hashes tell nothing about the plaintexts
+	reuseKey = bytes.Repeat([]byte("x"), minReuseKeyBytes)
+	third := reseal(&key.PublicKey, map[string][]byte{"foo": []byte("bar"), "baz": []byte("changed")})
+	if bytes.Equal(third.Spec.EncryptedData["foo"], second.Spec.EncryptedData["foo"]) {
+		t.Errorf("Ciphertext kept despite another reuse key")
+	}
+
+	fourth := reseal(&otherKey.PublicKey, map[string][]byte{"foo": []byte("bar"), "baz": []byte("changed")})
+	if bytes.Equal(fourth.Spec.EncryptedData["foo"], third.Spec.EncryptedData["foo"]) {
+		t.Errorf("Ciphertext kept despite a new certificate")
+	}
+}
+
+func TestReadReuseKey(t *testing.T) {
+	f, err := ioutil.TempFile("", "reuse-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := readReuseKey(""); err == nil {
+		t.Errorf("Expected error without --reuse-key")
+	}
+	f.WriteString("short\n")
+	if _, err := readReuseKey(f.Name()); err == nil {
+		t.Errorf("Expected error for a short key")
+	}
+	f.WriteString("0123456789abcdef0123456789abcdef\n")
+	f.Close()
+	key, err := readReuseKey(f.Name())
+	if err != nil {
+		t.Fatalf("readReuseKey() returned error: %v", err)
+	}
+	if string(key) != "short\n0123456789abcdef0123456789abcdef" {
+		t.Errorf("Unexpected key %q", key)
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+	"gopkg.in/yaml.v2"
+	runtimeserializer "k8s.io/apimachinery/pkg/runtime/serializer"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+var (
+	scanFix = flag.Bool("fix", false, "With scan, seal the plaintext Secrets found in YAML files in place.")
+)
+
+var (
+	// Keys whose values are likely to be credentials.
+	suspiciousKeyRegexp = regexp.MustCompile(`(?i)(passw|secret|token|api_?key|credential|private)`)
+	base64Regexp        = regexp.MustCompile(`^[A-Za-z0-9+/]+={0,2}$`)
+)
+
+// Shortest base64 value reported, shorter ones are usually harmless.
+const minSuspiciousBase64 = 16
+
+// scannedDocument is the part of a manifest looked at by the scanner.
+type scannedDocument struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name      string `yaml:"name"`
+		Namespace string `yaml:"namespace"`
+	} `yaml:"metadata"`
+	Data       map[string]interface{} `yaml:"data"`
+	StringData map[string]interface{} `yaml:"stringData"`
+}
+
+// suspiciousBase64 reports whether value, found under key, looks like
+// an encoded credential.
+func suspiciousBase64(key, value string) bool {
+	if len(value) < minSuspiciousBase64 || len(value)%4 != 0 || !base64Regexp.MatchString(value) {
+		return false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return false
+	}
+	return suspiciousKeyRegexp.MatchString(key) || bytes.HasPrefix(decoded, []byte("-----BEGIN"))
+}
+
+// scanValues reports the suspicious base64 values found in v, at path.
+func scanValues(path string, v interface{}, report func(path string)) {
+	switch v := v.(type) {
+	case yaml.MapSlice:
+		// Environment variables are named by their name field
+		name, _ := mapSliceGet(v, "name")
+		for _, item := range v {
+			field := fmt.Sprint(item.Key)
+			key := field
+			if n, ok := name.(string); ok && field == "value" {
+				key = n
+			}
+			if s, ok := item.Value.(string); ok && suspiciousBase64(key, s) {
+				report(path + "." + field)
+				continue
+			}
+			scanValues(path+"."+field, item.Value, report)
+		}
+	case []interface{}:
+		for i, item := range v {
+			scanValues(fmt.Sprintf("%s[%d]", path, i), item, report)
+		}
+	}
+}
+
+// scanDocument returns the problems of doc, and whether it is a
+// plaintext Secret.
+func scanDocument(file string, doc []byte) ([]lintIssue, bool) {
+	var meta scannedDocument
+	var raw yaml.MapSlice
+	if err := yaml.Unmarshal(doc, &meta); err != nil {
+		// Templates and other non-manifests are skipped
+		return nil, false
+	}
+	yaml.Unmarshal(doc, &raw)
+
+	object := meta.Metadata.Name
+	if meta.Metadata.Namespace != "" {
+		object = meta.Metadata.Namespace + "/" + object
+	}
+	switch {
+	case meta.Kind == "SealedSecret":
+		return nil, false
+	case meta.APIVersion == "v1" && meta.Kind == "Secret":
+		if len(meta.Data) == 0 && len(meta.StringData) == 0 {
+			return nil, false
+		}
+		return []lintIssue{{file: file, object: object, message: "plaintext Secret, seal it with kubeseal"}}, true
+	}
+
+	var issues []lintIssue
+	scanValues("", raw, func(path string) {
+		issues = append(issues, lintIssue{file: file, object: object, message: fmt.Sprintf("%s looks like a base64 encoded secret", strings.TrimPrefix(path, "."))})
+	})
+	return issues, false
+}
+
+// scanFile returns the problems of the manifests of file, and whether
+// it has plaintext Secrets.
+func scanFile(file string, content []byte) ([]lintIssue, bool, error) {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(content)))
+	var issues []lintIssue
+	secrets := false
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("%s: %v", file, err)
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+		docIssues, secret := scanDocument(file, doc)
+		issues = append(issues, docIssues...)
+		secrets = secrets || secret
+	}
+	return issues, secrets, nil
+}
+
+// runScan scans the manifests of the given files and directories for
+// plaintext Secrets and encoded credentials, printing them to out. It
+// returns false if any were found. With fix, the Secrets of YAML files
+// are sealed in place with the key returned by pubKey instead.
+func runScan(out io.Writer, paths []string, fix bool, codecs runtimeserializer.CodecFactory, pubKey func() (*rsa.PublicKey, error)) (bool, error) {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	files, err := manifestFiles(paths)
+	if err != nil {
+		return false, err
+	}
+
+	ok := true
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return false, err
+		}
+		issues, secrets, err := scanFile(file, content)
+		if err != nil {
+			return false, err
+		}
+
+		ext := strings.ToLower(filepath.Ext(file))
+		if fix && secrets && (ext == ".yaml" || ext == ".yml") {
+			key, err := pubKey()
+			if err != nil {
+				return false, err
+			}
+			var sealed bytes.Buffer
+			if err := postRender(bytes.NewReader(content), &sealed, codecs, key); err != nil {
+				return false, fmt.Errorf("%s: %v", file, err)
+			}
+			info, err := os.Stat(file)
+			if err != nil {
+				return false, err
+			}
+			if err := ioutil.WriteFile(file, sealed.Bytes(), info.Mode()); err != nil {
+				return false, err
+			}
+			fmt.Fprintf(out, "%s: sealed plaintext Secrets\n", file)
+			if issues, _, err = scanFile(file, sealed.Bytes()); err != nil {
+				return false, err
+			}
+		}
+
+		if !printLintIssues(out, issues) {
+			ok = false
+		}
+	}
+	return ok, nil
+}
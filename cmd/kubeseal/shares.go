@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+	certUtil "k8s.io/client-go/util/cert"
+
+	"github.com/bitnami-labs/sealed-secrets/pkg/shamir"
+)
+
+var (
+	keyShares    = flag.Int("key-shares", 5, "Number of shares to split the private key into, used with the split-key command.")
+	keyThreshold = flag.Int("key-threshold", 3, "Number of shares required to reconstruct the private key, used with the split-key command.")
+)
+
+// splitKey splits the PEM encoded private key read from in into n
+// shares, written to out one per line, any threshold of which can
+// reconstruct it with combineKey.
+func splitKey(in io.Reader, out io.Writer, n, threshold int) error {
+	pem, err := ioutil.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	if _, err := certUtil.ParsePrivateKeyPEM(pem); err != nil {
+		return fmt.Errorf("Invalid private key: %v", err)
+	}
+
+	shares, err := shamir.Split(pem, n, threshold)
+	if err != nil {
+		return err
+	}
+	for _, share := range shares {
+		if _, err := fmt.Fprintln(out, base64.StdEncoding.EncodeToString(share)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// combineKey reconstructs the private key from the shares read from
+// in, one per line, and writes it to out.
+func combineKey(in io.Reader, out io.Writer) error {
+	var shares [][]byte
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		share, err := base64.StdEncoding.DecodeString(line)
+		if err != nil {
+			return fmt.Errorf("Invalid share: %v", err)
+		}
+		shares = append(shares, share)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	pem, err := shamir.Combine(shares)
+	if err != nil {
+		return err
+	}
+	if _, err := certUtil.ParsePrivateKeyPEM(pem); err != nil {
+		return errors.New("The shares don't reconstruct a private key, are there enough of them?")
+	}
+	_, err = out.Write(pem)
+	return err
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"strings"
+	"testing"
+
+	certUtil "k8s.io/client-go/util/cert"
+)
+
+func TestSplitCombineKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pem := certUtil.EncodePrivateKeyPEM(key)
+
+	var shares bytes.Buffer
+	if err := splitKey(bytes.NewReader(pem), &shares, 5, 3); err != nil {
+		t.Fatalf("splitKey() returned err: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(shares.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("Expected 5 shares, got %d", len(lines))
+	}
+
+	var out bytes.Buffer
+	in := strings.Join([]string{lines[4], "", lines[0], lines[2]}, "\n")
+	if err := combineKey(strings.NewReader(in), &out); err != nil {
+		t.Fatalf("combineKey() returned err: %v", err)
+	}
+	if !bytes.Equal(out.Bytes(), pem) {
+		t.Errorf("Reconstructed a different key")
+	}
+
+	out.Reset()
+	in = strings.Join(lines[:2], "\n")
+	if err := combineKey(strings.NewReader(in), &out); err == nil {
+		t.Errorf("Expected 2 shares not to be enough")
+	}
+}
+
+func TestSplitKeyInvalid(t *testing.T) {
+	var out bytes.Buffer
+	if err := splitKey(strings.NewReader("not a key"), &out, 5, 3); err == nil {
+		t.Errorf("Expected invalid key to be rejected")
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+
+	flag "github.com/spf13/pflag"
+	v1 "k8s.io/api/core/v1"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+var (
+	maxSecretBytes       = flag.Int("max-secret-bytes", 1<<20, "Maximum size of the data of a Secret to seal, 1MiB by default as for the API server. Unlimited if 0.")
+	maxSealedSecretBytes = flag.Int("max-sealed-secret-bytes", 1<<20, "Maximum size of the encrypted data of a SealedSecret, 1MiB by default to stay within the etcd request limit once base64 encoded. Unlimited if 0.")
+)
+
+// secretSize is the size of the data of secret, merged with its
+// stringData, as counted by the API server.
+func secretSize(secret *v1.Secret) int {
+	size := 0
+	for k, v := range ssv1alpha1.SecretData(secret) {
+		size += len(k) + len(v)
+	}
+	return size
+}
+
+// sealedSecretSize is the size of the encrypted data of ssecret.
+func sealedSecretSize(ssecret *ssv1alpha1.SealedSecret) int {
+	size := len(ssecret.Spec.Data)
+	for k, v := range ssecret.Spec.EncryptedData {
+		size += len(k) + len(v)
+	}
+	return size
+}
+
+func checkSecretSize(secret *v1.Secret) error {
+	if size := secretSize(secret); *maxSecretBytes > 0 && size > *maxSecretBytes {
+		return fmt.Errorf("Secret %s/%s has %d bytes of data, more than --max-secret-bytes=%d", secret.GetNamespace(), secret.GetName(), size, *maxSecretBytes)
+	}
+	return nil
+}
+
+func checkSealedSecretSize(ssecret *ssv1alpha1.SealedSecret) error {
+	if size := sealedSecretSize(ssecret); *maxSealedSecretBytes > 0 && size > *maxSealedSecretBytes {
+		return fmt.Errorf("SealedSecret %s/%s has %d bytes of encrypted data, more than --max-sealed-secret-bytes=%d, try --compress=gzip", ssecret.GetNamespace(), ssecret.GetName(), size, *maxSealedSecretBytes)
+	}
+	return nil
+}
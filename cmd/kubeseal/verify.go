@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"text/tabwriter"
+
+	flag "github.com/spf13/pflag"
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/runtime"
+	runtimeserializer "k8s.io/apimachinery/pkg/runtime/serializer"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	certUtil "k8s.io/client-go/util/cert"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	sealing "github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+var (
+	verifyRecursive   = flag.Bool("recursive", false, "With verify, descend into the given directories.")
+	verifyPrivateKeys = flag.StringSlice("private-key", nil, "With verify, PEM private key file to verify offline with instead of asking the controller. Can be repeated.")
+)
+
+// sealedSecretVerifier returns an error if ssecret can't be decrypted.
+type sealedSecretVerifier func(ssecret *ssv1alpha1.SealedSecret) error
+
+// controllerVerifier verifies SealedSecrets with the controller.
+func controllerVerifier(namespace, name string) (sealedSecretVerifier, error) {
+	conf, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	restClient, err := corev1.NewForConfig(conf)
+	if err != nil {
+		return nil, err
+	}
+	return func(ssecret *ssv1alpha1.SealedSecret) error {
+		// Decoding drops the type, which the controller needs
+		ssecret = ssecret.DeepCopy()
+		ssecret.APIVersion = ssv1alpha1.SchemeGroupVersion.String()
+		ssecret.Kind = "SealedSecret"
+		content, err := json.Marshal(ssecret)
+		if err != nil {
+			return err
+		}
+		return verifyWithController(restClient, namespace, name, content)
+	}, nil
+}
+
+// offlineVerifier verifies SealedSecrets with the private keys of the
+// given PEM files.
+func offlineVerifier(files []string) (sealedSecretVerifier, error) {
+	var keys sealing.PrivateKeys
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		key, err := certUtil.ParsePrivateKeyPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%s: not an RSA private key", file)
+		}
+		keys = append(keys, rsaKey)
+	}
+	return func(ssecret *ssv1alpha1.SealedSecret) error {
+		_, err := sealing.Unseal(ssecret, keys)
+		return err
+	}, nil
+}
+
+// verifyResult is the outcome of the verification of a file.
+type verifyResult struct {
+	file   string
+	result string
+	detail string
+}
+
+// verifyFile verifies the SealedSecrets of the manifests of content.
+// Files without any SealedSecret are skipped.
+func verifyFile(file string, content []byte, codecs runtimeserializer.CodecFactory, verify sealedSecretVerifier) verifyResult {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(content)))
+	count := 0
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return verifyResult{file, "FAIL", err.Error()}
+		}
+		var meta scannedDocument
+		if err := yaml.Unmarshal(doc, &meta); err != nil || meta.Kind != "SealedSecret" {
+			continue
+		}
+
+		var ssecret ssv1alpha1.SealedSecret
+		if err := runtime.DecodeInto(codecs.UniversalDecoder(), doc, &ssecret); err != nil {
+			return verifyResult{file, "FAIL", err.Error()}
+		}
+		if err := verify(&ssecret); err != nil {
+			return verifyResult{file, "FAIL", fmt.Sprintf("%s/%s: %v", ssecret.GetNamespace(), ssecret.GetName(), err)}
+		}
+		count++
+	}
+	if count == 0 {
+		return verifyResult{file, "SKIP", "no SealedSecret"}
+	}
+	return verifyResult{file, "PASS", fmt.Sprintf("%d SealedSecret(s)", count)}
+}
+
+// runVerify verifies the SealedSecrets of the given files, and of the
+// files of the given directories with recursive, printing a table of
+// the results to out. It returns false if any of them failed.
+func runVerify(out io.Writer, paths []string, recursive bool, codecs runtimeserializer.CodecFactory, verify sealedSecretVerifier) (bool, error) {
+	if len(paths) == 0 {
+		return false, fmt.Errorf("verify requires files or directories")
+	}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return false, err
+		}
+		if info.IsDir() && !recursive {
+			return false, fmt.Errorf("%s is a directory, use --recursive", path)
+		}
+	}
+	files, err := manifestFiles(paths)
+	if err != nil {
+		return false, err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "FILE\tRESULT\tDETAILS")
+	ok := true
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return false, err
+		}
+		r := verifyFile(file, content, codecs, verify)
+		ok = ok && r.result != "FAIL"
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.file, r.result, r.detail)
+	}
+	return ok, w.Flush()
+}
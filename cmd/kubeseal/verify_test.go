@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	sealing "github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+func TestRunVerify(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "kubeseal-verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeSealed := func(name string, pubKey *rsa.PublicKey) {
+		ssecret, err := sealing.Seal(&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "myns"},
+			Data:       map[string][]byte{"foo": []byte("bar")},
+		}, pubKey, sealing.StrictScope)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := sealedSecretOutput(&buf, scheme.Codecs, ssecret); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeSealed("good.json", &key.PublicKey)
+	writeSealed("apps/bad.json", &other.PublicKey)
+	if err := ioutil.WriteFile(filepath.Join(dir, "cm.yaml"), []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	verify := func(keys ...*rsa.PrivateKey) sealedSecretVerifier {
+		return func(ssecret *ssv1alpha1.SealedSecret) error {
+			_, err := sealing.Unseal(ssecret, sealing.PrivateKeys(keys))
+			return err
+		}
+	}
+
+	if _, err := runVerify(ioutil.Discard, []string{dir}, false, scheme.Codecs, verify(key)); err == nil {
+		t.Error("Expected directories to require --recursive")
+	}
+
+	var out bytes.Buffer
+	ok, err := runVerify(&out, []string{dir}, true, scheme.Codecs, verify(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Error("Expected verification to fail")
+	}
+	for _, expected := range []string{"FAIL", "good.json", "PASS", "cm.yaml", "SKIP"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected %q in:\n%s", expected, out.String())
+		}
+	}
+
+	ok, err = runVerify(ioutil.Discard, []string{dir}, true, scheme.Codecs, verify(key, other))
+	if err != nil || !ok {
+		t.Errorf("Expected verification to pass with both keys, got %v, %v", ok, err)
+	}
+}
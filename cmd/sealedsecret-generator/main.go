@@ -0,0 +1,300 @@
+// sealedsecret-generator is a kustomize generator producing SealedSecrets
+// from secretGenerator-style inputs, so that plaintext never needs to be
+// committed next to the kustomization.
+//
+// It runs either as a KRM function, reading a ResourceList on stdin, or
+// as a legacy exec plugin, receiving the path of its config as argument.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	goflag "flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	"github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+const (
+	generatorKind = "SealedSecretGenerator"
+
+	resourceListAPIVersion = "config.kubernetes.io/v1"
+	resourceListKind       = "ResourceList"
+)
+
+var (
+	printVersion = goflag.Bool("version", false, "Print version information and exit")
+
+	// VERSION set from Makefile
+	VERSION = "UNKNOWN"
+)
+
+// generatorConfig is the SealedSecretGenerator resource. Its fields
+// mirror kustomize's secretGenerator.
+type generatorConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	// Cert is the path of the sealing certificate, as written by
+	// kubeseal --fetch-cert.
+	Cert string `json:"cert"`
+	// Scope is one of strict (the default), namespace-wide or
+	// cluster-wide.
+	Scope string        `json:"scope,omitempty"`
+	Type  v1.SecretType `json:"type,omitempty"`
+
+	Literals []string `json:"literals,omitempty"`
+	Files    []string `json:"files,omitempty"`
+	Envs     []string `json:"envs,omitempty"`
+}
+
+// resourceList is the KRM function input and output.
+type resourceList struct {
+	APIVersion     string                   `json:"apiVersion"`
+	Kind           string                   `json:"kind"`
+	Items          []map[string]interface{} `json:"items"`
+	FunctionConfig map[string]interface{}   `json:"functionConfig,omitempty"`
+}
+
+// splitKeyValue parses the "key=value" form used by literals and
+// files. If there is no "=", ok is false.
+func splitKeyValue(s string) (key, value string, ok bool) {
+	i := strings.Index(s, "=")
+	if i < 0 {
+		return "", s, false
+	}
+	return s[:i], s[i+1:], true
+}
+
+func parseEnvFile(file string, data map[string][]byte) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := splitKeyValue(line)
+		if !ok {
+			return fmt.Errorf("%s:%d: expected KEY=VALUE, got %q", file, n, line)
+		}
+		data[key] = []byte(value)
+	}
+	return scanner.Err()
+}
+
+// secretFor builds the plaintext Secret described by the generator.
+func secretFor(cfg *generatorConfig) (*v1.Secret, error) {
+	if cfg.GetName() == "" {
+		return nil, errors.New("metadata.name is required")
+	}
+	if cfg.GetNamespace() == "" {
+		return nil, errors.New("metadata.namespace is required, the namespace is part of the sealed secret")
+	}
+
+	data := map[string][]byte{}
+	for _, env := range cfg.Envs {
+		if err := parseEnvFile(env, data); err != nil {
+			return nil, err
+		}
+	}
+	for _, file := range cfg.Files {
+		key, path, ok := splitKeyValue(file)
+		if !ok {
+			key = filepath.Base(path)
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		data[key] = content
+	}
+	for _, literal := range cfg.Literals {
+		key, value, ok := splitKeyValue(literal)
+		if !ok {
+			return nil, fmt.Errorf("literal %q is not of the form key=value", literal)
+		}
+		data[key] = []byte(value)
+	}
+	if len(data) == 0 {
+		return nil, errors.New("no literals, files or envs given")
+	}
+
+	annotations := map[string]string{}
+	for k, v := range cfg.GetAnnotations() {
+		// Drop kustomize's own bookkeeping, such as the function
+		// declaration of the generator itself.
+		if strings.Contains(k, "config.kubernetes.io/") || strings.HasPrefix(k, "kustomize.config.k8s.io/") {
+			continue
+		}
+		annotations[k] = v
+	}
+	secretType := cfg.Type
+	if secretType == "" {
+		secretType = v1.SecretTypeOpaque
+	}
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        cfg.GetName(),
+			Namespace:   cfg.GetNamespace(),
+			Labels:      cfg.GetLabels(),
+			Annotations: annotations,
+		},
+		Data: data,
+		Type: secretType,
+	}, nil
+}
+
+// generate returns the SealedSecret described by cfg, as a generic
+// object suitable for a ResourceList.
+func generate(cfg *generatorConfig) (map[string]interface{}, error) {
+	if cfg.Kind != generatorKind {
+		return nil, fmt.Errorf("expected a %s, got %q", generatorKind, cfg.Kind)
+	}
+	if cfg.Cert == "" {
+		return nil, errors.New("cert is required")
+	}
+	scope, err := seal.ParseScope(cfg.Scope)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(cfg.Cert)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	pubKey, err := seal.ParsePublicKey(f)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := secretFor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	ssecret, err := seal.Seal(secret, pubKey, scope)
+	if err != nil {
+		return nil, err
+	}
+	ssecret.APIVersion = ssv1alpha1.SchemeGroupVersion.String()
+	ssecret.Kind = "SealedSecret"
+	ssecret.Labels = secret.GetLabels()
+
+	buf, err := json.Marshal(ssecret)
+	if err != nil {
+		return nil, err
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(buf, &obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func decodeConfig(obj interface{}) (*generatorConfig, error) {
+	buf, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var cfg generatorConfig
+	if err := json.Unmarshal(buf, &cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// runFunction implements the KRM function protocol: the generated
+// SealedSecret is appended to the items of the input ResourceList.
+func runFunction(in io.Reader, out io.Writer) error {
+	input, err := ioutil.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	var list resourceList
+	if err := yaml.Unmarshal(input, &list); err != nil {
+		return err
+	}
+	if list.Kind != resourceListKind {
+		return fmt.Errorf("expected a %s on stdin, got %q", resourceListKind, list.Kind)
+	}
+
+	cfg, err := decodeConfig(list.FunctionConfig)
+	if err != nil {
+		return err
+	}
+	obj, err := generate(cfg)
+	if err != nil {
+		return err
+	}
+
+	if list.APIVersion == "" {
+		list.APIVersion = resourceListAPIVersion
+	}
+	list.Items = append(list.Items, obj)
+	buf, err := yaml.Marshal(list)
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(buf)
+	return err
+}
+
+// runExecPlugin implements the legacy kustomize exec plugin protocol:
+// the config is read from file and only the generated object is
+// written to out.
+func runExecPlugin(file string, out io.Writer) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	var cfg generatorConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return err
+	}
+	obj, err := generate(&cfg)
+	if err != nil {
+		return err
+	}
+	buf, err := yaml.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(buf)
+	return err
+}
+
+func main() {
+	goflag.Parse()
+
+	if *printVersion {
+		fmt.Printf("sealedsecret-generator version: %s\n", VERSION)
+		return
+	}
+
+	var err error
+	if goflag.NArg() > 0 {
+		err = runExecPlugin(goflag.Arg(0), os.Stdout)
+	} else {
+		err = runFunction(os.Stdin, os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", generatorKind, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+const testCert = `
+-----BEGIN CERTIFICATE-----
+MIIErTCCApWgAwIBAgIQBekz48i8NbrzIpIrLMIULTANBgkqhkiG9w0BAQsFADAA
+MB4XDTE3MDYyMDA0MzI0NVoXDTI3MDYxODA0MzI0NVowADCCAiIwDQYJKoZIhvcN
+AQEBBQADggIPADCCAgoCggIBAL6ISW4MnHAmC6MdmJOwo9C6YYhKYDwPD2tF+j4p
+I2duB3y7DLF+zWNHgbUlBZck8CudacJTuxOJFEqr4umqm0f4EGgRPwZgFvFLHKSZ
+/hxUFnMcGVhY1qsk55peSghPHarOYyBhhHDtCu7qdMu9MqPZB68y16HdPvwWPadI
+dBKSxDLvwYfjDnG/ZHX9rmlDKej7jPGdvqAY5VJteP30w6YHb1Uc4whppNcDSc2l
+gOuKAWtQ5WfZbB0NpMhj4framNeXMYwjZytEdC1c/4O45zm5eK4FNPueCfxOlzFQ
+D3y34OuQlJwlrPE4KmdMHtE1a8x0ihbglInJrtqcXK3vEdUJ2c/BKWgFtPOTz6Du
+jV4j0OMVVGnk5jUmh+yfbgielIkPcpSTWP1cIPwK3eWbrvMziq6sv0x7QoOD3Pzm
+GBE8Y9sa5uy+bJZt5MywbamZ3xWaxoQbSN8RPoxRhTe0DEpx6utCXSWpapT7kWZ3
+R1PTuVx+Ktyz7MRoDUWvxfpMJ2hsJ71Az0AuUZ4N4fmmGdUcM81GPUOiMZ4uqySQ
+A2phgikbJaTzcT85RcNFYSi4eKc5mYFNqr5xVa6uHhZ+OGeGy1yyOEWLgIZV3A/8
+4eZshOyYtRlZjCkaGZTfXNft+8QJi8rEZRcJtVhqLzezBVRsL7pt6P/mQj4+XHsE
+VSBrAgMBAAGjIzAhMA4GA1UdDwEB/wQEAwIAATAPBgNVHRMBAf8EBTADAQH/MA0G
+CSqGSIb3DQEBCwUAA4ICAQCSizqBB3bjHCSGk/8lpqIyHJQR5u4Cf7LRrC9U8mxe
+pvC3Fx3/RlVe87Y4cUb37xZc/TmB6Bq10Y6R7ydS3oe8PCh4UQRnEfBgtJ6m59ha
+t3iPX0NdQVYz/D+yEiHjpI7gpyFNuGkd4/78JE51SO4yGYvWk/ChHoMvbLcxzfdK
+PI2Ymf3MWtGfoF/TQ1jy/Biy+qumDPSz23MynQG39cdUInSK26oemUbTH0koLulN
+fNl4TwSEdSm2DRl0la+vkrzu7SvF9SJ2ES6wMWVjYiJLNpApjGuF9/ZOFw9DvSSH
+m+UYXn+IC7rTgvXKvXTlG//z/14Lx0GFIY+ZjdENwLH//orBQLg37TZatKEpaWO6
+uRzFUxZVw3ic3RxoHfEbRA9vQlQdKnV+BpZe/Pb08RAh82OZyujqqyK7cPPOW5Vi
+T9y+NeMwfKH8H4un7mQWkgWFw3LMIspYY5uHWp6jBwU9u/mjoK4+Y219dkaAhAcx
+D+YIZRXwxc6ehLCavGF2DIepybzDlJbiCe8JxUDsrE/Xkm6x28uq35oZ3UQznubU
+7LfAeRSI99sNvFnq0TqhSlp+CUDs8Z1LvDXzAHX4UeZQl4g+H+w1KudCvjO0mPPp
+R9bIjJLIvp7CQPDkdRzJSjvetrKtI0l97VjsjbRB9v6ZekGY9SFI49KzKUTk8fsF
+/A==
+-----END CERTIFICATE-----
+`
+
+func TestSecretFor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "generator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	envFile := filepath.Join(dir, "app.env")
+	if err := ioutil.WriteFile(envFile, []byte("# comment\nUSER=admin\nPASSWORD=env\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(dir, "tls.key")
+	if err := ioutil.WriteFile(keyFile, []byte("keydata"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &generatorConfig{
+		Literals: []string{"PASSWORD=literal"},
+		Files:    []string{keyFile, "renamed=" + keyFile},
+		Envs:     []string{envFile},
+	}
+	cfg.Name = "mysecret"
+	cfg.Namespace = "myns"
+	cfg.Annotations = map[string]string{
+		"config.kubernetes.io/function": "exec: {}",
+		"team":                          "payments",
+	}
+
+	secret, err := secretFor(cfg)
+	if err != nil {
+		t.Fatalf("secretFor() returned error: %v", err)
+	}
+	expected := map[string]string{
+		"USER":     "admin",
+		"PASSWORD": "literal",
+		"tls.key":  "keydata",
+		"renamed":  "keydata",
+	}
+	if len(secret.Data) != len(expected) {
+		t.Errorf("Unexpected data %v", secret.Data)
+	}
+	for k, v := range expected {
+		if string(secret.Data[k]) != v {
+			t.Errorf("Expected %s=%q, got %q", k, v, secret.Data[k])
+		}
+	}
+	if _, ok := secret.Annotations["config.kubernetes.io/function"]; ok || secret.Annotations["team"] != "payments" {
+		t.Errorf("Unexpected annotations: %v", secret.Annotations)
+	}
+
+	cfg.Namespace = ""
+	if _, err := secretFor(cfg); err == nil {
+		t.Errorf("Expected error without namespace")
+	}
+}
+
+func TestRunFunction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "generator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	certFile := filepath.Join(dir, "cert.pem")
+	if err := ioutil.WriteFile(certFile, []byte(testCert), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	input := `
+apiVersion: config.kubernetes.io/v1
+kind: ResourceList
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: existing
+functionConfig:
+  apiVersion: bitnami.com/v1alpha1
+  kind: SealedSecretGenerator
+  metadata:
+    name: mysecret
+    namespace: myns
+  cert: ` + certFile + `
+  scope: namespace-wide
+  literals:
+  - foo=bar
+`
+	var out bytes.Buffer
+	if err := runFunction(strings.NewReader(input), &out); err != nil {
+		t.Fatalf("runFunction() returned error: %v", err)
+	}
+
+	var list resourceList
+	if err := yaml.Unmarshal(out.Bytes(), &list); err != nil {
+		t.Fatalf("Failed to parse output: %v", err)
+	}
+	if len(list.Items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(list.Items))
+	}
+
+	buf, err := yaml.Marshal(list.Items[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ssecret ssv1alpha1.SealedSecret
+	if err := yaml.Unmarshal(buf, &ssecret); err != nil {
+		t.Fatalf("Failed to parse SealedSecret: %v", err)
+	}
+	if ssecret.Kind != "SealedSecret" || ssecret.GetName() != "mysecret" || ssecret.GetNamespace() != "myns" {
+		t.Errorf("Unexpected item %s %s/%s", ssecret.Kind, ssecret.GetNamespace(), ssecret.GetName())
+	}
+	if ssecret.Annotations[ssv1alpha1.SealedSecretNamespaceWideAnnotation] != "true" {
+		t.Errorf("Missing scope annotation: %v", ssecret.Annotations)
+	}
+	if _, ok := ssecret.Spec.EncryptedData["foo"]; !ok {
+		t.Errorf("Missing encrypted item: %v", ssecret.Spec.EncryptedData)
+	}
+}
@@ -1,4 +1,5 @@
 //go:generate ../../../../vendor/k8s.io/code-generator/generate-groups.sh all github.com/bitnami-labs/sealed-secrets/pkg/client github.com/bitnami-labs/sealed-secrets/pkg/apis sealed-secrets:v1alpha1
+//go:generate go run gen_validation.go ../../../../sealedsecret-validation.json
 // +k8s:deepcopy-gen=package,register
 
 // +groupName=bitnami.com
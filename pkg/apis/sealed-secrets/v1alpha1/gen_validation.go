@@ -0,0 +1,28 @@
+// +build ignore
+
+// gen_validation writes the validation of the SealedSecret
+// CustomResourceDefinition, imported by controller-norbac.jsonnet, to
+// the file given as argument.
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		log.Fatalf("Usage: %s <output file>", os.Args[0])
+	}
+	data, err := json.MarshalIndent(ssv1alpha1.SealedSecretValidation(), "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(os.Args[1], append(data, '\n'), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -32,6 +32,12 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&SealedSecret{},
 		&SealedSecretList{},
+		&SealedConfigMap{},
+		&SealedConfigMapList{},
+		&SealedObject{},
+		&SealedObjectList{},
+		&SealedSecretPolicy{},
+		&SealedSecretPolicyList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
@@ -0,0 +1,126 @@
+package v1alpha1
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+)
+
+// configMapLabelFor returns the RSA-OAEP label for a ConfigMap. It
+// differs from the label of a Secret with the same namespace and name,
+// so that values sealed for a Secret can't be unsealed into a
+// (possibly more widely readable) ConfigMap.
+func configMapLabelFor(o metav1.Object) ([]byte, bool, bool) {
+	label, clusterWide, namespaceWide := labelFor(o)
+	return append([]byte("configmap:"), label...), clusterWide, namespaceWide
+}
+
+func encryptValues(pubKey *rsa.PublicKey, values map[string][]byte, label []byte) (map[string][]byte, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	res := make(map[string][]byte, len(values))
+	for key, value := range values {
+		ciphertext, err := crypto.HybridEncrypt(rand.Reader, pubKey, value, label)
+		if err != nil {
+			return nil, err
+		}
+		res[key] = ciphertext
+	}
+	return res, nil
+}
+
+func decryptValues(privKey *rsa.PrivateKey, values map[string][]byte, label []byte) (map[string][]byte, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	res := make(map[string][]byte, len(values))
+	for key, value := range values {
+		plaintext, err := crypto.HybridDecrypt(rand.Reader, privKey, value, label)
+		if err != nil {
+			return nil, err
+		}
+		res[key] = plaintext
+	}
+	return res, nil
+}
+
+// NewSealedConfigMap creates a new SealedConfigMap object wrapping the
+// provided ConfigMap, encrypting each value individually.
+func NewSealedConfigMap(pubKey *rsa.PublicKey, cm *v1.ConfigMap) (*SealedConfigMap, error) {
+	if cm.GetNamespace() == "" {
+		return nil, fmt.Errorf("ConfigMap must declare a namespace")
+	}
+
+	s := &SealedConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        cm.GetName(),
+			Namespace:   cm.GetNamespace(),
+			Annotations: cm.GetAnnotations(),
+		},
+	}
+
+	label, _, _ := configMapLabelFor(cm)
+
+	data := make(map[string][]byte, len(cm.Data))
+	for key, value := range cm.Data {
+		data[key] = []byte(value)
+	}
+	var err error
+	if s.Spec.EncryptedData, err = encryptValues(pubKey, data, label); err != nil {
+		return nil, err
+	}
+	if s.Spec.EncryptedBinaryData, err = encryptValues(pubKey, cm.BinaryData, label); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Unseal decrypts and returns the embedded v1.ConfigMap.
+func (s *SealedConfigMap) Unseal(privKey *rsa.PrivateKey) (*v1.ConfigMap, error) {
+	boolTrue := true
+	smeta := s.GetObjectMeta()
+
+	// This will fail to decrypt unless the same label was used
+	// during encryption, see SealedSecret.Unseal.
+	label, _, _ := configMapLabelFor(smeta)
+
+	data, err := decryptValues(privKey, s.Spec.EncryptedData, label)
+	if err != nil {
+		return nil, err
+	}
+	binaryData, err := decryptValues(privKey, s.Spec.EncryptedBinaryData, label)
+	if err != nil {
+		return nil, err
+	}
+
+	var cm v1.ConfigMap
+	if len(data) > 0 {
+		cm.Data = make(map[string]string, len(data))
+		for key, value := range data {
+			cm.Data[key] = string(value)
+		}
+	}
+	cm.BinaryData = binaryData
+
+	cm.SetNamespace(smeta.GetNamespace())
+	cm.SetAnnotations(smeta.GetAnnotations())
+	cm.SetName(smeta.GetName())
+
+	gvk := SchemeGroupVersion.WithKind("SealedConfigMap")
+	cm.SetOwnerReferences([]metav1.OwnerReference{
+		{
+			APIVersion: gvk.GroupVersion().String(),
+			Kind:       gvk.Kind,
+			Name:       smeta.GetName(),
+			UID:        smeta.GetUID(),
+			Controller: &boolTrue,
+		},
+	})
+	return &cm, nil
+}
@@ -0,0 +1,85 @@
+package v1alpha1
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+)
+
+// objectLabelFor returns the RSA-OAEP label for a SealedObject. As with
+// configMapLabelFor, the prefix keeps ciphertext sealed for a Secret
+// from being unsealed into an arbitrary object.
+func objectLabelFor(o metav1.Object) ([]byte, bool, bool) {
+	label, clusterWide, namespaceWide := labelFor(o)
+	return append([]byte("object:"), label...), clusterWide, namespaceWide
+}
+
+// NewSealedObject creates a new SealedObject wrapping the provided
+// object. The whole object, as JSON, is encrypted in a single value.
+func NewSealedObject(pubKey *rsa.PublicKey, obj *unstructured.Unstructured) (*SealedObject, error) {
+	if obj.GetNamespace() == "" {
+		return nil, fmt.Errorf("Object must declare a namespace")
+	}
+	if obj.GetAPIVersion() == "" || obj.GetKind() == "" {
+		return nil, fmt.Errorf("Object must declare apiVersion and kind")
+	}
+
+	s := &SealedObject{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        obj.GetName(),
+			Namespace:   obj.GetNamespace(),
+			Annotations: obj.GetAnnotations(),
+		},
+	}
+
+	content, err := obj.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	label, _, _ := objectLabelFor(obj)
+	s.Spec.EncryptedObject, err = crypto.HybridEncrypt(rand.Reader, pubKey, content, label)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Unseal decrypts and returns the embedded object. Its name and
+// namespace are always those of the SealedObject.
+func (s *SealedObject) Unseal(privKey *rsa.PrivateKey) (*unstructured.Unstructured, error) {
+	boolTrue := true
+	smeta := s.GetObjectMeta()
+
+	label, _, _ := objectLabelFor(smeta)
+
+	content, err := crypto.HybridDecrypt(rand.Reader, privKey, s.Spec.EncryptedObject, label)
+	if err != nil {
+		return nil, err
+	}
+
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(content); err != nil {
+		return nil, err
+	}
+
+	obj.SetNamespace(smeta.GetNamespace())
+	obj.SetName(smeta.GetName())
+
+	gvk := SchemeGroupVersion.WithKind("SealedObject")
+	obj.SetOwnerReferences([]metav1.OwnerReference{
+		{
+			APIVersion: gvk.GroupVersion().String(),
+			Kind:       gvk.Kind,
+			Name:       smeta.GetName(),
+			UID:        smeta.GetUID(),
+			Controller: &boolTrue,
+		},
+	})
+	return obj, nil
+}
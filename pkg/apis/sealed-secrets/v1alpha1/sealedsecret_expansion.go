@@ -3,6 +3,7 @@ package v1alpha1
 import (
 	"crypto/rand"
 	"crypto/rsa"
+	"errors"
 	"fmt"
 
 	v1 "k8s.io/api/core/v1"
@@ -11,8 +12,57 @@ import (
 	runtimeserializer "k8s.io/apimachinery/pkg/runtime/serializer"
 
 	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto/mlkem"
 )
 
+// ErrUnsupportedAlgorithm is returned when unsealing an object sealed
+// with an unknown algorithm.
+var ErrUnsupportedAlgorithm = errors.New("Unsupported sealing algorithm")
+
+// ErrEmptyData is returned when unsealing a SealedSecret without any
+// items that isn't annotated with SealedSecretAllowEmptyDataAnnotation.
+var ErrEmptyData = errors.New("SealedSecret has no data, annotate it with " + SealedSecretAllowEmptyDataAnnotation + "=true if intended")
+
+// ParseCipher returns the session cipher named name, CipherAESGCM if
+// empty.
+func ParseCipher(name string) (crypto.Cipher, error) {
+	switch name {
+	case "", CipherAESGCM:
+		return crypto.CipherAESGCM, nil
+	case CipherChaCha20Poly1305:
+		return crypto.CipherChaCha20Poly1305, nil
+	default:
+		return 0, fmt.Errorf("Unsupported cipher %q", name)
+	}
+}
+
+// ParseOAEPHash returns the RSA-OAEP hash named name, OAEPHashSHA256 if
+// empty.
+func ParseOAEPHash(name string) (crypto.OAEPHash, error) {
+	switch name {
+	case "", OAEPHashSHA256:
+		return crypto.OAEPSHA256, nil
+	case OAEPHashSHA1:
+		return crypto.OAEPSHA1, nil
+	default:
+		return 0, fmt.Errorf("Unsupported OAEP hash %q", name)
+	}
+}
+
+// ParseCompression returns the compression named name, none if empty.
+func ParseCompression(name string) (crypto.Compression, error) {
+	switch name {
+	case "":
+		return crypto.CompressionNone, nil
+	case CompressionGzip:
+		return crypto.CompressionGzip, nil
+	case CompressionZstd:
+		return crypto.CompressionZstd, nil
+	default:
+		return 0, fmt.Errorf("Unsupported compression %q", name)
+	}
+}
+
 // Returns labels followed by clusterWide followed by namespaceWide.
 func labelFor(o metav1.Object) ([]byte, bool, bool) {
 	clusterWide := o.GetAnnotations()[SealedSecretClusterWideAnnotation]
@@ -78,6 +128,90 @@ func NewSealedSecretV1(codecs runtimeserializer.CodecFactory, pubKey *rsa.Public
 // provided secret. This encrypts only the values of each secrets
 // individually, so secrets can be updated one by one.
 func NewSealedSecret(codecs runtimeserializer.CodecFactory, pubKey *rsa.PublicKey, secret *v1.Secret) (*SealedSecret, error) {
+	return newSealedSecret(secret, "", func(value, label []byte) ([]byte, error) {
+		return crypto.HybridEncrypt(rand.Reader, pubKey, value, label)
+	})
+}
+
+// NewSealedSecretParams is like NewSealedSecret, sealing with the
+// primitives of params, see ParseCipher, ParseOAEPHash and
+// ParseCompression.
+func NewSealedSecretParams(codecs runtimeserializer.CodecFactory, pubKey *rsa.PublicKey, params SealingParams, secret *v1.Secret) (*SealedSecret, error) {
+	var p crypto.Params
+	var err error
+	if p.Cipher, err = ParseCipher(params.Cipher); err != nil {
+		return nil, err
+	}
+	if p.OAEPHash, err = ParseOAEPHash(params.OAEPHash); err != nil {
+		return nil, err
+	}
+	if p.Compression, err = ParseCompression(params.Compression); err != nil {
+		return nil, err
+	}
+	p.ChunkSize = params.ChunkSize
+	s, err := newSealedSecret(secret, "", func(value, label []byte) ([]byte, error) {
+		return crypto.HybridEncryptParams(rand.Reader, pubKey, p, value, label)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.Spec.Cipher = params.Cipher
+	s.Spec.OAEPHash = params.OAEPHash
+	s.Spec.Compression = params.Compression
+	s.Spec.ChunkSize = params.ChunkSize
+	return s, nil
+}
+
+// SealingParams returns the primitives s is sealed with.
+func (s *SealedSecretSpec) SealingParams() SealingParams {
+	return SealingParams{
+		Cipher:      s.Cipher,
+		OAEPHash:    s.OAEPHash,
+		Compression: s.Compression,
+		ChunkSize:   s.ChunkSize,
+	}
+}
+
+// NewSealedSecretX25519 is like NewSealedSecret, with AlgorithmX25519.
+func NewSealedSecretX25519(codecs runtimeserializer.CodecFactory, pubKey *[crypto.X25519KeySize]byte, secret *v1.Secret) (*SealedSecret, error) {
+	return newSealedSecret(secret, AlgorithmX25519, func(value, label []byte) ([]byte, error) {
+		return crypto.X25519Encrypt(rand.Reader, pubKey, value, label)
+	})
+}
+
+// NewSealedSecretRSAMLKEM is like NewSealedSecret, with
+// AlgorithmRSAMLKEM.
+func NewSealedSecretRSAMLKEM(codecs runtimeserializer.CodecFactory, pubKey *rsa.PublicKey, kemKey *mlkem.EncapsulationKey768, secret *v1.Secret) (*SealedSecret, error) {
+	return newSealedSecret(secret, AlgorithmRSAMLKEM, func(value, label []byte) ([]byte, error) {
+		return crypto.HybridPQEncrypt(rand.Reader, pubKey, kemKey, value, label)
+	})
+}
+
+// NewSealedSecretExternal is like NewSealedSecret, with
+// AlgorithmRSAExternal.
+func NewSealedSecretExternal(codecs runtimeserializer.CodecFactory, pubKey *rsa.PublicKey, secret *v1.Secret) (*SealedSecret, error) {
+	return newSealedSecret(secret, AlgorithmRSAExternal, func(value, label []byte) ([]byte, error) {
+		return crypto.HybridEncryptExternal(rand.Reader, pubKey, value, label)
+	})
+}
+
+// SecretData returns the data of secret merged with its stringData, as
+// the API server does: stringData takes precedence.
+func SecretData(secret *v1.Secret) map[string][]byte {
+	if len(secret.StringData) == 0 {
+		return secret.Data
+	}
+	data := make(map[string][]byte, len(secret.Data)+len(secret.StringData))
+	for k, v := range secret.Data {
+		data[k] = v
+	}
+	for k, v := range secret.StringData {
+		data[k] = []byte(v)
+	}
+	return data
+}
+
+func newSealedSecret(secret *v1.Secret, algorithm string, encrypt func(value, label []byte) ([]byte, error)) (*SealedSecret, error) {
 	if secret.GetNamespace() == "" {
 		return nil, fmt.Errorf("Secret must declare a namespace")
 	}
@@ -90,6 +224,7 @@ func NewSealedSecret(codecs runtimeserializer.CodecFactory, pubKey *rsa.PublicKe
 		},
 		Spec: SealedSecretSpec{
 			EncryptedData: map[string][]byte{},
+			Algorithm:     algorithm,
 		},
 		Type: secret.Type,
 	}
@@ -98,8 +233,8 @@ func NewSealedSecret(codecs runtimeserializer.CodecFactory, pubKey *rsa.PublicKe
 	// during decryption.
 	label, clusterWide, namespaceWide := labelFor(secret)
 
-	for key, value := range secret.Data {
-		ciphertext, err := crypto.HybridEncrypt(rand.Reader, pubKey, value, label)
+	for key, value := range SecretData(secret) {
+		ciphertext, err := encrypt(value, label)
 		if err != nil {
 			return nil, err
 		}
@@ -115,9 +250,14 @@ func NewSealedSecret(codecs runtimeserializer.CodecFactory, pubKey *rsa.PublicKe
 	return s, nil
 }
 
+// Empty reports whether s has no sealed, generated or templated items.
+func (s *SealedSecret) Empty() bool {
+	return len(s.Spec.EncryptedData) == 0 && len(s.Spec.Data) == 0 && len(s.Spec.Generate) == 0 &&
+		(s.Spec.Template == nil || len(s.Spec.Template.Data) == 0)
+}
+
 // Unseal decrypts and returns the embedded v1.Secret.
 func (s *SealedSecret) Unseal(codecs runtimeserializer.CodecFactory, privKey *rsa.PrivateKey) (*v1.Secret, error) {
-	boolTrue := true
 	smeta := s.GetObjectMeta()
 
 	// This will fail to decrypt unless the same label was used
@@ -126,11 +266,64 @@ func (s *SealedSecret) Unseal(codecs runtimeserializer.CodecFactory, privKey *rs
 	// namespace/name.
 	label, _, _ := labelFor(smeta)
 
+	decrypt := func(ciphertext []byte) ([]byte, error) {
+		return crypto.HybridDecrypt(rand.Reader, privKey, ciphertext, label)
+	}
+	switch s.Spec.Algorithm {
+	case "", AlgorithmRSA:
+	case AlgorithmX25519:
+		x25519Key, _, err := crypto.X25519KeyFromRSA(privKey)
+		if err != nil {
+			return nil, err
+		}
+		decrypt = func(ciphertext []byte) ([]byte, error) {
+			return crypto.X25519Decrypt(x25519Key, ciphertext, label)
+		}
+	case AlgorithmRSAMLKEM:
+		kemKey, err := crypto.MLKEMKeyFromRSA(privKey)
+		if err != nil {
+			return nil, err
+		}
+		decrypt = func(ciphertext []byte) ([]byte, error) {
+			return crypto.HybridPQDecrypt(rand.Reader, privKey, kemKey, ciphertext, label)
+		}
+	case AlgorithmRSAExternal:
+		// The private key of an external key may have been exported
+		return s.UnsealExternal(codecs, crypto.PrivateKeyDecrypter(privKey))
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+	return s.unseal(codecs, decrypt)
+}
+
+// UnsealExternal is like Unseal for a SealedSecret sealed with
+// AlgorithmRSAExternal, whose session keys are decrypted by d.
+func (s *SealedSecret) UnsealExternal(codecs runtimeserializer.CodecFactory, d crypto.SessionKeyDecrypter) (*v1.Secret, error) {
+	if s.Spec.Algorithm != AlgorithmRSAExternal {
+		return nil, ErrUnsupportedAlgorithm
+	}
+	label, _, _ := labelFor(s.GetObjectMeta())
+	return s.unseal(codecs, func(ciphertext []byte) ([]byte, error) {
+		return crypto.HybridDecryptExternal(d, ciphertext, label)
+	})
+}
+
+// unseal returns the Secret of s, decrypting its items with decrypt.
+func (s *SealedSecret) unseal(codecs runtimeserializer.CodecFactory, decrypt func(ciphertext []byte) ([]byte, error)) (*v1.Secret, error) {
+	boolTrue := true
+	smeta := s.GetObjectMeta()
+
+	if s.Empty() && s.GetAnnotations()[SealedSecretAllowEmptyDataAnnotation] != "true" {
+		return nil, ErrEmptyData
+	}
+
 	var secret v1.Secret
-	if len(s.Spec.EncryptedData) > 0 {
+	// Items may all be generated or templated by the controller, or
+	// there may be none
+	if len(s.Spec.EncryptedData) > 0 || len(s.Spec.Data) == 0 {
 	        secret.Data = map[string][]byte{}
 		for key, value := range s.Spec.EncryptedData {
-			plaintext, err := crypto.HybridDecrypt(rand.Reader, privKey, value, label)
+			plaintext, err := decrypt(value)
 			if err != nil {
 				return nil, err
 			}
@@ -138,7 +331,7 @@ func (s *SealedSecret) Unseal(codecs runtimeserializer.CodecFactory, privKey *rs
 		}
 		secret.Type = s.Type
 	} else { // Support decrypting old secrets for backward compatibility
-		plaintext, err := crypto.HybridDecrypt(rand.Reader, privKey, s.Spec.Data, label)
+		plaintext, err := decrypt(s.Spec.Data)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,114 @@
+package v1alpha1
+
+import (
+	"reflect"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Expiry returns the time after which s is no longer unsealed, and
+// whether s expires at all. The earliest of NotAfter and ExpireAfter
+// wins.
+func (s *SealedSecret) Expiry() (time.Time, bool) {
+	var expiry time.Time
+	if s.Spec.NotAfter != nil {
+		expiry = s.Spec.NotAfter.Time
+	}
+	if s.Spec.ExpireAfter != nil {
+		t := s.GetCreationTimestamp().Add(s.Spec.ExpireAfter.Duration)
+		if expiry.IsZero() || t.Before(expiry) {
+			expiry = t
+		}
+	}
+	return expiry, !expiry.IsZero()
+}
+
+// GetCondition returns the condition of type t, or nil if there is none.
+func (s *SealedSecret) GetCondition(t SealedSecretConditionType) *SealedSecretCondition {
+	if s.Status == nil {
+		return nil
+	}
+	for i := range s.Status.Conditions {
+		if s.Status.Conditions[i].Type == t {
+			return &s.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// SetCondition adds or updates the condition of type t. It returns
+// false if the condition was already in the requested state.
+func (s *SealedSecret) SetCondition(t SealedSecretConditionType, status apiv1.ConditionStatus, reason, message string) bool {
+	now := metav1.Now()
+	if c := s.GetCondition(t); c != nil {
+		if c.Status == status && c.Reason == reason && c.Message == message {
+			return false
+		}
+		if c.Status != status {
+			c.LastTransitionTime = now
+		}
+		c.Status = status
+		c.Reason = reason
+		c.Message = message
+		c.LastUpdateTime = now
+		return true
+	}
+
+	if s.Status == nil {
+		s.Status = &SealedSecretStatus{}
+	}
+	s.Status.Conditions = append(s.Status.Conditions, SealedSecretCondition{
+		Type:               t,
+		Status:             status,
+		LastUpdateTime:     now,
+		LastTransitionTime: now,
+		Reason:             reason,
+		Message:            message,
+	})
+	return true
+}
+
+// SetKeyFingerprint records the fingerprint of the key that decrypted
+// s. Returns whether the status has changed.
+func (s *SealedSecret) SetKeyFingerprint(fingerprint string) bool {
+	if s.Status != nil && s.Status.KeyFingerprint == fingerprint {
+		return false
+	}
+	if s.Status == nil {
+		s.Status = &SealedSecretStatus{}
+	}
+	s.Status.KeyFingerprint = fingerprint
+	return true
+}
+
+// SetObservedGeneration records that the status applies to generation.
+// Returns whether the status has changed.
+func (s *SealedSecret) SetObservedGeneration(generation int64) bool {
+	if s.Status != nil && s.Status.ObservedGeneration == generation {
+		return false
+	}
+	if s.Status == nil {
+		s.Status = &SealedSecretStatus{}
+	}
+	s.Status.ObservedGeneration = generation
+	return true
+}
+
+// SetReplicaNamespaces records the namespaces the Secret has been
+// replicated to. Returns whether the status has changed.
+func (s *SealedSecret) SetReplicaNamespaces(namespaces []string) bool {
+	var current []string
+	if s.Status != nil {
+		current = s.Status.ReplicaNamespaces
+	}
+	if (len(current) == 0 && len(namespaces) == 0) || reflect.DeepEqual(current, namespaces) {
+		return false
+	}
+	if s.Status == nil {
+		s.Status = &SealedSecretStatus{}
+	}
+	s.Status.ReplicaNamespaces = namespaces
+	return true
+}
@@ -6,7 +6,9 @@ import (
 	"io"
 	mathrand "math/rand"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/gofuzz"
 
@@ -18,6 +20,8 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/client-go/kubernetes/scheme"
 
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+
 	// Install standard API types
 	_ "k8s.io/client-go/kubernetes"
 )
@@ -203,6 +207,320 @@ func TestSealRoundTrip(t *testing.T) {
 	}
 }
 
+func TestSealRoundTripEmpty(t *testing.T) {
+	scheme := runtime.NewScheme()
+	codecs := serializer.NewCodecFactory(scheme)
+
+	SchemeBuilder.AddToScheme(scheme)
+	v1.SchemeBuilder.AddToScheme(scheme)
+
+	key, err := rsa.GenerateKey(testRand(), 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate test key: %v", err)
+	}
+
+	secret := v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myname",
+			Namespace: "myns",
+		},
+	}
+
+	ssecret, err := NewSealedSecret(codecs, &key.PublicKey, &secret)
+	if err != nil {
+		t.Fatalf("NewSealedSecret returned error: %v", err)
+	}
+	if !ssecret.Empty() {
+		t.Errorf("Expected SealedSecret to be empty")
+	}
+	if _, err := ssecret.Unseal(codecs, key); err != ErrEmptyData {
+		t.Errorf("Expected %v, got %v", ErrEmptyData, err)
+	}
+
+	ssecret.Annotations = map[string]string{SealedSecretAllowEmptyDataAnnotation: "true"}
+	secret2, err := ssecret.Unseal(codecs, key)
+	if err != nil {
+		t.Fatalf("Unseal returned error: %v", err)
+	}
+	if len(secret2.Data) != 0 {
+		t.Errorf("Expected no data, got %v", secret2.Data)
+	}
+}
+
+func TestSealRoundTripChaCha20Poly1305(t *testing.T) {
+	scheme := runtime.NewScheme()
+	codecs := serializer.NewCodecFactory(scheme)
+
+	SchemeBuilder.AddToScheme(scheme)
+	v1.SchemeBuilder.AddToScheme(scheme)
+
+	rand := testRand()
+	key, err := rsa.GenerateKey(rand, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate test key: %v", err)
+	}
+
+	secret := v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myname",
+			Namespace: "myns",
+		},
+		Data: map[string][]byte{
+			"foo": []byte("bar"),
+		},
+	}
+
+	ssecret, err := NewSealedSecretParams(codecs, &key.PublicKey, SealingParams{Cipher: CipherChaCha20Poly1305}, &secret)
+	if err != nil {
+		t.Fatalf("NewSealedSecretParams returned error: %v", err)
+	}
+	if ssecret.Spec.Cipher != CipherChaCha20Poly1305 {
+		t.Errorf("Unexpected cipher %q", ssecret.Spec.Cipher)
+	}
+
+	// The cipher is read from the ciphertext, not the spec.
+	ssecret.Spec.Cipher = ""
+	secret2, err := ssecret.Unseal(codecs, key)
+	if err != nil {
+		t.Fatalf("Unseal returned error: %v", err)
+	}
+
+	if !reflect.DeepEqual(secret.Data, secret2.Data) {
+		t.Errorf("Unsealed secret != original secret: %v != %v", secret, secret2)
+	}
+
+	if _, err := NewSealedSecretParams(codecs, &key.PublicKey, SealingParams{Cipher: "ROT13"}, &secret); err == nil {
+		t.Errorf("NewSealedSecretParams accepted an unknown cipher")
+	}
+}
+
+func TestSealRoundTripOAEPSHA1(t *testing.T) {
+	scheme := runtime.NewScheme()
+	codecs := serializer.NewCodecFactory(scheme)
+
+	SchemeBuilder.AddToScheme(scheme)
+	v1.SchemeBuilder.AddToScheme(scheme)
+
+	rand := testRand()
+	key, err := rsa.GenerateKey(rand, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate test key: %v", err)
+	}
+
+	secret := v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myname",
+			Namespace: "myns",
+		},
+		Data: map[string][]byte{
+			"foo": []byte("bar"),
+		},
+	}
+
+	for _, cipherName := range []string{"", CipherChaCha20Poly1305} {
+		ssecret, err := NewSealedSecretParams(codecs, &key.PublicKey, SealingParams{Cipher: cipherName, OAEPHash: OAEPHashSHA1}, &secret)
+		if err != nil {
+			t.Fatalf("NewSealedSecretParams returned error: %v", err)
+		}
+		if ssecret.Spec.OAEPHash != OAEPHashSHA1 {
+			t.Errorf("Unexpected OAEP hash %q", ssecret.Spec.OAEPHash)
+		}
+
+		secret2, err := ssecret.Unseal(codecs, key)
+		if err != nil {
+			t.Fatalf("Unseal returned error: %v", err)
+		}
+
+		if !reflect.DeepEqual(secret.Data, secret2.Data) {
+			t.Errorf("Unsealed secret != original secret: %v != %v", secret, secret2)
+		}
+	}
+
+	if _, err := NewSealedSecretParams(codecs, &key.PublicKey, SealingParams{OAEPHash: "MD5"}, &secret); err == nil {
+		t.Errorf("NewSealedSecretParams accepted an unknown OAEP hash")
+	}
+}
+
+func TestSealRoundTripCompression(t *testing.T) {
+	scheme := runtime.NewScheme()
+	codecs := serializer.NewCodecFactory(scheme)
+
+	SchemeBuilder.AddToScheme(scheme)
+	v1.SchemeBuilder.AddToScheme(scheme)
+
+	rand := testRand()
+	key, err := rsa.GenerateKey(rand, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate test key: %v", err)
+	}
+
+	kubeconfig := []byte(strings.Repeat(`{"apiVersion": "v1", "kind": "Config", "clusters": []}`, 100))
+	secret := v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myname",
+			Namespace: "myns",
+		},
+		Data: map[string][]byte{
+			"kubeconfig": kubeconfig,
+		},
+	}
+
+	for _, params := range []SealingParams{
+		{Compression: CompressionGzip},
+		{Compression: CompressionZstd},
+		{Compression: CompressionZstd, Cipher: CipherChaCha20Poly1305, OAEPHash: OAEPHashSHA1},
+	} {
+		ssecret, err := NewSealedSecretParams(codecs, &key.PublicKey, params, &secret)
+		if err != nil {
+			t.Fatalf("NewSealedSecretParams(%v) returned error: %v", params, err)
+		}
+		if l := len(ssecret.Spec.EncryptedData["kubeconfig"]); l >= len(kubeconfig) {
+			t.Errorf("%v: compressed ciphertext isn't smaller: %d >= %d bytes", params, l, len(kubeconfig))
+		}
+
+		secret2, err := ssecret.Unseal(codecs, key)
+		if err != nil {
+			t.Fatalf("%v: Unseal returned error: %v", params, err)
+		}
+
+		if !reflect.DeepEqual(secret.Data, secret2.Data) {
+			t.Errorf("%v: unsealed secret != original secret", params)
+		}
+	}
+
+	if _, err := NewSealedSecretParams(codecs, &key.PublicKey, SealingParams{Compression: "lz4"}, &secret); err == nil {
+		t.Errorf("NewSealedSecretParams accepted an unknown compression")
+	}
+}
+
+func TestSealRoundTripChunked(t *testing.T) {
+	scheme := runtime.NewScheme()
+	codecs := serializer.NewCodecFactory(scheme)
+
+	SchemeBuilder.AddToScheme(scheme)
+	v1.SchemeBuilder.AddToScheme(scheme)
+
+	rand := testRand()
+	key, err := rsa.GenerateKey(rand, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate test key: %v", err)
+	}
+
+	chunkSize := crypto.MinChunkSize
+	random := make([]byte, 3*chunkSize+100)
+	if _, err := io.ReadFull(rand, random); err != nil {
+		t.Fatal(err)
+	}
+	secret := v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myname",
+			Namespace: "myns",
+		},
+		Data: map[string][]byte{
+			"small":    []byte("sekret"),
+			"multiple": random[:2*chunkSize],
+			"random":   random,
+		},
+	}
+
+	for _, params := range []SealingParams{
+		{ChunkSize: chunkSize},
+		{ChunkSize: chunkSize, Compression: CompressionZstd, Cipher: CipherChaCha20Poly1305},
+	} {
+		ssecret, err := NewSealedSecretParams(codecs, &key.PublicKey, params, &secret)
+		if err != nil {
+			t.Fatalf("NewSealedSecretParams(%v) returned error: %v", params, err)
+		}
+		if ssecret.Spec.ChunkSize != chunkSize {
+			t.Errorf("%v: unexpected chunk size %d", params, ssecret.Spec.ChunkSize)
+		}
+		secret2, err := ssecret.Unseal(codecs, key)
+		if err != nil {
+			t.Fatalf("%v: Unseal returned error: %v", params, err)
+		}
+		if !reflect.DeepEqual(secret.Data, secret2.Data) {
+			t.Errorf("%v: unsealed secret != original secret", params)
+		}
+	}
+
+	ssecret, err := NewSealedSecretParams(codecs, &key.PublicKey, SealingParams{ChunkSize: chunkSize}, &secret)
+	if err != nil {
+		t.Fatalf("NewSealedSecretParams returned error: %v", err)
+	}
+	// Values fitting in a chunk can be unsealed by older controllers
+	if crypto.HeaderLen(ssecret.Spec.EncryptedData["small"]) != 2 {
+		t.Errorf("Unexpected chunked header for a small value")
+	}
+
+	// Dropping or reordering chunks is detected
+	ciphertext := ssecret.Spec.EncryptedData["random"]
+	sealedChunk := chunkSize + 16
+	body := len(ciphertext) - 100 - 16 - 3*sealedChunk
+	swapped := append([]byte{}, ciphertext[:body]...)
+	swapped = append(swapped, ciphertext[body+sealedChunk:body+2*sealedChunk]...)
+	swapped = append(swapped, ciphertext[body:body+sealedChunk]...)
+	swapped = append(swapped, ciphertext[body+2*sealedChunk:]...)
+	for name, tampered := range map[string][]byte{
+		"truncated": ciphertext[:body+3*sealedChunk],
+		"swapped":   swapped,
+	} {
+		ssecret.Spec.EncryptedData["random"] = tampered
+		if _, err := ssecret.Unseal(codecs, key); err == nil {
+			t.Errorf("Expected an error unsealing a %s ciphertext", name)
+		}
+	}
+
+	if _, err := NewSealedSecretParams(codecs, &key.PublicKey, SealingParams{ChunkSize: 16}, &secret); err == nil {
+		t.Errorf("NewSealedSecretParams accepted a chunk size below the minimum")
+	}
+}
+
+func TestSealRoundTripStringData(t *testing.T) {
+	scheme := runtime.NewScheme()
+	codecs := serializer.NewCodecFactory(scheme)
+
+	SchemeBuilder.AddToScheme(scheme)
+	v1.SchemeBuilder.AddToScheme(scheme)
+
+	rand := testRand()
+	key, err := rsa.GenerateKey(rand, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate test key: %v", err)
+	}
+
+	secret := v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myname",
+			Namespace: "myns",
+		},
+		Data: map[string][]byte{
+			"foo": []byte("bar"),
+			"baz": []byte("old"),
+		},
+		StringData: map[string]string{
+			"baz": "new",
+		},
+	}
+
+	ssecret, err := NewSealedSecret(codecs, &key.PublicKey, &secret)
+	if err != nil {
+		t.Fatalf("NewSealedSecret returned error: %v", err)
+	}
+
+	secret2, err := ssecret.Unseal(codecs, key)
+	if err != nil {
+		t.Fatalf("Unseal returned error: %v", err)
+	}
+
+	expected := map[string][]byte{"foo": []byte("bar"), "baz": []byte("new")}
+	if !reflect.DeepEqual(secret2.Data, expected) {
+		t.Errorf("Unsealed data %v != %v", secret2.Data, expected)
+	}
+	if len(secret.Data) != 2 || string(secret.Data["baz"]) != "old" {
+		t.Errorf("NewSealedSecret modified its input: %v", secret.Data)
+	}
+}
+
 func TestSealRoundTripWithClusterWide(t *testing.T) {
 	scheme := runtime.NewScheme()
 	codecs := serializer.NewCodecFactory(scheme)
@@ -404,3 +722,43 @@ func TestUnsealingV1Format(t *testing.T) {
 		t.Errorf("Unsealed secret != original secret: %v != %v", secret, secret2)
 	}
 }
+
+func TestExpiry(t *testing.T) {
+	created := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := SealedSecret{
+		ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(created)},
+	}
+	if _, ok := s.Expiry(); ok {
+		t.Errorf("Expected no expiry")
+	}
+
+	s.Spec.ExpireAfter = &metav1.Duration{Duration: 48 * time.Hour}
+	if expiry, ok := s.Expiry(); !ok || !expiry.Equal(created.Add(48*time.Hour)) {
+		t.Errorf("Unexpected expiry %v", expiry)
+	}
+
+	notAfter := metav1.NewTime(created.Add(24 * time.Hour))
+	s.Spec.NotAfter = &notAfter
+	if expiry, ok := s.Expiry(); !ok || !expiry.Equal(notAfter.Time) {
+		t.Errorf("Unexpected expiry %v", expiry)
+	}
+}
+
+func TestSetCondition(t *testing.T) {
+	var s SealedSecret
+	if s.GetCondition(SealedSecretExpired) != nil {
+		t.Errorf("Expected no condition")
+	}
+	if !s.SetCondition(SealedSecretExpired, v1.ConditionTrue, "Expired", "") {
+		t.Errorf("Expected condition to be added")
+	}
+	if s.SetCondition(SealedSecretExpired, v1.ConditionTrue, "Expired", "") {
+		t.Errorf("Expected condition to be unchanged")
+	}
+	if !s.SetCondition(SealedSecretExpired, v1.ConditionFalse, "", "") {
+		t.Errorf("Expected condition to be updated")
+	}
+	if c := s.GetCondition(SealedSecretExpired); c == nil || c.Status != v1.ConditionFalse || len(s.Status.Conditions) != 1 {
+		t.Errorf("Unexpected conditions %v", s.Status)
+	}
+}
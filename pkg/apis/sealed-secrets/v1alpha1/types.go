@@ -10,6 +10,10 @@ const (
 	SealedSecretName = "sealed-secret." + GroupName
 	// SealedSecretPlural is the collection plural used with SealedSecret API
 	SealedSecretPlural = "sealedsecrets"
+	// SealedConfigMapPlural is the collection plural used with SealedConfigMap API
+	SealedConfigMapPlural = "sealedconfigmaps"
+	// SealedObjectPlural is the collection plural used with SealedObject API
+	SealedObjectPlural = "sealedobjects"
 
 	// Annotation namespace prefix
 	annoNs = "sealedsecrets." + GroupName + "/"
@@ -21,13 +25,336 @@ const (
 	// SealedSecretNamespaceWideAnnotation is the name for the annotation for
 	// setting the secret to be available namespace wide.
 	SealedSecretNamespaceWideAnnotation = annoNs + "namespace-wide"
+
+	// SealedSecretAllowEmptyDataAnnotation is the name for the annotation
+	// for allowing a SealedSecret without any items, eg. a placeholder.
+	SealedSecretAllowEmptyDataAnnotation = annoNs + "allow-empty-data"
 )
 
 // SealedSecretSpec is the specification of a SealedSecret
 type SealedSecretSpec struct {
 	// Data is deprecated and will be removed eventually. Use per-value EncryptedData instead.
 	Data          []byte            `json:"data,omitempty"`
-	EncryptedData map[string][]byte `json:"encryptedData"`
+	EncryptedData map[string][]byte `json:"encryptedData,omitempty"`
+
+	// ExpireAfter is the lifetime of the unsealed Secret, counted from
+	// the creation of the SealedSecret.
+	// +optional
+	ExpireAfter *metav1.Duration `json:"expireAfter,omitempty"`
+	// NotAfter is the time after which the Secret is no longer
+	// unsealed and is deleted.
+	// +optional
+	NotAfter *metav1.Time `json:"notAfter,omitempty"`
+	// ActivateAt is the time before which the Secret is not unsealed.
+	// +optional
+	ActivateAt *metav1.Time `json:"activateAt,omitempty"`
+	// Algorithm is the algorithm EncryptedData is sealed with,
+	// AlgorithmRSA if empty.
+	// +optional
+	Algorithm string `json:"algorithm,omitempty"`
+	// Cipher is the cipher encrypting the values with RSA-OAEP,
+	// CipherAESGCM if empty. The ciphertexts are tagged with their
+	// cipher, this only selects the cipher used when resealing.
+	// +optional
+	Cipher string `json:"cipher,omitempty"`
+	// OAEPHash is the hash RSA-OAEP encrypts with, OAEPHashSHA256 if
+	// empty. Like Cipher, it is tagged in the ciphertexts and only
+	// selects the hash used when resealing.
+	// +optional
+	OAEPHash string `json:"oaepHash,omitempty"`
+	// Compression is the compression of the values before RSA-OAEP
+	// encryption, none if empty. Like Cipher, it is tagged in the
+	// ciphertexts and only selects the compression used when resealing.
+	// +optional
+	Compression string `json:"compression,omitempty"`
+	// ChunkSize, if not 0, splits the values larger than it in chunks
+	// encrypted separately. Like Cipher, it is tagged in the ciphertexts
+	// and only selects the chunk size used when resealing.
+	// +optional
+	ChunkSize int `json:"chunkSize,omitempty"`
+	// MergeStrategy tells how the data is written to an existing
+	// Secret, MergeStrategyReplace if empty.
+	// +optional
+	MergeStrategy string `json:"mergeStrategy,omitempty"`
+	// MetadataStrategy tells what happens to the labels and annotations
+	// of an existing Secret, MetadataStrategyPreserve if empty.
+	// +optional
+	MetadataStrategy string `json:"metadataStrategy,omitempty"`
+	// DeletionPolicy tells what happens to the Secret when the
+	// SealedSecret is deleted with the cleanup finalizer,
+	// DeletionPolicyDelete if empty.
+	// +optional
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+	// Targets are the other namespaces the Secret is replicated to.
+	// Only cluster-wide SealedSecrets can have targets.
+	// +optional
+	Targets *SealedSecretTargets `json:"targets,omitempty"`
+	// Template describes items of the Secret derived from the
+	// decrypted ones.
+	// +optional
+	Template *SealedSecretTemplate `json:"template,omitempty"`
+	// Generate are the items of the Secret generated by the controller
+	// instead of being sealed. They are generated once and kept as
+	// long as the Secret exists.
+	// +optional
+	Generate map[string]SealedSecretGenerator `json:"generate,omitempty"`
+}
+
+// SealedSecretGenerator describes a random item of a Secret.
+type SealedSecretGenerator struct {
+	// Length is the number of characters, 32 if zero.
+	// +optional
+	Length int `json:"length,omitempty"`
+	// Charset is the characters to choose from, CharsetAlphanumeric if
+	// empty.
+	// +optional
+	Charset string `json:"charset,omitempty"`
+	// RotateEvery is the period after which the item is generated
+	// again. Never if nil.
+	// +optional
+	RotateEvery *metav1.Duration `json:"rotateEvery,omitempty"`
+}
+
+// SealedSecretTemplate describes the items and metadata of a Secret
+// rendered by the controller when unsealing.
+type SealedSecretTemplate struct {
+	// Data are Go templates of items of the Secret, rendered with the
+	// decrypted items, eg. "postgres://{{ .user }}:{{ .password }}@db".
+	// +optional
+	Data map[string]string `json:"data,omitempty"`
+	// PropagateAnnotations are the annotations of the SealedSecret
+	// copied to the Secret, on top of those of the controller, eg.
+	// "reloader.stakater.com/*", a trailing * matching any suffix.
+	// +optional
+	PropagateAnnotations []string `json:"propagateAnnotations,omitempty"`
+	// PropagateLabels are the labels of the SealedSecret copied to the
+	// Secret, eg. "app.kubernetes.io/*", a trailing * matching any
+	// suffix. None are by default.
+	// +optional
+	PropagateLabels []string `json:"propagateLabels,omitempty"`
+}
+
+// SealedSecretTargets selects the namespaces a SealedSecret is
+// replicated to, those listed and those matching the selector.
+type SealedSecretTargets struct {
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+}
+
+// SealingParams names the primitives NewSealedSecretParams seals with,
+// as recorded in SealedSecretSpec, empty for the defaults.
+// +k8s:deepcopy-gen=false
+type SealingParams struct {
+	Cipher      string
+	OAEPHash    string
+	Compression string
+	ChunkSize   int
+}
+
+const (
+	// AlgorithmRSA seals the values with RSA-OAEP and AES-GCM.
+	AlgorithmRSA = "RSA-OAEP"
+	// AlgorithmX25519 seals the values with X25519 and AES-GCM, which
+	// is faster and more compact than RSA.
+	AlgorithmX25519 = "X25519"
+	// AlgorithmRSAMLKEM seals the values with both RSA-OAEP and the
+	// post-quantum ML-KEM-768, so that recording them today doesn't
+	// allow decrypting them once RSA is broken. Experimental.
+	AlgorithmRSAMLKEM = "RSA-OAEP+ML-KEM-768"
+	// AlgorithmRSAExternal seals the values with RSA-OAEP and AES-GCM
+	// for a key held outside of the cluster, such as in the transit
+	// engine of Vault, see crypto.HybridEncryptExternal.
+	AlgorithmRSAExternal = "RSA-OAEP-External"
+)
+
+const (
+	// CipherAESGCM encrypts the values with AES-256-GCM.
+	CipherAESGCM = "AES-256-GCM"
+	// CipherChaCha20Poly1305 encrypts the values with
+	// ChaCha20-Poly1305, for CPUs without AES instructions.
+	CipherChaCha20Poly1305 = "ChaCha20-Poly1305"
+)
+
+const (
+	// OAEPHashSHA256 is the default RSA-OAEP hash.
+	OAEPHashSHA256 = "SHA-256"
+	// OAEPHashSHA1 is the legacy RSA-OAEP hash, for tools and HSMs
+	// that don't support SHA-256.
+	OAEPHashSHA1 = "SHA-1"
+)
+
+const (
+	// CompressionGzip compresses the values with gzip before
+	// encryption, for large values such as kubeconfigs.
+	CompressionGzip = "gzip"
+	// CompressionZstd compresses the values with zstd, faster than
+	// gzip, but only supported by the recent controllers.
+	CompressionZstd = "zstd"
+)
+
+const (
+	// MergeStrategyReplace replaces the data of an existing Secret
+	// with the data of the SealedSecret.
+	MergeStrategyReplace = "Replace"
+	// MergeStrategyMerge only writes the keys of the SealedSecret to an
+	// existing Secret, leaving the keys managed by other tools intact,
+	// for Secrets that are legitimately co-managed.
+	MergeStrategyMerge = "Merge"
+)
+
+const (
+	// MetadataStrategyPreserve leaves the labels and annotations of an
+	// existing Secret as they are, those of the SealedSecret are only
+	// set when the Secret is created.
+	MetadataStrategyPreserve = "Preserve"
+	// MetadataStrategyReplace replaces the labels and annotations of an
+	// existing Secret with those of the SealedSecret.
+	MetadataStrategyReplace = "Replace"
+	// MetadataStrategyPrune sets the labels and annotations of the
+	// SealedSecret on an existing Secret, and removes those it set
+	// before but no longer has, leaving those of other tools intact.
+	MetadataStrategyPrune = "Prune"
+)
+
+const (
+	// DeletionPolicyDelete deletes the Secret, and its replicas, with
+	// the SealedSecret.
+	DeletionPolicyDelete = "Delete"
+	// DeletionPolicyRetain releases the Secret from the SealedSecret,
+	// which no longer owns it, and only deletes its replicas.
+	DeletionPolicyRetain = "Retain"
+)
+
+const (
+	// CharsetAlphanumeric is letters and digits.
+	CharsetAlphanumeric = "alphanumeric"
+	// CharsetNumeric is digits.
+	CharsetNumeric = "numeric"
+	// CharsetHex is lowercase hexadecimal digits.
+	CharsetHex = "hex"
+	// CharsetSymbols is letters, digits and punctuation.
+	CharsetSymbols = "symbols"
+)
+
+// SealedSecretConditionType describes the type of a SealedSecret condition.
+type SealedSecretConditionType string
+
+const (
+	// SealedSecretExpired is true once the SealedSecret has expired and
+	// its Secret has been deleted.
+	SealedSecretExpired SealedSecretConditionType = "Expired"
+	// SealedSecretPending is true while the SealedSecret waits for its
+	// activation time.
+	SealedSecretPending SealedSecretConditionType = "Pending"
+	// SealedSecretDrifted is true when the data of the Secret has been
+	// edited directly and no longer matches the SealedSecret.
+	SealedSecretDrifted SealedSecretConditionType = "Drifted"
+	// SealedSecretSkipped is true when the SealedSecret isn't unsealed
+	// because its namespace hasn't opted in.
+	SealedSecretSkipped SealedSecretConditionType = "Skipped"
+	// SealedSecretQuotaExceeded is true when the SealedSecret isn't
+	// unsealed because its namespace is over quota.
+	SealedSecretQuotaExceeded SealedSecretConditionType = "QuotaExceeded"
+	// SealedSecretTooLarge is true when the SealedSecret or its
+	// Secret exceed the size limits of the controller, and it isn't
+	// unsealed.
+	SealedSecretTooLarge SealedSecretConditionType = "TooLarge"
+	// SealedSecretPolicyDenied is true when the SealedSecret isn't
+	// unsealed because it violates a SealedSecretPolicy.
+	SealedSecretPolicyDenied SealedSecretConditionType = "PolicyDenied"
+	// SealedSecretDryRun is true when the controller runs with
+	// --dry-run, its reason tells what would be done to the Secret.
+	SealedSecretDryRun SealedSecretConditionType = "DryRun"
+	// SealedSecretSealedWithCompromisedKey is true when the
+	// SealedSecret only decrypts with a blacklisted key, and must be
+	// sealed again.
+	SealedSecretSealedWithCompromisedKey SealedSecretConditionType = "SealedWithCompromisedKey"
+	// SealedSecretSynced is true once the Secret has been created or
+	// updated from the SealedSecret. When false, its reason tells why
+	// the last attempt failed.
+	SealedSecretSynced SealedSecretConditionType = "Synced"
+)
+
+// Reasons of the SealedSecret conditions, so that automation can react
+// to specific failures.
+const (
+	// ReasonUnsealed means that the Secret is up to date.
+	ReasonUnsealed = "Unsealed"
+	// ReasonNoMatchingKey means that none of the keys of the
+	// controller can decrypt the SealedSecret: it was sealed for
+	// another controller, with a blacklisted key, or for another
+	// namespace or name.
+	ReasonNoMatchingKey = "NoMatchingKey"
+	// ReasonDecryptFailed means that the encrypted data is malformed,
+	// or uses an unsupported algorithm.
+	ReasonDecryptFailed = "DecryptFailed"
+	// ReasonWrongNamespace means that the SealedSecret was sealed with
+	// another scope than the one of its annotations, eg. for another
+	// namespace.
+	ReasonWrongNamespace = "WrongNamespace"
+	// ReasonCompromisedKey means that the SealedSecret was sealed with
+	// a key blacklisted since, see SealedSecretSealedWithCompromisedKey.
+	ReasonCompromisedKey = "CompromisedKey"
+	// ReasonSecretConflict means that the Secret was modified
+	// concurrently, or already exists.
+	ReasonSecretConflict = "SecretConflict"
+	// ReasonForbidden means that the controller isn't allowed to
+	// write the Secret.
+	ReasonForbidden = "Forbidden"
+	// ReasonNamespaceTerminating means that the namespace of the Secret
+	// is being deleted. It isn't retried until the namespace reappears.
+	ReasonNamespaceTerminating = "NamespaceTerminating"
+	// ReasonQuota means that a quota, of the controller or a
+	// ResourceQuota of the namespace, is exceeded.
+	ReasonQuota = "Quota"
+	// ReasonTemplateFailed means that the template of the
+	// SealedSecret couldn't be rendered.
+	ReasonTemplateFailed = "TemplateFailed"
+	// ReasonUnsealFailed is the reason of the other failures.
+	ReasonUnsealFailed = "UnsealFailed"
+)
+
+// SealedSecretCondition describes the state of a SealedSecret at a
+// certain point.
+type SealedSecretCondition struct {
+	// Type of condition.
+	Type SealedSecretConditionType `json:"type"`
+	// Status of the condition, one of True, False, Unknown.
+	Status apiv1.ConditionStatus `json:"status"`
+	// The last time this condition was updated.
+	// +optional
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+	// Last time the condition transitioned from one status to another.
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// The reason for the condition's last transition.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// A human readable message indicating details about the transition.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// SealedSecretStatus is the most recently observed status of a
+// SealedSecret.
+type SealedSecretStatus struct {
+	// +optional
+	Conditions []SealedSecretCondition `json:"conditions,omitempty"`
+	// KeyFingerprint is the fingerprint of the public key of the
+	// controller key that last decrypted the SealedSecret.
+	// +optional
+	KeyFingerprint string `json:"keyFingerprint,omitempty"`
+	// ObservedGeneration is the generation of the SealedSecret last
+	// processed by the controller, whatever the outcome. The conditions
+	// are stale while it is lower than metadata.generation.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// ReplicaNamespaces are the namespaces the Secret has been
+	// replicated to, see SealedSecretSpec.Targets.
+	// +optional
+	ReplicaNamespaces []string `json:"replicaNamespaces,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -44,6 +371,9 @@ type SealedSecret struct {
 
 	// +optional
 	Type apiv1.SecretType `json:"type,omitempty" protobuf:"bytes,3,opt,name=type,casttype=SecretType"`
+
+	// +optional
+	Status *SealedSecretStatus `json:"status,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -56,6 +386,114 @@ type SealedSecretList struct {
 	Items []SealedSecret `json:"items"`
 }
 
+// SealedConfigMapSpec is the specification of a SealedConfigMap
+type SealedConfigMapSpec struct {
+	// EncryptedData holds the encrypted values of the ConfigMap data.
+	EncryptedData map[string][]byte `json:"encryptedData,omitempty"`
+	// EncryptedBinaryData holds the encrypted values of the ConfigMap
+	// binaryData.
+	EncryptedBinaryData map[string][]byte `json:"encryptedBinaryData,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +genclient
+
+// SealedConfigMap is a ConfigMap that has been sealed using the
+// controller's key, for configuration that is sensitive but not a
+// Secret.
+type SealedConfigMap struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec SealedConfigMapSpec `json:"spec"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SealedConfigMapList represents a list of SealedConfigMaps
+type SealedConfigMapList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []SealedConfigMap `json:"items"`
+}
+
+// SealedObjectSpec is the specification of a SealedObject
+type SealedObjectSpec struct {
+	// EncryptedObject is the encrypted JSON manifest of the object.
+	EncryptedObject []byte `json:"encryptedObject"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +genclient
+
+// SealedObject is an arbitrary namespaced resource that has been sealed
+// using the controller's key, eg. a custom resource embedding
+// credentials. It unseals into an object of the same name and namespace.
+type SealedObject struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec SealedObjectSpec `json:"spec"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SealedObjectList represents a list of SealedObjects
+type SealedObjectList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []SealedObject `json:"items"`
+}
+
+// SealedSecretPolicySpec constrains the Secrets unsealed by the
+// controller. Empty fields don't constrain anything.
+type SealedSecretPolicySpec struct {
+	// AllowedNamespaces are the only namespaces in which Secrets are
+	// unsealed.
+	// +optional
+	AllowedNamespaces []string `json:"allowedNamespaces,omitempty"`
+	// AllowedTypes are the only types of the unsealed Secrets.
+	// +optional
+	AllowedTypes []apiv1.SecretType `json:"allowedTypes,omitempty"`
+	// MaxSize is the maximum size, in bytes, of the data of the
+	// unsealed Secrets.
+	// +optional
+	MaxSize int64 `json:"maxSize,omitempty"`
+	// RequiredLabels are label keys that the unsealed Secrets must have.
+	// +optional
+	RequiredLabels []string `json:"requiredLabels,omitempty"`
+	// DefaultLabels are set on the SealedSecrets missing them by the
+	// defaulting webhook, eg. to give a value to the RequiredLabels.
+	// +optional
+	DefaultLabels map[string]string `json:"defaultLabels,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +genclient
+// +genclient:nonNamespaced
+
+// SealedSecretPolicy is a cluster-wide constraint on the SealedSecrets
+// the controller unseals. A SealedSecret is only unsealed if it
+// satisfies all the policies.
+type SealedSecretPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec SealedSecretPolicySpec `json:"spec"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SealedSecretPolicyList represents a list of SealedSecretPolicies
+type SealedSecretPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []SealedSecretPolicy `json:"items"`
+}
+
 // ByCreationTimestamp is used to sort a list of secrets
 type ByCreationTimestamp []apiv1.Secret
 
@@ -0,0 +1,133 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+)
+
+// Base64Pattern matches the standard base64 encoding of the sealed
+// values.
+const Base64Pattern = `^[A-Za-z0-9+/]*={0,2}$`
+
+// sealedSecretRules are the constraints of the fields of a SealedSecret,
+// by path, on top of their types.
+var sealedSecretRules = map[string]map[string]interface{}{
+	// A sealed value is never empty, even for an empty item, and a
+	// SealedSecret without sealed items omits encryptedData
+	"spec.encryptedData.*":    {"pattern": Base64Pattern, "minLength": 1},
+	"spec.encryptedData":      {"nullable": true, "minProperties": 1},
+	"spec.data":               {"pattern": Base64Pattern},
+	"spec.algorithm":          {"enum": []string{AlgorithmRSA, AlgorithmX25519, AlgorithmRSAMLKEM, AlgorithmRSAExternal}},
+	"spec.cipher":             {"enum": []string{CipherAESGCM, CipherChaCha20Poly1305}},
+	"spec.oaepHash":           {"enum": []string{OAEPHashSHA256, OAEPHashSHA1}},
+	"spec.compression":        {"enum": []string{CompressionGzip, CompressionZstd}},
+	"spec.chunkSize":          {"minimum": crypto.MinChunkSize, "maximum": crypto.MaxChunkSize},
+	"spec.mergeStrategy":      {"enum": []string{MergeStrategyReplace, MergeStrategyMerge}},
+	"spec.metadataStrategy":   {"enum": []string{MetadataStrategyPreserve, MetadataStrategyReplace, MetadataStrategyPrune}},
+	"spec.deletionPolicy":     {"enum": []string{DeletionPolicyDelete, DeletionPolicyRetain}},
+	"spec.generate.*.length":  {"minimum": 0},
+	"spec.generate.*.charset": {"enum": []string{CharsetAlphanumeric, CharsetNumeric, CharsetHex, CharsetSymbols}},
+}
+
+// SealedSecretValidation returns the validation of the SealedSecret
+// CustomResourceDefinition: the OpenAPI v3 schema of SealedSecret, as
+// encoded by encoding/json, with the constraints of sealedSecretRules.
+// The schema of a custom resource can't constrain its metadata, not even
+// with the CEL rules of later apiextensions versions, so the constraints
+// involving the annotations are checked by ValidateMetadata instead.
+func SealedSecretValidation() map[string]interface{} {
+	t := reflect.TypeOf(SealedSecret{})
+	properties := map[string]interface{}{}
+	for _, name := range []string{"Spec", "Type", "Status"} {
+		f, _ := t.FieldByName(name)
+		properties[jsonName(f)] = openAPISchema(f.Type, jsonName(f), sealedSecretRules)
+	}
+	schema := map[string]interface{}{
+		"type":       "object",
+		"required":   []string{"spec"},
+		"properties": properties,
+	}
+	return map[string]interface{}{"openAPIV3Schema": schema}
+}
+
+// ValidateMetadata checks the constraints of s involving its
+// annotations, which the schema of SealedSecretValidation can't express:
+// the scope annotations are "true" or "false" and at most one of them is
+// "true", and s has items unless annotated with
+// SealedSecretAllowEmptyDataAnnotation.
+func (s *SealedSecret) ValidateMetadata() error {
+	annotations := s.GetAnnotations()
+	for _, a := range []string{SealedSecretClusterWideAnnotation, SealedSecretNamespaceWideAnnotation} {
+		if v, ok := annotations[a]; ok && v != "true" && v != "false" {
+			return fmt.Errorf("Annotation %s must be \"true\" or \"false\", got %q", a, v)
+		}
+	}
+	if annotations[SealedSecretClusterWideAnnotation] == "true" && annotations[SealedSecretNamespaceWideAnnotation] == "true" {
+		return fmt.Errorf("Annotations %s and %s are exclusive", SealedSecretClusterWideAnnotation, SealedSecretNamespaceWideAnnotation)
+	}
+	if s.Empty() && annotations[SealedSecretAllowEmptyDataAnnotation] != "true" {
+		return ErrEmptyData
+	}
+	return nil
+}
+
+// jsonName returns the name of f in JSON, empty if it isn't encoded.
+func jsonName(f reflect.StructField) string {
+	name := strings.Split(f.Tag.Get("json"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// openAPISchema returns the schema of the values of t at path, the keys
+// and items of the maps and slices being *, with their rules.
+func openAPISchema(t reflect.Type, path string, rules map[string]map[string]interface{}) map[string]interface{} {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var schema map[string]interface{}
+	switch {
+	case t == reflect.TypeOf(metav1.Time{}):
+		// The zero time is encoded as null
+		schema = map[string]interface{}{"type": "string", "format": "date-time", "nullable": true}
+	case t == reflect.TypeOf(metav1.Duration{}):
+		schema = map[string]interface{}{"type": "string"}
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		schema = map[string]interface{}{"type": "string", "format": "byte"}
+	default:
+		switch t.Kind() {
+		case reflect.Bool:
+			schema = map[string]interface{}{"type": "boolean"}
+		case reflect.Int, reflect.Int32:
+			schema = map[string]interface{}{"type": "integer"}
+		case reflect.Int64:
+			schema = map[string]interface{}{"type": "integer", "format": "int64"}
+		case reflect.String:
+			schema = map[string]interface{}{"type": "string"}
+		case reflect.Slice:
+			schema = map[string]interface{}{"type": "array", "items": openAPISchema(t.Elem(), path+".*", rules)}
+		case reflect.Map:
+			schema = map[string]interface{}{"type": "object", "additionalProperties": openAPISchema(t.Elem(), path+".*", rules)}
+		case reflect.Struct:
+			properties := map[string]interface{}{}
+			for i := 0; i < t.NumField(); i++ {
+				if name := jsonName(t.Field(i)); name != "" {
+					properties[name] = openAPISchema(t.Field(i).Type, path+"."+name, rules)
+				}
+			}
+			schema = map[string]interface{}{"type": "object", "properties": properties}
+		default:
+			schema = map[string]interface{}{}
+		}
+	}
+	for k, v := range rules[path] {
+		schema[k] = v
+	}
+	return schema
+}
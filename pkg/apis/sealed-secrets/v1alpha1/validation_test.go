@@ -0,0 +1,92 @@
+package v1alpha1
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"regexp"
+	"testing"
+)
+
+func TestSealedSecretValidationUpToDate(t *testing.T) {
+	generated, err := json.MarshalIndent(SealedSecretValidation(), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	committed, err := ioutil.ReadFile("../../../../sealedsecret-validation.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(append(generated, '\n'), committed) {
+		t.Errorf("sealedsecret-validation.json is out of date, run go generate")
+	}
+
+	// The committed schema rejects an empty encryptedData
+	var validation struct {
+		OpenAPIV3Schema struct {
+			Properties struct {
+				Spec struct {
+					Properties struct {
+						EncryptedData struct {
+							MinProperties int `json:"minProperties"`
+						} `json:"encryptedData"`
+					} `json:"properties"`
+				} `json:"spec"`
+			} `json:"properties"`
+		} `json:"openAPIV3Schema"`
+	}
+	if err := json.Unmarshal(committed, &validation); err != nil {
+		t.Fatal(err)
+	}
+	if n := validation.OpenAPIV3Schema.Properties.Spec.Properties.EncryptedData.MinProperties; n != 1 {
+		t.Errorf("Expected minProperties 1 for encryptedData, got %d", n)
+	}
+
+	// An empty SealedSecret omits encryptedData rather than failing it
+	encoded, err := json.Marshal(SealedSecretSpec{EncryptedData: map[string][]byte{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(encoded, []byte("encryptedData")) {
+		t.Errorf("Expected empty encryptedData to be omitted, got %s", encoded)
+	}
+}
+
+func TestValidateMetadata(t *testing.T) {
+	item := map[string][]byte{"foo": []byte("sealed")}
+	testCases := []struct {
+		annotations map[string]string
+		data        map[string][]byte
+		valid       bool
+	}{
+		{nil, item, true},
+		{map[string]string{SealedSecretClusterWideAnnotation: "true"}, item, true},
+		{map[string]string{SealedSecretNamespaceWideAnnotation: "true", SealedSecretClusterWideAnnotation: "false"}, item, true},
+		{map[string]string{SealedSecretNamespaceWideAnnotation: "true", SealedSecretClusterWideAnnotation: "true"}, item, false},
+		{map[string]string{SealedSecretClusterWideAnnotation: "yes"}, item, false},
+		{nil, nil, false},
+		{map[string]string{SealedSecretAllowEmptyDataAnnotation: "true"}, nil, true},
+	}
+	for i, tc := range testCases {
+		s := SealedSecret{Spec: SealedSecretSpec{EncryptedData: tc.data}}
+		s.SetAnnotations(tc.annotations)
+		if err := s.ValidateMetadata(); (err == nil) != tc.valid {
+			t.Errorf("%d: expected valid %v, got %v", i, tc.valid, err)
+		}
+	}
+}
+
+func TestBase64Pattern(t *testing.T) {
+	pattern := regexp.MustCompile(Base64Pattern)
+	for _, value := range []string{"", "a", "ab", "abc", "\x00\xff\xfe"} {
+		if encoded := base64.StdEncoding.EncodeToString([]byte(value)); !pattern.MatchString(encoded) {
+			t.Errorf("Expected %q to match", encoded)
+		}
+	}
+	for _, s := range []string{"not base64!", "YWJj\n", "YQ==="} {
+		if pattern.MatchString(s) {
+			t.Errorf("Expected %q not to match", s)
+		}
+	}
+}
@@ -21,15 +21,207 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SealedConfigMap) DeepCopyInto(out *SealedConfigMap) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SealedConfigMap.
+func (in *SealedConfigMap) DeepCopy() *SealedConfigMap {
+	if in == nil {
+		return nil
+	}
+	out := new(SealedConfigMap)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SealedConfigMap) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	} else {
+		return nil
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SealedConfigMapList) DeepCopyInto(out *SealedConfigMapList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SealedConfigMap, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SealedConfigMapList.
+func (in *SealedConfigMapList) DeepCopy() *SealedConfigMapList {
+	if in == nil {
+		return nil
+	}
+	out := new(SealedConfigMapList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SealedConfigMapList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	} else {
+		return nil
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SealedConfigMapSpec) DeepCopyInto(out *SealedConfigMapSpec) {
+	*out = *in
+	if in.EncryptedData != nil {
+		in, out := &in.EncryptedData, &out.EncryptedData
+		*out = make(map[string][]byte, len(*in))
+		for key, val := range *in {
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				(*out)[key] = make([]byte, len(val))
+				copy((*out)[key], val)
+			}
+		}
+	}
+	if in.EncryptedBinaryData != nil {
+		in, out := &in.EncryptedBinaryData, &out.EncryptedBinaryData
+		*out = make(map[string][]byte, len(*in))
+		for key, val := range *in {
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				(*out)[key] = make([]byte, len(val))
+				copy((*out)[key], val)
+			}
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SealedConfigMapSpec.
+func (in *SealedConfigMapSpec) DeepCopy() *SealedConfigMapSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SealedConfigMapSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SealedObject) DeepCopyInto(out *SealedObject) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SealedObject.
+func (in *SealedObject) DeepCopy() *SealedObject {
+	if in == nil {
+		return nil
+	}
+	out := new(SealedObject)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SealedObject) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	} else {
+		return nil
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SealedObjectList) DeepCopyInto(out *SealedObjectList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SealedObject, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SealedObjectList.
+func (in *SealedObjectList) DeepCopy() *SealedObjectList {
+	if in == nil {
+		return nil
+	}
+	out := new(SealedObjectList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SealedObjectList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	} else {
+		return nil
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SealedObjectSpec) DeepCopyInto(out *SealedObjectSpec) {
+	*out = *in
+	if in.EncryptedObject != nil {
+		in, out := &in.EncryptedObject, &out.EncryptedObject
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SealedObjectSpec.
+func (in *SealedObjectSpec) DeepCopy() *SealedObjectSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SealedObjectSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SealedSecret) DeepCopyInto(out *SealedSecret) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(SealedSecretStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -52,6 +244,27 @@ func (in *SealedSecret) DeepCopyObject() runtime.Object {
 	}
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SealedSecretGenerator) DeepCopyInto(out *SealedSecretGenerator) {
+	*out = *in
+	if in.RotateEvery != nil {
+		in, out := &in.RotateEvery, &out.RotateEvery
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SealedSecretGenerator.
+func (in *SealedSecretGenerator) DeepCopy() *SealedSecretGenerator {
+	if in == nil {
+		return nil
+	}
+	out := new(SealedSecretGenerator)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SealedSecretList) DeepCopyInto(out *SealedSecretList) {
 	*out = *in
@@ -86,6 +299,124 @@ func (in *SealedSecretList) DeepCopyObject() runtime.Object {
 	}
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SealedSecretCondition) DeepCopyInto(out *SealedSecretCondition) {
+	*out = *in
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SealedSecretCondition.
+func (in *SealedSecretCondition) DeepCopy() *SealedSecretCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(SealedSecretCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SealedSecretPolicy) DeepCopyInto(out *SealedSecretPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SealedSecretPolicy.
+func (in *SealedSecretPolicy) DeepCopy() *SealedSecretPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(SealedSecretPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SealedSecretPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	} else {
+		return nil
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SealedSecretPolicyList) DeepCopyInto(out *SealedSecretPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SealedSecretPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SealedSecretPolicyList.
+func (in *SealedSecretPolicyList) DeepCopy() *SealedSecretPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(SealedSecretPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SealedSecretPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	} else {
+		return nil
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SealedSecretPolicySpec) DeepCopyInto(out *SealedSecretPolicySpec) {
+	*out = *in
+	if in.AllowedNamespaces != nil {
+		in, out := &in.AllowedNamespaces, &out.AllowedNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedTypes != nil {
+		in, out := &in.AllowedTypes, &out.AllowedTypes
+		*out = make([]corev1.SecretType, len(*in))
+		copy(*out, *in)
+	}
+	if in.RequiredLabels != nil {
+		in, out := &in.RequiredLabels, &out.RequiredLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DefaultLabels != nil {
+		in, out := &in.DefaultLabels, &out.DefaultLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SealedSecretPolicySpec.
+func (in *SealedSecretPolicySpec) DeepCopy() *SealedSecretPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SealedSecretPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SealedSecretSpec) DeepCopyInto(out *SealedSecretSpec) {
 	*out = *in
@@ -106,6 +437,36 @@ func (in *SealedSecretSpec) DeepCopyInto(out *SealedSecretSpec) {
 			}
 		}
 	}
+	if in.ExpireAfter != nil {
+		in, out := &in.ExpireAfter, &out.ExpireAfter
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.NotAfter != nil {
+		in, out := &in.NotAfter, &out.NotAfter
+		*out = (*in).DeepCopy()
+	}
+	if in.ActivateAt != nil {
+		in, out := &in.ActivateAt, &out.ActivateAt
+		*out = (*in).DeepCopy()
+	}
+	if in.Targets != nil {
+		in, out := &in.Targets, &out.Targets
+		*out = new(SealedSecretTargets)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Template != nil {
+		in, out := &in.Template, &out.Template
+		*out = new(SealedSecretTemplate)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Generate != nil {
+		in, out := &in.Generate, &out.Generate
+		*out = make(map[string]SealedSecretGenerator, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 	return
 }
 
@@ -118,3 +479,90 @@ func (in *SealedSecretSpec) DeepCopy() *SealedSecretSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SealedSecretStatus) DeepCopyInto(out *SealedSecretStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]SealedSecretCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ReplicaNamespaces != nil {
+		in, out := &in.ReplicaNamespaces, &out.ReplicaNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SealedSecretStatus.
+func (in *SealedSecretStatus) DeepCopy() *SealedSecretStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SealedSecretStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SealedSecretTargets) DeepCopyInto(out *SealedSecretTargets) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SealedSecretTargets.
+func (in *SealedSecretTargets) DeepCopy() *SealedSecretTargets {
+	if in == nil {
+		return nil
+	}
+	out := new(SealedSecretTargets)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SealedSecretTemplate) DeepCopyInto(out *SealedSecretTemplate) {
+	*out = *in
+	if in.Data != nil {
+		in, out := &in.Data, &out.Data
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PropagateAnnotations != nil {
+		in, out := &in.PropagateAnnotations, &out.PropagateAnnotations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PropagateLabels != nil {
+		in, out := &in.PropagateLabels, &out.PropagateLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SealedSecretTemplate.
+func (in *SealedSecretTemplate) DeepCopy() *SealedSecretTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(SealedSecretTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -26,10 +26,22 @@ type FakeBitnamiV1alpha1 struct {
 	*testing.Fake
 }
 
+func (c *FakeBitnamiV1alpha1) SealedConfigMaps(namespace string) v1alpha1.SealedConfigMapInterface {
+	return &FakeSealedConfigMaps{c, namespace}
+}
+
+func (c *FakeBitnamiV1alpha1) SealedObjects(namespace string) v1alpha1.SealedObjectInterface {
+	return &FakeSealedObjects{c, namespace}
+}
+
 func (c *FakeBitnamiV1alpha1) SealedSecrets(namespace string) v1alpha1.SealedSecretInterface {
 	return &FakeSealedSecrets{c, namespace}
 }
 
+func (c *FakeBitnamiV1alpha1) SealedSecretPolicies() v1alpha1.SealedSecretPolicyInterface {
+	return &FakeSealedSecretPolicies{c}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeBitnamiV1alpha1) RESTClient() rest.Interface {
@@ -0,0 +1,126 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	v1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeSealedConfigMaps implements SealedConfigMapInterface
+type FakeSealedConfigMaps struct {
+	Fake *FakeBitnamiV1alpha1
+	ns   string
+}
+
+var sealedconfigmapsResource = schema.GroupVersionResource{Group: "bitnami.com", Version: "v1alpha1", Resource: "sealedconfigmaps"}
+
+var sealedconfigmapsKind = schema.GroupVersionKind{Group: "bitnami.com", Version: "v1alpha1", Kind: "SealedConfigMap"}
+
+// Get takes name of the sealedConfigMap, and returns the corresponding sealedConfigMap object, and an error if there is any.
+func (c *FakeSealedConfigMaps) Get(name string, options v1.GetOptions) (result *v1alpha1.SealedConfigMap, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(sealedconfigmapsResource, c.ns, name), &v1alpha1.SealedConfigMap{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SealedConfigMap), err
+}
+
+// List takes label and field selectors, and returns the list of SealedConfigMaps that match those selectors.
+func (c *FakeSealedConfigMaps) List(opts v1.ListOptions) (result *v1alpha1.SealedConfigMapList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(sealedconfigmapsResource, sealedconfigmapsKind, c.ns, opts), &v1alpha1.SealedConfigMapList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1alpha1.SealedConfigMapList{}
+	for _, item := range obj.(*v1alpha1.SealedConfigMapList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested sealedConfigMaps.
+func (c *FakeSealedConfigMaps) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(sealedconfigmapsResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a sealedConfigMap and creates it.  Returns the server's representation of the sealedConfigMap, and an error, if there is any.
+func (c *FakeSealedConfigMaps) Create(sealedConfigMap *v1alpha1.SealedConfigMap) (result *v1alpha1.SealedConfigMap, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(sealedconfigmapsResource, c.ns, sealedConfigMap), &v1alpha1.SealedConfigMap{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SealedConfigMap), err
+}
+
+// Update takes the representation of a sealedConfigMap and updates it. Returns the server's representation of the sealedConfigMap, and an error, if there is any.
+func (c *FakeSealedConfigMaps) Update(sealedConfigMap *v1alpha1.SealedConfigMap) (result *v1alpha1.SealedConfigMap, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(sealedconfigmapsResource, c.ns, sealedConfigMap), &v1alpha1.SealedConfigMap{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SealedConfigMap), err
+}
+
+// Delete takes name of the sealedConfigMap and deletes it. Returns an error if one occurs.
+func (c *FakeSealedConfigMaps) Delete(name string, options *v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteAction(sealedconfigmapsResource, c.ns, name), &v1alpha1.SealedConfigMap{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeSealedConfigMaps) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(sealedconfigmapsResource, c.ns, listOptions)
+
+	_, err := c.Fake.Invokes(action, &v1alpha1.SealedConfigMapList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched sealedConfigMap.
+func (c *FakeSealedConfigMaps) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.SealedConfigMap, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(sealedconfigmapsResource, c.ns, name, data, subresources...), &v1alpha1.SealedConfigMap{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SealedConfigMap), err
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	v1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeSealedObjects implements SealedObjectInterface
+type FakeSealedObjects struct {
+	Fake *FakeBitnamiV1alpha1
+	ns   string
+}
+
+var sealedobjectsResource = schema.GroupVersionResource{Group: "bitnami.com", Version: "v1alpha1", Resource: "sealedobjects"}
+
+var sealedobjectsKind = schema.GroupVersionKind{Group: "bitnami.com", Version: "v1alpha1", Kind: "SealedObject"}
+
+// Get takes name of the sealedObject, and returns the corresponding sealedObject object, and an error if there is any.
+func (c *FakeSealedObjects) Get(name string, options v1.GetOptions) (result *v1alpha1.SealedObject, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(sealedobjectsResource, c.ns, name), &v1alpha1.SealedObject{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SealedObject), err
+}
+
+// List takes label and field selectors, and returns the list of SealedObjects that match those selectors.
+func (c *FakeSealedObjects) List(opts v1.ListOptions) (result *v1alpha1.SealedObjectList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(sealedobjectsResource, sealedobjectsKind, c.ns, opts), &v1alpha1.SealedObjectList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1alpha1.SealedObjectList{}
+	for _, item := range obj.(*v1alpha1.SealedObjectList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested sealedObjects.
+func (c *FakeSealedObjects) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(sealedobjectsResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a sealedObject and creates it.  Returns the server's representation of the sealedObject, and an error, if there is any.
+func (c *FakeSealedObjects) Create(sealedObject *v1alpha1.SealedObject) (result *v1alpha1.SealedObject, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(sealedobjectsResource, c.ns, sealedObject), &v1alpha1.SealedObject{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SealedObject), err
+}
+
+// Update takes the representation of a sealedObject and updates it. Returns the server's representation of the sealedObject, and an error, if there is any.
+func (c *FakeSealedObjects) Update(sealedObject *v1alpha1.SealedObject) (result *v1alpha1.SealedObject, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(sealedobjectsResource, c.ns, sealedObject), &v1alpha1.SealedObject{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SealedObject), err
+}
+
+// Delete takes name of the sealedObject and deletes it. Returns an error if one occurs.
+func (c *FakeSealedObjects) Delete(name string, options *v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteAction(sealedobjectsResource, c.ns, name), &v1alpha1.SealedObject{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeSealedObjects) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(sealedobjectsResource, c.ns, listOptions)
+
+	_, err := c.Fake.Invokes(action, &v1alpha1.SealedObjectList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched sealedObject.
+func (c *FakeSealedObjects) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.SealedObject, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(sealedobjectsResource, c.ns, name, data, subresources...), &v1alpha1.SealedObject{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SealedObject), err
+}
@@ -98,6 +98,18 @@ func (c *FakeSealedSecrets) Update(sealedSecret *v1alpha1.SealedSecret) (result
 	return obj.(*v1alpha1.SealedSecret), err
 }
 
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeSealedSecrets) UpdateStatus(sealedSecret *v1alpha1.SealedSecret) (*v1alpha1.SealedSecret, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(sealedsecretsResource, "status", c.ns, sealedSecret), &v1alpha1.SealedSecret{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SealedSecret), err
+}
+
 // Delete takes name of the sealedSecret and deletes it. Returns an error if one occurs.
 func (c *FakeSealedSecrets) Delete(name string, options *v1.DeleteOptions) error {
 	_, err := c.Fake.
@@ -0,0 +1,125 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	v1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeSealedSecretPolicies implements SealedSecretPolicyInterface
+type FakeSealedSecretPolicies struct {
+	Fake *FakeBitnamiV1alpha1
+}
+
+var sealedsecretpoliciesResource = schema.GroupVersionResource{Group: "bitnami.com", Version: "v1alpha1", Resource: "sealedsecretpolicies"}
+
+var sealedsecretpoliciesKind = schema.GroupVersionKind{Group: "bitnami.com", Version: "v1alpha1", Kind: "SealedSecretPolicy"}
+
+// Get takes name of the sealedSecretPolicy, and returns the corresponding sealedSecretPolicy object, and an error if there is any.
+func (c *FakeSealedSecretPolicies) Get(name string, options v1.GetOptions) (result *v1alpha1.SealedSecretPolicy, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootGetAction(sealedsecretpoliciesResource, name), &v1alpha1.SealedSecretPolicy{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SealedSecretPolicy), err
+}
+
+// List takes label and field selectors, and returns the list of SealedSecretPolicies that match those selectors.
+func (c *FakeSealedSecretPolicies) List(opts v1.ListOptions) (result *v1alpha1.SealedSecretPolicyList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootListAction(sealedsecretpoliciesResource, sealedsecretpoliciesKind, opts), &v1alpha1.SealedSecretPolicyList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1alpha1.SealedSecretPolicyList{}
+	for _, item := range obj.(*v1alpha1.SealedSecretPolicyList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested sealedSecretPolicies.
+func (c *FakeSealedSecretPolicies) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewRootWatchAction(sealedsecretpoliciesResource, opts))
+
+}
+
+// Create takes the representation of a sealedSecretPolicy and creates it.  Returns the server's representation of the sealedSecretPolicy, and an error, if there is any.
+func (c *FakeSealedSecretPolicies) Create(sealedSecretPolicy *v1alpha1.SealedSecretPolicy) (result *v1alpha1.SealedSecretPolicy, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootCreateAction(sealedsecretpoliciesResource, sealedSecretPolicy), &v1alpha1.SealedSecretPolicy{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SealedSecretPolicy), err
+}
+
+// Update takes the representation of a sealedSecretPolicy and updates it. Returns the server's representation of the sealedSecretPolicy, and an error, if there is any.
+func (c *FakeSealedSecretPolicies) Update(sealedSecretPolicy *v1alpha1.SealedSecretPolicy) (result *v1alpha1.SealedSecretPolicy, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateAction(sealedsecretpoliciesResource, sealedSecretPolicy), &v1alpha1.SealedSecretPolicy{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SealedSecretPolicy), err
+}
+
+// Delete takes name of the sealedSecretPolicy and deletes it. Returns an error if one occurs.
+func (c *FakeSealedSecretPolicies) Delete(name string, options *v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewRootDeleteAction(sealedsecretpoliciesResource, name), &v1alpha1.SealedSecretPolicy{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeSealedSecretPolicies) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	action := testing.NewRootDeleteCollectionAction(sealedsecretpoliciesResource, listOptions)
+
+	_, err := c.Fake.Invokes(action, &v1alpha1.SealedSecretPolicyList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched sealedSecretPolicy.
+func (c *FakeSealedSecretPolicies) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.SealedSecretPolicy, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootPatchSubresourceAction(sealedsecretpoliciesResource, name, data, subresources...), &v1alpha1.SealedSecretPolicy{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SealedSecretPolicy), err
+}
@@ -16,4 +16,10 @@ limitations under the License.
 
 package v1alpha1
 
+type SealedConfigMapExpansion interface{}
+
+type SealedObjectExpansion interface{}
+
 type SealedSecretExpansion interface{}
+
+type SealedSecretPolicyExpansion interface{}
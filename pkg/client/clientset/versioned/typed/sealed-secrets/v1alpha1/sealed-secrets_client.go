@@ -25,7 +25,10 @@ import (
 
 type BitnamiV1alpha1Interface interface {
 	RESTClient() rest.Interface
+	SealedConfigMapsGetter
+	SealedObjectsGetter
 	SealedSecretsGetter
+	SealedSecretPoliciesGetter
 }
 
 // BitnamiV1alpha1Client is used to interact with features provided by the bitnami.com group.
@@ -33,10 +36,22 @@ type BitnamiV1alpha1Client struct {
 	restClient rest.Interface
 }
 
+func (c *BitnamiV1alpha1Client) SealedConfigMaps(namespace string) SealedConfigMapInterface {
+	return newSealedConfigMaps(c, namespace)
+}
+
+func (c *BitnamiV1alpha1Client) SealedObjects(namespace string) SealedObjectInterface {
+	return newSealedObjects(c, namespace)
+}
+
 func (c *BitnamiV1alpha1Client) SealedSecrets(namespace string) SealedSecretInterface {
 	return newSealedSecrets(c, namespace)
 }
 
+func (c *BitnamiV1alpha1Client) SealedSecretPolicies() SealedSecretPolicyInterface {
+	return newSealedSecretPolicies(c)
+}
+
 // NewForConfig creates a new BitnamiV1alpha1Client for the given config.
 func NewForConfig(c *rest.Config) (*BitnamiV1alpha1Client, error) {
 	config := *c
@@ -0,0 +1,155 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	v1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	scheme "github.com/bitnami-labs/sealed-secrets/pkg/client/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// SealedConfigMapsGetter has a method to return a SealedConfigMapInterface.
+// A group's client should implement this interface.
+type SealedConfigMapsGetter interface {
+	SealedConfigMaps(namespace string) SealedConfigMapInterface
+}
+
+// SealedConfigMapInterface has methods to work with SealedConfigMap resources.
+type SealedConfigMapInterface interface {
+	Create(*v1alpha1.SealedConfigMap) (*v1alpha1.SealedConfigMap, error)
+	Update(*v1alpha1.SealedConfigMap) (*v1alpha1.SealedConfigMap, error)
+	Delete(name string, options *v1.DeleteOptions) error
+	DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error
+	Get(name string, options v1.GetOptions) (*v1alpha1.SealedConfigMap, error)
+	List(opts v1.ListOptions) (*v1alpha1.SealedConfigMapList, error)
+	Watch(opts v1.ListOptions) (watch.Interface, error)
+	Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.SealedConfigMap, err error)
+	SealedConfigMapExpansion
+}
+
+// sealedConfigMaps implements SealedConfigMapInterface
+type sealedConfigMaps struct {
+	client rest.Interface
+	ns     string
+}
+
+// newSealedConfigMaps returns a SealedConfigMaps
+func newSealedConfigMaps(c *BitnamiV1alpha1Client, namespace string) *sealedConfigMaps {
+	return &sealedConfigMaps{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the sealedConfigMap, and returns the corresponding sealedConfigMap object, and an error if there is any.
+func (c *sealedConfigMaps) Get(name string, options v1.GetOptions) (result *v1alpha1.SealedConfigMap, err error) {
+	result = &v1alpha1.SealedConfigMap{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("sealedconfigmaps").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of SealedConfigMaps that match those selectors.
+func (c *sealedConfigMaps) List(opts v1.ListOptions) (result *v1alpha1.SealedConfigMapList, err error) {
+	result = &v1alpha1.SealedConfigMapList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("sealedconfigmaps").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested sealedConfigMaps.
+func (c *sealedConfigMaps) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("sealedconfigmaps").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Watch()
+}
+
+// Create takes the representation of a sealedConfigMap and creates it.  Returns the server's representation of the sealedConfigMap, and an error, if there is any.
+func (c *sealedConfigMaps) Create(sealedConfigMap *v1alpha1.SealedConfigMap) (result *v1alpha1.SealedConfigMap, err error) {
+	result = &v1alpha1.SealedConfigMap{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("sealedconfigmaps").
+		Body(sealedConfigMap).
+		Do().
+		Into(result)
+	return
+}
+
+// Update takes the representation of a sealedConfigMap and updates it. Returns the server's representation of the sealedConfigMap, and an error, if there is any.
+func (c *sealedConfigMaps) Update(sealedConfigMap *v1alpha1.SealedConfigMap) (result *v1alpha1.SealedConfigMap, err error) {
+	result = &v1alpha1.SealedConfigMap{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("sealedconfigmaps").
+		Name(sealedConfigMap.Name).
+		Body(sealedConfigMap).
+		Do().
+		Into(result)
+	return
+}
+
+// Delete takes name of the sealedConfigMap and deletes it. Returns an error if one occurs.
+func (c *sealedConfigMaps) Delete(name string, options *v1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("sealedconfigmaps").
+		Name(name).
+		Body(options).
+		Do().
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *sealedConfigMaps) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("sealedconfigmaps").
+		VersionedParams(&listOptions, scheme.ParameterCodec).
+		Body(options).
+		Do().
+		Error()
+}
+
+// Patch applies the patch and returns the patched sealedConfigMap.
+func (c *sealedConfigMaps) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.SealedConfigMap, err error) {
+	result = &v1alpha1.SealedConfigMap{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("sealedconfigmaps").
+		SubResource(subresources...).
+		Name(name).
+		Body(data).
+		Do().
+		Into(result)
+	return
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	v1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	scheme "github.com/bitnami-labs/sealed-secrets/pkg/client/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// SealedObjectsGetter has a method to return a SealedObjectInterface.
+// A group's client should implement this interface.
+type SealedObjectsGetter interface {
+	SealedObjects(namespace string) SealedObjectInterface
+}
+
+// SealedObjectInterface has methods to work with SealedObject resources.
+type SealedObjectInterface interface {
+	Create(*v1alpha1.SealedObject) (*v1alpha1.SealedObject, error)
+	Update(*v1alpha1.SealedObject) (*v1alpha1.SealedObject, error)
+	Delete(name string, options *v1.DeleteOptions) error
+	DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error
+	Get(name string, options v1.GetOptions) (*v1alpha1.SealedObject, error)
+	List(opts v1.ListOptions) (*v1alpha1.SealedObjectList, error)
+	Watch(opts v1.ListOptions) (watch.Interface, error)
+	Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.SealedObject, err error)
+	SealedObjectExpansion
+}
+
+// sealedObjects implements SealedObjectInterface
+type sealedObjects struct {
+	client rest.Interface
+	ns     string
+}
+
+// newSealedObjects returns a SealedObjects
+func newSealedObjects(c *BitnamiV1alpha1Client, namespace string) *sealedObjects {
+	return &sealedObjects{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the sealedObject, and returns the corresponding sealedObject object, and an error if there is any.
+func (c *sealedObjects) Get(name string, options v1.GetOptions) (result *v1alpha1.SealedObject, err error) {
+	result = &v1alpha1.SealedObject{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("sealedobjects").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of SealedObjects that match those selectors.
+func (c *sealedObjects) List(opts v1.ListOptions) (result *v1alpha1.SealedObjectList, err error) {
+	result = &v1alpha1.SealedObjectList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("sealedobjects").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested sealedObjects.
+func (c *sealedObjects) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("sealedobjects").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Watch()
+}
+
+// Create takes the representation of a sealedObject and creates it.  Returns the server's representation of the sealedObject, and an error, if there is any.
+func (c *sealedObjects) Create(sealedObject *v1alpha1.SealedObject) (result *v1alpha1.SealedObject, err error) {
+	result = &v1alpha1.SealedObject{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("sealedobjects").
+		Body(sealedObject).
+		Do().
+		Into(result)
+	return
+}
+
+// Update takes the representation of a sealedObject and updates it. Returns the server's representation of the sealedObject, and an error, if there is any.
+func (c *sealedObjects) Update(sealedObject *v1alpha1.SealedObject) (result *v1alpha1.SealedObject, err error) {
+	result = &v1alpha1.SealedObject{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("sealedobjects").
+		Name(sealedObject.Name).
+		Body(sealedObject).
+		Do().
+		Into(result)
+	return
+}
+
+// Delete takes name of the sealedObject and deletes it. Returns an error if one occurs.
+func (c *sealedObjects) Delete(name string, options *v1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("sealedobjects").
+		Name(name).
+		Body(options).
+		Do().
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *sealedObjects) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("sealedobjects").
+		VersionedParams(&listOptions, scheme.ParameterCodec).
+		Body(options).
+		Do().
+		Error()
+}
+
+// Patch applies the patch and returns the patched sealedObject.
+func (c *sealedObjects) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.SealedObject, err error) {
+	result = &v1alpha1.SealedObject{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("sealedobjects").
+		SubResource(subresources...).
+		Name(name).
+		Body(data).
+		Do().
+		Into(result)
+	return
+}
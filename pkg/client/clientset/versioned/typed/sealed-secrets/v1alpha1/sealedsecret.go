@@ -35,6 +35,7 @@ type SealedSecretsGetter interface {
 type SealedSecretInterface interface {
 	Create(*v1alpha1.SealedSecret) (*v1alpha1.SealedSecret, error)
 	Update(*v1alpha1.SealedSecret) (*v1alpha1.SealedSecret, error)
+	UpdateStatus(*v1alpha1.SealedSecret) (*v1alpha1.SealedSecret, error)
 	Delete(name string, options *v1.DeleteOptions) error
 	DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error
 	Get(name string, options v1.GetOptions) (*v1alpha1.SealedSecret, error)
@@ -118,6 +119,22 @@ func (c *sealedSecrets) Update(sealedSecret *v1alpha1.SealedSecret) (result *v1a
 	return
 }
 
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+
+func (c *sealedSecrets) UpdateStatus(sealedSecret *v1alpha1.SealedSecret) (result *v1alpha1.SealedSecret, err error) {
+	result = &v1alpha1.SealedSecret{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("sealedsecrets").
+		Name(sealedSecret.Name).
+		SubResource("status").
+		Body(sealedSecret).
+		Do().
+		Into(result)
+	return
+}
+
 // Delete takes name of the sealedSecret and deletes it. Returns an error if one occurs.
 func (c *sealedSecrets) Delete(name string, options *v1.DeleteOptions) error {
 	return c.client.Delete().
@@ -0,0 +1,145 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	v1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	scheme "github.com/bitnami-labs/sealed-secrets/pkg/client/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// SealedSecretPoliciesGetter has a method to return a SealedSecretPolicyInterface.
+// A group's client should implement this interface.
+type SealedSecretPoliciesGetter interface {
+	SealedSecretPolicies() SealedSecretPolicyInterface
+}
+
+// SealedSecretPolicyInterface has methods to work with SealedSecretPolicy resources.
+type SealedSecretPolicyInterface interface {
+	Create(*v1alpha1.SealedSecretPolicy) (*v1alpha1.SealedSecretPolicy, error)
+	Update(*v1alpha1.SealedSecretPolicy) (*v1alpha1.SealedSecretPolicy, error)
+	Delete(name string, options *v1.DeleteOptions) error
+	DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error
+	Get(name string, options v1.GetOptions) (*v1alpha1.SealedSecretPolicy, error)
+	List(opts v1.ListOptions) (*v1alpha1.SealedSecretPolicyList, error)
+	Watch(opts v1.ListOptions) (watch.Interface, error)
+	Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.SealedSecretPolicy, err error)
+	SealedSecretPolicyExpansion
+}
+
+// sealedSecretPolicies implements SealedSecretPolicyInterface
+type sealedSecretPolicies struct {
+	client rest.Interface
+}
+
+// newSealedSecretPolicies returns a SealedSecretPolicies
+func newSealedSecretPolicies(c *BitnamiV1alpha1Client) *sealedSecretPolicies {
+	return &sealedSecretPolicies{
+		client: c.RESTClient(),
+	}
+}
+
+// Get takes name of the sealedSecretPolicy, and returns the corresponding sealedSecretPolicy object, and an error if there is any.
+func (c *sealedSecretPolicies) Get(name string, options v1.GetOptions) (result *v1alpha1.SealedSecretPolicy, err error) {
+	result = &v1alpha1.SealedSecretPolicy{}
+	err = c.client.Get().
+		Resource("sealedsecretpolicies").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of SealedSecretPolicies that match those selectors.
+func (c *sealedSecretPolicies) List(opts v1.ListOptions) (result *v1alpha1.SealedSecretPolicyList, err error) {
+	result = &v1alpha1.SealedSecretPolicyList{}
+	err = c.client.Get().
+		Resource("sealedsecretpolicies").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested sealedSecretPolicies.
+func (c *sealedSecretPolicies) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	opts.Watch = true
+	return c.client.Get().
+		Resource("sealedsecretpolicies").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Watch()
+}
+
+// Create takes the representation of a sealedSecretPolicy and creates it.  Returns the server's representation of the sealedSecretPolicy, and an error, if there is any.
+func (c *sealedSecretPolicies) Create(sealedSecretPolicy *v1alpha1.SealedSecretPolicy) (result *v1alpha1.SealedSecretPolicy, err error) {
+	result = &v1alpha1.SealedSecretPolicy{}
+	err = c.client.Post().
+		Resource("sealedsecretpolicies").
+		Body(sealedSecretPolicy).
+		Do().
+		Into(result)
+	return
+}
+
+// Update takes the representation of a sealedSecretPolicy and updates it. Returns the server's representation of the sealedSecretPolicy, and an error, if there is any.
+func (c *sealedSecretPolicies) Update(sealedSecretPolicy *v1alpha1.SealedSecretPolicy) (result *v1alpha1.SealedSecretPolicy, err error) {
+	result = &v1alpha1.SealedSecretPolicy{}
+	err = c.client.Put().
+		Resource("sealedsecretpolicies").
+		Name(sealedSecretPolicy.Name).
+		Body(sealedSecretPolicy).
+		Do().
+		Into(result)
+	return
+}
+
+// Delete takes name of the sealedSecretPolicy and deletes it. Returns an error if one occurs.
+func (c *sealedSecretPolicies) Delete(name string, options *v1.DeleteOptions) error {
+	return c.client.Delete().
+		Resource("sealedsecretpolicies").
+		Name(name).
+		Body(options).
+		Do().
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *sealedSecretPolicies) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	return c.client.Delete().
+		Resource("sealedsecretpolicies").
+		VersionedParams(&listOptions, scheme.ParameterCodec).
+		Body(options).
+		Do().
+		Error()
+}
+
+// Patch applies the patch and returns the patched sealedSecretPolicy.
+func (c *sealedSecretPolicies) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.SealedSecretPolicy, err error) {
+	result = &v1alpha1.SealedSecretPolicy{}
+	err = c.client.Patch(pt).
+		Resource("sealedsecretpolicies").
+		SubResource(subresources...).
+		Name(name).
+		Body(data).
+		Do().
+		Into(result)
+	return
+}
@@ -53,8 +53,14 @@ func (f *genericInformer) Lister() cache.GenericLister {
 func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource) (GenericInformer, error) {
 	switch resource {
 	// Group=bitnami.com, Version=v1alpha1
+	case v1alpha1.SchemeGroupVersion.WithResource("sealedconfigmaps"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Bitnami().V1alpha1().SealedConfigMaps().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("sealedobjects"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Bitnami().V1alpha1().SealedObjects().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("sealedsecrets"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Bitnami().V1alpha1().SealedSecrets().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("sealedsecretpolicies"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Bitnami().V1alpha1().SealedSecretPolicies().Informer()}, nil
 
 	}
 
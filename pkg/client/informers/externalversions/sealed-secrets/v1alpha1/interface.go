@@ -24,8 +24,14 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// SealedConfigMaps returns a SealedConfigMapInformer.
+	SealedConfigMaps() SealedConfigMapInformer
+	// SealedObjects returns a SealedObjectInformer.
+	SealedObjects() SealedObjectInformer
 	// SealedSecrets returns a SealedSecretInformer.
 	SealedSecrets() SealedSecretInformer
+	// SealedSecretPolicies returns a SealedSecretPolicyInformer.
+	SealedSecretPolicies() SealedSecretPolicyInformer
 }
 
 type version struct {
@@ -39,7 +45,22 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// SealedConfigMaps returns a SealedConfigMapInformer.
+func (v *version) SealedConfigMaps() SealedConfigMapInformer {
+	return &sealedConfigMapInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// SealedObjects returns a SealedObjectInformer.
+func (v *version) SealedObjects() SealedObjectInformer {
+	return &sealedObjectInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // SealedSecrets returns a SealedSecretInformer.
 func (v *version) SealedSecrets() SealedSecretInformer {
 	return &sealedSecretInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// SealedSecretPolicies returns a SealedSecretPolicyInformer.
+func (v *version) SealedSecretPolicies() SealedSecretPolicyInformer {
+	return &sealedSecretPolicyInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file was automatically generated by informer-gen
+
+package v1alpha1
+
+import (
+	time "time"
+
+	sealed_secrets_v1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	versioned "github.com/bitnami-labs/sealed-secrets/pkg/client/clientset/versioned"
+	internalinterfaces "github.com/bitnami-labs/sealed-secrets/pkg/client/informers/externalversions/internalinterfaces"
+	v1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/client/listers/sealed-secrets/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// SealedConfigMapInformer provides access to a shared informer and lister for
+// SealedConfigMaps.
+type SealedConfigMapInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1alpha1.SealedConfigMapLister
+}
+
+type sealedConfigMapInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+	namespace        string
+}
+
+// NewSealedConfigMapInformer constructs a new informer for SealedConfigMap type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewSealedConfigMapInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredSealedConfigMapInformer(client, namespace, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredSealedConfigMapInformer constructs a new informer for SealedConfigMap type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredSealedConfigMapInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.BitnamiV1alpha1().SealedConfigMaps(namespace).List(options)
+			},
+			WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.BitnamiV1alpha1().SealedConfigMaps(namespace).Watch(options)
+			},
+		},
+		&sealed_secrets_v1alpha1.SealedConfigMap{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *sealedConfigMapInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredSealedConfigMapInformer(client, f.namespace, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *sealedConfigMapInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&sealed_secrets_v1alpha1.SealedConfigMap{}, f.defaultInformer)
+}
+
+func (f *sealedConfigMapInformer) Lister() v1alpha1.SealedConfigMapLister {
+	return v1alpha1.NewSealedConfigMapLister(f.Informer().GetIndexer())
+}
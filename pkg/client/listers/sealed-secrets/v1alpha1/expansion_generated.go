@@ -18,6 +18,22 @@ limitations under the License.
 
 package v1alpha1
 
+// SealedConfigMapListerExpansion allows custom methods to be added to
+// SealedConfigMapLister.
+type SealedConfigMapListerExpansion interface{}
+
+// SealedConfigMapNamespaceListerExpansion allows custom methods to be added to
+// SealedConfigMapNamespaceLister.
+type SealedConfigMapNamespaceListerExpansion interface{}
+
+// SealedObjectListerExpansion allows custom methods to be added to
+// SealedObjectLister.
+type SealedObjectListerExpansion interface{}
+
+// SealedObjectNamespaceListerExpansion allows custom methods to be added to
+// SealedObjectNamespaceLister.
+type SealedObjectNamespaceListerExpansion interface{}
+
 // SealedSecretListerExpansion allows custom methods to be added to
 // SealedSecretLister.
 type SealedSecretListerExpansion interface{}
@@ -25,3 +41,7 @@ type SealedSecretListerExpansion interface{}
 // SealedSecretNamespaceListerExpansion allows custom methods to be added to
 // SealedSecretNamespaceLister.
 type SealedSecretNamespaceListerExpansion interface{}
+
+// SealedSecretPolicyListerExpansion allows custom methods to be added to
+// SealedSecretPolicyLister.
+type SealedSecretPolicyListerExpansion interface{}
@@ -0,0 +1,94 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file was automatically generated by lister-gen
+
+package v1alpha1
+
+import (
+	v1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// SealedConfigMapLister helps list SealedConfigMaps.
+type SealedConfigMapLister interface {
+	// List lists all SealedConfigMaps in the indexer.
+	List(selector labels.Selector) (ret []*v1alpha1.SealedConfigMap, err error)
+	// SealedConfigMaps returns an object that can list and get SealedConfigMaps.
+	SealedConfigMaps(namespace string) SealedConfigMapNamespaceLister
+	SealedConfigMapListerExpansion
+}
+
+// sealedConfigMapLister implements the SealedConfigMapLister interface.
+type sealedConfigMapLister struct {
+	indexer cache.Indexer
+}
+
+// NewSealedConfigMapLister returns a new SealedConfigMapLister.
+func NewSealedConfigMapLister(indexer cache.Indexer) SealedConfigMapLister {
+	return &sealedConfigMapLister{indexer: indexer}
+}
+
+// List lists all SealedConfigMaps in the indexer.
+func (s *sealedConfigMapLister) List(selector labels.Selector) (ret []*v1alpha1.SealedConfigMap, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.SealedConfigMap))
+	})
+	return ret, err
+}
+
+// SealedConfigMaps returns an object that can list and get SealedConfigMaps.
+func (s *sealedConfigMapLister) SealedConfigMaps(namespace string) SealedConfigMapNamespaceLister {
+	return sealedConfigMapNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// SealedConfigMapNamespaceLister helps list and get SealedConfigMaps.
+type SealedConfigMapNamespaceLister interface {
+	// List lists all SealedConfigMaps in the indexer for a given namespace.
+	List(selector labels.Selector) (ret []*v1alpha1.SealedConfigMap, err error)
+	// Get retrieves the SealedConfigMap from the indexer for a given namespace and name.
+	Get(name string) (*v1alpha1.SealedConfigMap, error)
+	SealedConfigMapNamespaceListerExpansion
+}
+
+// sealedConfigMapNamespaceLister implements the SealedConfigMapNamespaceLister
+// interface.
+type sealedConfigMapNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all SealedConfigMaps in the indexer for a given namespace.
+func (s sealedConfigMapNamespaceLister) List(selector labels.Selector) (ret []*v1alpha1.SealedConfigMap, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.SealedConfigMap))
+	})
+	return ret, err
+}
+
+// Get retrieves the SealedConfigMap from the indexer for a given namespace and name.
+func (s sealedConfigMapNamespaceLister) Get(name string) (*v1alpha1.SealedConfigMap, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1alpha1.Resource("sealedconfigmap"), name)
+	}
+	return obj.(*v1alpha1.SealedConfigMap), nil
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file was automatically generated by lister-gen
+
+package v1alpha1
+
+import (
+	v1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// SealedObjectLister helps list SealedObjects.
+type SealedObjectLister interface {
+	// List lists all SealedObjects in the indexer.
+	List(selector labels.Selector) (ret []*v1alpha1.SealedObject, err error)
+	// SealedObjects returns an object that can list and get SealedObjects.
+	SealedObjects(namespace string) SealedObjectNamespaceLister
+	SealedObjectListerExpansion
+}
+
+// sealedObjectLister implements the SealedObjectLister interface.
+type sealedObjectLister struct {
+	indexer cache.Indexer
+}
+
+// NewSealedObjectLister returns a new SealedObjectLister.
+func NewSealedObjectLister(indexer cache.Indexer) SealedObjectLister {
+	return &sealedObjectLister{indexer: indexer}
+}
+
+// List lists all SealedObjects in the indexer.
+func (s *sealedObjectLister) List(selector labels.Selector) (ret []*v1alpha1.SealedObject, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.SealedObject))
+	})
+	return ret, err
+}
+
+// SealedObjects returns an object that can list and get SealedObjects.
+func (s *sealedObjectLister) SealedObjects(namespace string) SealedObjectNamespaceLister {
+	return sealedObjectNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// SealedObjectNamespaceLister helps list and get SealedObjects.
+type SealedObjectNamespaceLister interface {
+	// List lists all SealedObjects in the indexer for a given namespace.
+	List(selector labels.Selector) (ret []*v1alpha1.SealedObject, err error)
+	// Get retrieves the SealedObject from the indexer for a given namespace and name.
+	Get(name string) (*v1alpha1.SealedObject, error)
+	SealedObjectNamespaceListerExpansion
+}
+
+// sealedObjectNamespaceLister implements the SealedObjectNamespaceLister
+// interface.
+type sealedObjectNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all SealedObjects in the indexer for a given namespace.
+func (s sealedObjectNamespaceLister) List(selector labels.Selector) (ret []*v1alpha1.SealedObject, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.SealedObject))
+	})
+	return ret, err
+}
+
+// Get retrieves the SealedObject from the indexer for a given namespace and name.
+func (s sealedObjectNamespaceLister) Get(name string) (*v1alpha1.SealedObject, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1alpha1.Resource("sealedobject"), name)
+	}
+	return obj.(*v1alpha1.SealedObject), nil
+}
@@ -0,0 +1,60 @@
+package crypto
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	// MinChunkSize and MaxChunkSize bound Params.ChunkSize. Smaller
+	// chunks would mostly be authentication tags, larger ones would
+	// exceed any Secret.
+	MinChunkSize = 4 << 10
+	MaxChunkSize = maxDecompressedBytes
+)
+
+// ErrInvalidChunkSize indicates a chunk size out of the MinChunkSize
+// to MaxChunkSize range.
+var ErrInvalidChunkSize = errors.New("Invalid chunk size")
+
+// chunkNonce returns the nonce of the i-th chunk. The session key is
+// only used for one plaintext, so the chunk index is enough to make
+// it unique, and the last chunk is flagged so that a ciphertext
+// truncated between two chunks can't be opened.
+func chunkNonce(aed cipher.AEAD, i uint32, last bool) []byte {
+	nonce := make([]byte, aed.NonceSize())
+	binary.BigEndian.PutUint32(nonce[len(nonce)-5:], i)
+	if last {
+		nonce[len(nonce)-1] = 1
+	}
+	return nonce
+}
+
+// sealChunks appends to dst the chunks of size bytes of plaintext, each
+// sealed with aed, the last one being shorter if need be.
+func sealChunks(aed cipher.AEAD, dst, plaintext []byte, size int) []byte {
+	for i := uint32(0); ; i++ {
+		if len(plaintext) <= size {
+			return aed.Seal(dst, chunkNonce(aed, i, true), plaintext, nil)
+		}
+		dst = aed.Seal(dst, chunkNonce(aed, i, false), plaintext[:size], nil)
+		plaintext = plaintext[size:]
+	}
+}
+
+// openChunks undoes sealChunks, one chunk at a time.
+func openChunks(aed cipher.AEAD, ciphertext []byte, size int) ([]byte, error) {
+	sealedSize := size + aed.Overhead()
+	var plaintext []byte
+	for i := uint32(0); ; i++ {
+		if len(ciphertext) <= sealedSize {
+			return aed.Open(plaintext, chunkNonce(aed, i, true), ciphertext, nil)
+		}
+		var err error
+		if plaintext, err = aed.Open(plaintext, chunkNonce(aed, i, false), ciphertext[:sealedSize], nil); err != nil {
+			return nil, err
+		}
+		ciphertext = ciphertext[sealedSize:]
+	}
+}
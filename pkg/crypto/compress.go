@@ -0,0 +1,110 @@
+package crypto
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// maxDecompressedBytes bounds the plaintexts inflated by HybridDecrypt,
+// well above the size of any Secret.
+const maxDecompressedBytes = 16 << 20
+
+// ErrTooLarge indicates the compressed plaintext inflates to more than
+// maxDecompressedBytes.
+var ErrTooLarge = errors.New("SealedSecret data is too large once decompressed")
+
+// Compression is the compression of the plaintext before encryption.
+type Compression uint16
+
+const (
+	// CompressionNone leaves the plaintext as is, the default.
+	CompressionNone Compression = iota
+	// CompressionGzip compresses the plaintext with gzip.
+	CompressionGzip
+	// CompressionZstd compresses the plaintext with zstd, faster than
+	// gzip. Its ciphertexts have a longer header, and can't be
+	// unsealed by the controllers predating it.
+	CompressionZstd
+)
+
+// compress compresses plaintext with c.
+func compress(c Compression, plaintext []byte) ([]byte, error) {
+	switch c {
+	case CompressionGzip:
+		return gzipCompress(plaintext)
+	case CompressionZstd:
+		return zstdCompress(plaintext)
+	}
+	return plaintext, nil
+}
+
+// decompress undoes compress, up to maxDecompressedBytes.
+func decompress(c Compression, compressed []byte) ([]byte, error) {
+	switch c {
+	case CompressionGzip:
+		return gzipDecompress(compressed)
+	case CompressionZstd:
+		return zstdDecompress(compressed)
+	}
+	return compressed, nil
+}
+
+func gzipCompress(plaintext []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func gzipDecompress(compressed []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return readLimited(r)
+}
+
+func zstdCompress(plaintext []byte) ([]byte, error) {
+	w, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedBestCompression), zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	defer w.Close()
+	return w.EncodeAll(plaintext, nil), nil
+}
+
+func zstdDecompress(compressed []byte) ([]byte, error) {
+	r, err := zstd.NewReader(bytes.NewReader(compressed), zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(maxDecompressedBytes))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return readLimited(r)
+}
+
+// readLimited reads r to the end, failing with ErrTooLarge beyond
+// maxDecompressedBytes.
+func readLimited(r io.Reader) ([]byte, error) {
+	plaintext, err := ioutil.ReadAll(io.LimitReader(r, maxDecompressedBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(plaintext) > maxDecompressedBytes {
+		return nil, ErrTooLarge
+	}
+	return plaintext, nil
+}
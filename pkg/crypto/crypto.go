@@ -4,51 +4,163 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rsa"
+	"crypto/sha1"
 	"crypto/sha256"
 	"encoding/binary"
 	"errors"
+	"hash"
 	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
 )
 
 const (
 	sessionKeyBytes = 32
+
+	// The cipher tag, OAEP hash and compression flags are stored in
+	// the top bits of the RSA ciphertext length, which leaves room for
+	// keys of up to 16376 bits. The compressions other than gzip, and
+	// chunking, have no flag: their ciphertexts have the extendedTag
+	// cipher tag, and a third header byte holding the chunked flag, the
+	// cipher and the compression, followed by the 4 bytes chunk size
+	// if chunked.
+	cipherTagShift = 12
+	cipherTagMask  = 0x7
+	extendedTag    = cipherTagMask
+	oaepSHA1Flag   = 1 << 15
+	gzipFlag       = 1 << 11
+	rsaLenMask     = gzipFlag - 1
+
+	extChunkedFlag     = 1 << 7
+	extCipherShift     = 4
+	extCompressionMask = 0xf
 )
 
 // ErrTooShort indicates the provided data is too short to be valid
 var ErrTooShort = errors.New("SealedSecret data is too short")
 
+// ErrUnsupportedCipher indicates the data is encrypted with an unknown
+// cipher.
+var ErrUnsupportedCipher = errors.New("Unsupported session cipher")
+
+// Cipher is the AEAD encrypting the plaintext with the session key.
+type Cipher uint16
+
+const (
+	// CipherAESGCM is AES-256-GCM, the default.
+	CipherAESGCM Cipher = iota
+	// CipherChaCha20Poly1305 is ChaCha20-Poly1305, faster than AES
+	// on CPUs without AES instructions.
+	CipherChaCha20Poly1305
+)
+
+// OAEPHash is the hash RSA-OAEP encrypts the session key with.
+type OAEPHash uint16
+
+const (
+	// OAEPSHA256 is SHA-256, the default.
+	OAEPSHA256 OAEPHash = iota
+	// OAEPSHA1 is SHA-1, for interoperability with tools and HSMs
+	// that don't support SHA-256.
+	OAEPSHA1
+)
+
+// Params selects the primitives of HybridEncryptParams, the zero value
+// being those of HybridEncrypt.
+type Params struct {
+	Cipher      Cipher
+	OAEPHash    OAEPHash
+	Compression Compression
+	// ChunkSize, if not 0, splits the plaintexts larger than it, once
+	// compressed, in chunks encrypted separately, see sealChunks.
+	ChunkSize int
+}
+
+func (h OAEPHash) new() hash.Hash {
+	if h == OAEPSHA1 {
+		return sha1.New()
+	}
+	return sha256.New()
+}
+
+// newAEAD returns the AEAD of c keyed with sessionKey.
+func newAEAD(c Cipher, sessionKey []byte) (cipher.AEAD, error) {
+	switch c {
+	case CipherAESGCM:
+		block, err := aes.NewCipher(sessionKey)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	case CipherChaCha20Poly1305:
+		return chacha20poly1305.New(sessionKey)
+	default:
+		return nil, ErrUnsupportedCipher
+	}
+}
+
 // HybridEncrypt performs a regular AES-GCM + RSA-OAEP encryption.
 // The output bytestring is:
 //   RSA ciphertext length || RSA ciphertext || AES ciphertext
 func HybridEncrypt(rnd io.Reader, pubKey *rsa.PublicKey, plaintext, label []byte) ([]byte, error) {
+	return HybridEncryptParams(rnd, pubKey, Params{}, plaintext, label)
+}
+
+// HybridEncryptParams is like HybridEncrypt, with the cipher, OAEP hash
+// and compression of p. They are tagged in the top bits of the RSA
+// ciphertext length, so that HybridDecrypt needs no other input. The
+// defaults are tagged 0, as in the ciphertexts predating the tag.
+func HybridEncryptParams(rnd io.Reader, pubKey *rsa.PublicKey, p Params, plaintext, label []byte) ([]byte, error) {
+	if p.Cipher >= extendedTag || p.OAEPHash > OAEPSHA1 || p.Compression > CompressionZstd {
+		return nil, ErrUnsupportedCipher
+	}
+	if p.ChunkSize != 0 && (p.ChunkSize < MinChunkSize || p.ChunkSize > MaxChunkSize) {
+		return nil, ErrInvalidChunkSize
+	}
+	if err := checkFIPS(p, pubKey, rnd); err != nil {
+		return nil, err
+	}
+	plaintext, err := compress(p.Compression, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
 	// Generate a random symmetric key
 	sessionKey := make([]byte, sessionKeyBytes)
 	if _, err := io.ReadFull(rnd, sessionKey); err != nil {
 		return nil, err
 	}
 
-	block, err := aes.NewCipher(sessionKey)
+	aed, err := newAEAD(p.Cipher, sessionKey)
 	if err != nil {
 		return nil, err
 	}
 
-	aed, err := cipher.NewGCM(block)
+	// Encrypt symmetric key
+	rsaCiphertext, err := rsa.EncryptOAEP(p.OAEPHash.new(), rnd, pubKey, sessionKey, label)
 	if err != nil {
 		return nil, err
 	}
 
-	// Encrypt symmetric key
-	rsaCiphertext, err := rsa.EncryptOAEP(sha256.New(), rnd, pubKey, sessionKey, label)
-	if err != nil {
-		return nil, err
+	if len(rsaCiphertext) > rsaLenMask {
+		return nil, errors.New("RSA key too large")
 	}
 
-	// First 2 bytes are RSA ciphertext length, so we can separate
-	// all the pieces later.
-	ciphertext := make([]byte, 2)
-	binary.BigEndian.PutUint16(ciphertext, uint16(len(rsaCiphertext)))
+	// The plaintexts fitting in a chunk are sealed as usual, for the
+	// controllers predating chunking.
+	if len(plaintext) <= p.ChunkSize {
+		p.ChunkSize = 0
+	}
+
+	// First 2 bytes, or more, are the tags and RSA ciphertext length,
+	// so we can separate all the pieces later.
+	ciphertext := encodeHeader(p, len(rsaCiphertext))
 	ciphertext = append(ciphertext, rsaCiphertext...)
 
+	if p.ChunkSize > 0 {
+		return sealChunks(aed, ciphertext, plaintext, p.ChunkSize), nil
+	}
+
 	// SessionKey is only used once, so zero nonce is ok
 	zeroNonce := make([]byte, aed.NonceSize())
 
@@ -58,41 +170,130 @@ func HybridEncrypt(rnd io.Reader, pubKey *rsa.PublicKey, plaintext, label []byte
 	return ciphertext, nil
 }
 
-// HybridDecrypt performs a regular AES-GCM + RSA-OAEP decryption
-func HybridDecrypt(rnd io.Reader, privKey *rsa.PrivateKey, ciphertext, label []byte) ([]byte, error) {
+// encodeHeader returns the header of a HybridEncryptParams ciphertext
+// with the tags of p, followed by an RSA ciphertext of rsaLen bytes.
+func encodeHeader(p Params, rsaLen int) []byte {
+	word := uint16(rsaLen)
+	if p.OAEPHash == OAEPSHA1 {
+		word |= oaepSHA1Flag
+	}
+	if p.Compression > CompressionGzip || p.ChunkSize > 0 {
+		// No flag left, see extendedTag
+		header := make([]byte, 3, 7)
+		binary.BigEndian.PutUint16(header, word|extendedTag<<cipherTagShift)
+		header[2] = byte(p.Cipher)<<extCipherShift | byte(p.Compression)
+		if p.ChunkSize > 0 {
+			header[2] |= extChunkedFlag
+			header = header[:7]
+			binary.BigEndian.PutUint32(header[3:], uint32(p.ChunkSize))
+		}
+		return header
+	}
+
+	word |= uint16(p.Cipher) << cipherTagShift
+	if p.Compression == CompressionGzip {
+		word |= gzipFlag
+	}
+	header := make([]byte, 2)
+	binary.BigEndian.PutUint16(header, word)
+	return header
+}
+
+// decodeHeader returns the tags of a HybridEncryptParams ciphertext and
+// the length of its header.
+func decodeHeader(ciphertext []byte) (Params, int, error) {
 	if len(ciphertext) < 2 {
-		return nil, ErrTooShort
+		return Params{}, 0, ErrTooShort
 	}
-	rsaLen := int(binary.BigEndian.Uint16(ciphertext))
-	if len(ciphertext) < rsaLen+2 {
-		return nil, ErrTooShort
+	word := binary.BigEndian.Uint16(ciphertext)
+	p := Params{Cipher: Cipher(word >> cipherTagShift & cipherTagMask)}
+	if word&oaepSHA1Flag != 0 {
+		p.OAEPHash = OAEPSHA1
+	}
+	if p.Cipher != extendedTag {
+		if word&gzipFlag != 0 {
+			p.Compression = CompressionGzip
+		}
+		return p, 2, nil
 	}
 
-	rsaCiphertext := ciphertext[2 : rsaLen+2]
-	aesCiphertext := ciphertext[rsaLen+2:]
+	headerLen := HeaderLen(ciphertext)
+	if len(ciphertext) < headerLen {
+		return Params{}, 0, ErrTooShort
+	}
+	p.Cipher = Cipher(ciphertext[2] >> extCipherShift & cipherTagMask)
+	p.Compression = Compression(ciphertext[2] & extCompressionMask)
+	if word&gzipFlag != 0 || p.Compression > CompressionZstd {
+		return Params{}, 0, ErrUnsupportedCipher
+	}
+	if ciphertext[2]&extChunkedFlag != 0 {
+		p.ChunkSize = int(binary.BigEndian.Uint32(ciphertext[3:]))
+		if p.ChunkSize < MinChunkSize || p.ChunkSize > MaxChunkSize {
+			return Params{}, 0, ErrInvalidChunkSize
+		}
+	}
+	return p, headerLen, nil
+}
+
+// HeaderLen returns the length of the header of a HybridEncryptParams
+// ciphertext: 2 bytes, 3 with the compressions lacking a flag, or 7
+// if chunked.
+func HeaderLen(ciphertext []byte) int {
+	if Cipher(binary.BigEndian.Uint16(ciphertext)>>cipherTagShift&cipherTagMask) != extendedTag {
+		return 2
+	}
+	if len(ciphertext) > 2 && ciphertext[2]&extChunkedFlag != 0 {
+		return 7
+	}
+	return 3
+}
+
+// RSACiphertextLen returns the length of the RSA ciphertext following
+// the header of a HybridEncryptParams ciphertext, see HeaderLen.
+func RSACiphertextLen(ciphertext []byte) int {
+	return int(binary.BigEndian.Uint16(ciphertext) & rsaLenMask)
+}
 
-	sessionKey, err := rsa.DecryptOAEP(sha256.New(), rnd, privKey, rsaCiphertext, label)
+// HybridDecrypt performs a regular AES-GCM + RSA-OAEP decryption, or
+// uses the cipher, OAEP hash and compression tagged by
+// HybridEncryptParams.
+func HybridDecrypt(rnd io.Reader, privKey *rsa.PrivateKey, ciphertext, label []byte) ([]byte, error) {
+	p, headerLen, err := decodeHeader(ciphertext)
 	if err != nil {
 		return nil, err
 	}
+	rsaLen := RSACiphertextLen(ciphertext)
+	if err := checkFIPS(p, &privKey.PublicKey, nil); err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < headerLen+rsaLen {
+		return nil, ErrTooShort
+	}
 
-	block, err := aes.NewCipher(sessionKey)
+	rsaCiphertext := ciphertext[headerLen : headerLen+rsaLen]
+	aesCiphertext := ciphertext[headerLen+rsaLen:]
+
+	sessionKey, err := rsa.DecryptOAEP(p.OAEPHash.new(), rnd, privKey, rsaCiphertext, label)
 	if err != nil {
 		return nil, err
 	}
 
-	aed, err := cipher.NewGCM(block)
+	aed, err := newAEAD(p.Cipher, sessionKey)
 	if err != nil {
 		return nil, err
 	}
 
-	// Key is only used once, so zero nonce is ok
-	zeroNonce := make([]byte, aed.NonceSize())
-
-	plaintext, err := aed.Open(nil, zeroNonce, aesCiphertext, nil)
+	var plaintext []byte
+	if p.ChunkSize > 0 {
+		plaintext, err = openChunks(aed, aesCiphertext, p.ChunkSize)
+	} else {
+		// Key is only used once, so zero nonce is ok
+		zeroNonce := make([]byte, aed.NonceSize())
+		plaintext, err = aed.Open(nil, zeroNonce, aesCiphertext, nil)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return plaintext, nil
+	return decompress(p.Compression, plaintext)
 }
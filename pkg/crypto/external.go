@@ -0,0 +1,93 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// SessionKeyDecrypter decrypts session keys encrypted with RSA-OAEP,
+// SHA-256 and no label, eg. with a private key held by a service out
+// of reach of the caller.
+type SessionKeyDecrypter interface {
+	DecryptSessionKey(rsaCiphertext []byte) ([]byte, error)
+}
+
+type privateKeyDecrypter struct {
+	privKey *rsa.PrivateKey
+}
+
+func (d privateKeyDecrypter) DecryptSessionKey(rsaCiphertext []byte) ([]byte, error) {
+	return rsa.DecryptOAEP(sha256.New(), rand.Reader, d.privKey, rsaCiphertext, nil)
+}
+
+// PrivateKeyDecrypter returns the SessionKeyDecrypter of privKey.
+func PrivateKeyDecrypter(privKey *rsa.PrivateKey) SessionKeyDecrypter {
+	return privateKeyDecrypter{privKey}
+}
+
+// HybridEncryptExternal is like HybridEncrypt, for keys held by
+// services which can't decrypt RSA-OAEP with a label, such as the
+// transit engine of Vault: the session key is encrypted without label,
+// and label is bound by the AES-GCM additional data instead.
+func HybridEncryptExternal(rnd io.Reader, pubKey *rsa.PublicKey, plaintext, label []byte) ([]byte, error) {
+	if err := checkFIPS(Params{}, pubKey, rnd); err != nil {
+		return nil, err
+	}
+
+	sessionKey := make([]byte, sessionKeyBytes)
+	if _, err := io.ReadFull(rnd, sessionKey); err != nil {
+		return nil, err
+	}
+	aed, err := newAEAD(CipherAESGCM, sessionKey)
+	if err != nil {
+		return nil, err
+	}
+	rsaCiphertext, err := rsa.EncryptOAEP(sha256.New(), rnd, pubKey, sessionKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(rsaCiphertext) > rsaLenMask {
+		return nil, errors.New("RSA key too large")
+	}
+
+	ciphertext := make([]byte, 2)
+	binary.BigEndian.PutUint16(ciphertext, uint16(len(rsaCiphertext)))
+	ciphertext = append(ciphertext, rsaCiphertext...)
+
+	// SessionKey is only used once, so zero nonce is ok
+	zeroNonce := make([]byte, aed.NonceSize())
+	return aed.Seal(ciphertext, zeroNonce, plaintext, label), nil
+}
+
+// HybridDecryptExternal decrypts a ciphertext of HybridEncryptExternal,
+// the session key being decrypted by d.
+func HybridDecryptExternal(d SessionKeyDecrypter, ciphertext, label []byte) ([]byte, error) {
+	if len(ciphertext) < 2 {
+		return nil, ErrTooShort
+	}
+	if binary.BigEndian.Uint16(ciphertext) & ^uint16(rsaLenMask) != 0 {
+		// No other primitives are supported by the external keys
+		return nil, ErrUnsupportedCipher
+	}
+	rsaLen := RSACiphertextLen(ciphertext)
+	if len(ciphertext) < rsaLen+2 {
+		return nil, ErrTooShort
+	}
+
+	sessionKey, err := d.DecryptSessionKey(ciphertext[2 : rsaLen+2])
+	if err != nil {
+		return nil, err
+	}
+	aed, err := newAEAD(CipherAESGCM, sessionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Key is only used once, so zero nonce is ok
+	zeroNonce := make([]byte, aed.NonceSize())
+	return aed.Open(nil, zeroNonce, ciphertext[rsaLen+2:], label)
+}
@@ -0,0 +1,56 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// FIPSMinKeySize is the smallest RSA key allowed in FIPS mode.
+const FIPSMinKeySize = 2048
+
+// ErrNotFIPSApproved is returned in FIPS mode for the algorithms and
+// random sources that aren't approved by FIPS 140-2.
+var ErrNotFIPSApproved = errors.New("Not FIPS 140-2 approved")
+
+var fipsMode bool
+
+// SetFIPS restricts the package to the FIPS 140-2 approved algorithms,
+// RSA-OAEP with SHA-256 and AES-GCM, keys of at least FIPSMinKeySize
+// bits and crypto/rand. It must be called before any encryption.
+func SetFIPS(enabled bool) {
+	fipsMode = enabled
+}
+
+// FIPSEnabled reports whether FIPS mode is enabled.
+func FIPSEnabled() bool {
+	return fipsMode
+}
+
+// FIPSModule reports whether the primitives are provided by a FIPS
+// 140-2 validated module, ie. the binary is built with boringcrypto.
+func FIPSModule() bool {
+	return boringEnabled()
+}
+
+// CheckFIPSKey returns an error in FIPS mode if pubKey is too small.
+func CheckFIPSKey(pubKey *rsa.PublicKey) error {
+	if fipsMode && pubKey.N.BitLen() < FIPSMinKeySize {
+		return fmt.Errorf("%d bits RSA key: %v, at least %d bits are required", pubKey.N.BitLen(), ErrNotFIPSApproved, FIPSMinKeySize)
+	}
+	return nil
+}
+
+// checkFIPS returns an error in FIPS mode if p, pubKey or rnd isn't
+// approved, rnd may be nil when unused.
+func checkFIPS(p Params, pubKey *rsa.PublicKey, rnd io.Reader) error {
+	if !fipsMode {
+		return nil
+	}
+	if p.Cipher != CipherAESGCM || p.OAEPHash != OAEPSHA256 || (rnd != nil && rnd != rand.Reader) {
+		return ErrNotFIPSApproved
+	}
+	return CheckFIPSKey(pubKey)
+}
@@ -0,0 +1,193 @@
+package mlkem
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+func TestEncapsulateDecapsulate(t *testing.T) {
+	seed := make([]byte, SeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		t.Fatalf("Read() returned error: %v", err)
+	}
+	dk, err := NewDecapsulationKey768(seed)
+	if err != nil {
+		t.Fatalf("NewDecapsulationKey768() returned error: %v", err)
+	}
+	ek, err := NewEncapsulationKey768(dk.EncapsulationKey().Bytes())
+	if err != nil {
+		t.Fatalf("NewEncapsulationKey768() returned error: %v", err)
+	}
+
+	key, ct, err := ek.Encapsulate(rand.Reader)
+	if err != nil {
+		t.Fatalf("Encapsulate() returned error: %v", err)
+	}
+	if len(key) != SharedKeySize || len(ct) != CiphertextSize768 {
+		t.Fatalf("Unexpected sizes: key %d, ciphertext %d", len(key), len(ct))
+	}
+	got, err := dk.Decapsulate(ct)
+	if err != nil {
+		t.Fatalf("Decapsulate() returned error: %v", err)
+	}
+	if !bytes.Equal(got, key) {
+		t.Errorf("Decapsulated a different key")
+	}
+
+	ct[0] ^= 1
+	if got, err := dk.Decapsulate(ct); err != nil || bytes.Equal(got, key) {
+		t.Errorf("Tampered ciphertext decapsulated to the same key (err %v)", err)
+	}
+	if _, err := dk.Decapsulate(ct[1:]); err == nil {
+		t.Errorf("Decapsulate() accepted a short ciphertext")
+	}
+}
+
+func TestBadLengths(t *testing.T) {
+	seed := make([]byte, SeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		t.Fatalf("Read() returned error: %v", err)
+	}
+	dk, err := NewDecapsulationKey768(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ek := dk.EncapsulationKey()
+	ekBytes := ek.Bytes()
+	_, c, err := ek.Encapsulate(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < len(seed)-1; i++ {
+		if _, err := NewDecapsulationKey768(seed[:i]); err == nil {
+			t.Errorf("expected error for seed length %d", i)
+		}
+	}
+	seedLong := seed
+	for i := 0; i < 100; i++ {
+		seedLong = append(seedLong, 0)
+		if _, err := NewDecapsulationKey768(seedLong); err == nil {
+			t.Errorf("expected error for seed length %d", len(seedLong))
+		}
+	}
+
+	for i := 0; i < len(ekBytes)-1; i++ {
+		if _, err := NewEncapsulationKey768(ekBytes[:i]); err == nil {
+			t.Errorf("expected error for ek length %d", i)
+		}
+	}
+	ekLong := ekBytes
+	for i := 0; i < 100; i++ {
+		ekLong = append(ekLong, 0)
+		if _, err := NewEncapsulationKey768(ekLong); err == nil {
+			t.Errorf("expected error for ek length %d", len(ekLong))
+		}
+	}
+
+	for i := 0; i < len(c)-1; i++ {
+		if _, err := dk.Decapsulate(c[:i]); err == nil {
+			t.Errorf("expected error for c length %d", i)
+		}
+	}
+	cLong := c
+	for i := 0; i < 100; i++ {
+		cLong = append(cLong, 0)
+		if _, err := dk.Decapsulate(cLong); err == nil {
+			t.Errorf("expected error for c length %d", len(cLong))
+		}
+	}
+}
+
+// TestKnownAnswer checks the ML-KEM-768 vector of the FIPS 140-3 self-test of
+// the Go standard library.
+func TestKnownAnswer(t *testing.T) {
+	var d, z, m [32]byte
+	for i := range d {
+		d[i] = byte(0x01 + i)
+		z[i] = byte(0x21 + i)
+		m[i] = byte(0x41 + i)
+	}
+	expected := "5501fc523b745f41762a188de44a59b920f430146204ee4e793732396df7aa48"
+
+	dk := &DecapsulationKey768{}
+	kemKeyGen(dk, &d, &z)
+	var cc [CiphertextSize768]byte
+	Ke, c := kemEncaps(&cc, dk.EncapsulationKey(), &m)
+	if got := hex.EncodeToString(Ke); got != expected {
+		t.Errorf("encapsulated key: got %s, expected %s", got, expected)
+	}
+	Kd, err := dk.Decapsulate(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(Kd); got != expected {
+		t.Errorf("decapsulated key: got %s, expected %s", got, expected)
+	}
+}
+
+var millionFlag = flag.Bool("million", false, "run the million vector test")
+
+// TestAccumulated accumulates 10k (or 100, or 1M) random vectors and checks the
+// hash of the result, to avoid checking in 150MB of test vectors. The expected
+// hashes are the ones of the Go standard library.
+func TestAccumulated(t *testing.T) {
+	n := 10000
+	expected := "8a518cc63da366322a8e7a818c7a0d63483cb3528d34a4cf42f35d5ad73f22fc"
+	if testing.Short() {
+		n = 100
+		expected = "1114b1b6699ed191734fa339376afa7e285c9e6acf6ff0177d346696ce564415"
+	}
+	if *millionFlag {
+		n = 1000000
+		expected = "424bf8f0e8ae99b78d788a6e2e8e9cdaf9773fc0c08a6f433507cb559edfd0f0"
+	}
+
+	s := sha3.NewShake128()
+	o := sha3.NewShake128()
+	seed := make([]byte, SeedSize)
+	var msg [messageSize]byte
+	var cc [CiphertextSize768]byte
+	ct1 := make([]byte, CiphertextSize768)
+
+	for i := 0; i < n; i++ {
+		s.Read(seed)
+		dk, err := NewDecapsulationKey768(seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ek := dk.EncapsulationKey()
+		o.Write(ek.Bytes())
+
+		s.Read(msg[:])
+		k, ct := kemEncaps(&cc, ek, &msg)
+		o.Write(ct)
+		o.Write(k)
+
+		kk, err := dk.Decapsulate(ct)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(kk, k) {
+			t.Errorf("k: got %x, expected %x", kk, k)
+		}
+
+		s.Read(ct1)
+		k1, err := dk.Decapsulate(ct1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		o.Write(k1)
+	}
+
+	sum := make([]byte, 32)
+	o.Read(sum)
+	if got := hex.EncodeToString(sum); got != expected {
+		t.Errorf("got %s, expected %s", got, expected)
+	}
+}
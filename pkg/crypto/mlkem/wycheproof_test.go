@@ -0,0 +1,140 @@
+package mlkem
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// The test vectors in testdata are the ML-KEM-768 ones of Project Wycheproof
+// (https://github.com/C2SP/wycheproof, testvectors_v1), which include the
+// FIPS 203 vectors and the CCTV edge cases.
+
+type wycheproofTest struct {
+	TcID    int      `json:"tcId"`
+	Comment string   `json:"comment"`
+	Flags   []string `json:"flags"`
+	Seed    string   `json:"seed"`
+	Ek      string   `json:"ek"`
+	M       string   `json:"m"`
+	C       string   `json:"c"`
+	K       string   `json:"K"`
+	Result  string   `json:"result"`
+}
+
+func loadWycheproof(t *testing.T, file string) []wycheproofTest {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors struct {
+		TestGroups []struct {
+			ParameterSet string           `json:"parameterSet"`
+			Tests        []wycheproofTest `json:"tests"`
+		} `json:"testGroups"`
+	}
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+	var tests []wycheproofTest
+	for _, tg := range vectors.TestGroups {
+		if tg.ParameterSet != "ML-KEM-768" {
+			t.Fatalf("%s: unexpected parameter set %s", file, tg.ParameterSet)
+		}
+		tests = append(tests, tg.Tests...)
+	}
+	if len(tests) == 0 {
+		t.Fatalf("%s: no test vectors", file)
+	}
+	return tests
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestEncapsWycheproof(t *testing.T) {
+	for _, tv := range loadWycheproof(t, "mlkem_768_encaps_test.json") {
+		shouldPass := tv.Result == "valid"
+		ekBytes := mustDecodeHex(t, tv.Ek)
+		m := mustDecodeHex(t, tv.M)
+
+		ek, err := NewEncapsulationKey768(ekBytes)
+		if err != nil {
+			if shouldPass {
+				t.Errorf("tcId %d: NewEncapsulationKey768() returned error: %v", tv.TcID, err)
+			}
+			continue
+		}
+		if len(m) != messageSize {
+			if shouldPass {
+				t.Errorf("tcId %d: unexpected message length %d", tv.TcID, len(m))
+			}
+			continue
+		}
+		if !shouldPass {
+			t.Errorf("tcId %d: invalid vector accepted (%s %v)", tv.TcID, tv.Comment, tv.Flags)
+			continue
+		}
+		if !bytes.Equal(ek.Bytes(), ekBytes) {
+			t.Errorf("tcId %d: encapsulation key roundtrip mismatch", tv.TcID)
+		}
+		var msg [messageSize]byte
+		copy(msg[:], m)
+		var cc [CiphertextSize768]byte
+		k, c := kemEncaps(&cc, ek, &msg)
+		if !bytes.Equal(c, mustDecodeHex(t, tv.C)) {
+			t.Errorf("tcId %d: ciphertext mismatch", tv.TcID)
+		}
+		if !bytes.Equal(k, mustDecodeHex(t, tv.K)) {
+			t.Errorf("tcId %d: shared key mismatch", tv.TcID)
+		}
+	}
+}
+
+func TestDecapsWycheproof(t *testing.T) {
+	for _, tv := range loadWycheproof(t, "mlkem_768_test.json") {
+		shouldPass := tv.Result == "valid"
+
+		dk, err := NewDecapsulationKey768(mustDecodeHex(t, tv.Seed))
+		if err != nil {
+			if shouldPass {
+				t.Errorf("tcId %d: NewDecapsulationKey768() returned error: %v", tv.TcID, err)
+			}
+			continue
+		}
+		if tv.Ek != "" && !bytes.Equal(dk.EncapsulationKey().Bytes(), mustDecodeHex(t, tv.Ek)) {
+			t.Errorf("tcId %d: encapsulation key mismatch", tv.TcID)
+		}
+		k, err := dk.Decapsulate(mustDecodeHex(t, tv.C))
+		if err != nil {
+			if shouldPass {
+				t.Errorf("tcId %d: Decapsulate() returned error: %v", tv.TcID, err)
+			}
+			continue
+		}
+		if !shouldPass {
+			t.Errorf("tcId %d: invalid vector accepted (%s %v)", tv.TcID, tv.Comment, tv.Flags)
+			continue
+		}
+		if !bytes.Equal(k, mustDecodeHex(t, tv.K)) {
+			t.Errorf("tcId %d: shared key mismatch: got %x, want %s", tv.TcID, k, tv.K)
+		}
+
+		kFresh, cFresh, err := dk.EncapsulationKey().Encapsulate(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kRT, err := dk.Decapsulate(cFresh); err != nil || !bytes.Equal(kFresh, kRT) {
+			t.Errorf("tcId %d: encaps/decaps roundtrip mismatch (err %v)", tv.TcID, err)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+// +build !boringcrypto
+
+package crypto
+
+func boringEnabled() bool {
+	return false
+}
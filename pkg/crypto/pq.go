@@ -0,0 +1,124 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto/mlkem"
+)
+
+// MLKEMKeyFromRSA derives the ML-KEM-768 key paired with an RSA key, see
+// X25519KeyFromRSA.
+func MLKEMKeyFromRSA(privKey *rsa.PrivateKey) (*mlkem.DecapsulationKey768, error) {
+	seed := make([]byte, mlkem.SeedSize)
+	kdf := hkdf.New(sha256.New, privKey.D.Bytes(), nil, []byte("sealed-secrets ml-kem-768 key"))
+	if _, err := io.ReadFull(kdf, seed); err != nil {
+		return nil, err
+	}
+	return mlkem.NewDecapsulationKey768(seed)
+}
+
+// pqSessionKey combines the secrets protected by RSA and ML-KEM, so that
+// both have to be broken to recover the session key.
+func pqSessionKey(rsaSecret, kemSecret, label []byte) ([]byte, error) {
+	secret := append(append([]byte{}, rsaSecret...), kemSecret...)
+	sessionKey := make([]byte, sessionKeyBytes)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, nil, label), sessionKey); err != nil {
+		return nil, err
+	}
+	return sessionKey, nil
+}
+
+// HybridPQEncrypt performs an AES-GCM encryption whose key is derived
+// from a secret encrypted with RSA-OAEP and a secret encapsulated with
+// ML-KEM-768, which is expected to resist quantum computers.
+// The output bytestring is:
+//   RSA ciphertext length || RSA ciphertext || ML-KEM ciphertext || AES ciphertext
+func HybridPQEncrypt(rnd io.Reader, pubKey *rsa.PublicKey, kemKey *mlkem.EncapsulationKey768, plaintext, label []byte) ([]byte, error) {
+	if fipsMode {
+		return nil, ErrNotFIPSApproved
+	}
+	rsaSecret := make([]byte, sessionKeyBytes)
+	if _, err := io.ReadFull(rnd, rsaSecret); err != nil {
+		return nil, err
+	}
+	rsaCiphertext, err := rsa.EncryptOAEP(sha256.New(), rnd, pubKey, rsaSecret, label)
+	if err != nil {
+		return nil, err
+	}
+	kemSecret, kemCiphertext, err := kemKey.Encapsulate(rnd)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionKey, err := pqSessionKey(rsaSecret, kemSecret, label)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(sessionKey)
+	if err != nil {
+		return nil, err
+	}
+	aed, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext := make([]byte, 2)
+	binary.BigEndian.PutUint16(ciphertext, uint16(len(rsaCiphertext)))
+	ciphertext = append(ciphertext, rsaCiphertext...)
+	ciphertext = append(ciphertext, kemCiphertext...)
+
+	// SessionKey is only used once, so zero nonce is ok
+	zeroNonce := make([]byte, aed.NonceSize())
+	return aed.Seal(ciphertext, zeroNonce, plaintext, nil), nil
+}
+
+// HybridPQDecrypt performs a HybridPQEncrypt decryption.
+func HybridPQDecrypt(rnd io.Reader, privKey *rsa.PrivateKey, kemKey *mlkem.DecapsulationKey768, ciphertext, label []byte) ([]byte, error) {
+	if fipsMode {
+		return nil, ErrNotFIPSApproved
+	}
+	if len(ciphertext) < 2 {
+		return nil, ErrTooShort
+	}
+	rsaLen := int(binary.BigEndian.Uint16(ciphertext))
+	if len(ciphertext) < rsaLen+2+mlkem.CiphertextSize768 {
+		return nil, ErrTooShort
+	}
+	rsaCiphertext := ciphertext[2 : rsaLen+2]
+	kemCiphertext := ciphertext[rsaLen+2 : rsaLen+2+mlkem.CiphertextSize768]
+	aesCiphertext := ciphertext[rsaLen+2+mlkem.CiphertextSize768:]
+
+	rsaSecret, err := rsa.DecryptOAEP(sha256.New(), rnd, privKey, rsaCiphertext, label)
+	if err != nil {
+		return nil, err
+	}
+	kemSecret, err := kemKey.Decapsulate(kemCiphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionKey, err := pqSessionKey(rsaSecret, kemSecret, label)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(sessionKey)
+	if err != nil {
+		return nil, err
+	}
+	aed, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// Key is only used once, so zero nonce is ok
+	zeroNonce := make([]byte, aed.NonceSize())
+	return aed.Open(nil, zeroNonce, aesCiphertext, nil)
+}
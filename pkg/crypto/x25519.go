@@ -0,0 +1,104 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rsa"
+	"crypto/sha256"
+	"io"
+
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+// X25519KeySize is the size of X25519 public and private keys.
+const X25519KeySize = 32
+
+// X25519KeyFromRSA derives the X25519 key paired with an RSA key, so
+// that the keys of the controller can be used with either algorithm
+// without storing more secrets.
+func X25519KeyFromRSA(privKey *rsa.PrivateKey) (priv, pub *[X25519KeySize]byte, err error) {
+	priv, pub = new([X25519KeySize]byte), new([X25519KeySize]byte)
+	kdf := hkdf.New(sha256.New, privKey.D.Bytes(), nil, []byte("sealed-secrets x25519 key"))
+	if _, err := io.ReadFull(kdf, priv[:]); err != nil {
+		return nil, nil, err
+	}
+	curve25519.ScalarBaseMult(pub, priv)
+	return priv, pub, nil
+}
+
+// x25519SessionKey derives the AES key from the shared secret of an
+// ephemeral and a recipient key, bound to both public keys and label.
+func x25519SessionKey(shared, ephemeralPub, pub *[X25519KeySize]byte, label []byte) ([]byte, error) {
+	salt := append(append([]byte{}, ephemeralPub[:]...), pub[:]...)
+	sessionKey := make([]byte, sessionKeyBytes)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared[:], salt, label), sessionKey); err != nil {
+		return nil, err
+	}
+	return sessionKey, nil
+}
+
+// X25519Encrypt performs an ephemeral-static X25519 + AES-GCM
+// encryption, the AES key being derived with HKDF-SHA256.
+// The output bytestring is:
+//   ephemeral public key || AES ciphertext
+func X25519Encrypt(rnd io.Reader, pubKey *[X25519KeySize]byte, plaintext, label []byte) ([]byte, error) {
+	if fipsMode {
+		return nil, ErrNotFIPSApproved
+	}
+	var ephemeralPriv, ephemeralPub, shared [X25519KeySize]byte
+	if _, err := io.ReadFull(rnd, ephemeralPriv[:]); err != nil {
+		return nil, err
+	}
+	curve25519.ScalarBaseMult(&ephemeralPub, &ephemeralPriv)
+	curve25519.ScalarMult(&shared, &ephemeralPriv, pubKey)
+
+	sessionKey, err := x25519SessionKey(&shared, &ephemeralPub, pubKey, label)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(sessionKey)
+	if err != nil {
+		return nil, err
+	}
+	aed, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// SessionKey is only used once, so zero nonce is ok
+	zeroNonce := make([]byte, aed.NonceSize())
+	return aed.Seal(ephemeralPub[:], zeroNonce, plaintext, nil), nil
+}
+
+// X25519Decrypt performs an ephemeral-static X25519 + AES-GCM
+// decryption.
+func X25519Decrypt(privKey *[X25519KeySize]byte, ciphertext, label []byte) ([]byte, error) {
+	if fipsMode {
+		return nil, ErrNotFIPSApproved
+	}
+	if len(ciphertext) < X25519KeySize {
+		return nil, ErrTooShort
+	}
+	var ephemeralPub, pub, shared [X25519KeySize]byte
+	copy(ephemeralPub[:], ciphertext)
+	curve25519.ScalarBaseMult(&pub, privKey)
+	curve25519.ScalarMult(&shared, privKey, &ephemeralPub)
+
+	sessionKey, err := x25519SessionKey(&shared, &ephemeralPub, &pub, label)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(sessionKey)
+	if err != nil {
+		return nil, err
+	}
+	aed, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// Key is only used once, so zero nonce is ok
+	zeroNonce := make([]byte, aed.NonceSize())
+	return aed.Open(nil, zeroNonce, ciphertext[X25519KeySize:], nil)
+}
@@ -0,0 +1,6 @@
+// Package grpcapi contains the gRPC service exposed by the controller.
+//
+// sealedsecrets.pb.go is generated from sealedsecrets.proto by protoc-gen-go.
+package grpcapi
+
+//go:generate protoc --go_out=plugins=grpc:. sealedsecrets.proto
@@ -0,0 +1,648 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: sealedsecrets.proto
+
+package grpcapi
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+import (
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+type SealRequest struct {
+	Secret               []byte   `protobuf:"bytes,1,opt,name=secret,proto3" json:"secret,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SealRequest) Reset()         { *m = SealRequest{} }
+func (m *SealRequest) String() string { return proto.CompactTextString(m) }
+func (*SealRequest) ProtoMessage()    {}
+func (*SealRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_sealedsecrets_e1039564d4f05fc1, []int{0}
+}
+func (m *SealRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SealRequest.Unmarshal(m, b)
+}
+func (m *SealRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SealRequest.Marshal(b, m, deterministic)
+}
+func (dst *SealRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SealRequest.Merge(dst, src)
+}
+func (m *SealRequest) XXX_Size() int {
+	return xxx_messageInfo_SealRequest.Size(m)
+}
+func (m *SealRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SealRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SealRequest proto.InternalMessageInfo
+
+func (m *SealRequest) GetSecret() []byte {
+	if m != nil {
+		return m.Secret
+	}
+	return nil
+}
+
+type SealResponse struct {
+	SealedSecret         []byte   `protobuf:"bytes,1,opt,name=sealed_secret,json=sealedSecret,proto3" json:"sealed_secret,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SealResponse) Reset()         { *m = SealResponse{} }
+func (m *SealResponse) String() string { return proto.CompactTextString(m) }
+func (*SealResponse) ProtoMessage()    {}
+func (*SealResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_sealedsecrets_e1039564d4f05fc1, []int{1}
+}
+func (m *SealResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SealResponse.Unmarshal(m, b)
+}
+func (m *SealResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SealResponse.Marshal(b, m, deterministic)
+}
+func (dst *SealResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SealResponse.Merge(dst, src)
+}
+func (m *SealResponse) XXX_Size() int {
+	return xxx_messageInfo_SealResponse.Size(m)
+}
+func (m *SealResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_SealResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SealResponse proto.InternalMessageInfo
+
+func (m *SealResponse) GetSealedSecret() []byte {
+	if m != nil {
+		return m.SealedSecret
+	}
+	return nil
+}
+
+type SealChunk struct {
+	Data                 []byte   `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SealChunk) Reset()         { *m = SealChunk{} }
+func (m *SealChunk) String() string { return proto.CompactTextString(m) }
+func (*SealChunk) ProtoMessage()    {}
+func (*SealChunk) Descriptor() ([]byte, []int) {
+	return fileDescriptor_sealedsecrets_e1039564d4f05fc1, []int{2}
+}
+func (m *SealChunk) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SealChunk.Unmarshal(m, b)
+}
+func (m *SealChunk) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SealChunk.Marshal(b, m, deterministic)
+}
+func (dst *SealChunk) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SealChunk.Merge(dst, src)
+}
+func (m *SealChunk) XXX_Size() int {
+	return xxx_messageInfo_SealChunk.Size(m)
+}
+func (m *SealChunk) XXX_DiscardUnknown() {
+	xxx_messageInfo_SealChunk.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SealChunk proto.InternalMessageInfo
+
+func (m *SealChunk) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+type VerifyRequest struct {
+	SealedSecret         []byte   `protobuf:"bytes,1,opt,name=sealed_secret,json=sealedSecret,proto3" json:"sealed_secret,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *VerifyRequest) Reset()         { *m = VerifyRequest{} }
+func (m *VerifyRequest) String() string { return proto.CompactTextString(m) }
+func (*VerifyRequest) ProtoMessage()    {}
+func (*VerifyRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_sealedsecrets_e1039564d4f05fc1, []int{3}
+}
+func (m *VerifyRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VerifyRequest.Unmarshal(m, b)
+}
+func (m *VerifyRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VerifyRequest.Marshal(b, m, deterministic)
+}
+func (dst *VerifyRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VerifyRequest.Merge(dst, src)
+}
+func (m *VerifyRequest) XXX_Size() int {
+	return xxx_messageInfo_VerifyRequest.Size(m)
+}
+func (m *VerifyRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_VerifyRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VerifyRequest proto.InternalMessageInfo
+
+func (m *VerifyRequest) GetSealedSecret() []byte {
+	if m != nil {
+		return m.SealedSecret
+	}
+	return nil
+}
+
+type VerifyResponse struct {
+	Valid                bool     `protobuf:"varint,1,opt,name=valid,proto3" json:"valid,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *VerifyResponse) Reset()         { *m = VerifyResponse{} }
+func (m *VerifyResponse) String() string { return proto.CompactTextString(m) }
+func (*VerifyResponse) ProtoMessage()    {}
+func (*VerifyResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_sealedsecrets_e1039564d4f05fc1, []int{4}
+}
+func (m *VerifyResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VerifyResponse.Unmarshal(m, b)
+}
+func (m *VerifyResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VerifyResponse.Marshal(b, m, deterministic)
+}
+func (dst *VerifyResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VerifyResponse.Merge(dst, src)
+}
+func (m *VerifyResponse) XXX_Size() int {
+	return xxx_messageInfo_VerifyResponse.Size(m)
+}
+func (m *VerifyResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_VerifyResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VerifyResponse proto.InternalMessageInfo
+
+func (m *VerifyResponse) GetValid() bool {
+	if m != nil {
+		return m.Valid
+	}
+	return false
+}
+
+type RotateRequest struct {
+	SealedSecret         []byte   `protobuf:"bytes,1,opt,name=sealed_secret,json=sealedSecret,proto3" json:"sealed_secret,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RotateRequest) Reset()         { *m = RotateRequest{} }
+func (m *RotateRequest) String() string { return proto.CompactTextString(m) }
+func (*RotateRequest) ProtoMessage()    {}
+func (*RotateRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_sealedsecrets_e1039564d4f05fc1, []int{5}
+}
+func (m *RotateRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RotateRequest.Unmarshal(m, b)
+}
+func (m *RotateRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RotateRequest.Marshal(b, m, deterministic)
+}
+func (dst *RotateRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RotateRequest.Merge(dst, src)
+}
+func (m *RotateRequest) XXX_Size() int {
+	return xxx_messageInfo_RotateRequest.Size(m)
+}
+func (m *RotateRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RotateRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RotateRequest proto.InternalMessageInfo
+
+func (m *RotateRequest) GetSealedSecret() []byte {
+	if m != nil {
+		return m.SealedSecret
+	}
+	return nil
+}
+
+type RotateResponse struct {
+	SealedSecret         []byte   `protobuf:"bytes,1,opt,name=sealed_secret,json=sealedSecret,proto3" json:"sealed_secret,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RotateResponse) Reset()         { *m = RotateResponse{} }
+func (m *RotateResponse) String() string { return proto.CompactTextString(m) }
+func (*RotateResponse) ProtoMessage()    {}
+func (*RotateResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_sealedsecrets_e1039564d4f05fc1, []int{6}
+}
+func (m *RotateResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RotateResponse.Unmarshal(m, b)
+}
+func (m *RotateResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RotateResponse.Marshal(b, m, deterministic)
+}
+func (dst *RotateResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RotateResponse.Merge(dst, src)
+}
+func (m *RotateResponse) XXX_Size() int {
+	return xxx_messageInfo_RotateResponse.Size(m)
+}
+func (m *RotateResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_RotateResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RotateResponse proto.InternalMessageInfo
+
+func (m *RotateResponse) GetSealedSecret() []byte {
+	if m != nil {
+		return m.SealedSecret
+	}
+	return nil
+}
+
+type GetCertsRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetCertsRequest) Reset()         { *m = GetCertsRequest{} }
+func (m *GetCertsRequest) String() string { return proto.CompactTextString(m) }
+func (*GetCertsRequest) ProtoMessage()    {}
+func (*GetCertsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_sealedsecrets_e1039564d4f05fc1, []int{7}
+}
+func (m *GetCertsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetCertsRequest.Unmarshal(m, b)
+}
+func (m *GetCertsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetCertsRequest.Marshal(b, m, deterministic)
+}
+func (dst *GetCertsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetCertsRequest.Merge(dst, src)
+}
+func (m *GetCertsRequest) XXX_Size() int {
+	return xxx_messageInfo_GetCertsRequest.Size(m)
+}
+func (m *GetCertsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetCertsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetCertsRequest proto.InternalMessageInfo
+
+type GetCertsResponse struct {
+	Certs                [][]byte `protobuf:"bytes,1,rep,name=certs,proto3" json:"certs,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetCertsResponse) Reset()         { *m = GetCertsResponse{} }
+func (m *GetCertsResponse) String() string { return proto.CompactTextString(m) }
+func (*GetCertsResponse) ProtoMessage()    {}
+func (*GetCertsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_sealedsecrets_e1039564d4f05fc1, []int{8}
+}
+func (m *GetCertsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetCertsResponse.Unmarshal(m, b)
+}
+func (m *GetCertsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetCertsResponse.Marshal(b, m, deterministic)
+}
+func (dst *GetCertsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetCertsResponse.Merge(dst, src)
+}
+func (m *GetCertsResponse) XXX_Size() int {
+	return xxx_messageInfo_GetCertsResponse.Size(m)
+}
+func (m *GetCertsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetCertsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetCertsResponse proto.InternalMessageInfo
+
+func (m *GetCertsResponse) GetCerts() [][]byte {
+	if m != nil {
+		return m.Certs
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*SealRequest)(nil), "sealedsecrets.v1.SealRequest")
+	proto.RegisterType((*SealResponse)(nil), "sealedsecrets.v1.SealResponse")
+	proto.RegisterType((*SealChunk)(nil), "sealedsecrets.v1.SealChunk")
+	proto.RegisterType((*VerifyRequest)(nil), "sealedsecrets.v1.VerifyRequest")
+	proto.RegisterType((*VerifyResponse)(nil), "sealedsecrets.v1.VerifyResponse")
+	proto.RegisterType((*RotateRequest)(nil), "sealedsecrets.v1.RotateRequest")
+	proto.RegisterType((*RotateResponse)(nil), "sealedsecrets.v1.RotateResponse")
+	proto.RegisterType((*GetCertsRequest)(nil), "sealedsecrets.v1.GetCertsRequest")
+	proto.RegisterType((*GetCertsResponse)(nil), "sealedsecrets.v1.GetCertsResponse")
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// SealedSecretsClient is the client API for SealedSecrets service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type SealedSecretsClient interface {
+	// Seal encrypts a Secret with the latest controller key.
+	Seal(ctx context.Context, in *SealRequest, opts ...grpc.CallOption) (*SealResponse, error)
+	// SealStream seals a Secret whose manifest doesn't fit in a single
+	// message. The client streams the manifest in chunks and closes its side,
+	// the server then streams back the sealed secret in chunks.
+	SealStream(ctx context.Context, opts ...grpc.CallOption) (SealedSecrets_SealStreamClient, error)
+	// Verify checks that a SealedSecret can be decrypted.
+	Verify(ctx context.Context, in *VerifyRequest, opts ...grpc.CallOption) (*VerifyResponse, error)
+	// Rotate re-encrypts a SealedSecret with the latest controller key.
+	Rotate(ctx context.Context, in *RotateRequest, opts ...grpc.CallOption) (*RotateResponse, error)
+	// GetCerts returns the PEM encoded certificates of the sealing keys.
+	GetCerts(ctx context.Context, in *GetCertsRequest, opts ...grpc.CallOption) (*GetCertsResponse, error)
+}
+
+type sealedSecretsClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewSealedSecretsClient(cc *grpc.ClientConn) SealedSecretsClient {
+	return &sealedSecretsClient{cc}
+}
+
+func (c *sealedSecretsClient) Seal(ctx context.Context, in *SealRequest, opts ...grpc.CallOption) (*SealResponse, error) {
+	out := new(SealResponse)
+	err := c.cc.Invoke(ctx, "/sealedsecrets.v1.SealedSecrets/Seal", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sealedSecretsClient) SealStream(ctx context.Context, opts ...grpc.CallOption) (SealedSecrets_SealStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_SealedSecrets_serviceDesc.Streams[0], "/sealedsecrets.v1.SealedSecrets/SealStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &sealedSecretsSealStreamClient{stream}
+	return x, nil
+}
+
+type SealedSecrets_SealStreamClient interface {
+	Send(*SealChunk) error
+	Recv() (*SealChunk, error)
+	grpc.ClientStream
+}
+
+type sealedSecretsSealStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *sealedSecretsSealStreamClient) Send(m *SealChunk) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *sealedSecretsSealStreamClient) Recv() (*SealChunk, error) {
+	m := new(SealChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *sealedSecretsClient) Verify(ctx context.Context, in *VerifyRequest, opts ...grpc.CallOption) (*VerifyResponse, error) {
+	out := new(VerifyResponse)
+	err := c.cc.Invoke(ctx, "/sealedsecrets.v1.SealedSecrets/Verify", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sealedSecretsClient) Rotate(ctx context.Context, in *RotateRequest, opts ...grpc.CallOption) (*RotateResponse, error) {
+	out := new(RotateResponse)
+	err := c.cc.Invoke(ctx, "/sealedsecrets.v1.SealedSecrets/Rotate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sealedSecretsClient) GetCerts(ctx context.Context, in *GetCertsRequest, opts ...grpc.CallOption) (*GetCertsResponse, error) {
+	out := new(GetCertsResponse)
+	err := c.cc.Invoke(ctx, "/sealedsecrets.v1.SealedSecrets/GetCerts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SealedSecretsServer is the server API for SealedSecrets service.
+type SealedSecretsServer interface {
+	// Seal encrypts a Secret with the latest controller key.
+	Seal(context.Context, *SealRequest) (*SealResponse, error)
+	// SealStream seals a Secret whose manifest doesn't fit in a single
+	// message. The client streams the manifest in chunks and closes its side,
+	// the server then streams back the sealed secret in chunks.
+	SealStream(SealedSecrets_SealStreamServer) error
+	// Verify checks that a SealedSecret can be decrypted.
+	Verify(context.Context, *VerifyRequest) (*VerifyResponse, error)
+	// Rotate re-encrypts a SealedSecret with the latest controller key.
+	Rotate(context.Context, *RotateRequest) (*RotateResponse, error)
+	// GetCerts returns the PEM encoded certificates of the sealing keys.
+	GetCerts(context.Context, *GetCertsRequest) (*GetCertsResponse, error)
+}
+
+func RegisterSealedSecretsServer(s *grpc.Server, srv SealedSecretsServer) {
+	s.RegisterService(&_SealedSecrets_serviceDesc, srv)
+}
+
+func _SealedSecrets_Seal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SealRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SealedSecretsServer).Seal(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sealedsecrets.v1.SealedSecrets/Seal",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SealedSecretsServer).Seal(ctx, req.(*SealRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SealedSecrets_SealStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(SealedSecretsServer).SealStream(&sealedSecretsSealStreamServer{stream})
+}
+
+type SealedSecrets_SealStreamServer interface {
+	Send(*SealChunk) error
+	Recv() (*SealChunk, error)
+	grpc.ServerStream
+}
+
+type sealedSecretsSealStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *sealedSecretsSealStreamServer) Send(m *SealChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *sealedSecretsSealStreamServer) Recv() (*SealChunk, error) {
+	m := new(SealChunk)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _SealedSecrets_Verify_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SealedSecretsServer).Verify(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sealedsecrets.v1.SealedSecrets/Verify",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SealedSecretsServer).Verify(ctx, req.(*VerifyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SealedSecrets_Rotate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RotateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SealedSecretsServer).Rotate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sealedsecrets.v1.SealedSecrets/Rotate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SealedSecretsServer).Rotate(ctx, req.(*RotateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SealedSecrets_GetCerts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCertsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SealedSecretsServer).GetCerts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sealedsecrets.v1.SealedSecrets/GetCerts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SealedSecretsServer).GetCerts(ctx, req.(*GetCertsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _SealedSecrets_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "sealedsecrets.v1.SealedSecrets",
+	HandlerType: (*SealedSecretsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Seal",
+			Handler:    _SealedSecrets_Seal_Handler,
+		},
+		{
+			MethodName: "Verify",
+			Handler:    _SealedSecrets_Verify_Handler,
+		},
+		{
+			MethodName: "Rotate",
+			Handler:    _SealedSecrets_Rotate_Handler,
+		},
+		{
+			MethodName: "GetCerts",
+			Handler:    _SealedSecrets_GetCerts_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SealStream",
+			Handler:       _SealedSecrets_SealStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "sealedsecrets.proto",
+}
+
+func init() { proto.RegisterFile("sealedsecrets.proto", fileDescriptor_sealedsecrets_e1039564d4f05fc1) }
+
+var fileDescriptor_sealedsecrets_e1039564d4f05fc1 = []byte{
+	// 331 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x93, 0xc1, 0x4e, 0xb3, 0x40,
+	0x10, 0xc7, 0x43, 0xda, 0x8f, 0xaf, 0x1d, 0xa1, 0xd6, 0xd5, 0x98, 0x06, 0xa3, 0x45, 0x8c, 0x86,
+	0x13, 0x51, 0xab, 0x2f, 0x60, 0x63, 0x4c, 0xec, 0x49, 0x48, 0x3c, 0x78, 0x31, 0x2b, 0x8c, 0x4a,
+	0xc4, 0x82, 0xbb, 0xdb, 0x26, 0x3e, 0x85, 0xaf, 0x6c, 0x60, 0x97, 0xb4, 0xb4, 0xa5, 0xb1, 0x37,
+	0x66, 0xe6, 0x37, 0xff, 0x19, 0xe6, 0x9f, 0x85, 0x5d, 0x8e, 0x34, 0xc1, 0x88, 0x63, 0xc8, 0x50,
+	0x70, 0x2f, 0x63, 0xa9, 0x48, 0x49, 0xb7, 0x9a, 0x9c, 0x5e, 0x38, 0xa7, 0xb0, 0x15, 0x20, 0x4d,
+	0x7c, 0xfc, 0x9a, 0x20, 0x17, 0x64, 0x1f, 0x74, 0x59, 0xec, 0x69, 0xb6, 0xe6, 0x1a, 0xbe, 0x8a,
+	0x9c, 0x01, 0x18, 0x12, 0xe3, 0x59, 0x3a, 0xe6, 0x48, 0x4e, 0xc0, 0x94, 0x52, 0xcf, 0x15, 0xdc,
+	0x90, 0xc9, 0x40, 0x36, 0xf5, 0xa1, 0x9d, 0x37, 0x0d, 0xdf, 0x27, 0xe3, 0x0f, 0x42, 0xa0, 0x19,
+	0x51, 0x41, 0x15, 0x58, 0x7c, 0x3b, 0x57, 0x60, 0x3e, 0x22, 0x8b, 0x5f, 0xbf, 0xcb, 0xf1, 0x7f,
+	0x92, 0x3d, 0x83, 0x4e, 0xd9, 0xa5, 0xb6, 0xd9, 0x83, 0x7f, 0x53, 0x9a, 0xc4, 0x51, 0x81, 0xb7,
+	0x7c, 0x19, 0xe4, 0xea, 0x7e, 0x2a, 0xa8, 0xc0, 0x8d, 0xd4, 0xaf, 0xa1, 0x53, 0x76, 0x6d, 0xf2,
+	0xaf, 0x3b, 0xb0, 0x7d, 0x87, 0x62, 0x88, 0x4c, 0x70, 0x35, 0xce, 0x71, 0xa1, 0x3b, 0x4b, 0xcd,
+	0x36, 0x0d, 0xf3, 0x44, 0x4f, 0xb3, 0x1b, 0xae, 0xe1, 0xcb, 0xe0, 0xf2, 0xa7, 0x01, 0x66, 0x30,
+	0xa7, 0xc6, 0xc9, 0x2d, 0x34, 0xf3, 0x04, 0x39, 0xf4, 0x16, 0x1d, 0xf3, 0xe6, 0xec, 0xb2, 0x8e,
+	0xea, 0xca, 0x6a, 0xdc, 0x3d, 0x40, 0x1e, 0x07, 0x82, 0x21, 0xfd, 0x24, 0x07, 0xab, 0xe9, 0xc2,
+	0x1f, 0x6b, 0x5d, 0xd1, 0xd5, 0xce, 0x35, 0x32, 0x02, 0x5d, 0x9e, 0x9d, 0xf4, 0x97, 0xd1, 0x8a,
+	0x8d, 0x96, 0x5d, 0x0f, 0xa8, 0xc5, 0x46, 0xa0, 0xcb, 0x2b, 0xaf, 0x12, 0xab, 0xb8, 0x66, 0xd9,
+	0xf5, 0x80, 0x12, 0x7b, 0x80, 0x56, 0x79, 0x68, 0x72, 0xbc, 0x4c, 0x2f, 0xf8, 0x62, 0x39, 0xeb,
+	0x10, 0x29, 0x79, 0xd3, 0x7e, 0xfa, 0xff, 0xc6, 0xb2, 0x90, 0x66, 0xf1, 0x8b, 0x5e, 0x3c, 0x9d,
+	0xc1, 0xef, 0x00, 0x3a, 0xe1, 0x44, 0x8b, 0x51, 0x03, 0x00, 0x00,
+}
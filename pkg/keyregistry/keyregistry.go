@@ -0,0 +1,138 @@
+// Package keyregistry holds the sealing keys of a controller in memory,
+// so that the controller, its webhook and admin servers and external
+// integrations can share them. Registries are safe for concurrent use;
+// persisting the keys is left to their users.
+package keyregistry
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"sync"
+
+	"github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+var (
+	// ErrNoKey is returned by LatestKey when no key is registered.
+	ErrNoKey = errors.New("no key registered")
+	// ErrKeyNotFound is returned when looking up a key that isn't
+	// registered.
+	ErrKeyNotFound = errors.New("no such key")
+	// ErrDuplicateKey is returned by RegisterKey for a name already
+	// registered.
+	ErrDuplicateKey = errors.New("key already registered")
+)
+
+// Key is a registered key and the certificate published for it.
+type Key struct {
+	Name       string
+	PrivateKey *rsa.PrivateKey
+	Cert       *x509.Certificate
+	// Fingerprint identifies the public key, see seal.Fingerprint.
+	Fingerprint string
+}
+
+// Interface is implemented by key registries. The latest registered key
+// is used for sealing, all of them for unsealing.
+type Interface interface {
+	seal.KeySource
+
+	// RegisterKey adds a key, which becomes the latest one.
+	RegisterKey(name string, privKey *rsa.PrivateKey, cert *x509.Certificate) error
+	// LatestKey returns the key used for sealing.
+	LatestKey() (*Key, error)
+	// KeyForFingerprint returns the key of the public key with the
+	// given fingerprint.
+	KeyForFingerprint(fingerprint string) (*Key, error)
+	// Blacklist removes the key called name and returns it, so that
+	// it is no longer used.
+	Blacklist(name string) (*Key, error)
+	// Keys returns the registered keys, oldest first.
+	Keys() []Key
+}
+
+// Registry is the in-memory Interface.
+type Registry struct {
+	mu   sync.RWMutex
+	keys []Key
+}
+
+var _ Interface = &Registry{}
+
+// New returns an empty Registry.
+func New() *Registry {
+	return &Registry{}
+}
+
+// RegisterKey implements Interface.
+func (r *Registry) RegisterKey(name string, privKey *rsa.PrivateKey, cert *x509.Certificate) error {
+	fingerprint, err := seal.Fingerprint(&privKey.PublicKey)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, k := range r.keys {
+		if k.Name == name {
+			return ErrDuplicateKey
+		}
+	}
+	r.keys = append(r.keys, Key{Name: name, PrivateKey: privKey, Cert: cert, Fingerprint: fingerprint})
+	return nil
+}
+
+// LatestKey implements Interface.
+func (r *Registry) LatestKey() (*Key, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.keys) == 0 {
+		return nil, ErrNoKey
+	}
+	k := r.keys[len(r.keys)-1]
+	return &k, nil
+}
+
+// KeyForFingerprint implements Interface.
+func (r *Registry) KeyForFingerprint(fingerprint string) (*Key, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, k := range r.keys {
+		if k.Fingerprint == fingerprint {
+			return &k, nil
+		}
+	}
+	return nil, ErrKeyNotFound
+}
+
+// Blacklist implements Interface.
+func (r *Registry) Blacklist(name string) (*Key, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, k := range r.keys {
+		if k.Name == name {
+			r.keys = append(r.keys[:i:i], r.keys[i+1:]...)
+			return &k, nil
+		}
+	}
+	return nil, ErrKeyNotFound
+}
+
+// Keys implements Interface.
+func (r *Registry) Keys() []Key {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Key(nil), r.keys...)
+}
+
+// PrivateKeys implements seal.KeySource.
+func (r *Registry) PrivateKeys() []*rsa.PrivateKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	keys := make([]*rsa.PrivateKey, len(r.keys))
+	for i, k := range r.keys {
+		keys[i] = k.PrivateKey
+	}
+	return keys
+}
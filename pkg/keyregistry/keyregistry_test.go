@@ -0,0 +1,90 @@
+package keyregistry
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/bitnami-labs/sealed-secrets/pkg/seal"
+)
+
+func testKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestRegistry(t *testing.T) {
+	r := New()
+	if _, err := r.LatestKey(); err != ErrNoKey {
+		t.Errorf("Expected ErrNoKey, got %v", err)
+	}
+
+	key1, key2 := testKey(t), testKey(t)
+	cert2 := &x509.Certificate{}
+	if err := r.RegisterKey("key1", key1, &x509.Certificate{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RegisterKey("key2", key2, cert2); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RegisterKey("key1", key2, cert2); err != ErrDuplicateKey {
+		t.Errorf("Expected ErrDuplicateKey, got %v", err)
+	}
+
+	latest, err := r.LatestKey()
+	if err != nil || latest.Name != "key2" || latest.PrivateKey != key2 || latest.Cert != cert2 {
+		t.Errorf("Unexpected latest key %+v, %v", latest, err)
+	}
+	if keys := r.PrivateKeys(); len(keys) != 2 || keys[0] != key1 {
+		t.Errorf("Unexpected private keys %v", keys)
+	}
+
+	fp, err := seal.Fingerprint(&key1.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k, err := r.KeyForFingerprint(fp); err != nil || k.Name != "key1" {
+		t.Errorf("Expected key1, got %+v, %v", k, err)
+	}
+	if _, err := r.KeyForFingerprint("unknown"); err != ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound, got %v", err)
+	}
+
+	if k, err := r.Blacklist("key2"); err != nil || k.PrivateKey != key2 {
+		t.Errorf("Expected key2 to be blacklisted, got %+v, %v", k, err)
+	}
+	if _, err := r.Blacklist("key2"); err != ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound, got %v", err)
+	}
+	if latest, _ := r.LatestKey(); latest.Name != "key1" {
+		t.Errorf("Expected key1 to be the latest key, got %s", latest.Name)
+	}
+}
+
+func TestRegistryConcurrency(t *testing.T) {
+	r := New()
+	key := testKey(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			r.RegisterKey(fmt.Sprintf("key%d", i), key, nil)
+		}(i)
+		go func() {
+			defer wg.Done()
+			r.PrivateKeys()
+			r.LatestKey()
+		}()
+	}
+	wg.Wait()
+	if n := len(r.Keys()); n != 10 {
+		t.Errorf("Expected 10 keys, got %d", n)
+	}
+}
@@ -0,0 +1,475 @@
+// Package seal provides the sealing and unsealing operations used by
+// kubeseal and the controller, for programs that want to embed them
+// instead of shelling out to kubeseal.
+package seal
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	certUtil "k8s.io/client-go/util/cert"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto/mlkem"
+)
+
+// Scope determines where a sealed secret can be unsealed.
+type Scope int
+
+const (
+	// StrictScope binds the secret to its namespace and name.
+	StrictScope Scope = iota
+	// NamespaceWideScope allows renaming the secret within its namespace.
+	NamespaceWideScope
+	// ClusterWideScope allows unsealing the secret in any namespace,
+	// under any name.
+	ClusterWideScope
+)
+
+func (s Scope) String() string {
+	switch s {
+	case StrictScope:
+		return "strict"
+	case NamespaceWideScope:
+		return "namespace-wide"
+	case ClusterWideScope:
+		return "cluster-wide"
+	default:
+		return fmt.Sprintf("Scope(%d)", int(s))
+	}
+}
+
+// ParseScope parses the name of a scope, as returned by Scope.String.
+// The empty string is the strict scope.
+func ParseScope(s string) (Scope, error) {
+	switch s {
+	case "", "strict":
+		return StrictScope, nil
+	case "namespace-wide":
+		return NamespaceWideScope, nil
+	case "cluster-wide":
+		return ClusterWideScope, nil
+	default:
+		return StrictScope, fmt.Errorf("unknown scope %q", s)
+	}
+}
+
+// ScopeOf returns the scope requested by the annotations of o.
+func ScopeOf(o metav1.Object) Scope {
+	annotations := o.GetAnnotations()
+	if annotations[ssv1alpha1.SealedSecretClusterWideAnnotation] == "true" {
+		return ClusterWideScope
+	}
+	if annotations[ssv1alpha1.SealedSecretNamespaceWideAnnotation] == "true" {
+		return NamespaceWideScope
+	}
+	return StrictScope
+}
+
+// ErrNoKey is returned by Unseal when none of the keys can decrypt a
+// sealed secret.
+var ErrNoKey = errors.New("No key could decrypt secret")
+
+// KeySource provides the private keys used for unsealing.
+type KeySource interface {
+	PrivateKeys() []*rsa.PrivateKey
+}
+
+// ExternalKeySource is a KeySource which also provides keys held
+// outside of the process, used to unseal the secrets sealed with
+// SealExternal.
+type ExternalKeySource interface {
+	KeySource
+	ExternalKeys() []crypto.SessionKeyDecrypter
+}
+
+// PrivateKeys is a static KeySource.
+type PrivateKeys []*rsa.PrivateKey
+
+// PrivateKeys implements KeySource.
+func (k PrivateKeys) PrivateKeys() []*rsa.PrivateKey {
+	return k
+}
+
+// ParsePublicKey reads the public key of the first PEM encoded
+// certificate in r, such as the output of kubeseal --fetch-cert.
+func ParsePublicKey(r io.Reader) (*rsa.PublicKey, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	certs, err := certUtil.ParseCertsPEM(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// ParseCertsPem returns error if len(certs) == 0, but best to be sure...
+	if len(certs) == 0 {
+		return nil, errors.New("Failed to read any certificates")
+	}
+
+	pubKey, ok := certs[0].PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("Expected RSA public key but found %v", certs[0].PublicKey)
+	}
+	return pubKey, nil
+}
+
+// X25519KeyExtension is the certificate extension holding the X25519
+// public key paired with the RSA key of the certificate, see
+// crypto.X25519KeyFromRSA.
+var X25519KeyExtension = asn1.ObjectIdentifier{2, 25, 977437562, 1}
+
+// MLKEMKeyExtension is the certificate extension holding the ML-KEM-768
+// encapsulation key paired with the RSA key of the certificate, see
+// crypto.MLKEMKeyFromRSA.
+var MLKEMKeyExtension = asn1.ObjectIdentifier{2, 25, 977437562, 2}
+
+// ExternalKeyExtension marks the certificates of keys held outside of
+// the cluster, which must be sealed with SealExternal. Its value names
+// the service holding the key.
+var ExternalKeyExtension = asn1.ObjectIdentifier{2, 25, 977437562, 3}
+
+// ErrNoX25519Key is returned when sealing with X25519 for a certificate
+// issued before the controller supported it.
+var ErrNoX25519Key = errors.New("The certificate has no X25519 key, rotate the sealing key first")
+
+// ErrNoMLKEMKey is returned when sealing with ML-KEM for a certificate
+// issued before the controller supported it.
+var ErrNoMLKEMKey = errors.New("The certificate has no ML-KEM key, rotate the sealing key first")
+
+// ParseX25519PublicKey reads the X25519 public key from a PEM encoded
+// certificate.
+func ParseX25519PublicKey(r io.Reader) (*[crypto.X25519KeySize]byte, error) {
+	value, err := readCertExtension(r, X25519KeyExtension, ErrNoX25519Key)
+	if err != nil {
+		return nil, err
+	}
+	if len(value) != crypto.X25519KeySize {
+		return nil, fmt.Errorf("Invalid X25519 key of %d bytes", len(value))
+	}
+	pubKey := new([crypto.X25519KeySize]byte)
+	copy(pubKey[:], value)
+	return pubKey, nil
+}
+
+// ParseMLKEMPublicKey reads the ML-KEM-768 encapsulation key from a PEM
+// encoded certificate.
+func ParseMLKEMPublicKey(r io.Reader) (*mlkem.EncapsulationKey768, error) {
+	value, err := readCertExtension(r, MLKEMKeyExtension, ErrNoMLKEMKey)
+	if err != nil {
+		return nil, err
+	}
+	return mlkem.NewEncapsulationKey768(value)
+}
+
+// readCertExtension returns the value of the extension id of the first
+// certificate read from r, or errMissing.
+func readCertExtension(r io.Reader, id asn1.ObjectIdentifier, errMissing error) ([]byte, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	certs, err := certUtil.ParseCertsPEM(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, ext := range certs[0].Extensions {
+		if !ext.Id.Equal(id) {
+			continue
+		}
+		var value []byte
+		if _, err := asn1.Unmarshal(ext.Value, &value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+	return nil, errMissing
+}
+
+// NewX25519Extension returns the certificate extension publishing
+// pubKey, read by ParseX25519PublicKey.
+func NewX25519Extension(pubKey *[crypto.X25519KeySize]byte) (pkix.Extension, error) {
+	return newCertExtension(X25519KeyExtension, pubKey[:])
+}
+
+// NewMLKEMExtension returns the certificate extension publishing
+// kemKey, read by ParseMLKEMPublicKey.
+func NewMLKEMExtension(kemKey *mlkem.EncapsulationKey768) (pkix.Extension, error) {
+	return newCertExtension(MLKEMKeyExtension, kemKey.Bytes())
+}
+
+// NewExternalKeyExtension returns the certificate extension marking a
+// key held by service, read by ParseExternalKey.
+func NewExternalKeyExtension(service string) (pkix.Extension, error) {
+	return newCertExtension(ExternalKeyExtension, []byte(service))
+}
+
+// ParseExternalKey returns the service holding the key of the PEM
+// encoded certificate read from r, empty if the key isn't external.
+func ParseExternalKey(r io.Reader) (string, error) {
+	value, err := readCertExtension(r, ExternalKeyExtension, errNoExternalKey)
+	if err == errNoExternalKey {
+		return "", nil
+	}
+	return string(value), err
+}
+
+var errNoExternalKey = errors.New("The certificate has no external key")
+
+func newCertExtension(id asn1.ObjectIdentifier, data []byte) (pkix.Extension, error) {
+	value, err := asn1.Marshal(data)
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+	return pkix.Extension{Id: id, Value: value}, nil
+}
+
+// stripServerMeta clears read-only server-side ObjectMeta (if present).
+func stripServerMeta(meta *metav1.ObjectMeta) {
+	meta.SetSelfLink("")
+	meta.SetUID("")
+	meta.SetResourceVersion("")
+	meta.Generation = 0
+	meta.SetCreationTimestamp(metav1.Time{})
+	meta.SetDeletionTimestamp(nil)
+	meta.DeletionGracePeriodSeconds = nil
+}
+
+// setScope replaces the scope annotations of meta with those for scope.
+func setScope(meta *metav1.ObjectMeta, scope Scope) error {
+	annotations := map[string]string{}
+	for k, v := range meta.GetAnnotations() {
+		annotations[k] = v
+	}
+	delete(annotations, ssv1alpha1.SealedSecretClusterWideAnnotation)
+	delete(annotations, ssv1alpha1.SealedSecretNamespaceWideAnnotation)
+	switch scope {
+	case StrictScope:
+	case NamespaceWideScope:
+		annotations[ssv1alpha1.SealedSecretNamespaceWideAnnotation] = "true"
+	case ClusterWideScope:
+		annotations[ssv1alpha1.SealedSecretClusterWideAnnotation] = "true"
+	default:
+		return fmt.Errorf("unknown scope %v", scope)
+	}
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	meta.SetAnnotations(annotations)
+	return nil
+}
+
+// Seal encrypts secret with pubKey for the given scope. The secret must
+// have a name and a namespace; it is not modified.
+func Seal(secret *v1.Secret, pubKey *rsa.PublicKey, scope Scope) (*ssv1alpha1.SealedSecret, error) {
+	s, err := prepareSecret(secret, scope)
+	if err != nil {
+		return nil, err
+	}
+	return ssv1alpha1.NewSealedSecret(scheme.Codecs, pubKey, s)
+}
+
+// SealParams is like Seal, sealing with the primitives of params, see
+// ssv1alpha1.NewSealedSecretParams.
+func SealParams(secret *v1.Secret, pubKey *rsa.PublicKey, params ssv1alpha1.SealingParams, scope Scope) (*ssv1alpha1.SealedSecret, error) {
+	s, err := prepareSecret(secret, scope)
+	if err != nil {
+		return nil, err
+	}
+	return ssv1alpha1.NewSealedSecretParams(scheme.Codecs, pubKey, params, s)
+}
+
+// SealX25519 is like Seal, with the X25519 algorithm. pubKey is read
+// from the certificate of the controller with ParseX25519PublicKey.
+func SealX25519(secret *v1.Secret, pubKey *[crypto.X25519KeySize]byte, scope Scope) (*ssv1alpha1.SealedSecret, error) {
+	s, err := prepareSecret(secret, scope)
+	if err != nil {
+		return nil, err
+	}
+	return ssv1alpha1.NewSealedSecretX25519(scheme.Codecs, pubKey, s)
+}
+
+// SealRSAMLKEM is like Seal, with both RSA and the post-quantum
+// ML-KEM-768. kemKey is read from the certificate of the controller
+// with ParseMLKEMPublicKey.
+func SealRSAMLKEM(secret *v1.Secret, pubKey *rsa.PublicKey, kemKey *mlkem.EncapsulationKey768, scope Scope) (*ssv1alpha1.SealedSecret, error) {
+	s, err := prepareSecret(secret, scope)
+	if err != nil {
+		return nil, err
+	}
+	return ssv1alpha1.NewSealedSecretRSAMLKEM(scheme.Codecs, pubKey, kemKey, s)
+}
+
+// SealExternal is like Seal, for a key held outside of the cluster,
+// see ParseExternalKey.
+func SealExternal(secret *v1.Secret, pubKey *rsa.PublicKey, scope Scope) (*ssv1alpha1.SealedSecret, error) {
+	s, err := prepareSecret(secret, scope)
+	if err != nil {
+		return nil, err
+	}
+	return ssv1alpha1.NewSealedSecretExternal(scheme.Codecs, pubKey, s)
+}
+
+// prepareSecret returns a copy of secret ready to be sealed for scope.
+func prepareSecret(secret *v1.Secret, scope Scope) (*v1.Secret, error) {
+	if secret.GetName() == "" {
+		return nil, errors.New("Missing metadata.name in input Secret")
+	}
+	if secret.GetNamespace() == "" {
+		return nil, errors.New("Missing metadata.namespace in input Secret")
+	}
+
+	s := secret.DeepCopy()
+	stripServerMeta(&s.ObjectMeta)
+
+	// stringData is otherwise only merged by the API server.
+	s.Data = ssv1alpha1.SecretData(s)
+	s.StringData = nil
+
+	if err := setScope(&s.ObjectMeta, scope); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Unseal decrypts ss with the first key of keys that is able to.
+func Unseal(ss *ssv1alpha1.SealedSecret, keys KeySource) (*v1.Secret, error) {
+	secret, _, err := UnsealWithKey(ss, keys)
+	return secret, err
+}
+
+// UnsealWithKey is like Unseal, and also returns the key that decrypted
+// ss, nil for an external key. crypto.ErrTooShort is returned if the
+// data of ss is malformed.
+func UnsealWithKey(ss *ssv1alpha1.SealedSecret, keys KeySource) (*v1.Secret, *rsa.PrivateKey, error) {
+	if external, ok := keys.(ExternalKeySource); ok && ss.Spec.Algorithm == ssv1alpha1.AlgorithmRSAExternal {
+		// No private key is returned for the external keys
+		for _, d := range external.ExternalKeys() {
+			if secret, err := ss.UnsealExternal(scheme.Codecs, d); err == nil {
+				return secret, nil, nil
+			} else if err == crypto.ErrTooShort || err == ssv1alpha1.ErrEmptyData {
+				return nil, nil, err
+			}
+		}
+	}
+	for _, privKey := range keys.PrivateKeys() {
+		secret, err := ss.Unseal(scheme.Codecs, privKey)
+		if err == nil {
+			return secret, privKey, nil
+		}
+		if err == crypto.ErrTooShort || err == ssv1alpha1.ErrUnsupportedAlgorithm || err == ssv1alpha1.ErrEmptyData {
+			// No key can help
+			return nil, nil, err
+		}
+	}
+	return nil, nil, ErrNoKey
+}
+
+// Fingerprint identifies a public key, it is the hex encoded SHA-256
+// hash of its PKIX, ASN.1 DER form.
+func Fingerprint(pubKey *rsa.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pubKey)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// keyBackupLabel binds the key backups to their purpose, so that they
+// can't be mistaken for sealed values.
+var keyBackupLabel = []byte("sealed-secrets-key-backup")
+
+// WrapKeyBackup encrypts the PEM encoded key and certificates of a
+// sealing key for the operator holding the private key of pubKey.
+func WrapKeyBackup(rnd io.Reader, pubKey *rsa.PublicKey, pem []byte) ([]byte, error) {
+	return crypto.HybridEncrypt(rnd, pubKey, pem, keyBackupLabel)
+}
+
+// UnwrapKeyBackup decrypts a backup made by WrapKeyBackup.
+func UnwrapKeyBackup(rnd io.Reader, privKey *rsa.PrivateKey, backup []byte) ([]byte, error) {
+	return crypto.HybridDecrypt(rnd, privKey, backup, keyBackupLabel)
+}
+
+// SealConfigMap encrypts cm with pubKey for the given scope. The
+// ConfigMap must have a name and a namespace; it is not modified.
+func SealConfigMap(cm *v1.ConfigMap, pubKey *rsa.PublicKey, scope Scope) (*ssv1alpha1.SealedConfigMap, error) {
+	if cm.GetName() == "" {
+		return nil, errors.New("Missing metadata.name in input ConfigMap")
+	}
+	if cm.GetNamespace() == "" {
+		return nil, errors.New("Missing metadata.namespace in input ConfigMap")
+	}
+
+	c := cm.DeepCopy()
+	stripServerMeta(&c.ObjectMeta)
+	if err := setScope(&c.ObjectMeta, scope); err != nil {
+		return nil, err
+	}
+	return ssv1alpha1.NewSealedConfigMap(pubKey, c)
+}
+
+// UnsealConfigMap decrypts scm with the first key of keys that is able to.
+func UnsealConfigMap(scm *ssv1alpha1.SealedConfigMap, keys KeySource) (*v1.ConfigMap, error) {
+	for _, privKey := range keys.PrivateKeys() {
+		if cm, err := scm.Unseal(privKey); err == nil {
+			return cm, nil
+		}
+	}
+	return nil, ErrNoKey
+}
+
+// SealObject encrypts obj with pubKey for the given scope. The object
+// must have a name and a namespace; it is not modified.
+func SealObject(obj *unstructured.Unstructured, pubKey *rsa.PublicKey, scope Scope) (*ssv1alpha1.SealedObject, error) {
+	if obj.GetName() == "" {
+		return nil, fmt.Errorf("Missing metadata.name in input %s", obj.GetKind())
+	}
+	if obj.GetNamespace() == "" {
+		return nil, fmt.Errorf("Missing metadata.namespace in input %s", obj.GetKind())
+	}
+
+	o := obj.DeepCopy()
+	unstructured.RemoveNestedField(o.Object, "metadata", "selfLink")
+	unstructured.RemoveNestedField(o.Object, "metadata", "uid")
+	unstructured.RemoveNestedField(o.Object, "metadata", "resourceVersion")
+	unstructured.RemoveNestedField(o.Object, "metadata", "generation")
+	unstructured.RemoveNestedField(o.Object, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(o.Object, "metadata", "deletionTimestamp")
+	unstructured.RemoveNestedField(o.Object, "metadata", "deletionGracePeriodSeconds")
+	unstructured.RemoveNestedField(o.Object, "status")
+
+	var meta metav1.ObjectMeta
+	meta.SetAnnotations(o.GetAnnotations())
+	if err := setScope(&meta, scope); err != nil {
+		return nil, err
+	}
+	o.SetAnnotations(meta.GetAnnotations())
+
+	return ssv1alpha1.NewSealedObject(pubKey, o)
+}
+
+// UnsealObject decrypts so with the first key of keys that is able to.
+func UnsealObject(so *ssv1alpha1.SealedObject, keys KeySource) (*unstructured.Unstructured, error) {
+	for _, privKey := range keys.PrivateKeys() {
+		if obj, err := so.Unseal(privKey); err == nil {
+			return obj, nil
+		}
+	}
+	return nil, ErrNoKey
+}
@@ -0,0 +1,407 @@
+package seal
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	certUtil "k8s.io/client-go/util/cert"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+)
+
+func testSecret() *v1.Secret {
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "mysecret",
+			Namespace:       "myns",
+			ResourceVersion: "42",
+			Annotations: map[string]string{
+				ssv1alpha1.SealedSecretClusterWideAnnotation: "true",
+			},
+		},
+		Data: map[string][]byte{
+			"foo": []byte("bar"),
+		},
+		StringData: map[string]string{
+			"baz": "qux",
+		},
+	}
+}
+
+func TestSealUnseal(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() returned error: %v", err)
+	}
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() returned error: %v", err)
+	}
+
+	secret := testSecret()
+	ss, err := Seal(secret, &key.PublicKey, NamespaceWideScope)
+	if err != nil {
+		t.Fatalf("Seal() returned error: %v", err)
+	}
+	if secret.ResourceVersion != "42" || secret.StringData == nil {
+		t.Errorf("Seal() modified its input")
+	}
+	if got := ScopeOf(ss); got != NamespaceWideScope {
+		t.Errorf("Sealed with scope %v, expected %v", got, NamespaceWideScope)
+	}
+
+	result, err := Unseal(ss, PrivateKeys{other, key})
+	if err != nil {
+		t.Fatalf("Unseal() returned error: %v", err)
+	}
+	if string(result.Data["foo"]) != "bar" || string(result.Data["baz"]) != "qux" {
+		t.Errorf("Unexpected data %v", result.Data)
+	}
+
+	if _, used, err := UnsealWithKey(ss, PrivateKeys{other, key}); err != nil || used != key {
+		t.Errorf("UnsealWithKey() returned key %v, err %v", used, err)
+	}
+
+	if _, err := Unseal(ss, PrivateKeys{other}); err != ErrNoKey {
+		t.Errorf("Expected ErrNoKey, got %v", err)
+	}
+
+	// Namespace-wide secrets can't be moved to another namespace
+	ss.Namespace = "otherns"
+	if _, err := Unseal(ss, PrivateKeys{key}); err != ErrNoKey {
+		t.Errorf("Expected ErrNoKey, got %v", err)
+	}
+
+	// Malformed data can't be decrypted by any key
+	ss.Namespace = secret.Namespace
+	ss.Spec.EncryptedData["foo"] = []byte{0}
+	if _, err := Unseal(ss, PrivateKeys{other, key}); err != crypto.ErrTooShort {
+		t.Errorf("Expected ErrTooShort, got %v", err)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("GenerateKey() returned error: %v", err)
+	}
+	other, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("GenerateKey() returned error: %v", err)
+	}
+
+	fp, err := Fingerprint(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("Fingerprint() returned error: %v", err)
+	}
+	if len(fp) != 64 {
+		t.Errorf("Unexpected fingerprint %q", fp)
+	}
+	if again, _ := Fingerprint(&key.PublicKey); again != fp {
+		t.Errorf("Fingerprint() isn't stable: %q != %q", again, fp)
+	}
+	if otherFp, _ := Fingerprint(&other.PublicKey); otherFp == fp {
+		t.Errorf("Expected distinct fingerprints")
+	}
+}
+
+func TestSealUnsealX25519(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() returned error: %v", err)
+	}
+	_, x25519PubKey, err := crypto.X25519KeyFromRSA(key)
+	if err != nil {
+		t.Fatalf("X25519KeyFromRSA() returned error: %v", err)
+	}
+	ext, err := NewX25519Extension(x25519PubKey)
+	if err != nil {
+		t.Fatalf("NewX25519Extension() returned error: %v", err)
+	}
+
+	selfSign := func(exts ...pkix.Extension) []byte {
+		tmpl := &x509.Certificate{SerialNumber: big.NewInt(1), ExtraExtensions: exts}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+		if err != nil {
+			t.Fatalf("CreateCertificate() returned error: %v", err)
+		}
+		return certUtil.EncodeCertPEM(&x509.Certificate{Raw: der})
+	}
+
+	pubKey, err := ParseX25519PublicKey(bytes.NewReader(selfSign(ext)))
+	if err != nil {
+		t.Fatalf("ParseX25519PublicKey() returned error: %v", err)
+	}
+	if *pubKey != *x25519PubKey {
+		t.Errorf("Parsed a different X25519 key")
+	}
+	if _, err := ParseX25519PublicKey(bytes.NewReader(selfSign())); err != ErrNoX25519Key {
+		t.Errorf("Expected ErrNoX25519Key, got %v", err)
+	}
+
+	ss, err := SealX25519(testSecret(), pubKey, StrictScope)
+	if err != nil {
+		t.Fatalf("SealX25519() returned error: %v", err)
+	}
+	if ss.Spec.Algorithm != ssv1alpha1.AlgorithmX25519 {
+		t.Errorf("Unexpected algorithm %q", ss.Spec.Algorithm)
+	}
+	if len(ss.Spec.EncryptedData["foo"]) > 100 {
+		t.Errorf("Encrypted data is implausibly long: %d bytes", len(ss.Spec.EncryptedData["foo"]))
+	}
+	secret, err := Unseal(ss, PrivateKeys{key})
+	if err != nil {
+		t.Fatalf("Unseal() returned error: %v", err)
+	}
+	if string(secret.Data["baz"]) != "qux" {
+		t.Errorf("Unexpected data: %v", secret.Data)
+	}
+
+	ss.Spec.Algorithm = "ROT13"
+	if _, err := Unseal(ss, PrivateKeys{key}); err != ssv1alpha1.ErrUnsupportedAlgorithm {
+		t.Errorf("Expected ErrUnsupportedAlgorithm, got %v", err)
+	}
+}
+
+func TestSealRequiresNamespace(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() returned error: %v", err)
+	}
+
+	secret := testSecret()
+	secret.Namespace = ""
+	if _, err := Seal(secret, &key.PublicKey, StrictScope); err == nil {
+		t.Errorf("Expected error for secret without namespace")
+	}
+}
+
+func TestParseScope(t *testing.T) {
+	for _, scope := range []Scope{StrictScope, NamespaceWideScope, ClusterWideScope} {
+		got, err := ParseScope(scope.String())
+		if err != nil {
+			t.Errorf("ParseScope(%q) returned error: %v", scope, err)
+		}
+		if got != scope {
+			t.Errorf("ParseScope(%q) returned %v", scope, got)
+		}
+	}
+	if _, err := ParseScope("global"); err == nil {
+		t.Errorf("Expected error for unknown scope")
+	}
+}
+
+func TestSealUnsealConfigMap(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() returned error: %v", err)
+	}
+
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myconfig",
+			Namespace: "myns",
+		},
+		Data:       map[string]string{"endpoint": "https://internal"},
+		BinaryData: map[string][]byte{"license": {0, 1, 2}},
+	}
+	scm, err := SealConfigMap(cm, &key.PublicKey, StrictScope)
+	if err != nil {
+		t.Fatalf("SealConfigMap() returned error: %v", err)
+	}
+
+	result, err := UnsealConfigMap(scm, PrivateKeys{key})
+	if err != nil {
+		t.Fatalf("UnsealConfigMap() returned error: %v", err)
+	}
+	if result.Data["endpoint"] != "https://internal" || string(result.BinaryData["license"]) != "\x00\x01\x02" {
+		t.Errorf("Unexpected result %v %v", result.Data, result.BinaryData)
+	}
+	if len(result.OwnerReferences) != 1 || result.OwnerReferences[0].Kind != "SealedConfigMap" {
+		t.Errorf("Unexpected owner references %v", result.OwnerReferences)
+	}
+
+	// Values sealed for a Secret can't be unsealed into a ConfigMap
+	secret := testSecret()
+	secret.Name = "myconfig"
+	secret.Annotations = nil
+	ss, err := Seal(secret, &key.PublicKey, StrictScope)
+	if err != nil {
+		t.Fatalf("Seal() returned error: %v", err)
+	}
+	scm.Spec.EncryptedData = map[string][]byte{"foo": ss.Spec.EncryptedData["foo"]}
+	scm.Spec.EncryptedBinaryData = nil
+	if _, err := UnsealConfigMap(scm, PrivateKeys{key}); err != ErrNoKey {
+		t.Errorf("Expected ErrNoKey, got %v", err)
+	}
+}
+
+func TestSealUnsealObject(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() returned error: %v", err)
+	}
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Database",
+		"metadata": map[string]interface{}{
+			"name":            "mydb",
+			"namespace":       "myns",
+			"resourceVersion": "42",
+		},
+		"spec": map[string]interface{}{
+			"password": "hunter2",
+		},
+		"status": map[string]interface{}{
+			"ready": true,
+		},
+	}}
+	so, err := SealObject(obj, &key.PublicKey, StrictScope)
+	if err != nil {
+		t.Fatalf("SealObject() returned error: %v", err)
+	}
+	if obj.GetResourceVersion() != "42" {
+		t.Errorf("SealObject() modified its input")
+	}
+
+	result, err := UnsealObject(so, PrivateKeys{key})
+	if err != nil {
+		t.Fatalf("UnsealObject() returned error: %v", err)
+	}
+	if result.GetKind() != "Database" || result.GetResourceVersion() != "" {
+		t.Errorf("Unexpected result %v", result.Object)
+	}
+	if password, _, _ := unstructured.NestedString(result.Object, "spec", "password"); password != "hunter2" {
+		t.Errorf("Unexpected spec %v", result.Object["spec"])
+	}
+	if _, ok := result.Object["status"]; ok {
+		t.Errorf("Expected status to be dropped")
+	}
+	if refs := result.GetOwnerReferences(); len(refs) != 1 || refs[0].Kind != "SealedObject" {
+		t.Errorf("Unexpected owner references %v", refs)
+	}
+
+	// Strict scope binds the object to its name
+	so.Name = "otherdb"
+	if _, err := UnsealObject(so, PrivateKeys{key}); err != ErrNoKey {
+		t.Errorf("Expected ErrNoKey, got %v", err)
+	}
+}
+
+func TestSealUnsealRSAMLKEM(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() returned error: %v", err)
+	}
+	dk, err := crypto.MLKEMKeyFromRSA(key)
+	if err != nil {
+		t.Fatalf("MLKEMKeyFromRSA() returned error: %v", err)
+	}
+	ext, err := NewMLKEMExtension(dk.EncapsulationKey())
+	if err != nil {
+		t.Fatalf("NewMLKEMExtension() returned error: %v", err)
+	}
+	tmpl := &x509.Certificate{SerialNumber: big.NewInt(1), ExtraExtensions: []pkix.Extension{ext}}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() returned error: %v", err)
+	}
+
+	kemKey, err := ParseMLKEMPublicKey(bytes.NewReader(certUtil.EncodeCertPEM(&x509.Certificate{Raw: der})))
+	if err != nil {
+		t.Fatalf("ParseMLKEMPublicKey() returned error: %v", err)
+	}
+	if !bytes.Equal(kemKey.Bytes(), dk.EncapsulationKey().Bytes()) {
+		t.Errorf("Parsed a different ML-KEM key")
+	}
+
+	ss, err := SealRSAMLKEM(testSecret(), &key.PublicKey, kemKey, StrictScope)
+	if err != nil {
+		t.Fatalf("SealRSAMLKEM() returned error: %v", err)
+	}
+	if ss.Spec.Algorithm != ssv1alpha1.AlgorithmRSAMLKEM {
+		t.Errorf("Unexpected algorithm %q", ss.Spec.Algorithm)
+	}
+	secret, err := Unseal(ss, PrivateKeys{key})
+	if err != nil {
+		t.Fatalf("Unseal() returned error: %v", err)
+	}
+	if string(secret.Data["baz"]) != "qux" {
+		t.Errorf("Unexpected data: %v", secret.Data)
+	}
+
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() returned error: %v", err)
+	}
+	if _, err := Unseal(ss, PrivateKeys{other}); err == nil {
+		t.Errorf("Unseal() with the wrong key succeeded")
+	}
+}
+
+// externalKeys is an ExternalKeySource without private keys.
+type externalKeys []crypto.SessionKeyDecrypter
+
+func (externalKeys) PrivateKeys() []*rsa.PrivateKey { return nil }
+
+func (k externalKeys) ExternalKeys() []crypto.SessionKeyDecrypter { return k }
+
+func TestSealUnsealExternal(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() returned error: %v", err)
+	}
+	ext, err := NewExternalKeyExtension("vault-transit")
+	if err != nil {
+		t.Fatalf("NewExternalKeyExtension() returned error: %v", err)
+	}
+	selfSign := func(exts ...pkix.Extension) []byte {
+		tmpl := &x509.Certificate{SerialNumber: big.NewInt(1), ExtraExtensions: exts}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+		if err != nil {
+			t.Fatalf("CreateCertificate() returned error: %v", err)
+		}
+		return certUtil.EncodeCertPEM(&x509.Certificate{Raw: der})
+	}
+	if service, err := ParseExternalKey(bytes.NewReader(selfSign(ext))); err != nil || service != "vault-transit" {
+		t.Errorf("ParseExternalKey() = %q, %v", service, err)
+	}
+	if service, err := ParseExternalKey(bytes.NewReader(selfSign())); err != nil || service != "" {
+		t.Errorf("ParseExternalKey() of a local key = %q, %v", service, err)
+	}
+
+	ss, err := SealExternal(testSecret(), &key.PublicKey, StrictScope)
+	if err != nil {
+		t.Fatalf("SealExternal() returned error: %v", err)
+	}
+	if ss.Spec.Algorithm != ssv1alpha1.AlgorithmRSAExternal {
+		t.Errorf("Unexpected algorithm %q", ss.Spec.Algorithm)
+	}
+	secret, privKey, err := UnsealWithKey(ss, externalKeys{crypto.PrivateKeyDecrypter(key)})
+	if err != nil {
+		t.Fatalf("UnsealWithKey() returned error: %v", err)
+	}
+	if privKey != nil || string(secret.Data["baz"]) != "qux" {
+		t.Errorf("Unexpected data: %v", secret.Data)
+	}
+	// The exported private key works too
+	if _, err := Unseal(ss, PrivateKeys{key}); err != nil {
+		t.Errorf("Unseal() with the private key returned error: %v", err)
+	}
+
+	// The scope is still enforced
+	ss.Name = "renamed"
+	if _, err := Unseal(ss, externalKeys{crypto.PrivateKeyDecrypter(key)}); err == nil {
+		t.Errorf("Unseal() of a renamed SealedSecret succeeded")
+	}
+}
@@ -0,0 +1,133 @@
+// Package shamir implements Shamir's secret sharing over GF(256), so
+// that a key can be split into shares of which any threshold of them
+// reconstruct it, while fewer reveal nothing.
+package shamir
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+)
+
+var (
+	// ErrInvalidParameters is returned by Split for an impossible
+	// number of shares or threshold.
+	ErrInvalidParameters = errors.New("threshold must be between 2 and the number of shares, at most 255")
+	// ErrInvalidShares is returned by Combine when the shares can't
+	// belong together.
+	ErrInvalidShares = errors.New("shares must have the same length and distinct indexes")
+)
+
+// Log and exponential tables of GF(256) with the AES polynomial
+// x^8 + x^4 + x^3 + x + 1, generated by 3.
+var (
+	expTable [510]byte
+	logTable [256]byte
+)
+
+func init() {
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		expTable[i] = x
+		expTable[i+255] = x
+		logTable[x] = byte(i)
+		// x *= 3
+		x ^= mul2(x)
+	}
+}
+
+func mul2(x byte) byte {
+	if x&0x80 != 0 {
+		return x<<1 ^ 0x1b
+	}
+	return x << 1
+}
+
+func mul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return expTable[int(logTable[a])+int(logTable[b])]
+}
+
+func div(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return expTable[int(logTable[a])+255-int(logTable[b])]
+}
+
+// Split divides secret into n shares, any threshold of which can be
+// given to Combine to recover it. Each share is one byte longer than
+// secret, its last byte is its index.
+func Split(secret []byte, n, threshold int) ([][]byte, error) {
+	if threshold < 2 || threshold > n || n > 255 {
+		return nil, ErrInvalidParameters
+	}
+	if len(secret) == 0 {
+		return nil, errors.New("cannot split an empty secret")
+	}
+
+	shares := make([][]byte, n)
+	for i := range shares {
+		shares[i] = make([]byte, len(secret)+1)
+		shares[i][len(secret)] = byte(i + 1)
+	}
+
+	// A random polynomial of degree threshold-1 per byte, whose
+	// constant term is the secret byte.
+	coeffs := make([]byte, threshold)
+	for j, s := range secret {
+		coeffs[0] = s
+		if _, err := io.ReadFull(rand.Reader, coeffs[1:]); err != nil {
+			return nil, err
+		}
+		for _, share := range shares {
+			x := share[len(secret)]
+			// Horner's method
+			var y byte
+			for k := threshold - 1; k >= 0; k-- {
+				y = mul(y, x) ^ coeffs[k]
+			}
+			share[j] = y
+		}
+	}
+	return shares, nil
+}
+
+// Combine recovers the secret from shares produced by Split. With
+// fewer shares than the threshold, the result is meaningless.
+func Combine(shares [][]byte) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, ErrInvalidShares
+	}
+	size := len(shares[0])
+	xs := make([]byte, len(shares))
+	seen := map[byte]bool{}
+	for i, share := range shares {
+		if len(share) != size || size < 2 {
+			return nil, ErrInvalidShares
+		}
+		x := share[size-1]
+		if x == 0 || seen[x] {
+			return nil, ErrInvalidShares
+		}
+		seen[x] = true
+		xs[i] = x
+	}
+
+	// Lagrange interpolation at 0
+	secret := make([]byte, size-1)
+	for i := range shares {
+		basis := byte(1)
+		for j := range shares {
+			if i != j {
+				basis = mul(basis, div(xs[j], xs[i]^xs[j]))
+			}
+		}
+		for k := range secret {
+			secret[k] ^= mul(shares[i][k], basis)
+		}
+	}
+	return secret, nil
+}